@@ -15,17 +15,26 @@
 package appmetrics
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/rcrowley/go-metrics"
 )
 
 const (
-	MetricTag       = "metric"
-	MetricSampleTag = "metric-sample"
+	MetricTag               = "metric"
+	MetricSampleTag         = "metric-sample"
+	MetricTagKeysTag        = "metric-tag-keys"
+	MetricAllowedTagKeysTag = "metric-allowed-tag-keys"
+	MetricPrefixTag         = "metric-prefix"
+	MetricTagsTag           = "metric-tags"
+	MetricAlphaTag          = "metric-alpha"
 )
 
 // DefaultReservoirSize and DefaultExpDecayAlpha are the values used for
@@ -46,6 +55,8 @@ var (
 	histogramType              = reflect.TypeOf((*metrics.Histogram)(nil)).Elem()
 	meterType                  = reflect.TypeOf((*metrics.Meter)(nil)).Elem()
 	timerType                  = reflect.TypeOf((*metrics.Timer)(nil)).Elem()
+	ewmaType                   = reflect.TypeOf((*metrics.EWMA)(nil)).Elem()
+	healthcheckType            = reflect.TypeOf((*metrics.Healthcheck)(nil)).Elem()
 )
 
 // New creates a new metrics struct. The type M must be a struct and should
@@ -57,12 +68,22 @@ var (
 // types:
 //
 //   - [metrics.Counter]
+//   - [CounterFloat64]
+//   - [IntervalCounter]
+//   - [UpDownCounter]
+//   - [DurationGauge]
+//   - [MaxGauge]
+//   - [MinGauge]
 //   - [metrics.Gauge]
 //   - [metrics.GaugeFloat64]
 //   - [metrics.Histogram]
 //   - [metrics.Meter]
 //   - [metrics.Timer]
-//   - [Tagged]
+//   - [metrics.EWMA]
+//   - [metrics.Healthcheck]
+//   - [Tagged], [Tagged1], [Tagged2], or [Tagged3]
+//
+// Applications can add other metric types with [RegisterMetricType].
 //
 // For example, this struct defines two metrics, a counter and a gauge:
 //
@@ -79,6 +100,19 @@ var (
 // tag. You can define metrics with dynamic names by using the [Tagged]
 // interface; see that type for more details.
 //
+// Any metric field may set the "metric-tags" tag to add constant tags to the
+// metric. The value is a comma-separated list of tags, which must be valid
+// according to ValidateTags. For example, this counter registers as
+// "requests[component:api,region:us]":
+//
+//	type M struct {
+//		Requests metrics.Counter `metric:"requests" metric-tags:"component:api,region:us"`
+//	}
+//
+// Tagged metrics add the constant tags to the tags passed to Tag. They may
+// also set the "metric-max-tags" and "metric-tag-ttl" tags to limit the number
+// of series they create; see [Tagged] for details.
+//
 // If the metric is a histogram or a timer, the field may also set the
 // "metric-sample" tag. This tag defines the sample type for the metric's
 // histogram. The tag value is a comma-separated list of the sample type and
@@ -87,25 +121,103 @@ var (
 //   - "uniform": optionally accepts an integer for the reservoir size
 //   - "expdecay": optionally accepts an integer for the reservoir size and a
 //     float for the alpha value; you must set both or neither value
+//   - "window": requires a duration for the length of a sliding time window,
+//     like "1m", and optionally accepts an integer for the reservoir size;
+//     see [WindowSample]
 //
 // For example:
 //
 //	type M struct {
 //		DownloadSize    metrics.Histogram `metric:"download.size" metric-sample:"uniform,100"`
 //		DownloadLatency metrics.Time      `metric:"download.latency" metric-sample:"expdecay,1028,0.015"`
+//		UploadLatency   metrics.Timer     `metric:"upload.latency" metric-sample:"window,5m"`
 //	}
 //
 // See [rcrowley/go-metrics] for an explanation of the differences between
 // sample types.
 //
+// Histograms and timers may also set the "metric-buckets" tag to a
+// comma-separated list of bucket upper bounds in increasing order. These
+// metrics count every value in a bucket in addition to recording it in the
+// sample and implement [Bucketed], so emitters can export true histograms.
+// Timer bounds are in seconds:
+//
+//	type M struct {
+//		RequestLatency metrics.Timer `metric:"request.latency" metric-buckets:"0.005,0.01,0.05,0.1,0.5,1,5"`
+//	}
+//
+// If the metric is an EWMA, the field may set the "metric-alpha" tag to the
+// smoothing factor of the average. The value is either a float or one of
+// "1m", "5m", or "15m" for the factors of one, five, and fifteen minute load
+// averages. The default is "1m". EWMA metrics must be updated by calling Tick
+// every five seconds. Register adds EWMA metrics to the registry as a
+// [metrics.GaugeFloat64] that reports the rate.
+//
+// Healthcheck metrics call a check function, similar to the compute functions
+// of functional gauges. For a field named X, the function is a method or a
+// function field named CheckX that takes no parameters and returns an error:
+//
+//	type M struct {
+//		Database metrics.Healthcheck `metric:"database"`
+//	}
+//
+//	func (m *M) CheckDatabase() error {
+//		return db.Ping()
+//	}
+//
+// Call Check on the metric, or RunHealthchecks on the registry, to run the
+// function and update the status of the healthcheck. Healthchecks and EWMA
+// metrics cannot be used with [Tagged].
+//
+// Any metric field may set the "metric-help" and "metric-unit" tags to
+// document the metric. Emitters use the help text to describe the metric and
+// may add the unit to the metric name, following the conventions of the
+// monitoring system:
+//
+//	type M struct {
+//		UploadSize metrics.Histogram `metric:"upload.size" metric-help:"Size of uploaded files" metric-unit:"bytes"`
+//	}
+//
+// Metrics structs may contain other metrics structs. New initializes the
+// metrics in embedded structs and in struct fields with the "metric-prefix"
+// tag. The tag value is added to the start of the names of all metrics in the
+// nested struct, including metrics in structs nested within it:
+//
+//	type DBMetrics struct {
+//		Queries metrics.Timer `metric:"queries"`
+//	}
+//
+//	type M struct {
+//		Requests metrics.Counter `metric:"requests"`
+//		DB       DBMetrics       `metric-prefix:"db."`
+//	}
+//
+// In this example, the metrics are named "requests" and "db.queries". The
+// nested struct must be a struct value, not a pointer.
+//
 // If the tag is not set, the histogram uses an exponentially decaying sample
 // with DefaultReservoirSize and DefaultExpDecayAlpha. These values are also
 // used when the reservoir size and alpha are not specified.
 //
+// Histograms allocate their sample and meters and timers start tracking
+// rates in a background goroutine when they are created. For metrics that a
+// program may never use, like metrics in a shared struct imported by command
+// line tools, the "metric-lazy" tag delays creating the metric until the
+// first update:
+//
+//	type M struct {
+//		Uploads metrics.Meter `metric:"uploads" metric-lazy:"true"`
+//	}
+//
+// Until then, the metric reports the values of an empty metric. Tagged
+// metrics always create their instances on first use.
+//
 // Metric fields can also be one of the functional metric interface types:
 //
 //   - [FunctionalGauge]
 //   - [FunctionalGaugeFloat64]
+//   - [FunctionalHistogram]
+//   - [FunctionalTimer]
 //
 // A functional metrics execute a function each time a client requests its
 // value. Each functional metric must have a corresponding exported method or
@@ -123,60 +235,305 @@ var (
 //		return getCurrentTemperature()
 //	}
 //
+// Functional histograms and timers compute a sample of values, so their
+// functions return []int64 or []time.Duration. They do not support dynamic
+// tags.
+//
 // New panics if a functional metric is missing its compute function or if the
-// function has the wrong type. At this time, functional metrics do not support
-// tagging.
+// function has the wrong type. Tagged functional gauges, declared with
+// [TaggedFunctionalGauge] or [TaggedFunctionalGaugeFloat64], use compute
+// functions that receive the tags, like func(tags ...string) int64.
 //
 // [rcrowley/go-metrics]: https://pkg.go.dev/github.com/rcrowley/go-metrics
 func New[M any]() *M {
+	m, err := NewE[M]()
+	if err != nil {
+		panic("appmetrics.New: " + err.Error())
+	}
+	return m
+}
+
+// NewE is like New, but returns an error instead of panicking if any aspect of
+// the struct definition is invalid. Use NewE when the metrics struct type is
+// not known to be valid at compile time, like when it comes from a plugin.
+func NewE[M any]() (*M, error) {
 	var m M
 
-	typ := reflect.TypeOf(m)
+	typ := reflect.TypeOf(&m).Elem()
 	if typ.Kind() != reflect.Struct {
-		panic("appmetrics.New: type is not a struct")
+		return nil, fmt.Errorf("type %s is not a struct", typ)
 	}
 
 	fields, err := getMetricFields(typ)
 	if err != nil {
-		panic("appmetrics.New: " + err.Error())
+		return nil, fmt.Errorf("type %s: %w", typ, err)
 	}
 
 	v := reflect.ValueOf(&m).Elem()
 	for _, f := range fields {
-		if err := createField(v, f, f.Tag.Get(MetricTag)); err != nil {
-			panic(fmt.Sprintf("appmetrics.New: field %s: %v", f.Name, err))
+		if err := createField(v, f); err != nil {
+			return nil, fmt.Errorf("type %s: field %s: %w", typ, f.Name, err)
 		}
 	}
-	return &m
+	return &m, nil
+}
+
+// RegisterOption configures the behavior of Register.
+type RegisterOption func(*registerOptions)
+
+type registerOptions struct {
+	prefix       string
+	tags         []string
+	strictNames  bool
+	strictTags   bool
+	sanitizeTags bool
+	maxTagLength int
+	onInvalidTag func(name string, tags []string, err error)
+}
+
+// WithStrictTags enables validation of the tags passed to Tagged metrics. See
+// ValidateTags for the rules. Tagged metrics report calls with invalid tags to
+// a series with the single tag "invalid" instead of creating a new series.
+func WithStrictTags() RegisterOption {
+	return func(o *registerOptions) {
+		o.strictTags = true
+	}
+}
+
+// WithMaxTagLength sets the maximum length of tag values in strict mode. The
+// default is DefaultMaxTagLength. WithMaxTagLength enables strict mode.
+func WithMaxTagLength(n int) RegisterOption {
+	return func(o *registerOptions) {
+		o.strictTags = true
+		o.maxTagLength = n
+	}
+}
+
+// WithSanitizedTags replaces invalid characters in the tags passed to Tagged
+// metrics instead of using them as is, so that values like "/a,b" or "x[0]"
+// do not corrupt the names of the metrics. See SanitizeTags for the rules.
+// In strict mode, tags are sanitized before they are validated, so only tags
+// that cannot be repaired, like tags with conflicting values, are reported
+// as invalid.
+func WithSanitizedTags() RegisterOption {
+	return func(o *registerOptions) {
+		o.sanitizeTags = true
+	}
+}
+
+// WithInvalidTagHandler sets a function that is called when a Tagged metric
+// receives invalid tags in strict mode or tags with keys that are not allowed
+// by the "metric-allowed-tag-keys" tag. The function receives the base name
+// of the metric, the original tags, and the validation error. Because valid
+// and invalid lookups are cached, the function may only be called the first
+// time a particular set of tags is used. WithInvalidTagHandler enables strict
+// mode.
+func WithInvalidTagHandler(fn func(name string, tags []string, err error)) RegisterOption {
+	return func(o *registerOptions) {
+		o.strictTags = true
+		o.onInvalidTag = fn
+	}
+}
+
+// WithPrefix adds a prefix to the names of all metrics in the struct. Use it
+// to register multiple instances of the same metrics struct type in one
+// registry, like one instance for each worker:
+//
+//	appmetrics.Register(registry, m, appmetrics.WithPrefix("worker.email."))
+//
+// The prefix is added before any "metric-prefix" tags and is included in the
+// names used by LookupMetadata. Pass the same option to Unregister to remove
+// the metrics.
+func WithPrefix(prefix string) RegisterOption {
+	return func(o *registerOptions) {
+		o.prefix = prefix
+	}
+}
+
+// WithTags adds static tags to all metrics in the struct, in addition to the
+// tags from "metric-tags" tags. Like WithPrefix, use it to register multiple
+// instances of the same metrics struct type in one registry, but with series
+// that emitters can aggregate by tag:
+//
+//	appmetrics.Register(registry, m, appmetrics.WithTags("worker:email"))
+//
+// Pass the same option to Unregister to remove the metrics.
+func WithTags(tags ...string) RegisterOption {
+	return func(o *registerOptions) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// RegisterOptionsProvider is implemented by metrics structs that set their
+// own options for Register, like a struct that always uses strict tags.
+type RegisterOptionsProvider interface {
+	RegisterOptions() []RegisterOption
 }
 
 // Register registers all of the metrics in the struct m with the registry. See
 // New for an explanation of how this package identifies metric fields.
 // Register panics if the struct contains invalid metric definitions.
 //
+// If m implements RegisterOptionsProvider, Register applies the options from
+// the struct before the options passed to Register.
+//
 // Register skips any metric with a name that already exist in the registry,
-// even if the existing metric has a different type.
-func Register[M any](r metrics.Registry, m *M) {
+// even if the existing metric has a different type. Use RegisterStrict or
+// MustRegister to report these conflicts.
+//
+// Register also records the metadata from the "metric-help" and "metric-unit"
+// tags so that emitters can find it with LookupMetadata.
+func Register[M any](r metrics.Registry, m *M, opts ...RegisterOption) {
+	if err := RegisterE(r, m, opts...); err != nil {
+		panic("appmetrics.Register: " + err.Error())
+	}
+}
+
+// RegisterE is like Register, but returns an error instead of panicking if the
+// struct contains invalid metric definitions or if a metric field is nil
+// because the struct was not created by New.
+func RegisterE[M any](r metrics.Registry, m *M, opts ...RegisterOption) error {
+	if m == nil {
+		return fmt.Errorf("metrics struct %T is nil", m)
+	}
+	registered := opts
+	if p, ok := any(m).(RegisterOptionsProvider); ok {
+		opts = append(p.RegisterOptions(), opts...)
+	}
+
+	ro, err := newRegisterOptions(opts)
+	if err != nil {
+		return err
+	}
+
 	v := reflect.ValueOf(m).Elem()
 	if v.Type().Kind() != reflect.Struct {
-		panic("appmetrics.Register: type is not a struct pointer")
+		return fmt.Errorf("type %s is not a struct", v.Type())
 	}
 
 	fields, err := getMetricFields(v.Type())
 	if err != nil {
-		panic("appmetrics.Register: " + err.Error())
+		return fmt.Errorf("type %s: %w", v.Type(), err)
 	}
 
 	for _, f := range fields {
-		name := f.Tag.Get(MetricTag)
-		metric := v.FieldByIndex(f.Index).Interface()
+		if fieldMetric(v.FieldByIndex(f.Index)) == nil {
+			return fmt.Errorf("type %s: field %s: metric is nil; create the struct with New", v.Type(), f.Name)
+		}
+	}
+	if ro.strictNames {
+		if err := checkConflicts(r, v, fields, ro); err != nil {
+			return fmt.Errorf("type %s: %w", v.Type(), err)
+		}
+	}
+	recordMetadata(fields, ro.prefix)
 
-		if m, ok := metric.(interface{ register(metrics.Registry) }); ok {
-			m.register(r)
+	registrations.Store(m, registration{r: r, opts: registered})
+
+	for _, f := range fields {
+		name := f.registeredName(ro)
+		metric := fieldMetric(v.FieldByIndex(f.Index))
+
+		if m, ok := metric.(interface {
+			register(metrics.Registry, registerOptions)
+		}); ok {
+			m.register(r, ro)
+		} else if e, ok := metric.(metrics.EWMA); ok {
+			// Registries do not support EWMA metrics, so report the rate as a
+			// gauge instead
+			_ = r.Register(name, metrics.NewFunctionalGaugeFloat64(e.Rate))
 		} else {
 			_ = r.Register(name, metric)
 		}
 	}
+	return nil
+}
+
+// RegisterStrict is like RegisterE, but also returns an error if the name of
+// any metric in the struct already exists in the registry, including if two
+// metrics in the struct have the same name. The error wraps ErrMetricExists
+// and includes the type of the existing metric if it does not match the type
+// of the field. If there is an error, RegisterStrict does not register any
+// metrics.
+//
+// For Tagged metrics, RegisterStrict only checks the name of the metric
+// without tags. Series created by Tag that conflict with other metrics cause
+// Tag to panic, like metrics.GetOrRegisterCounter.
+func RegisterStrict[M any](r metrics.Registry, m *M, opts ...RegisterOption) error {
+	opts = append(opts, func(o *registerOptions) {
+		o.strictNames = true
+	})
+	return RegisterE(r, m, opts...)
+}
+
+// MustRegister is like RegisterStrict, but panics if there is an error.
+func MustRegister[M any](r metrics.Registry, m *M, opts ...RegisterOption) {
+	if err := RegisterStrict(r, m, opts...); err != nil {
+		panic("appmetrics.MustRegister: " + err.Error())
+	}
+}
+
+// ErrMetricExists is returned by RegisterStrict if a metric name already
+// exists in the registry.
+var ErrMetricExists = errors.New("metric already exists")
+
+// newRegisterOptions applies the options and checks the tags set by
+// WithTags.
+func newRegisterOptions(opts []RegisterOption) (registerOptions, error) {
+	var ro registerOptions
+	for _, opt := range opts {
+		opt(&ro)
+	}
+	if ro.maxTagLength <= 0 {
+		ro.maxTagLength = DefaultMaxTagLength
+	}
+	if len(ro.tags) > 0 {
+		tags, err := parseStaticTags(strings.Join(ro.tags, ","))
+		if err != nil {
+			return ro, fmt.Errorf("invalid tags: %w", err)
+		}
+		ro.tags = tags
+	}
+	return ro, nil
+}
+
+// checkConflicts returns an error for each field with a name that already
+// exists in the registry or that is used by another field.
+func checkConflicts(r metrics.Registry, v reflect.Value, fields []metricField, ro registerOptions) error {
+	var errs []error
+	seen := make(map[string]string)
+	for _, f := range fields {
+		name := f.registeredName(ro)
+		if other, ok := seen[name]; ok {
+			errs = append(errs, fmt.Errorf("field %s: %s: %w: also used by field %s", f.Name, name, ErrMetricExists, other))
+			continue
+		}
+		seen[name] = f.Name
+
+		existing := r.Get(name)
+		if existing == nil {
+			continue
+		}
+		if matchesField(existing, f, fieldMetric(v.FieldByIndex(f.Index))) {
+			errs = append(errs, fmt.Errorf("field %s: %s: %w", f.Name, name, ErrMetricExists))
+		} else {
+			errs = append(errs, fmt.Errorf("field %s: %s: %w with different type %T", f.Name, name, ErrMetricExists, existing))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// matchesField returns true if the existing metric has the type that
+// Register would use for the field.
+func matchesField(existing any, f metricField, metric any) bool {
+	if t, ok := metric.(interface{ accepts(any) bool }); ok {
+		return t.accepts(existing)
+	}
+	if _, ok := metric.(metrics.EWMA); ok {
+		_, ok := existing.(metrics.GaugeFloat64)
+		return ok
+	}
+	return reflect.TypeOf(existing).AssignableTo(f.Type)
 }
 
 // Unregister unregisters all of the metrics in the struct m from the registry.
@@ -184,8 +541,12 @@ func Register[M any](r metrics.Registry, m *M) {
 // Unregister panics if the struct contains invalid metric definitions.
 //
 // Unregistering is generally not required, but is necessary to free meter and
-// timer metrics if they are otherwise unreferenced.
-func Unregister[M any](r metrics.Registry, m *M) {
+// timer metrics if they are otherwise unreferenced, and to free structs
+// created by NewChild. Only the WithPrefix and WithTags options affect
+// Unregister; other options are ignored. If opts is empty and m is registered
+// with r, Unregister uses the options from the registration, so children
+// created by NewChild can be unregistered without repeating their options.
+func Unregister[M any](r metrics.Registry, m *M, opts ...RegisterOption) {
 	v := reflect.ValueOf(m).Elem()
 	if v.Type().Kind() != reflect.Struct {
 		panic("appmetrics.Unregister: type is not a struct pointer")
@@ -196,8 +557,20 @@ func Unregister[M any](r metrics.Registry, m *M) {
 		panic("appmetrics.Unregister: " + err.Error())
 	}
 
+	if reg, ok := lookupRegistration(m); ok && reg.r == r {
+		if len(opts) == 0 {
+			opts = reg.opts
+		}
+		registrations.Delete(m)
+	}
+
+	ro, err := newRegisterOptions(opts)
+	if err != nil {
+		panic("appmetrics.Unregister: " + err.Error())
+	}
+
 	for _, f := range fields {
-		r.Unregister(f.Tag.Get(MetricTag))
+		r.Unregister(f.registeredName(ro))
 	}
 }
 
@@ -217,43 +590,186 @@ func MetricNames[M any](m *M) []string {
 
 	var names []string
 	for _, f := range fields {
-		names = append(names, f.Tag.Get(MetricTag))
+		names = append(names, f.registeredName(registerOptions{}))
 	}
 	return names
 }
 
-func getMetricFields(typ reflect.Type) ([]reflect.StructField, error) {
-	var fields []reflect.StructField
-	for _, f := range reflect.VisibleFields(typ) {
+// metricField is a metric field in a metrics struct or in a nested struct. The
+// index of the embedded StructField is relative to the root struct.
+type metricField struct {
+	reflect.StructField
+
+	// name is the full name of the metric, including any prefixes
+	name string
+
+	// tags are the cleaned and sorted tags from the "metric-tags" tag
+	tags []string
+
+	// limits are the limits on the series of a tagged metric
+	limits tagLimits
+
+	// allowedKeys are the sorted keys from the "metric-allowed-tag-keys" tag,
+	// or nil if any key is allowed
+	allowedKeys []string
+
+	// lazy is true if the metric is created on first use
+	lazy bool
+
+	// owner is the index of the struct that defines the metric, either the
+	// root struct or a struct with the "metric-prefix" tag. Functional gauges
+	// find their compute functions on the owner.
+	owner []int
+}
+
+// registeredName returns the name of the metric with the prefix and the tags
+// from the options and its constant tags. This is the name used in the
+// registry for untagged metrics.
+func (f metricField) registeredName(ro registerOptions) string {
+	return ro.prefix + joinTags(f.name, mergeTags(f.tags, ro.tags))
+}
+
+// fieldCache maps struct types to the metric fields returned by
+// getMetricFields, so that creating many instances of a struct only analyzes
+// its type once.
+var fieldCache sync.Map // reflect.Type -> []metricField
+
+// getMetricFields returns the metric fields of the struct type. Callers must
+// not modify the returned fields, which are shared between calls.
+func getMetricFields(typ reflect.Type) ([]metricField, error) {
+	if fields, ok := fieldCache.Load(typ); ok {
+		return fields.([]metricField), nil
+	}
+
+	// Errors are not cached because some depend on global state, like
+	// custom types that are registered after the first call
+	fields, err := appendMetricFields(nil, typ, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	fieldCache.Store(typ, fields)
+	return fields, nil
+}
+
+func appendMetricFields(fields []metricField, typ reflect.Type, index, owner []int, prefix string) ([]metricField, error) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		f.Index = append(append([]int(nil), index...), i)
+
+		if p, ok := f.Tag.Lookup(MetricPrefixTag); ok {
+			if f.Type.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("field %s: %s tag appears on non-struct type %s", f.Name, MetricPrefixTag, f.Type)
+			}
+			if !f.IsExported() && !f.Anonymous {
+				return nil, fmt.Errorf("field %s: %s tag appears on unexported field", f.Name, MetricPrefixTag)
+			}
+
+			var err error
+			if fields, err = appendMetricFields(fields, f.Type, f.Index, f.Index, prefix+p); err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			continue
+		}
+
 		if metric := f.Tag.Get(MetricTag); metric != "" {
-			if isMetric(f.Type) {
-				fields = append(fields, f)
-			} else {
+			if !isMetric(f.Type) {
 				return nil, fmt.Errorf("field %s: metric tag appears on non-metric type %s", f.Name, f.Type)
 			}
+			if tagged, _ := isTagged(f.Type); !tagged && f.Tag.Get(MetricTagKeysTag) != "" {
+				return nil, fmt.Errorf("field %s: %s tag appears on non-tagged type %s", f.Name, MetricTagKeysTag, f.Type)
+			}
+			if _, typed := asTypedTagged(f.Type); typed && f.Tag.Get(MetricTagKeysTag) != "" {
+				return nil, fmt.Errorf("field %s: %s tag appears on typed tagged type %s", f.Name, MetricTagKeysTag, f.Type)
+			}
+			tags, err := parseStaticTags(f.Tag.Get(MetricTagsTag))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: invalid %s tag: %w", f.Name, MetricTagsTag, err)
+			}
+			if b, ok := f.Tag.Lookup(MetricBucketsTag); ok {
+				if _, typ := isTagged(f.Type); typ != histogramType && typ != timerType && f.Type != histogramType && f.Type != timerType {
+					return nil, fmt.Errorf("field %s: %s tag appears on non-histogram type %s", f.Name, MetricBucketsTag, f.Type)
+				}
+				if _, err := parseBuckets(b); err != nil {
+					return nil, fmt.Errorf("field %s: %w", f.Name, err)
+				}
+			}
+			maxTags, ttl := f.Tag.Get(MetricMaxTagsTag), f.Tag.Get(MetricTagTTLTag)
+			if tagged, _ := isTagged(f.Type); !tagged && (maxTags != "" || ttl != "") {
+				return nil, fmt.Errorf("field %s: tag limits appear on non-tagged type %s", f.Name, f.Type)
+			}
+			limits, err := parseTagLimits(maxTags, ttl)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			allowedKeys, err := parseAllowedTagKeys(f)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			lazy, err := parseLazy(f)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			fields = append(fields, metricField{StructField: f, name: prefix + metric, tags: tags, limits: limits, allowedKeys: allowedKeys, lazy: lazy, owner: owner})
+			continue
+		}
+
+		// Embedded structs without a prefix share the names and the compute
+		// functions of the struct that contains them
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			var err error
+			if fields, err = appendMetricFields(fields, f.Type, f.Index, owner, prefix); err != nil {
+				return nil, err
+			}
 		}
 	}
 	return fields, nil
 }
 
+// parseStaticTags returns the cleaned and sorted tags from the value of a
+// "metric-tags" tag.
+func parseStaticTags(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	tags := cleanAndSortTags(strings.Split(s, ","))
+	if err := validateTags(tags, 0); err != nil {
+		return nil, err
+	}
+	return dedupTags(tags), nil
+}
+
 func isMetric(typ reflect.Type) bool {
 	tagged, taggedType := isTagged(typ)
 	if tagged {
-		typ = taggedType
+		switch taggedType {
+		case ewmaType, healthcheckType, functionalHistogramType, functionalTimerType:
+			return false
+		}
+		return isBuiltinMetric(taggedType)
+	}
+	if _, ok := lookupMetricType(typ); ok {
+		return true
 	}
+	return isBuiltinMetric(typ)
+}
+
+func isBuiltinMetric(typ reflect.Type) bool {
 	switch typ {
 	case counterType, gaugeType, gaugeFloat64Type, histogramType, meterType, timerType:
 		return true
-	case functionalGaugeType, functionalGaugeFloat64Type:
-		// Functional gauges cannot be tagged because there's currently no way
-		// to pass the tags in to the function. Without this, every tag will
-		// report the same value, making the tags redundant.
-		return !tagged
+	case functionalGaugeType, functionalGaugeFloat64Type, ewmaType, healthcheckType:
+		return true
+	case functionalHistogramType, functionalTimerType:
+		return true
+	case counterFloat64Type, upDownCounterType, durationGaugeType:
+		return true
+	case maxGaugeType, minGaugeType, intervalCounterType:
+		return true
 	}
 	return false
 }
 
-func createField(v reflect.Value, f reflect.StructField, metricName string) error {
+func createField(v reflect.Value, f metricField) error {
 	metricType := f.Type
 
 	tagged, taggedType := isTagged(metricType)
@@ -266,13 +782,71 @@ func createField(v reflect.Value, f reflect.StructField, metricName string) erro
 	case counterType:
 		newMetric := metrics.NewCounter
 		if tagged {
-			value = &taggedMetric[metrics.Counter]{name: metricName, newMetric: newMetric}
+			value = &taggedMetric[metrics.Counter]{newMetric: newMetric}
+		} else {
+			value = newMetric()
+		}
+
+	case counterFloat64Type:
+		newMetric := NewCounterFloat64
+		if tagged {
+			value = &taggedMetric[CounterFloat64]{newMetric: newMetric}
+		} else {
+			value = newMetric()
+		}
+
+	case intervalCounterType:
+		newMetric := NewIntervalCounter
+		if tagged {
+			value = &taggedMetric[IntervalCounter]{newMetric: newMetric}
+		} else {
+			value = newMetric()
+		}
+
+	case upDownCounterType:
+		newMetric := NewUpDownCounter
+		if tagged {
+			value = &taggedMetric[UpDownCounter]{newMetric: newMetric}
+		} else {
+			value = newMetric()
+		}
+
+	case durationGaugeType:
+		newMetric := NewDurationGauge
+		if tagged {
+			value = &taggedMetric[DurationGauge]{newMetric: newMetric}
+		} else {
+			value = newMetric()
+		}
+
+	case maxGaugeType:
+		newMetric := NewMaxGauge
+		if tagged {
+			value = &taggedMetric[MaxGauge]{newMetric: newMetric}
+		} else {
+			value = newMetric()
+		}
+
+	case minGaugeType:
+		newMetric := NewMinGauge
+		if tagged {
+			value = &taggedMetric[MinGauge]{newMetric: newMetric}
 		} else {
 			value = newMetric()
 		}
 
 	case functionalGaugeType:
-		fn, err := getGaugeFunction[int64](v, f.Name)
+		if tagged {
+			fn, err := getTaggedGaugeFunction[int64](v.FieldByIndex(f.owner), f.Name)
+			if err != nil {
+				return err
+			}
+			value = &taggedMetric[FunctionalGauge]{newTagged: func(tags []string) FunctionalGauge {
+				return metrics.NewFunctionalGauge(func() int64 { return fn(tags...) })
+			}}
+			break
+		}
+		fn, err := getGaugeFunction[int64](v.FieldByIndex(f.owner), f.Name)
 		if err != nil {
 			return err
 		}
@@ -281,13 +855,23 @@ func createField(v reflect.Value, f reflect.StructField, metricName string) erro
 	case gaugeType:
 		newMetric := metrics.NewGauge
 		if tagged {
-			value = &taggedMetric[metrics.Gauge]{name: metricName, newMetric: newMetric}
+			value = &taggedMetric[metrics.Gauge]{newMetric: newMetric}
 		} else {
 			value = newMetric()
 		}
 
 	case functionalGaugeFloat64Type:
-		fn, err := getGaugeFunction[float64](v, f.Name)
+		if tagged {
+			fn, err := getTaggedGaugeFunction[float64](v.FieldByIndex(f.owner), f.Name)
+			if err != nil {
+				return err
+			}
+			value = &taggedMetric[FunctionalGaugeFloat64]{newTagged: func(tags []string) FunctionalGaugeFloat64 {
+				return metrics.NewFunctionalGaugeFloat64(func() float64 { return fn(tags...) })
+			}}
+			break
+		}
+		fn, err := getGaugeFunction[float64](v.FieldByIndex(f.owner), f.Name)
 		if err != nil {
 			return err
 		}
@@ -296,7 +880,7 @@ func createField(v reflect.Value, f reflect.StructField, metricName string) erro
 	case gaugeFloat64Type:
 		newMetric := metrics.NewGaugeFloat64
 		if tagged {
-			value = &taggedMetric[metrics.GaugeFloat64]{name: metricName, newMetric: newMetric}
+			value = &taggedMetric[metrics.GaugeFloat64]{newMetric: newMetric}
 		} else {
 			value = newMetric()
 		}
@@ -316,17 +900,32 @@ func createField(v reflect.Value, f reflect.StructField, metricName string) erro
 				return metrics.NewHistogram(s())
 			}
 		}
-		if tagged {
-			value = &taggedMetric[metrics.Histogram]{name: metricName, newMetric: newMetric}
-		} else {
+		newMetric, err := withBuckets(f, newMetric)
+		if err != nil {
+			return err
+		}
+		switch {
+		case tagged:
+			value = &taggedMetric[metrics.Histogram]{newMetric: newMetric}
+		case f.lazy:
+			if value, err = withLazy(f, newMetric); err != nil {
+				return err
+			}
+		default:
 			value = newMetric()
 		}
 
 	case meterType:
 		newMetric := metrics.NewMeter
-		if tagged {
-			value = &taggedMetric[metrics.Meter]{name: metricName, newMetric: newMetric}
-		} else {
+		switch {
+		case tagged:
+			value = &taggedMetric[metrics.Meter]{newMetric: newMetric}
+		case f.lazy:
+			var err error
+			if value, err = withLazy(f, newMetric); err != nil {
+				return err
+			}
+		default:
 			value = newMetric()
 		}
 
@@ -341,17 +940,87 @@ func createField(v reflect.Value, f reflect.StructField, metricName string) erro
 				return metrics.NewCustomTimer(metrics.NewHistogram(s()), metrics.NewMeter())
 			}
 		}
-		if tagged {
-			value = &taggedMetric[metrics.Timer]{name: metricName, newMetric: newMetric}
-		} else {
+		newMetric, err := withBuckets(f, newMetric)
+		if err != nil {
+			return err
+		}
+		switch {
+		case tagged:
+			value = &taggedMetric[metrics.Timer]{newMetric: newMetric}
+		case f.lazy:
+			if value, err = withLazy(f, newMetric); err != nil {
+				return err
+			}
+		default:
 			value = newMetric()
 		}
+
+	case functionalHistogramType:
+		fn, err := getSampleFunction[int64](v.FieldByIndex(f.owner), f.Name)
+		if err != nil {
+			return err
+		}
+		value = newFunctionalHistogram(fn)
+
+	case functionalTimerType:
+		fn, err := getSampleFunction[time.Duration](v.FieldByIndex(f.owner), f.Name)
+		if err != nil {
+			return err
+		}
+		value = newFunctionalTimer(fn)
+
+	case ewmaType:
+		alpha, err := parseAlpha(f.Tag.Get(MetricAlphaTag))
+		if err != nil {
+			return err
+		}
+		value = metrics.NewEWMA(alpha)
+
+	case healthcheckType:
+		fn, err := getHealthcheckFunction(v.FieldByIndex(f.owner), f.Name)
+		if err != nil {
+			return err
+		}
+		value = metrics.NewHealthcheck(func(h metrics.Healthcheck) {
+			if err := fn(); err != nil {
+				h.Unhealthy(err)
+			} else {
+				h.Healthy()
+			}
+		})
+
+	default:
+		var err error
+		if value, err = newCustomMetric(metricType); err != nil {
+			return err
+		}
+	}
+
+	if t, ok := value.(interface{ init(metricField) }); ok {
+		t.init(f)
 	}
 
-	v.FieldByIndex(f.Index).Set(reflect.ValueOf(value))
+	field := v.FieldByIndex(f.Index)
+	if _, typed := asTypedTagged(f.Type); typed {
+		field.Addr().Interface().(interface{ setTagged(any) }).setTagged(value)
+		return nil
+	}
+	field.Set(reflect.ValueOf(value))
 	return nil
 }
 
+// fieldMetric returns the metric stored in a field or nil if the metric was
+// not created.
+func fieldMetric(field reflect.Value) any {
+	if t, ok := field.Interface().(typedTagged); ok {
+		return t.tagged()
+	}
+	if field.IsNil() {
+		return nil
+	}
+	return field.Interface()
+}
+
 func parseSample(s string) (func() metrics.Sample, error) {
 	parts := strings.Split(strings.ToLower(s), ",")
 	switch parts[0] {
@@ -359,11 +1028,35 @@ func parseSample(s string) (func() metrics.Sample, error) {
 		return parseUniformSample(parts)
 	case "expdecay":
 		return parseExpDecaySample(parts)
+	case "window":
+		return parseWindowSample(parts)
 	default:
 		return nil, fmt.Errorf("invalid sample type")
 	}
 }
 
+// parseAlpha returns the smoothing factor from the value of a "metric-alpha"
+// tag.
+func parseAlpha(s string) (float64, error) {
+	switch s {
+	case "", "1m":
+		return 1 - math.Exp(-5.0/60.0/1), nil
+	case "5m":
+		return 1 - math.Exp(-5.0/60.0/5), nil
+	case "15m":
+		return 1 - math.Exp(-5.0/60.0/15), nil
+	}
+
+	alpha, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid alpha: %w", err)
+	}
+	if alpha <= 0 || alpha > 1 {
+		return 0, fmt.Errorf("invalid alpha: must be between 0 and 1")
+	}
+	return alpha, nil
+}
+
 func parseUniformSample(parts []string) (func() metrics.Sample, error) {
 	var fn func() metrics.Sample
 	switch len(parts) {
@@ -409,3 +1102,28 @@ func parseExpDecaySample(parts []string) (func() metrics.Sample, error) {
 	}
 	return fn, nil
 }
+
+func parseWindowSample(parts []string) (func() metrics.Sample, error) {
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid window sample")
+	}
+
+	window, err := time.ParseDuration(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid window sample: window: %w", err)
+	}
+	if window <= 0 {
+		return nil, fmt.Errorf("invalid window sample: window must be positive")
+	}
+
+	rs := DefaultReservoirSize
+	if len(parts) == 3 {
+		if rs, err = strconv.Atoi(parts[2]); err != nil {
+			return nil, fmt.Errorf("invalid window sample: reservoir: %w", err)
+		}
+	}
+
+	return func() metrics.Sample {
+		return NewWindowSample(window, rs)
+	}, nil
+}
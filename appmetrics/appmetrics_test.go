@@ -15,10 +15,16 @@
 package appmetrics
 
 import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/rcrowley/go-metrics"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type SimpleMetrics struct {
@@ -41,9 +47,29 @@ func (m *FunctionalMetrics) ComputeActiveWorkers() int64 {
 	return m.workers
 }
 
+type FunctionalSampleMetrics struct {
+	RowSizes    FunctionalHistogram `metric:"row_sizes"`
+	MessageAges FunctionalTimer     `metric:"message_ages"`
+
+	ComputeMessageAges func() []time.Duration
+}
+
+func (m *FunctionalSampleMetrics) ComputeRowSizes() []int64 {
+	return []int64{10, 20, 30}
+}
+
+type AccountingMetrics struct {
+	Cost        CounterFloat64         `metric:"cost"`
+	Connections UpDownCounter          `metric:"connections"`
+	TenantCost  Tagged[CounterFloat64] `metric:"tenant.cost"`
+	SyncAge     DurationGauge          `metric:"sync.age"`
+	Retries     IntervalCounter        `metric:"retries"`
+}
+
 type SampleMetrics struct {
 	LatencyA metrics.Histogram `metric:"latency.a" metric-sample:"uniform,100"`
 	LatencyB metrics.Histogram `metric:"latency.b" metric-sample:"expdecay,20,0.1"`
+	LatencyC metrics.Timer     `metric:"latency.c" metric-sample:"window,1m,100"`
 }
 
 type TaggedMetrics struct {
@@ -51,6 +77,33 @@ type TaggedMetrics struct {
 	QueueSize Tagged[metrics.Gauge]   `metric:"queue_size"`
 }
 
+type StrictMetrics struct {
+	Responses Tagged[metrics.Counter] `metric:"responses"`
+}
+
+func (m *StrictMetrics) RegisterOptions() []RegisterOption {
+	return []RegisterOption{WithStrictTags()}
+}
+
+type AllowedTagMetrics struct {
+	Responses Tagged[metrics.Counter] `metric:"responses" metric-tags:"component:api" metric-allowed-tag-keys:"status,method"`
+}
+
+type DBMetrics struct {
+	Queries     metrics.Counter         `metric:"queries"`
+	Connections FunctionalGauge         `metric:"connections"`
+	Errors      Tagged[metrics.Counter] `metric:"errors"`
+
+	ComputeConnections func() int64
+}
+
+type NestedMetrics struct {
+	SimpleMetrics
+	Requests metrics.Counter `metric:"requests"`
+	Primary  DBMetrics       `metric-prefix:"db.primary."`
+	Replica  DBMetrics       `metric-prefix:"db.replica."`
+}
+
 func TestNew(t *testing.T) {
 	t.Run("simple", func(t *testing.T) {
 		m := New[SimpleMetrics]()
@@ -70,6 +123,80 @@ func TestNew(t *testing.T) {
 		assert.Equal(t, float64(20), m.Temperature.Value())
 	})
 
+	t.Run("functionalSample", func(t *testing.T) {
+		m := New[FunctionalSampleMetrics]()
+		ages := []time.Duration{time.Second}
+		m.ComputeMessageAges = func() []time.Duration { return ages }
+
+		assert.Equal(t, int64(3), m.RowSizes.Count())
+		assert.Equal(t, int64(60), m.RowSizes.Sum())
+		assert.Equal(t, float64(20), m.RowSizes.Snapshot().Percentile(0.5))
+
+		assert.Equal(t, int64(time.Second), m.MessageAges.Max())
+		ages = append(ages, 3*time.Second)
+		s := m.MessageAges.Snapshot()
+		ages = nil
+		assert.Equal(t, int64(2), s.Count())
+		assert.Equal(t, float64(2*time.Second), s.Mean())
+		assert.Zero(t, m.MessageAges.Count())
+
+		r := metrics.NewRegistry()
+		Register(r, m)
+		assert.Implements(t, (*metrics.Histogram)(nil), r.Get("row_sizes"))
+		assert.Implements(t, (*metrics.Timer)(nil), r.Get("message_ages"))
+		assert.Panics(t, func() { r.Get("row_sizes").(metrics.Histogram).Update(1) })
+
+		type Missing struct {
+			Sizes FunctionalHistogram `metric:"sizes"`
+		}
+		_, err := NewE[Missing]()
+		assert.Error(t, err)
+
+		type WrongType struct {
+			Ages FunctionalTimer `metric:"ages"`
+
+			ComputeAges func() []int64
+		}
+		_, err = NewE[WrongType]()
+		assert.Error(t, err)
+	})
+
+	t.Run("accounting", func(t *testing.T) {
+		m := New[AccountingMetrics]()
+		m.Cost.Inc(0.25)
+		m.Cost.Inc(0.5)
+		assert.Equal(t, 0.75, m.Cost.Count())
+		assert.Panics(t, func() { m.Cost.Inc(-1) })
+
+		s := m.Cost.Snapshot()
+		m.Cost.Clear()
+		assert.Equal(t, 0.75, s.Value())
+		assert.Zero(t, m.Cost.Count())
+
+		m.Connections.Inc(3)
+		m.Connections.Dec(1)
+		assert.Equal(t, int64(2), m.Connections.Value())
+
+		m.Retries.Inc(3)
+		m.Retries.Dec(1)
+		assert.Equal(t, int64(2), m.Retries.Reset())
+		assert.Zero(t, m.Retries.Count(), "reset should start a new interval")
+
+		m.SyncAge.UpdateDuration(1500 * time.Millisecond)
+		assert.Equal(t, 1500*time.Millisecond, m.SyncAge.Duration())
+		assert.Equal(t, int64(1500*time.Millisecond), m.SyncAge.Snapshot().Value())
+
+		r := metrics.NewRegistry()
+		Register(r, m)
+		m.TenantCost.Tag("tenant:a").Inc(1.5)
+		assert.Equal(t, 1.5, m.TenantCost.Tag("tenant:a").Count())
+		assert.Implements(t, (*metrics.Gauge)(nil), r.Get("connections"))
+		assert.Implements(t, (*CounterFloat64)(nil), r.Get("tenant.cost[tenant:a]"))
+		assert.Implements(t, (*DurationGauge)(nil), r.Get("sync.age"))
+		assert.Implements(t, (*metrics.Counter)(nil), r.Get("retries"))
+		assert.Equal(t, 1500*time.Millisecond, Snapshot(m)["SyncAge"])
+	})
+
 	t.Run("sample", func(t *testing.T) {
 		m := New[SampleMetrics]()
 		m.LatencyA.Update(300)
@@ -77,6 +204,9 @@ func TestNew(t *testing.T) {
 
 		assert.IsType(t, &metrics.UniformSample{}, m.LatencyA.Sample(), "incorrect sample type")
 		assert.IsType(t, &metrics.ExpDecaySample{}, m.LatencyB.Sample(), "incorrect sample type")
+
+		m.LatencyC.Update(10 * time.Millisecond)
+		assert.Equal(t, int64(10*time.Millisecond), m.LatencyC.Max())
 	})
 
 	t.Run("tagged", func(t *testing.T) {
@@ -84,4 +214,890 @@ func TestNew(t *testing.T) {
 		m.Responses.Tag("code:200").Inc(1)
 		m.QueueSize.Tag("reindex").Update(12)
 	})
+
+	t.Run("nested", func(t *testing.T) {
+		m := New[NestedMetrics]()
+		m.Primary.ComputeConnections = func() int64 { return 4 }
+		m.Replica.ComputeConnections = func() int64 { return 2 }
+
+		assert.Equal(t, int64(4), m.Primary.Connections.Value())
+		assert.Equal(t, int64(2), m.Replica.Connections.Value())
+
+		r := metrics.NewRegistry()
+		Register(r, m)
+		m.Primary.Queries.Inc(3)
+		m.Replica.Errors.Tag("timeout").Inc(1)
+		m.FooCount.Inc(1)
+
+		assert.Equal(t, int64(3), r.Get("db.primary.queries").(metrics.Counter).Count())
+		assert.Equal(t, int64(1), r.Get("db.replica.errors[timeout]").(metrics.Counter).Count())
+		assert.Equal(t, int64(1), r.Get("foo.count").(metrics.Counter).Count())
+		assert.ElementsMatch(t, []string{
+			"foo.count", "bar.count", "active_workers", "requests",
+			"db.primary.queries", "db.primary.connections", "db.primary.errors",
+			"db.replica.queries", "db.replica.connections", "db.replica.errors",
+		}, MetricNames(m))
+	})
+
+	t.Run("fieldCache", func(t *testing.T) {
+		type Cached struct {
+			Requests metrics.Counter `metric:"requests"`
+		}
+		typ := reflect.TypeOf(Cached{})
+
+		fields, err := getMetricFields(typ)
+		require.NoError(t, err)
+		cached, ok := fieldCache.Load(typ)
+		require.True(t, ok, "fields should be cached")
+		assert.Equal(t, fields, cached)
+
+		again, err := getMetricFields(typ)
+		require.NoError(t, err)
+		assert.Same(t, &fields[0], &again[0], "second call should return cached fields")
+
+		type Invalid struct {
+			Requests int `metric:"requests"`
+		}
+		_, err = getMetricFields(reflect.TypeOf(Invalid{}))
+		assert.Error(t, err)
+		_, ok = fieldCache.Load(reflect.TypeOf(Invalid{}))
+		assert.False(t, ok, "errors should not be cached")
+	})
+
+	t.Run("invalidPrefix", func(t *testing.T) {
+		type InvalidMetrics struct {
+			Requests metrics.Counter `metric-prefix:"api."`
+		}
+		assert.Panics(t, func() { New[InvalidMetrics]() })
+	})
+
+	t.Run("invalidWindow", func(t *testing.T) {
+		type InvalidMetrics struct {
+			Latency metrics.Timer `metric:"latency" metric-sample:"window,forever"`
+		}
+		_, err := NewE[InvalidMetrics]()
+		assert.ErrorContains(t, err, "invalid window sample: window")
+	})
+
+	t.Run("invalidTagKeys", func(t *testing.T) {
+		type InvalidMetrics struct {
+			Requests metrics.Counter `metric:"requests" metric-tag-keys:"status:int"`
+		}
+		assert.Panics(t, func() { New[InvalidMetrics]() })
+	})
+}
+
+func TestNewE(t *testing.T) {
+	m, err := NewE[SimpleMetrics]()
+	require.NoError(t, err)
+	assert.NotNil(t, m.FooCount)
+
+	_, err = NewE[int]()
+	assert.EqualError(t, err, "type int is not a struct")
+
+	type InvalidMetrics struct {
+		Count int `metric:"count"`
+	}
+	_, err = NewE[InvalidMetrics]()
+	assert.EqualError(t, err, "type appmetrics.InvalidMetrics: field Count: metric tag appears on non-metric type int")
+
+	type MissingFunction struct {
+		Workers FunctionalGauge `metric:"workers"`
+	}
+	_, err = NewE[MissingFunction]()
+	assert.ErrorContains(t, err, "field Workers: ComputeWorkers: method or field does not exist")
+}
+
+func TestRegisterE(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	require.NoError(t, RegisterE(r, New[SimpleMetrics]()))
+	assert.NotNil(t, r.Get("foo.count"))
+
+	err := RegisterE(r, &SimpleMetrics{})
+	assert.EqualError(t, err, "type appmetrics.SimpleMetrics: field FooCount: metric is nil; create the struct with New")
+	assert.Panics(t, func() { Register(r, &SimpleMetrics{}) })
+
+	assert.Error(t, RegisterE[SimpleMetrics](r, nil))
+}
+
+func TestTagged(t *testing.T) {
+	t.Run("cache", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[TaggedMetrics]()
+		Register(r, m)
+
+		c := m.Responses.Tag("code:200", "method:GET")
+		c.Inc(1)
+
+		assert.Same(t, c, m.Responses.Tag("code:200", "method:GET"), "repeated tags should return the same metric")
+		assert.Same(t, c, m.Responses.Tag("method:GET", " code:200"), "equivalent tags should return the same metric")
+		assert.Same(t, c, r.Get("responses[code:200,method:GET]"), "cached metric should be registered")
+	})
+
+	t.Run("cacheKeys", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[TaggedMetrics]()
+		Register(r, m)
+
+		single := m.Responses.Tag("a\x00b")
+		multiple := m.Responses.Tag("a", "b")
+
+		assert.NotSame(t, single, multiple, "different tags should not share a cache entry")
+		assert.Same(t, single, r.Get("responses[a\x00b]"))
+		assert.Same(t, multiple, r.Get("responses[a,b]"))
+	})
+
+	t.Run("unregister", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[TaggedMetrics]()
+		Register(r, m)
+
+		m.Responses.Tag("code:200").Inc(1)
+		r.Unregister("responses[code:200]")
+
+		c := m.Responses.Tag("code:200")
+		c.Inc(1)
+
+		assert.Same(t, c, r.Get("responses[code:200]"), "unregistered metrics should be registered again")
+		assert.Equal(t, int64(1), c.Count())
+	})
+
+	t.Run("tracked", func(t *testing.T) {
+		r := NewTrackedRegistry(metrics.NewPrefixedChildRegistry(metrics.NewRegistry(), "app."))
+		m := New[TaggedMetrics]()
+		Register(r, m)
+
+		tags := []string{"code:200"}
+		c := m.Responses.Tag(tags...)
+		assert.Same(t, c, m.Responses.Tag("code:200"))
+		assert.Zero(t, testing.AllocsPerRun(100, func() { m.Responses.Tag(tags...).Inc(1) }), "cached metrics should not look up prefixed names")
+
+		r.Unregister("responses[code:200]")
+		c = m.Responses.Tag("code:200")
+		assert.Same(t, c, r.Get("responses[code:200]"), "unregistered metrics should be registered again")
+
+		r.UnregisterAll()
+		c = m.Responses.Tag("code:200")
+		assert.Same(t, c, r.Get("responses[code:200]"), "unregistered metrics should be registered again")
+	})
+
+	t.Run("reregister", func(t *testing.T) {
+		m := New[TaggedMetrics]()
+
+		r1 := metrics.NewRegistry()
+		Register(r1, m)
+		m.Responses.Tag("code:200").Inc(1)
+
+		r2 := metrics.NewRegistry()
+		Register(r2, m)
+		m.Responses.Tag("code:200").Inc(1)
+
+		assert.Equal(t, int64(1), r1.Get("responses[code:200]").(metrics.Counter).Count())
+		assert.Equal(t, int64(1), r2.Get("responses[code:200]").(metrics.Counter).Count())
+	})
+
+	t.Run("tagSet", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[StaticTagMetrics]()
+		Register(r, m)
+
+		tags := []string{"code:200", "method:GET"}
+		s := NewTagSet(m.Responses, tags...)
+		tags[0] = "code:500"
+
+		c := s.Metric()
+		assert.Same(t, c, r.Get("responses[code:200,component:api,method:GET]"))
+		assert.Same(t, c, m.Responses.Tag("method:GET", "code:200"))
+		assert.Same(t, c, s.Metric(), "repeated lookups should return the same metric")
+
+		r.Unregister("responses[code:200,component:api,method:GET]")
+		assert.Same(t, s.Metric(), r.Get("responses[code:200,component:api,method:GET]"), "unregistered metrics should be registered again")
+
+		assert.Zero(t, testing.AllocsPerRun(100, func() { s.Metric().Inc(1) }))
+	})
+}
+
+type StaticTagMetrics struct {
+	Requests  metrics.Counter         `metric:"requests" metric-tags:"region:us, component:api"`
+	Responses Tagged[metrics.Counter] `metric:"responses" metric-tags:"component:api"`
+	Workers   FunctionalGauge         `metric:"workers" metric-tags:"pool:default"`
+
+	ComputeWorkers func() int64
+}
+
+type invalidStaticTags struct {
+	Requests metrics.Counter `metric:"requests" metric-tags:"region:us,region:eu"`
+}
+
+type DuplicateMetrics struct {
+	Requests metrics.Counter `metric:"requests"`
+	Total    metrics.Counter `metric:"requests"`
+}
+
+func TestRegisterStrict(t *testing.T) {
+	r := metrics.NewRegistry()
+	require.NoError(t, RegisterStrict(r, New[TaggedMetrics]()))
+
+	err := RegisterStrict(r, New[TaggedMetrics]())
+	assert.ErrorIs(t, err, ErrMetricExists)
+	assert.ErrorContains(t, err, "field Responses: responses: metric already exists")
+	assert.NotContains(t, err.Error(), "different type")
+
+	_ = r.Register("foo.count", metrics.NewGauge())
+	err = RegisterStrict(r, New[SimpleMetrics]())
+	assert.ErrorContains(t, err, "field FooCount: foo.count: metric already exists with different type *metrics.StandardGauge")
+	assert.Nil(t, r.Get("bar.count"), "no metrics should be registered after a conflict")
+
+	require.NoError(t, RegisterStrict(r, New[SimpleMetrics](), WithPrefix("other.")))
+
+	err = RegisterStrict(metrics.NewRegistry(), New[DuplicateMetrics]())
+	assert.ErrorContains(t, err, "field Total: requests: metric already exists: also used by field Requests")
+
+	assert.Panics(t, func() { MustRegister(r, New[SimpleMetrics]()) })
+	assert.NotPanics(t, func() { Register(r, New[SimpleMetrics]()) })
+}
+
+type BucketMetrics struct {
+	Size      metrics.Histogram       `metric:"size" metric-buckets:"10,100,1000"`
+	Latency   metrics.Timer           `metric:"latency" metric-buckets:"0.01,0.1,1"`
+	Responses Tagged[metrics.Timer]   `metric:"responses" metric-buckets:"0.5"`
+	Plain     metrics.Histogram       `metric:"plain"`
+	Requests  Tagged[metrics.Counter] `metric:"requests"`
+}
+
+func TestBuckets(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := New[BucketMetrics]()
+	Register(r, m)
+
+	for _, v := range []int64{5, 10, 50, 500, 5000} {
+		m.Size.Update(v)
+	}
+	assert.Equal(t, BucketCounts{
+		Bounds: []float64{10, 100, 1000},
+		Counts: []uint64{2, 3, 4},
+		Count:  5,
+		Sum:    5565,
+	}, m.Size.(Bucketed).Buckets())
+	assert.Equal(t, int64(5), m.Size.Count(), "sample should record values")
+
+	m.Latency.Update(50 * time.Millisecond)
+	m.Latency.Time(func() {})
+	m.Latency.UpdateSince(time.Now().Add(-2 * time.Second))
+	b := m.Latency.(Bucketed).Buckets()
+	assert.Equal(t, []uint64{1, 2, 2}, b.Counts)
+	assert.Equal(t, uint64(3), b.Count)
+	assert.Equal(t, int64(3), m.Latency.Count())
+
+	m.Responses.Tag("code:200").Update(time.Second)
+	b = r.Get("responses[code:200]").(Bucketed).Buckets()
+	assert.Equal(t, []uint64{0}, b.Counts)
+	assert.Equal(t, uint64(1), b.Count)
+
+	_, ok := m.Plain.(Bucketed)
+	assert.False(t, ok, "histograms without buckets should not implement Bucketed")
+
+	m.Size.Clear()
+	assert.Equal(t, uint64(0), m.Size.(Bucketed).Buckets().Count)
+
+	type invalidOrder struct {
+		Size metrics.Histogram `metric:"size" metric-buckets:"1,0.5"`
+	}
+	_, err := NewE[invalidOrder]()
+	assert.ErrorContains(t, err, "field Size: invalid metric-buckets tag: bounds must be in increasing order")
+
+	type invalidType struct {
+		Count metrics.Counter `metric:"count" metric-buckets:"1,2"`
+	}
+	_, err = NewE[invalidType]()
+	assert.ErrorContains(t, err, "field Count: metric-buckets tag appears on non-histogram type metrics.Counter")
+
+	type invalidValue struct {
+		Size metrics.Histogram `metric:"size" metric-buckets:"1,+Inf"`
+	}
+	_, err = NewE[invalidValue]()
+	assert.ErrorContains(t, err, "is not a finite number")
+}
+
+func TestWithPrefix(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	email := New[NestedMetrics]()
+	sms := New[NestedMetrics]()
+	email.Primary.ComputeConnections = func() int64 { return 1 }
+	email.Replica.ComputeConnections = func() int64 { return 2 }
+	sms.Primary.ComputeConnections = func() int64 { return 3 }
+	sms.Replica.ComputeConnections = func() int64 { return 4 }
+
+	Register(r, email, WithPrefix("worker.email."))
+	Register(r, sms, WithPrefix("worker.sms."))
+
+	email.Requests.Inc(1)
+	sms.Requests.Inc(2)
+	email.Primary.Errors.Tag("code:timeout").Inc(3)
+
+	assert.Equal(t, int64(1), r.Get("worker.email.requests").(metrics.Counter).Count())
+	assert.Equal(t, int64(2), r.Get("worker.sms.requests").(metrics.Counter).Count())
+	assert.Equal(t, int64(3), r.Get("worker.email.db.primary.errors[code:timeout]").(metrics.Counter).Count())
+	assert.NotNil(t, r.Get("worker.sms.db.primary.errors"), "bare tagged metric should have the prefix")
+	assert.Equal(t, int64(4), r.Get("worker.sms.db.replica.connections").(metrics.Gauge).Value())
+	assert.Nil(t, r.Get("requests"))
+
+	Unregister(r, email, WithPrefix("worker.email."))
+	assert.Nil(t, r.Get("worker.email.requests"))
+	assert.NotNil(t, r.Get("worker.sms.requests"))
+}
+
+func TestStaticTags(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := New[StaticTagMetrics]()
+	m.ComputeWorkers = func() int64 { return 4 }
+	Register(r, m)
+
+	m.Requests.Inc(1)
+	m.Responses.Tag("code:200").Inc(2)
+
+	assert.Equal(t, []string{
+		"requests[component:api,region:us]",
+		"responses[component:api]",
+		"workers[pool:default]",
+	}, MetricNames(m))
+
+	assert.Equal(t, int64(1), r.Get("requests[component:api,region:us]").(metrics.Counter).Count())
+	assert.Equal(t, int64(2), r.Get("responses[code:200,component:api]").(metrics.Counter).Count())
+	assert.NotNil(t, r.Get("responses[component:api]"), "bare tagged metric should have the constant tags")
+	assert.Equal(t, int64(4), r.Get("workers[pool:default]").(metrics.Gauge).Value())
+	assert.Nil(t, r.Get("requests"))
+
+	Unregister(r, m)
+	assert.Nil(t, r.Get("requests[component:api,region:us]"))
+
+	_, err := NewE[invalidStaticTags]()
+	assert.ErrorContains(t, err, "field Requests: invalid metric-tags tag")
+}
+
+func TestNewChild(t *testing.T) {
+	r := metrics.NewRegistry()
+	parent := New[StaticTagMetrics]()
+	Register(r, parent, WithPrefix("pool."))
+
+	children := make([]*StaticTagMetrics, 2)
+	for i := range children {
+		children[i] = NewChild(parent, WithTags("worker:"+strconv.Itoa(i)))
+		children[i].ComputeWorkers = func() int64 { return int64(i + 1) }
+	}
+
+	parent.Requests.Inc(1)
+	children[0].Requests.Inc(2)
+	children[1].Responses.Tag("code:200").Inc(3)
+
+	assert.Equal(t, int64(1), r.Get("pool.requests[component:api,region:us]").(metrics.Counter).Count())
+	assert.Equal(t, int64(2), r.Get("pool.requests[component:api,region:us,worker:0]").(metrics.Counter).Count())
+	assert.Equal(t, int64(3), r.Get("pool.responses[code:200,component:api,worker:1]").(metrics.Counter).Count())
+	assert.NotNil(t, r.Get("pool.responses[component:api,worker:0]"), "bare tagged metric should have the child tags")
+	assert.Equal(t, int64(2), r.Get("pool.workers[pool:default,worker:1]").(metrics.Gauge).Value())
+
+	other := NewChild(parent, WithPrefix("other."))
+	other.Requests.Inc(4)
+	assert.Equal(t, int64(4), r.Get("other.requests[component:api,region:us]").(metrics.Counter).Count())
+
+	assert.Panics(t, func() { NewChild(parent) }, "children should not reuse the names of the parent")
+	assert.Panics(t, func() { NewChild(New[StaticTagMetrics]()) }, "parents should be registered")
+
+	Unregister(r, children[0])
+	assert.Nil(t, r.Get("pool.requests[component:api,region:us,worker:0]"))
+	assert.NotNil(t, r.Get("pool.requests[component:api,region:us,worker:1]"))
+	assert.Panics(t, func() { NewChild(children[0]) }, "unregistered structs should not have children")
+
+	assert.Panics(t, func() { Register(r, New[SimpleMetrics](), WithTags("bad key:value")) })
+}
+
+type HealthMetrics struct {
+	Load     metrics.EWMA        `metric:"load"`
+	Requests metrics.EWMA        `metric:"requests" metric-alpha:"0.5"`
+	Database metrics.Healthcheck `metric:"database"`
+	Cache    metrics.Healthcheck `metric:"cache"`
+
+	CheckCache func() error
+
+	dbErr error
+}
+
+func (m *HealthMetrics) CheckDatabase() error {
+	return m.dbErr
+}
+
+func TestEWMAAndHealthcheck(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := New[HealthMetrics]()
+	Register(r, m)
+
+	m.Requests.Update(10)
+	m.Requests.Tick()
+	assert.Equal(t, 2.0, m.Requests.Rate(), "the first tick should set the rate")
+	assert.Equal(t, 2.0, r.Get("requests").(metrics.GaugeFloat64).Value(), "EWMA should register as a gauge")
+
+	m.dbErr = errors.New("connection refused")
+	m.CheckCache = func() error { return nil }
+	r.RunHealthchecks()
+
+	assert.EqualError(t, m.Database.Error(), "connection refused")
+	assert.NoError(t, m.Cache.Error())
+
+	snapshot := Snapshot(m)
+	assert.Equal(t, "connection refused", snapshot["Database"])
+	assert.Nil(t, snapshot["Cache"])
+
+	type invalidAlpha struct {
+		Load metrics.EWMA `metric:"load" metric-alpha:"2"`
+	}
+	_, err := NewE[invalidAlpha]()
+	assert.ErrorContains(t, err, "invalid alpha")
+
+	type missingCheck struct {
+		Database metrics.Healthcheck `metric:"database"`
+	}
+	_, err = NewE[missingCheck]()
+	assert.ErrorContains(t, err, "CheckDatabase: method or field does not exist")
+
+	type taggedHealthcheck struct {
+		Database Tagged[metrics.Healthcheck] `metric:"database"`
+	}
+	_, err = NewE[taggedHealthcheck]()
+	assert.ErrorContains(t, err, "metric tag appears on non-metric type")
+}
+
+type SettableHistogram interface {
+	metrics.Histogram
+	Set(values ...int64)
+}
+
+type settableHistogram struct {
+	metrics.Histogram
+}
+
+func (h settableHistogram) Set(values ...int64) {
+	h.Clear()
+	for _, v := range values {
+		h.Update(v)
+	}
+}
+
+type customMetric interface {
+	Value() string
+}
+
+func TestRegisterMetricType(t *testing.T) {
+	RegisterMetricType(reflect.TypeOf((*SettableHistogram)(nil)).Elem(), func() any {
+		return settableHistogram{metrics.NewHistogram(metrics.NewUniformSample(10))}
+	})
+
+	type M struct {
+		Sizes SettableHistogram `metric:"sizes" metric-tags:"pool:a"`
+	}
+
+	r := metrics.NewRegistry()
+	m := New[M]()
+	Register(r, m)
+
+	m.Sizes.Set(1, 2, 3)
+	h, ok := r.Get("sizes[pool:a]").(metrics.Histogram)
+	require.True(t, ok, "custom metric should be registered")
+	assert.Equal(t, int64(6), h.Sum())
+
+	c := NewCatalog[M]()
+	assert.Equal(t, TypeHistogram, c.Metrics[0].Type)
+
+	type Invalid struct {
+		Values customMetric `metric:"values"`
+	}
+	_, err := NewE[Invalid]()
+	assert.ErrorContains(t, err, "metric tag appears on non-metric type")
+
+	RegisterMetricType(reflect.TypeOf((*customMetric)(nil)).Elem(), func() any { return 1 })
+	_, err = NewE[Invalid]()
+	assert.ErrorContains(t, err, "does not implement the type")
+
+	type TaggedCustom struct {
+		Sizes Tagged[SettableHistogram] `metric:"sizes"`
+	}
+	_, err = NewE[TaggedCustom]()
+	assert.Error(t, err, "custom types should not support Tagged")
+
+	assert.Panics(t, func() { RegisterMetricType(reflect.TypeOf(0), func() any { return 0 }) })
+	assert.Panics(t, func() { RegisterMetricType(counterType, func() any { return metrics.NewCounter() }) })
+}
+
+type testStatus int
+
+func (testStatus) TagKey() string { return "status" }
+
+type testMethod string
+
+func (testMethod) TagKey() string { return "method" }
+
+type testRegion string
+
+func (testRegion) TagKey() string   { return "region" }
+func (r testRegion) String() string { return strings.ToLower(string(r)) }
+
+type TypedTaggedMetrics struct {
+	Requests  Tagged1[metrics.Counter, testMethod]                       `metric:"requests"`
+	Responses Tagged2[metrics.Counter, testStatus, testMethod]           `metric:"responses" metric-tags:"component:api"`
+	Latency   Tagged3[metrics.Timer, testRegion, testMethod, testStatus] `metric:"latency"`
+}
+
+func TestTypedTagged(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := New[TypedTaggedMetrics]()
+	Register(r, m)
+
+	m.Requests.Tag("GET").Inc(1)
+	m.Responses.Tag(200, "GET").Inc(2)
+	m.Latency.Tag("US-East", "PUT", 201).Update(time.Second)
+
+	assert.Equal(t, int64(1), r.Get("requests[method:GET]").(metrics.Counter).Count())
+	assert.Equal(t, int64(2), r.Get("responses[component:api,method:GET,status:200]").(metrics.Counter).Count())
+	assert.Equal(t, int64(1), r.Get("latency[method:PUT,region:us-east,status:201]").(metrics.Timer).Count())
+	assert.Equal(t, []string{"requests", "responses[component:api]", "latency"}, MetricNames(m))
+
+	c := NewCatalog[TypedTaggedMetrics]()
+	require.Len(t, c.Metrics, 3)
+	assert.Equal(t, []string{"region", "method", "status"}, c.Metrics[0].TagKeys)
+	assert.True(t, c.Metrics[0].Tagged)
+
+	snapshot := Snapshot(m)
+	assert.Equal(t, map[string]any{"method:GET": int64(1)}, snapshot["Requests"])
+
+	assert.EqualError(t, RegisterE(r, &TypedTaggedMetrics{}), "type appmetrics.TypedTaggedMetrics: field Requests: metric is nil; create the struct with New")
+
+	type invalidTagKeys struct {
+		Requests Tagged1[metrics.Counter, testMethod] `metric:"requests" metric-tag-keys:"method"`
+	}
+	_, err := NewE[invalidTagKeys]()
+	assert.ErrorContains(t, err, "field Requests: metric-tag-keys tag appears on typed tagged type")
+}
+
+type LimitedMetrics struct {
+	Responses Tagged[metrics.Counter] `metric:"responses" metric-max-tags:"2" metric-tags:"component:api"`
+	Clients   Tagged[metrics.Counter] `metric:"clients" metric-max-tags:"2,lru"`
+	Sessions  Tagged[metrics.Counter] `metric:"sessions" metric-tag-ttl:"1m"`
+}
+
+func TestTagLimits(t *testing.T) {
+	count := func(r metrics.Registry, name string) int64 {
+		c, ok := r.Get(name).(metrics.Counter)
+		require.True(t, ok, "counter %s is not registered", name)
+		return c.Count()
+	}
+
+	t.Run("overflow", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[LimitedMetrics]()
+		Register(r, m)
+
+		m.Responses.Tag("code:200").Inc(1)
+		m.Responses.Tag("code:404").Inc(1)
+		m.Responses.Tag("code:500").Inc(1)
+		m.Responses.Tag("code:503").Inc(1)
+		m.Responses.Tag("code:200").Inc(1)
+		m.Responses.Tag().Inc(1)
+
+		assert.Equal(t, int64(2), count(r, "responses[code:200,component:api]"))
+		assert.Equal(t, int64(1), count(r, "responses[code:404,component:api]"))
+		assert.Equal(t, int64(2), count(r, "responses[component:api,overflow]"))
+		assert.Equal(t, int64(1), count(r, "responses[component:api]"), "bare metric should not count toward the limit")
+		assert.Nil(t, r.Get("responses[code:500,component:api]"))
+		assert.Equal(t, int64(2), count(r, "appmetrics.tags.overflow[metric:responses[component:api]]"))
+	})
+
+	t.Run("lru", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[LimitedMetrics]()
+		Register(r, m)
+
+		now := time.Unix(0, 0)
+		m.Clients.(*taggedMetric[metrics.Counter]).limits.now = func() time.Time { return now }
+
+		m.Clients.Tag("client:a").Inc(1)
+		now = now.Add(time.Second)
+		m.Clients.Tag("client:b").Inc(1)
+		now = now.Add(time.Second)
+		m.Clients.Tag("client:a").Inc(1)
+		now = now.Add(time.Second)
+		m.Clients.Tag("client:c").Inc(1)
+
+		assert.Equal(t, int64(2), count(r, "clients[client:a]"))
+		assert.Nil(t, r.Get("clients[client:b]"), "least recently used series should be evicted")
+		assert.Equal(t, int64(1), count(r, "clients[client:c]"))
+		assert.Equal(t, int64(1), count(r, "appmetrics.tags.evicted[metric:clients]"))
+
+		m.Clients.Tag("client:b").Inc(1)
+		assert.Equal(t, int64(1), count(r, "clients[client:b]"), "evicted series should restart from zero")
+		assert.Nil(t, r.Get("clients[client:a]"))
+	})
+
+	t.Run("ttl", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[LimitedMetrics]()
+		Register(r, m)
+
+		now := time.Unix(0, 0)
+		m.Sessions.(*taggedMetric[metrics.Counter]).limits.now = func() time.Time { return now }
+
+		m.Sessions.Tag("user:a").Inc(1)
+		m.Sessions.Tag("user:b").Inc(1)
+		now = now.Add(50 * time.Second)
+		m.Sessions.Tag("user:a").Inc(1)
+		now = now.Add(20 * time.Second)
+		m.Sessions.Tag("user:c").Inc(1)
+
+		assert.Equal(t, int64(2), count(r, "sessions[user:a]"))
+		assert.Nil(t, r.Get("sessions[user:b]"), "expired series should be evicted")
+		assert.Equal(t, int64(1), count(r, "sessions[user:c]"))
+		assert.Equal(t, int64(1), count(r, "appmetrics.tags.evicted[metric:sessions]"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		type nonTagged struct {
+			Requests metrics.Counter `metric:"requests" metric-max-tags:"10"`
+		}
+		_, err := NewE[nonTagged]()
+		assert.ErrorContains(t, err, "field Requests: tag limits appear on non-tagged type")
+
+		type invalidLimit struct {
+			Requests Tagged[metrics.Counter] `metric:"requests" metric-max-tags:"0"`
+		}
+		_, err = NewE[invalidLimit]()
+		assert.ErrorContains(t, err, "invalid metric-max-tags tag")
+
+		type invalidPolicy struct {
+			Requests Tagged[metrics.Counter] `metric:"requests" metric-max-tags:"10,fifo"`
+		}
+		_, err = NewE[invalidPolicy]()
+		assert.ErrorContains(t, err, "unknown policy")
+
+		type invalidTTL struct {
+			Requests Tagged[metrics.Counter] `metric:"requests" metric-tag-ttl:"soon"`
+		}
+		_, err = NewE[invalidTTL]()
+		assert.ErrorContains(t, err, "invalid metric-tag-ttl tag")
+	})
+}
+
+type TaggedFunctionalMetrics struct {
+	QueueLength TaggedFunctionalGauge        `metric:"queue_length" metric-tags:"pool:default"`
+	Utilization TaggedFunctionalGaugeFloat64 `metric:"utilization"`
+
+	ComputeUtilization func(tags ...string) float64
+}
+
+func (m *TaggedFunctionalMetrics) ComputeQueueLength(tags ...string) int64 {
+	return int64(len(strings.Join(tags, ",")))
+}
+
+func TestTaggedFunctionalGauge(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := New[TaggedFunctionalMetrics]()
+	m.ComputeUtilization = func(tags ...string) float64 {
+		if len(tags) == 1 && tags[0] == "cpu:0" {
+			return 0.5
+		}
+		return 0
+	}
+	Register(r, m)
+
+	assert.Nil(t, r.Get("queue_length[pool:default]"), "base gauge should not be registered")
+
+	// len("pool:default,queue:emails")
+	assert.Equal(t, int64(25), m.QueueLength.Tag("queue:emails").Value())
+	assert.Equal(t, 0.5, m.Utilization.Tag("cpu:0").Value())
+
+	assert.Equal(t, int64(25), r.Get("queue_length[pool:default,queue:emails]").(metrics.Gauge).Value())
+	assert.Equal(t, 0.5, r.Get("utilization[cpu:0]").(metrics.GaugeFloat64).Value())
+
+	t.Run("invalidFunction", func(t *testing.T) {
+		type InvalidMetrics struct {
+			QueueLength TaggedFunctionalGauge `metric:"queue_length"`
+
+			ComputeQueueLength func() int64
+		}
+		_, err := NewE[InvalidMetrics]()
+		assert.ErrorContains(t, err, "function must take a single ...string parameter")
+	})
+}
+
+func TestTaggedName(t *testing.T) {
+	assert.Equal(t, "responses", TaggedName("responses"))
+	assert.Equal(t, "responses", TaggedName("responses", " ", ""))
+	assert.Equal(t, "responses[code:200,method:GET]", TaggedName("responses", "method:GET", " code:200"))
+}
+
+func TestStrictTags(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[TaggedMetrics]()
+		Register(r, m, WithStrictTags())
+
+		m.Responses.Tag("code:200", "method:GET", "code:200").Inc(1)
+		m.QueueSize.Tag("reindex").Update(12)
+
+		assert.NotNil(t, r.Get("responses[code:200,method:GET]"), "duplicate tags should be removed")
+		assert.NotNil(t, r.Get("queue_size[reindex]"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		var invalid []string
+
+		r := metrics.NewRegistry()
+		m := New[TaggedMetrics]()
+		Register(r, m, WithMaxTagLength(8), WithInvalidTagHandler(func(name string, tags []string, err error) {
+			invalid = append(invalid, name)
+		}))
+
+		m.Responses.Tag("code:200", "code:404").Inc(1)
+		m.Responses.Tag("path:/a,b").Inc(1)
+		m.Responses.Tag("path:/a/long/path").Inc(1)
+		m.Responses.Tag("bad key:value").Inc(1)
+
+		c, ok := r.Get("responses[invalid]").(metrics.Counter)
+		if assert.True(t, ok, "invalid metric was not registered") {
+			assert.Equal(t, int64(4), c.Count())
+		}
+		assert.Equal(t, []string{"responses", "responses", "responses", "responses"}, invalid)
+	})
+
+	t.Run("perStruct", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[StrictMetrics]()
+		Register(r, m)
+
+		m.Responses.Tag("code:200", "code:404").Inc(1)
+		m.Responses.Tag("200").Inc(1)
+
+		assert.NotNil(t, r.Get("responses[invalid]"), "struct options should enable strict mode")
+		assert.NotNil(t, r.Get("responses[200]"), "plain values should be valid")
+	})
+
+	t.Run("sanitized", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		m := New[TaggedMetrics]()
+		Register(r, m, WithSanitizedTags())
+
+		m.Responses.Tag("path:/a,b[0]").Inc(1)
+		m.Responses.Tag("path:/a_b_0_").Inc(1)
+		m.QueueSize.Tag("name:a b", "1key:x").Update(3)
+
+		c, ok := r.Get("responses[path:/a_b_0_]").(metrics.Counter)
+		if assert.True(t, ok, "sanitized metric was not registered") {
+			assert.Equal(t, int64(2), c.Count())
+		}
+		assert.NotNil(t, r.Get("queue_size[_1key:x,name:a_b]"))
+
+		r = metrics.NewRegistry()
+		m = New[TaggedMetrics]()
+		Register(r, m, WithSanitizedTags(), WithStrictTags())
+
+		m.Responses.Tag("path:/a,b").Inc(1)
+		m.Responses.Tag("code:200", "code:404").Inc(1)
+		assert.NotNil(t, r.Get("responses[path:/a_b]"), "sanitized tags should be valid in strict mode")
+		assert.NotNil(t, r.Get("responses[invalid]"), "conflicting tags should be invalid")
+	})
+
+	t.Run("allowedKeys", func(t *testing.T) {
+		var invalid []error
+
+		r := metrics.NewRegistry()
+		m := New[AllowedTagMetrics]()
+		Register(r, m, WithInvalidTagHandler(func(name string, tags []string, err error) {
+			invalid = append(invalid, err)
+		}))
+
+		m.Responses.Tag("status:200", "method:GET").Inc(1)
+		m.Responses.Tag("status:200", "user:alice").Inc(1)
+		m.Responses.Tag("200").Inc(1)
+
+		assert.NotNil(t, r.Get("responses[component:api,method:GET,status:200]"))
+		c, ok := r.Get("responses[invalid]").(metrics.Counter)
+		if assert.True(t, ok, "invalid metric was not registered") {
+			assert.Equal(t, int64(2), c.Count())
+		}
+		assert.Len(t, invalid, 2)
+
+		r = metrics.NewRegistry()
+		m = New[AllowedTagMetrics]()
+		Register(r, m)
+		m.Responses.Tag("user:alice").Inc(1)
+		assert.NotNil(t, r.Get("responses[invalid]"), "allowed keys should not require strict mode")
+
+		_, err := NewE[struct {
+			Requests metrics.Counter `metric:"requests" metric-allowed-tag-keys:"status"`
+		}]()
+		assert.Error(t, err, "allowed keys should require a tagged type")
+	})
+}
+
+func TestSanitizeTags(t *testing.T) {
+	assert.Equal(t, []string{"code:200", "method:GET"}, SanitizeTags([]string{"method:GET", "code:200"}, 0))
+	assert.Equal(t, []string{"path:/a_b__0_"}, SanitizeTags([]string{"path:/a,b[[0]"}, 0))
+	assert.Equal(t, []string{"_1_key:x"}, SanitizeTags([]string{"1-key:x"}, 0))
+	assert.Equal(t, []string{"value"}, SanitizeTags([]string{":value"}, 0))
+	assert.Equal(t, []string{"name:abc"}, SanitizeTags([]string{"name:abcdef"}, 3))
+	assert.Equal(t, []string{"name:a"}, SanitizeTags([]string{"name:aé"}, 2), "truncation should not split characters")
+
+	for _, tags := range [][]string{
+		{"path:/a,b"},
+		{"name:a b", "plain[0]"},
+		{"bad key:value"},
+	} {
+		assert.NoError(t, ValidateTags(SanitizeTags(tags, 0), 0), "%v", tags)
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	assert.NoError(t, ValidateTags([]string{"code:200", "method:GET", "plain", "path:/a/b:c"}, 0))
+	assert.NoError(t, ValidateTags([]string{"code:200", " code:200 "}, 0))
+	assert.NoError(t, ValidateTags([]string{"200", "api-v2", "/a/b"}, 0), "plain values")
+
+	assert.Error(t, ValidateTags([]string{"code:200", "code:500"}, 0), "conflicting values")
+	assert.Error(t, ValidateTags([]string{"code:"}, 0), "empty value")
+	assert.Error(t, ValidateTags([]string{"1code:200"}, 0), "invalid key")
+	assert.Error(t, ValidateTags([]string{"path:/a[0]"}, 0), "invalid value")
+	assert.Error(t, ValidateTags([]string{"name:a b"}, 0), "whitespace in value")
+	assert.Error(t, ValidateTags([]string{"name:abcdef"}, 5), "value too long")
+	assert.Error(t, ValidateTags([]string{"abcdef"}, 5), "plain value too long")
+	assert.Error(t, ValidateTags([]string{"a]b"}, 0), "invalid plain value")
+}
+
+func BenchmarkTagSet(b *testing.B) {
+	m := New[TaggedMetrics]()
+	Register(metrics.NewRegistry(), m)
+	ok := NewTagSet(m.Responses, "code:200")
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ok.Metric().Inc(1)
+		}
+	})
+}
+
+func BenchmarkTaggedTag(b *testing.B) {
+	m := New[TaggedMetrics]()
+	Register(metrics.NewRegistry(), m)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			m.Responses.Tag("code:200").Inc(1)
+		}
+	})
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		New[DBMetrics]()
+	}
 }
@@ -0,0 +1,349 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package appmetricstest provides helpers for testing code that reports
+// metrics to a go-metrics registry.
+//
+// The assertion functions find metrics by name using the same conventions as
+// the appmetrics package. A name with tags, like "responses[status:200]",
+// matches every series with the same base name that has at least the given
+// tags, regardless of order or any additional tags. The values of all
+// matching series are summed:
+//
+//	appmetricstest.AssertCounter(t, registry, "responses[status:200]", 3)
+//	appmetricstest.AssertCounter(t, registry, "responses", 5) // all statuses
+//
+// When a registry is shared between tests, like metrics.DefaultRegistry, use
+// a Recorder to assert on the changes made by a single test:
+//
+//	rec := appmetricstest.NewRecorder(registry)
+//	handler.ServeHTTP(w, r)
+//	rec.AssertCounterDelta(t, "responses[status:200]", 1)
+package appmetricstest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Series is a point-in-time copy of a metric in a registry.
+type Series struct {
+	// Name is the full name of the metric in the registry, including tags.
+	Name string
+
+	// Base is the name without tags and Tags are the sorted tags.
+	Base string
+	Tags []string
+
+	// Metric is a snapshot of the metric, like a metrics.CounterSnapshot.
+	// Metrics that do not support snapshots are included as-is.
+	Metric any
+}
+
+// HasTags returns true if the series has all of the given tags.
+func (s Series) HasTags(tags ...string) bool {
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t == "" {
+			continue
+		}
+		if i := sort.SearchStrings(s.Tags, t); i == len(s.Tags) || s.Tags[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+// CollectAll returns snapshots of all metrics in the registry, sorted by
+// name. Because the series are copies, later updates to the registry do not
+// change the result.
+func CollectAll(r metrics.Registry) []Series {
+	var all []Series
+	r.Each(func(name string, metric any) {
+		base, tags := splitName(name)
+		all = append(all, Series{
+			Name:   name,
+			Base:   base,
+			Tags:   tags,
+			Metric: snapshot(metric),
+		})
+	})
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+	return all
+}
+
+// Find returns snapshots of the metrics in the registry that match name, which
+// may contain a partial set of tags. The result is sorted by name.
+func Find(r metrics.Registry, name string) []Series {
+	base, tags := splitName(name)
+
+	var found []Series
+	for _, s := range CollectAll(r) {
+		if s.Base == base && s.HasTags(tags...) {
+			found = append(found, s)
+		}
+	}
+	return found
+}
+
+// AssertCounter asserts that the sum of the counters matching name equals
+// expected.
+func AssertCounter(t testing.TB, r metrics.Registry, name string, expected int64) bool {
+	t.Helper()
+	return assertSum(t, r, name, expected, nil, counterKind)
+}
+
+// AssertGauge asserts that the sum of the gauges matching name equals
+// expected.
+func AssertGauge(t testing.TB, r metrics.Registry, name string, expected int64) bool {
+	t.Helper()
+	return assertSum(t, r, name, expected, nil, gaugeKind)
+}
+
+// AssertCount asserts that the sum of the number of values recorded by the
+// histograms, meters, and timers matching name equals expected.
+func AssertCount(t testing.TB, r metrics.Registry, name string, expected int64) bool {
+	t.Helper()
+	return assertSum(t, r, name, expected, nil, countKind)
+}
+
+// Recorder records the state of a registry so that tests can assert on the
+// changes made after the recording, even if other tests use the same
+// registry.
+type Recorder struct {
+	registry metrics.Registry
+	before   map[string]Series
+}
+
+// NewRecorder returns a Recorder with a snapshot of the current state of the
+// registry.
+func NewRecorder(r metrics.Registry) *Recorder {
+	rec := &Recorder{registry: r}
+	rec.Reset()
+	return rec
+}
+
+// Reset replaces the recorded snapshot with the current state of the
+// registry.
+func (rec *Recorder) Reset() {
+	rec.before = make(map[string]Series)
+	for _, s := range CollectAll(rec.registry) {
+		rec.before[s.Name] = s
+	}
+}
+
+// Before returns the recorded snapshot, sorted by name.
+func (rec *Recorder) Before() []Series {
+	before := make([]Series, 0, len(rec.before))
+	for _, s := range rec.before {
+		before = append(before, s)
+	}
+	sort.Slice(before, func(i, j int) bool {
+		return before[i].Name < before[j].Name
+	})
+	return before
+}
+
+// After returns a snapshot of the current state of the registry.
+func (rec *Recorder) After() []Series {
+	return CollectAll(rec.registry)
+}
+
+// Changed returns the sorted names of the metrics that were added or whose
+// values changed since the snapshot. Counters and gauges change when their
+// values change and histograms, meters, and timers change when they record
+// new values.
+func (rec *Recorder) Changed() []string {
+	var changed []string
+	for _, s := range rec.After() {
+		b, ok := rec.before[s.Name]
+		if !ok || !sameValue(b.Metric, s.Metric) {
+			changed = append(changed, s.Name)
+		}
+	}
+	return changed
+}
+
+// AssertCounterDelta asserts that the sum of the counters matching name
+// increased by expected since the snapshot. Counters added after the
+// snapshot start at zero.
+func (rec *Recorder) AssertCounterDelta(t testing.TB, name string, expected int64) bool {
+	t.Helper()
+	return assertSum(t, rec.registry, name, expected, rec.before, counterKind)
+}
+
+// AssertCountDelta asserts that the sum of the number of values recorded by
+// the histograms, meters, and timers matching name increased by expected
+// since the snapshot.
+func (rec *Recorder) AssertCountDelta(t testing.TB, name string, expected int64) bool {
+	t.Helper()
+	return assertSum(t, rec.registry, name, expected, rec.before, countKind)
+}
+
+// AssertUnchanged asserts that no metric matching name was added or changed
+// since the snapshot.
+func (rec *Recorder) AssertUnchanged(t testing.TB, name string) bool {
+	t.Helper()
+
+	base, tags := splitName(name)
+	var changed []string
+	for _, n := range rec.Changed() {
+		if b, ts := splitName(n); b == base && (Series{Tags: ts}).HasTags(tags...) {
+			changed = append(changed, n)
+		}
+	}
+	if len(changed) > 0 {
+		t.Errorf("metrics matching %q changed: %s", name, strings.Join(changed, ", "))
+		return false
+	}
+	return true
+}
+
+// metricKind extracts the value compared by an assertion from a snapshot.
+type metricKind struct {
+	name  string
+	value func(any) (int64, bool)
+}
+
+var (
+	counterKind = metricKind{"counter", func(m any) (int64, bool) {
+		c, ok := m.(metrics.Counter)
+		if !ok {
+			return 0, false
+		}
+		return c.Count(), true
+	}}
+
+	gaugeKind = metricKind{"gauge", func(m any) (int64, bool) {
+		g, ok := m.(metrics.Gauge)
+		if !ok {
+			return 0, false
+		}
+		return g.Value(), true
+	}}
+
+	countKind = metricKind{"histogram, meter, or timer", func(m any) (int64, bool) {
+		switch m := m.(type) {
+		case metrics.Histogram:
+			return m.Count(), true
+		case metrics.Meter:
+			return m.Count(), true
+		case metrics.Timer:
+			return m.Count(), true
+		}
+		return 0, false
+	}}
+)
+
+// assertSum asserts that the sum of the values of the metrics matching name
+// equals expected. If before is not nil, the values of the matching metrics
+// in before are subtracted from the sum.
+func assertSum(t testing.TB, r metrics.Registry, name string, expected int64, before map[string]Series, kind metricKind) bool {
+	t.Helper()
+
+	var sum int64
+	var matched []string
+	for _, s := range Find(r, name) {
+		if v, ok := kind.value(s.Metric); ok {
+			if b, ok := before[s.Name]; ok {
+				prev, _ := kind.value(b.Metric)
+				v -= prev
+			}
+			sum += v
+			matched = append(matched, s.Name)
+		}
+	}
+
+	what := "actual"
+	if before != nil {
+		what = "actual change"
+	}
+
+	if len(matched) == 0 {
+		t.Errorf("no %s matches %q; registry contains: %s", kind.name, name, describe(r))
+		return false
+	}
+	if sum != expected {
+		t.Errorf("%s %q: expected %d, %s %d (matched %s)", kind.name, name, expected, what, sum, strings.Join(matched, ", "))
+		return false
+	}
+	return true
+}
+
+// sameValue returns true if two snapshots of a metric have the same value.
+func sameValue(a, b any) bool {
+	for _, kind := range []metricKind{counterKind, gaugeKind, countKind} {
+		if va, ok := kind.value(a); ok {
+			vb, ok := kind.value(b)
+			return ok && va == vb
+		}
+	}
+	if ga, ok := a.(metrics.GaugeFloat64); ok {
+		gb, ok := b.(metrics.GaugeFloat64)
+		return ok && ga.Value() == gb.Value()
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func describe(r metrics.Registry) string {
+	var names []string
+	for _, s := range CollectAll(r) {
+		names = append(names, fmt.Sprintf("%s (%T)", s.Name, s.Metric))
+	}
+	if len(names) == 0 {
+		return "no metrics"
+	}
+	return strings.Join(names, ", ")
+}
+
+func snapshot(metric any) any {
+	switch m := metric.(type) {
+	case metrics.Counter:
+		return m.Snapshot()
+	case metrics.Gauge:
+		return m.Snapshot()
+	case metrics.GaugeFloat64:
+		return m.Snapshot()
+	case metrics.Histogram:
+		return m.Snapshot()
+	case metrics.Meter:
+		return m.Snapshot()
+	case metrics.Timer:
+		return m.Snapshot()
+	}
+	return metric
+}
+
+// splitName returns the base name and the sorted tags of a metric name.
+func splitName(name string) (string, []string) {
+	start := strings.IndexRune(name, '[')
+	if start < 0 || name[len(name)-1] != ']' {
+		return name, nil
+	}
+
+	var tags []string
+	for _, t := range strings.Split(name[start+1:len(name)-1], ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	sort.Strings(tags)
+	return name[:start], tags
+}
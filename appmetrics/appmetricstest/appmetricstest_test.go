@@ -0,0 +1,112 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetricstest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingT records errors instead of failing the test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertCounter(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.GetOrRegisterCounter("responses[method:GET,status:200]", r).Inc(2)
+	metrics.GetOrRegisterCounter("responses[method:POST,status:200]", r).Inc(1)
+	metrics.GetOrRegisterCounter("responses[method:GET,status:500]", r).Inc(4)
+	metrics.GetOrRegisterGauge("workers", r).Update(3)
+	metrics.GetOrRegisterTimer("latency[route:/]", r).Update(1)
+
+	AssertCounter(t, r, "responses[method:GET,status:200]", 2)
+	AssertCounter(t, r, "responses[status:200,method:GET]", 2)
+	AssertCounter(t, r, "responses[status:200]", 3)
+	AssertCounter(t, r, "responses", 7)
+	AssertGauge(t, r, "workers", 3)
+	AssertCount(t, r, "latency", 1)
+
+	rt := &recordingT{TB: t}
+	assert.False(t, AssertCounter(rt, r, "responses[status:404]", 0))
+	assert.False(t, AssertCounter(rt, r, "responses[status:500]", 1))
+	assert.False(t, AssertCounter(rt, r, "workers", 3), "gauges should not match counters")
+	require.Len(t, rt.errors, 3)
+	assert.Contains(t, rt.errors[1], "expected 1, actual 4")
+}
+
+func TestCollectAll(t *testing.T) {
+	r := metrics.NewRegistry()
+	c := metrics.GetOrRegisterCounter("requests[status:200]", r)
+	c.Inc(1)
+	metrics.GetOrRegisterGauge("active", r).Update(2)
+
+	all := CollectAll(r)
+	c.Inc(1)
+
+	require.Len(t, all, 2)
+	assert.Equal(t, "active", all[0].Name)
+	assert.Equal(t, "requests", all[1].Base)
+	assert.Equal(t, []string{"status:200"}, all[1].Tags)
+	assert.Equal(t, int64(1), all[1].Metric.(metrics.Counter).Count(), "snapshots should not change")
+
+	found := Find(r, "requests[status:200]")
+	require.Len(t, found, 1)
+	assert.Equal(t, int64(2), found[0].Metric.(metrics.Counter).Count())
+	assert.Empty(t, Find(r, "requests[status:500]"))
+}
+
+func TestRecorder(t *testing.T) {
+	r := metrics.NewRegistry()
+	ok := metrics.GetOrRegisterCounter("responses[status:200]", r)
+	ok.Inc(5)
+	metrics.GetOrRegisterGauge("workers", r).Update(2)
+	metrics.GetOrRegisterTimer("latency", r).Update(1)
+
+	rec := NewRecorder(r)
+	ok.Inc(2)
+	metrics.GetOrRegisterCounter("responses[status:500]", r).Inc(1)
+	metrics.GetOrRegisterTimer("latency", r).Update(1)
+
+	rec.AssertCounterDelta(t, "responses[status:200]", 2)
+	rec.AssertCounterDelta(t, "responses[status:500]", 1)
+	rec.AssertCounterDelta(t, "responses", 3)
+	rec.AssertCountDelta(t, "latency", 1)
+	rec.AssertUnchanged(t, "workers")
+	assert.Equal(t, []string{"latency", "responses[status:200]", "responses[status:500]"}, rec.Changed())
+	assert.Equal(t, int64(5), rec.Before()[1].Metric.(metrics.Counter).Count())
+	assert.Len(t, rec.After(), 4)
+
+	rt := &recordingT{TB: t}
+	assert.False(t, rec.AssertCounterDelta(rt, "responses[status:200]", 7))
+	assert.False(t, rec.AssertUnchanged(rt, "responses[status:500]"))
+	require.Len(t, rt.errors, 2)
+	assert.Contains(t, rt.errors[0], "expected 7, actual change 2")
+
+	rec.Reset()
+	assert.Empty(t, rec.Changed())
+	rec.AssertCounterDelta(t, "responses", 0)
+}
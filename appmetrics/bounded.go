@@ -0,0 +1,101 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"strconv"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	MetricsKeyClampedTags = "appmetrics.tags.clamped"
+)
+
+// TagBound limits the values of a tag to a fixed set. Use it to keep the
+// number of series for a Tagged metric small when tag values come from
+// clients or other unbounded sources:
+//
+//	var browsers = appmetrics.BoundedTag([]string{"chrome", "firefox", "safari"}, "other")
+//
+//	func init() {
+//		_ = browsers.Register(metrics.DefaultRegistry, "browser")
+//	}
+//
+//	m.Requests.Tag(browsers.Tag("browser", browserName(r.UserAgent())))
+//
+// Values are compared exactly, so normalize values, for example by converting
+// them to lower case, before passing them to the bound. A TagBound is safe for
+// concurrent use.
+type TagBound struct {
+	allowed  map[string]struct{}
+	fallback string
+	clamped  metrics.Counter
+}
+
+// BoundedTag returns a TagBound that allows the given values and replaces all
+// other values with fallback.
+func BoundedTag(allowed []string, fallback string) *TagBound {
+	b := &TagBound{
+		allowed:  make(map[string]struct{}, len(allowed)),
+		fallback: fallback,
+		clamped:  metrics.NewCounter(),
+	}
+	for _, v := range allowed {
+		b.allowed[v] = struct{}{}
+	}
+	return b
+}
+
+// Value returns v if it is allowed or the fallback value otherwise. Each time
+// it returns the fallback for a value that is not allowed, Value increments
+// the clamped counter.
+func (b *TagBound) Value(v string) string {
+	if _, ok := b.allowed[v]; ok {
+		return v
+	}
+	if v != b.fallback {
+		b.clamped.Inc(1)
+	}
+	return b.fallback
+}
+
+// Tag returns a tag with the given key and the bounded value of v.
+func (b *TagBound) Tag(key, v string) string {
+	return key + ":" + b.Value(v)
+}
+
+// Clamped returns the counter of values that were replaced by the fallback.
+func (b *TagBound) Clamped() metrics.Counter {
+	return b.clamped
+}
+
+// Register adds the clamped counter to the registry with the given tag name,
+// as "appmetrics.tags.clamped[tag:<name>]". It returns an error if a metric
+// with the same name is already registered.
+func (b *TagBound) Register(r metrics.Registry, name string) error {
+	return r.Register(TaggedName(MetricsKeyClampedTags, "tag:"+name), b.clamped)
+}
+
+// StatusClass returns the class of an HTTP status code, like "2xx" for 200 or
+// "5xx" for 503. It returns "other" for codes outside of the range 100-599.
+// Use it to tag metrics with status codes without creating a series for each
+// code.
+func StatusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "other"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
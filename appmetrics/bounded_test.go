@@ -0,0 +1,50 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoundedTag(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	b := BoundedTag([]string{"chrome", "firefox"}, "other")
+	require.NoError(t, b.Register(r, "browser"))
+	assert.Error(t, b.Register(r, "browser"), "duplicate registration should fail")
+
+	assert.Equal(t, "chrome", b.Value("chrome"))
+	assert.Equal(t, "browser:firefox", b.Tag("browser", "firefox"))
+	assert.Equal(t, "other", b.Value("Chrome"))
+	assert.Equal(t, "browser:other", b.Tag("browser", "curl"))
+	assert.Equal(t, "other", b.Value("other"), "the fallback value is not clamped")
+
+	c, ok := r.Get("appmetrics.tags.clamped[tag:browser]").(metrics.Counter)
+	require.True(t, ok, "clamped counter is not registered")
+	assert.Equal(t, int64(2), c.Count())
+}
+
+func TestStatusClass(t *testing.T) {
+	assert.Equal(t, "1xx", StatusClass(101))
+	assert.Equal(t, "2xx", StatusClass(200))
+	assert.Equal(t, "4xx", StatusClass(404))
+	assert.Equal(t, "5xx", StatusClass(599))
+	assert.Equal(t, "other", StatusClass(600))
+	assert.Equal(t, "other", StatusClass(0))
+}
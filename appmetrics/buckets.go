@@ -0,0 +1,199 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	MetricBucketsTag = "metric-buckets"
+)
+
+// Bucketed is implemented by histograms and timers with the "metric-buckets"
+// tag. In addition to the sample used for statistics like percentiles, these
+// metrics count every value in the bucket that contains it, so emitters can
+// export the distribution of all values instead of pre-computed quantiles.
+type Bucketed interface {
+	Buckets() BucketCounts
+}
+
+// BucketCounts is the state of the buckets of a Bucketed metric. For timers,
+// bounds and the sum are in seconds.
+type BucketCounts struct {
+	// Bounds are the inclusive upper bounds of the buckets in increasing
+	// order. The final bucket, with an upper bound of +Inf, is implicit.
+	Bounds []float64
+
+	// Counts are the cumulative counts of values less than or equal to each
+	// bound. Counts has the same length as Bounds.
+	Counts []uint64
+
+	// Count is the number of values, which is the count of the +Inf bucket.
+	Count uint64
+
+	// Sum is the sum of all values.
+	Sum float64
+}
+
+// buckets counts values in buckets with fixed upper bounds.
+type buckets struct {
+	bounds []float64
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Uint64 // float64 bits
+}
+
+func newBuckets(bounds []float64) *buckets {
+	return &buckets{
+		bounds: bounds,
+		counts: make([]atomic.Uint64, len(bounds)),
+	}
+}
+
+func (b *buckets) update(v float64) {
+	if i := sort.SearchFloat64s(b.bounds, v); i < len(b.bounds) {
+		b.counts[i].Add(1)
+	}
+	b.count.Add(1)
+	for {
+		old := b.sum.Load()
+		if b.sum.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			break
+		}
+	}
+}
+
+func (b *buckets) clear() {
+	for i := range b.counts {
+		b.counts[i].Store(0)
+	}
+	b.count.Store(0)
+	b.sum.Store(0)
+}
+
+// snapshot returns the current state of the buckets. Updates are not
+// synchronized with the snapshot, so the counts may not include all values
+// in the sum if the metric is updated concurrently.
+func (b *buckets) snapshot() BucketCounts {
+	bc := BucketCounts{
+		Bounds: b.bounds,
+		Counts: make([]uint64, len(b.bounds)),
+		Sum:    math.Float64frombits(b.sum.Load()),
+	}
+	var cumulative uint64
+	for i := range b.counts {
+		cumulative += b.counts[i].Load()
+		bc.Counts[i] = cumulative
+	}
+	bc.Count = max(b.count.Load(), cumulative)
+	return bc
+}
+
+// bucketedHistogram is a histogram that also counts values in buckets.
+type bucketedHistogram struct {
+	metrics.Histogram
+	b *buckets
+}
+
+func (h *bucketedHistogram) Update(v int64) {
+	h.Histogram.Update(v)
+	h.b.update(float64(v))
+}
+
+func (h *bucketedHistogram) Clear() {
+	h.Histogram.Clear()
+	h.b.clear()
+}
+
+func (h *bucketedHistogram) Buckets() BucketCounts {
+	return h.b.snapshot()
+}
+
+// bucketedTimer is a timer that also counts durations in buckets with bounds
+// in seconds.
+type bucketedTimer struct {
+	metrics.Timer
+	b *buckets
+}
+
+func (t *bucketedTimer) Time(f func()) {
+	start := time.Now()
+	f()
+	t.Update(time.Since(start))
+}
+
+func (t *bucketedTimer) Update(d time.Duration) {
+	t.Timer.Update(d)
+	t.b.update(d.Seconds())
+}
+
+func (t *bucketedTimer) UpdateSince(start time.Time) {
+	t.Update(time.Since(start))
+}
+
+func (t *bucketedTimer) Buckets() BucketCounts {
+	return t.b.snapshot()
+}
+
+// parseBuckets returns the bucket bounds from the value of a
+// "metric-buckets" tag. Bounds must be finite and in increasing order.
+func parseBuckets(s string) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	bounds := make([]float64, 0, len(parts))
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil, fmt.Errorf("invalid %s tag: %q is not a finite number", MetricBucketsTag, p)
+		}
+		if len(bounds) > 0 && v <= bounds[len(bounds)-1] {
+			return nil, fmt.Errorf("invalid %s tag: bounds must be in increasing order", MetricBucketsTag)
+		}
+		bounds = append(bounds, v)
+	}
+	return bounds, nil
+}
+
+// withBuckets wraps the histograms or timers created by newMetric to count
+// values in buckets if the field has the "metric-buckets" tag.
+func withBuckets[M any](f metricField, newMetric func() M) (func() M, error) {
+	tag := f.Tag.Get(MetricBucketsTag)
+	if tag == "" {
+		return newMetric, nil
+	}
+	bounds, err := parseBuckets(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() M {
+		var m any = newMetric()
+		switch base := m.(type) {
+		case metrics.Timer:
+			m = &bucketedTimer{Timer: base, b: newBuckets(bounds)}
+		case metrics.Histogram:
+			m = &bucketedHistogram{Histogram: base, b: newBuckets(bounds)}
+		}
+		return m.(M)
+	}, nil
+}
@@ -0,0 +1,279 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	MetricHelpTag = "metric-help"
+	MetricUnitTag = "metric-unit"
+)
+
+// Metric types reported in catalog entries.
+const (
+	TypeCounter         = "counter"
+	TypeCounterFloat64  = "counter_float64"
+	TypeIntervalCounter = "interval_counter"
+	TypeGauge           = "gauge"
+	TypeGaugeFloat64    = "gauge_float64"
+	TypeDurationGauge   = "duration_gauge"
+	TypeMaxGauge        = "max_gauge"
+	TypeMinGauge        = "min_gauge"
+	TypeHistogram       = "histogram"
+	TypeMeter           = "meter"
+	TypeTimer           = "timer"
+	TypeHealthcheck     = "healthcheck"
+)
+
+// CatalogEntry describes a metric defined in a metrics struct.
+type CatalogEntry struct {
+	// Name is the base name of the metric, without any tags.
+	Name string `json:"name" yaml:"name"`
+
+	// Type is the go-metrics type of the metric, like "counter" or "timer".
+	// Functional gauges use the same types as regular gauges.
+	Type string `json:"type" yaml:"type"`
+
+	// Help and Unit are the values of the "metric-help" and "metric-unit"
+	// tags, if present.
+	Help string `json:"help,omitempty" yaml:"help,omitempty"`
+	Unit string `json:"unit,omitempty" yaml:"unit,omitempty"`
+
+	// Tagged is true if the metric is a Tagged metric. TagKeys contains the
+	// tag keys from the "metric-tag-keys" tag, without types.
+	Tagged  bool     `json:"tagged,omitempty" yaml:"tagged,omitempty"`
+	TagKeys []string `json:"tag_keys,omitempty" yaml:"tag_keys,omitempty"`
+
+	// Tags are the constant tags from the "metric-tags" tag, if present.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Sample is the value of the "metric-sample" tag for histograms and
+	// timers, if present.
+	Sample string `json:"sample,omitempty" yaml:"sample,omitempty"`
+
+	// Buckets are the bucket bounds from the "metric-buckets" tag for
+	// histograms and timers, if present.
+	Buckets []float64 `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+}
+
+// AllTagKeys returns the keys of the constant tags followed by the keys of
+// the dynamic tags. Plain tags without a key use the tag value as the key.
+func (e CatalogEntry) AllTagKeys() []string {
+	var keys []string
+	for _, t := range e.Tags {
+		k, _, _ := strings.Cut(t, ":")
+		keys = append(keys, k)
+	}
+	return append(keys, e.TagKeys...)
+}
+
+// Catalog is a machine-readable description of the metrics defined by one or
+// more metrics structs. Use a catalog to generate dashboards or to upload
+// metric metadata to a monitoring system. The emitter packages provide
+// functions that convert a catalog into the series they report.
+type Catalog struct {
+	Metrics []CatalogEntry `json:"metrics" yaml:"metrics"`
+}
+
+// MetricInfo describes a metric field in a metrics struct.
+type MetricInfo struct {
+	// Field is the name of the struct field that defines the metric. Fields
+	// in nested structs with the "metric-prefix" tag use dotted paths, like
+	// "DB.Queries".
+	Field string
+
+	CatalogEntry
+}
+
+// Describe returns a description of each metric in the struct type M, in the
+// order the fields are defined. It does not create any metrics, so it is
+// useful for generating documentation or alert templates from the same
+// struct definitions used by the application. Use NewCatalog for a sorted
+// description that can be encoded or merged with other structs.
+//
+// Describe panics if the struct contains invalid metric definitions.
+func Describe[M any]() []MetricInfo {
+	infos, err := describe(reflect.TypeOf((*M)(nil)).Elem())
+	if err != nil {
+		panic("appmetrics.Describe: " + err.Error())
+	}
+	return infos
+}
+
+// NewCatalog returns a catalog of the metrics in the struct type M, sorted by
+// name. See New for an explanation of how this package identifies metric
+// fields. Add documentation to the catalog with the "metric-help" and
+// "metric-unit" tags:
+//
+//	type M struct {
+//		Latency metrics.Timer `metric:"api.latency" metric-help:"Time to serve API requests"`
+//		Size    metrics.Histogram `metric:"api.size" metric-unit:"bytes"`
+//	}
+//
+// NewCatalog panics if the struct contains invalid metric definitions.
+func NewCatalog[M any]() Catalog {
+	infos, err := describe(reflect.TypeOf((*M)(nil)).Elem())
+	if err != nil {
+		panic("appmetrics.NewCatalog: " + err.Error())
+	}
+
+	var c Catalog
+	for _, info := range infos {
+		c.Metrics = append(c.Metrics, info.CatalogEntry)
+	}
+
+	c.sort()
+	return c
+}
+
+func describe(typ reflect.Type) ([]MetricInfo, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, errors.New("type is not a struct")
+	}
+
+	fields, err := getMetricFields(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]MetricInfo, 0, len(fields))
+	for _, f := range fields {
+		tagged, metricType := isTagged(f.Type)
+		if !tagged {
+			metricType = f.Type
+		}
+
+		e := CatalogEntry{
+			Name:   f.name,
+			Type:   metricTypeName(metricType),
+			Help:   f.Tag.Get(MetricHelpTag),
+			Unit:   f.Tag.Get(MetricUnitTag),
+			Tagged: tagged,
+			Tags:   f.tags,
+		}
+		if t, ok := asTypedTagged(f.Type); ok {
+			e.TagKeys = t.tagKeys()
+		} else if tagged {
+			e.TagKeys = parseTagKeys(f.Tag.Get(MetricTagKeysTag))
+			if e.TagKeys == nil {
+				e.TagKeys = parseTagKeys(f.Tag.Get(MetricAllowedTagKeysTag))
+			}
+		}
+		if e.Type == TypeHistogram || e.Type == TypeTimer {
+			e.Sample = f.Tag.Get(MetricSampleTag)
+			if b := f.Tag.Get(MetricBucketsTag); b != "" {
+				e.Buckets, _ = parseBuckets(b)
+			}
+		}
+		infos = append(infos, MetricInfo{Field: fieldPath(typ, f.Index), CatalogEntry: e})
+	}
+	return infos, nil
+}
+
+// fieldPath returns the dotted names of the fields at index in typ.
+func fieldPath(typ reflect.Type, index []int) string {
+	names := make([]string, len(index))
+	for i, n := range index {
+		f := typ.Field(n)
+		names[i] = f.Name
+		typ = f.Type
+	}
+	return strings.Join(names, ".")
+}
+
+// Merge returns a catalog that contains the metrics from c and all others,
+// sorted by name.
+func (c Catalog) Merge(others ...Catalog) Catalog {
+	merged := Catalog{Metrics: append([]CatalogEntry(nil), c.Metrics...)}
+	for _, o := range others {
+		merged.Metrics = append(merged.Metrics, o.Metrics...)
+	}
+	merged.sort()
+	return merged
+}
+
+// JSON returns the catalog encoded as indented JSON.
+func (c Catalog) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// YAML returns the catalog encoded as YAML.
+func (c Catalog) YAML() ([]byte, error) {
+	return yaml.Marshal(c)
+}
+
+func (c Catalog) sort() {
+	sort.SliceStable(c.Metrics, func(i, j int) bool {
+		return c.Metrics[i].Name < c.Metrics[j].Name
+	})
+}
+
+func metricTypeName(typ reflect.Type) string {
+	switch typ {
+	case counterType:
+		return TypeCounter
+	case counterFloat64Type:
+		return TypeCounterFloat64
+	case intervalCounterType:
+		return TypeIntervalCounter
+	case gaugeType, functionalGaugeType, upDownCounterType:
+		return TypeGauge
+	case durationGaugeType:
+		return TypeDurationGauge
+	case maxGaugeType:
+		return TypeMaxGauge
+	case minGaugeType:
+		return TypeMinGauge
+	case gaugeFloat64Type, functionalGaugeFloat64Type, ewmaType:
+		return TypeGaugeFloat64
+	case histogramType, functionalHistogramType:
+		return TypeHistogram
+	case meterType:
+		return TypeMeter
+	case timerType, functionalTimerType:
+		return TypeTimer
+	case healthcheckType:
+		return TypeHealthcheck
+	}
+
+	// Custom types use the name of the go-metrics type they extend, if any
+	for _, t := range []reflect.Type{timerType, histogramType, meterType, gaugeFloat64Type, gaugeType, counterType} {
+		if typ.Implements(t) {
+			return metricTypeName(t)
+		}
+	}
+	return ""
+}
+
+// parseTagKeys returns the keys from the value of a "metric-tag-keys" tag,
+// removing any type suffixes.
+func parseTagKeys(s string) []string {
+	var keys []string
+	for _, k := range strings.Split(s, ",") {
+		k, _, _ = strings.Cut(k, ":")
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type CatalogMetrics struct {
+	Responses Tagged[metrics.Counter] `metric:"responses" metric-tag-keys:"type,status:int" metric-help:"API responses"`
+	Latency   metrics.Timer           `metric:"latency" metric-sample:"uniform,100"`
+	Size      metrics.Histogram       `metric:"size" metric-unit:"byte"`
+	Workers   FunctionalGauge         `metric:"workers"`
+	Errors    metrics.Counter         `metric:"errors" metric-tags:"component:api"`
+
+	ComputeWorkers func() int64
+}
+
+func TestNewCatalog(t *testing.T) {
+	c := NewCatalog[CatalogMetrics]()
+
+	assert.Equal(t, []CatalogEntry{
+		{Name: "errors", Type: TypeCounter, Tags: []string{"component:api"}},
+		{Name: "latency", Type: TypeTimer, Sample: "uniform,100"},
+		{Name: "responses", Type: TypeCounter, Help: "API responses", Tagged: true, TagKeys: []string{"type", "status"}},
+		{Name: "size", Type: TypeHistogram, Unit: "byte"},
+		{Name: "workers", Type: TypeGauge},
+	}, c.Metrics)
+
+	merged := c.Merge(NewCatalog[SimpleMetrics]())
+	assert.Len(t, merged.Metrics, 8)
+	assert.Equal(t, "active_workers", merged.Metrics[0].Name)
+	assert.Len(t, c.Metrics, 5, "merge should not modify the original catalog")
+
+	b, err := NewCatalog[SimpleMetrics]().JSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"metrics": [
+		{"name": "active_workers", "type": "gauge"},
+		{"name": "bar.count", "type": "counter"},
+		{"name": "foo.count", "type": "counter"}
+	]}`, string(b))
+
+	b, err = c.YAML()
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "tag_keys:\n  - type\n  - status\n")
+
+	assert.Equal(t, []string{"component"}, c.Metrics[0].AllTagKeys())
+	assert.Equal(t, []string{"type", "status"}, c.Metrics[2].AllTagKeys())
+
+	assert.Panics(t, func() { NewCatalog[int]() })
+}
+
+func TestDescribe(t *testing.T) {
+	infos := Describe[CatalogMetrics]()
+
+	require.Len(t, infos, 5)
+	assert.Equal(t, MetricInfo{
+		Field:        "Responses",
+		CatalogEntry: CatalogEntry{Name: "responses", Type: TypeCounter, Help: "API responses", Tagged: true, TagKeys: []string{"type", "status"}},
+	}, infos[0])
+	assert.Equal(t, "Latency", infos[1].Field)
+	assert.Equal(t, "uniform,100", infos[1].Sample)
+
+	type Nested struct {
+		DB struct {
+			Queries metrics.Timer `metric:"queries" metric-buckets:"0.1,1"`
+		} `metric-prefix:"db."`
+	}
+	infos = Describe[Nested]()
+	require.Len(t, infos, 1)
+	assert.Equal(t, "DB.Queries", infos[0].Field)
+	assert.Equal(t, "db.queries", infos[0].Name)
+	assert.Equal(t, []float64{0.1, 1}, infos[0].Buckets)
+
+	assert.Panics(t, func() { Describe[int]() })
+}
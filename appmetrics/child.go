@@ -0,0 +1,78 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// registrations maps registered metrics structs to the registry and the
+// options passed to Register, so that NewChild can register children the
+// same way. Entries are removed by Unregister.
+var registrations sync.Map // *M -> registration
+
+type registration struct {
+	r    metrics.Registry
+	opts []RegisterOption
+}
+
+func lookupRegistration(m any) (registration, bool) {
+	v, ok := registrations.Load(m)
+	if !ok {
+		return registration{}, false
+	}
+	return v.(registration), true
+}
+
+// NewChild creates a new instance of the metrics struct type of parent and
+// registers it with the registry and the options used to register parent,
+// followed by opts. Use it to create an instance for each member of a pool,
+// like a worker, that reports under distinct tags or with a distinct prefix:
+//
+//	m := appmetrics.New[WorkerMetrics]()
+//	appmetrics.Register(registry, m, appmetrics.WithPrefix("workers."))
+//
+//	for i := range n {
+//		workers[i].metrics = appmetrics.NewChild(m, appmetrics.WithTags("worker:"+strconv.Itoa(i)))
+//	}
+//
+// Children share the definitions of the parent's fields, so creating many
+// children only analyzes the struct type once. Tags from WithTags are added
+// to the tags of the parent, while other options, like WithPrefix, replace
+// the options of the parent. Like structs created by New, children call their
+// own compute functions, so set any function fields on each child.
+//
+// NewChild panics if parent is not registered, if the struct contains invalid
+// metric definitions, or if the name of any metric in the child already
+// exists in the registry, like when opts do not change the names of the
+// metrics. Use Unregister to remove a child that is no longer used.
+func NewChild[M any](parent *M, opts ...RegisterOption) *M {
+	reg, ok := lookupRegistration(parent)
+	if !ok {
+		panic("appmetrics.NewChild: parent is not registered")
+	}
+
+	child, err := NewE[M]()
+	if err != nil {
+		panic("appmetrics.NewChild: " + err.Error())
+	}
+	if err := RegisterStrict(reg.r, child, append(slices.Clone(reg.opts), opts...)...); err != nil {
+		panic("appmetrics.NewChild: " + err.Error())
+	}
+	return child
+}
@@ -0,0 +1,482 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	metricTag        = "metric"
+	metricTagKeysTag = "metric-tag-keys"
+)
+
+type metricStruct struct {
+	name   string
+	fields []metricField
+}
+
+type metricField struct {
+	name       string
+	metricName string
+
+	// set for tagged fields with tag keys
+	metricType string
+	tagKeys    []tagKey
+}
+
+type tagKey struct {
+	key string
+	typ string
+}
+
+// generate parses the non-test Go files in dir and returns the formatted
+// source for the named metric struct types. The file named output is ignored
+// so that running the generator again does not see the previous output.
+func generate(dir string, typeNames []string, output string) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgName string
+	var files []*ast.File
+	for _, p := range paths {
+		base := filepath.Base(p)
+		if base == output || strings.HasSuffix(base, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, p, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		if pkgName == "" {
+			pkgName = f.Name.Name
+		}
+		if f.Name.Name == pkgName {
+			files = append(files, f)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files found in %s", dir)
+	}
+
+	g := &generator{fset: fset, imports: make(map[string]string)}
+	for _, name := range typeNames {
+		s, err := g.findStruct(files, name)
+		if err != nil {
+			return nil, err
+		}
+		g.structs = append(g.structs, s)
+	}
+	return g.render(pkgName)
+}
+
+type generator struct {
+	fset    *token.FileSet
+	structs []metricStruct
+
+	// imports maps package names to import paths for the generated file
+	imports map[string]string
+}
+
+func (g *generator) findStruct(files []*ast.File, name string) (metricStruct, error) {
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if ts.Name.Name != name {
+					continue
+				}
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					return metricStruct{}, fmt.Errorf("type %s is not a struct", name)
+				}
+				return g.parseStruct(f, name, st)
+			}
+		}
+	}
+	return metricStruct{}, fmt.Errorf("type %s not found", name)
+}
+
+func (g *generator) parseStruct(f *ast.File, name string, st *ast.StructType) (metricStruct, error) {
+	s := metricStruct{name: name}
+	for _, field := range st.Fields.List {
+		// Embedded fields are skipped: their types may be defined in other
+		// packages, which the generator does not load
+		if field.Tag == nil || len(field.Names) == 0 {
+			continue
+		}
+		tagValue, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return s, err
+		}
+		tag := reflect.StructTag(tagValue)
+
+		metricName := tag.Get(metricTag)
+		if metricName == "" {
+			continue
+		}
+
+		var metricType string
+		var tagKeys []tagKey
+		if keys := tag.Get(metricTagKeysTag); keys != "" {
+			typeArg, ok := taggedTypeArg(field.Type)
+			if !ok {
+				return s, fmt.Errorf("%s.%s: %s tag appears on a non-Tagged field", name, field.Names[0].Name, metricTagKeysTag)
+			}
+			if metricType, err = g.typeString(f, typeArg); err != nil {
+				return s, fmt.Errorf("%s.%s: %w", name, field.Names[0].Name, err)
+			}
+			if tagKeys, err = parseTagKeys(keys); err != nil {
+				return s, fmt.Errorf("%s.%s: %w", name, field.Names[0].Name, err)
+			}
+		}
+
+		for _, n := range field.Names {
+			s.fields = append(s.fields, metricField{
+				name:       n.Name,
+				metricName: metricName,
+				metricType: metricType,
+				tagKeys:    tagKeys,
+			})
+		}
+	}
+	return s, nil
+}
+
+// taggedTypeArg returns the type argument if expr is an instantiation of the
+// appmetrics.Tagged type or one of its aliases.
+func taggedTypeArg(expr ast.Expr) (ast.Expr, bool) {
+	if arg, ok := taggedAliasArg(expr); ok {
+		return arg, true
+	}
+
+	idx, ok := expr.(*ast.IndexExpr)
+	if !ok {
+		return nil, false
+	}
+	switch x := idx.X.(type) {
+	case *ast.Ident:
+		return idx.Index, x.Name == "Tagged"
+	case *ast.SelectorExpr:
+		return idx.Index, x.Sel.Name == "Tagged"
+	}
+	return nil, false
+}
+
+// taggedAliases maps the names of the Tagged aliases in the appmetrics package
+// to the names of their type arguments.
+var taggedAliases = map[string]string{
+	"TaggedFunctionalGauge":        "FunctionalGauge",
+	"TaggedFunctionalGaugeFloat64": "FunctionalGaugeFloat64",
+}
+
+// taggedAliasArg returns the type argument if expr is one of the Tagged
+// aliases, using the same package qualifier as the alias.
+func taggedAliasArg(expr ast.Expr) (ast.Expr, bool) {
+	switch x := expr.(type) {
+	case *ast.Ident:
+		if arg, ok := taggedAliases[x.Name]; ok {
+			return ast.NewIdent(arg), true
+		}
+	case *ast.SelectorExpr:
+		if arg, ok := taggedAliases[x.Sel.Name]; ok {
+			return &ast.SelectorExpr{X: x.X, Sel: ast.NewIdent(arg)}, true
+		}
+	}
+	return nil, false
+}
+
+// typeString formats the type expression and records the imports it needs.
+func (g *generator) typeString(f *ast.File, expr ast.Expr) (string, error) {
+	var err error
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok || err != nil {
+			return true
+		}
+		if id, ok := sel.X.(*ast.Ident); ok {
+			err = g.addImport(f, id.Name)
+		}
+		return false
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var b bytes.Buffer
+	if err := format.Node(&b, g.fset, expr); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (g *generator) addImport(f *ast.File, name string) error {
+	for _, imp := range f.Imports {
+		importPath, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return err
+		}
+
+		impName := guessPackageName(importPath)
+		if imp.Name != nil {
+			impName = imp.Name.Name
+		}
+		if impName == name {
+			g.imports[name] = importPath
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot find import for package %s", name)
+}
+
+// guessPackageName guesses the name of a package from its import path using
+// common conventions, like "github.com/rcrowley/go-metrics" for "metrics".
+func guessPackageName(importPath string) string {
+	base := path.Base(importPath)
+	if strings.HasPrefix(base, "v") {
+		if _, err := strconv.Atoi(base[1:]); err == nil {
+			base = path.Base(path.Dir(importPath))
+		}
+	}
+	base = strings.TrimPrefix(base, "go-")
+	base = strings.TrimSuffix(base, "-go")
+	if i := strings.IndexByte(base, '.'); i >= 0 {
+		base = base[:i]
+	}
+	return strings.ReplaceAll(base, "-", "")
+}
+
+func parseTagKeys(s string) ([]tagKey, error) {
+	var keys []tagKey
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ",") {
+		key, typ, _ := strings.Cut(strings.TrimSpace(part), ":")
+		if key == "" {
+			return nil, fmt.Errorf("invalid %s tag: empty key", metricTagKeysTag)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("invalid %s tag: duplicate key %q", metricTagKeysTag, key)
+		}
+		seen[key] = true
+
+		if typ == "" {
+			typ = "string"
+		}
+		if !token.IsIdentifier(typ) {
+			return nil, fmt.Errorf("invalid %s tag: key %q: type must be a builtin or local type", metricTagKeysTag, key)
+		}
+		keys = append(keys, tagKey{key: key, typ: typ})
+	}
+	return keys, nil
+}
+
+func (g *generator) render(pkgName string) ([]byte, error) {
+	var body bytes.Buffer
+
+	names := make(map[string]string)
+	for _, s := range g.structs {
+		for _, f := range s.fields {
+			constName := "MetricName" + f.name
+			if other, ok := names[constName]; ok {
+				return nil, fmt.Errorf("%s.%s: constant %s conflicts with field in %s", s.name, f.name, constName, other)
+			}
+			names[constName] = s.name
+		}
+	}
+
+	for _, s := range g.structs {
+		if len(s.fields) == 0 {
+			continue
+		}
+		fmt.Fprintf(&body, "// Metric names for %s.\nconst (\n", s.name)
+		for _, f := range s.fields {
+			fmt.Fprintf(&body, "\tMetricName%s = %q\n", f.name, f.metricName)
+		}
+		fmt.Fprintf(&body, ")\n\n")
+	}
+
+	for _, s := range g.structs {
+		for _, f := range s.fields {
+			if len(f.tagKeys) == 0 {
+				continue
+			}
+			g.renderAccessor(&body, s, f)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by appmetricsgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", pkgName)
+	if len(g.imports) > 0 {
+		var std, other []string
+		for name, p := range g.imports {
+			spec := strconv.Quote(p)
+			if name != guessPackageName(p) {
+				spec = name + " " + spec
+			}
+			if strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+				other = append(other, spec)
+			} else {
+				std = append(std, spec)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(other)
+
+		var groups []string
+		for _, group := range [][]string{std, other} {
+			if len(group) > 0 {
+				groups = append(groups, "\t"+strings.Join(group, "\n\t")+"\n")
+			}
+		}
+		fmt.Fprintf(&out, "import (\n%s)\n\n", strings.Join(groups, "\n"))
+	}
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+func (g *generator) renderAccessor(b *bytes.Buffer, s metricStruct, f metricField) {
+	// Parameters must not shadow the receiver or any package used in the
+	// generated file. formatValue may import strconv or fmt after this point,
+	// so always reserve them.
+	reserved := map[string]bool{"m": true, "strconv": true, "fmt": true}
+	for name := range g.imports {
+		reserved[name] = true
+	}
+
+	var methodParts, params, tags []string
+	for _, k := range f.tagKeys {
+		methodParts = append(methodParts, exportedName(k.key))
+
+		param := paramName(k.key, reserved)
+		params = append(params, param+" "+k.typ)
+		tags = append(tags, fmt.Sprintf("%q+%s", k.key+":", g.formatValue(param, k.typ)))
+	}
+
+	method := f.name + "By" + strings.Join(methodParts, "And")
+	fmt.Fprintf(b, "// %s returns the %s metric with the given tag values.\n", method, f.metricName)
+	fmt.Fprintf(b, "func (m *%s) %s(%s) %s {\n", s.name, method, strings.Join(params, ", "), f.metricType)
+	fmt.Fprintf(b, "\treturn m.%s.Tag(%s)\n", f.name, strings.Join(tags, ", "))
+	fmt.Fprintf(b, "}\n\n")
+}
+
+// formatValue returns an expression that converts the variable v of type typ
+// to a string, recording any required imports.
+func (g *generator) formatValue(v, typ string) string {
+	switch typ {
+	case "string":
+		return v
+	case "bool":
+		g.imports["strconv"] = "strconv"
+		return "strconv.FormatBool(" + v + ")"
+	case "int":
+		g.imports["strconv"] = "strconv"
+		return "strconv.Itoa(" + v + ")"
+	case "int64":
+		g.imports["strconv"] = "strconv"
+		return "strconv.FormatInt(" + v + ", 10)"
+	case "int8", "int16", "int32":
+		g.imports["strconv"] = "strconv"
+		return "strconv.FormatInt(int64(" + v + "), 10)"
+	case "uint64":
+		g.imports["strconv"] = "strconv"
+		return "strconv.FormatUint(" + v + ", 10)"
+	case "uint", "uint8", "uint16", "uint32":
+		g.imports["strconv"] = "strconv"
+		return "strconv.FormatUint(uint64(" + v + "), 10)"
+	case "float64":
+		g.imports["strconv"] = "strconv"
+		return "strconv.FormatFloat(" + v + ", 'g', -1, 64)"
+	case "float32":
+		g.imports["strconv"] = "strconv"
+		return "strconv.FormatFloat(float64(" + v + "), 'g', -1, 32)"
+	default:
+		g.imports["fmt"] = "fmt"
+		return "fmt.Sprint(" + v + ")"
+	}
+}
+
+// exportedName converts a tag key like "http_status" to "HTTPStatus".
+func exportedName(key string) string {
+	var b strings.Builder
+	for _, word := range splitWords(key) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			b.WriteString(upper)
+		} else {
+			r := []rune(word)
+			r[0] = unicode.ToUpper(r[0])
+			b.WriteString(string(r))
+		}
+	}
+	return b.String()
+}
+
+// paramName converts a tag key like "http_status" to "httpStatus". If the
+// result is a keyword or a reserved name, paramName adds a "Value" suffix.
+func paramName(key string, reserved map[string]bool) string {
+	var b strings.Builder
+	for i, word := range splitWords(key) {
+		if i == 0 {
+			b.WriteString(strings.ToLower(word))
+		} else {
+			b.WriteString(exportedName(word))
+		}
+	}
+
+	name := b.String()
+	if token.IsKeyword(name) || reserved[name] {
+		name += "Value"
+	}
+	return name
+}
+
+func splitWords(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+var commonInitialisms = map[string]bool{
+	"API":  true,
+	"HTTP": true,
+	"ID":   true,
+	"IP":   true,
+	"URL":  true,
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSource = `package example
+
+import (
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/rcrowley/go-metrics"
+)
+
+type Metrics struct {
+	Requests  metrics.Counter                    ` + "`" + `metric:"requests"` + "`" + `
+	Responses appmetrics.Tagged[metrics.Counter] ` + "`" + `metric:"responses" metric-tag-keys:"status:int,method"` + "`" + `
+	Latency   appmetrics.Tagged[metrics.Timer]   ` + "`" + `metric:"latency" metric-tag-keys:"http_route,type,cached:bool"` + "`" + `
+	Other     appmetrics.Tagged[metrics.Gauge]   ` + "`" + `metric:"other"` + "`" + `
+
+	count int
+}
+`
+
+const expectedOutput = `// Code generated by appmetricsgen; DO NOT EDIT.
+
+package example
+
+import (
+	"strconv"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Metric names for Metrics.
+const (
+	MetricNameRequests  = "requests"
+	MetricNameResponses = "responses"
+	MetricNameLatency   = "latency"
+	MetricNameOther     = "other"
+)
+
+// ResponsesByStatusAndMethod returns the responses metric with the given tag values.
+func (m *Metrics) ResponsesByStatusAndMethod(status int, method string) metrics.Counter {
+	return m.Responses.Tag("status:"+strconv.Itoa(status), "method:"+method)
+}
+
+// LatencyByHTTPRouteAndTypeAndCached returns the latency metric with the given tag values.
+func (m *Metrics) LatencyByHTTPRouteAndTypeAndCached(httpRoute string, typeValue string, cached bool) metrics.Timer {
+	return m.Latency.Tag("http_route:"+httpRoute, "type:"+typeValue, "cached:"+strconv.FormatBool(cached))
+}
+`
+
+func TestGenerate(t *testing.T) {
+	t.Run("accessors", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "metrics.go"), []byte(testSource), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "metrics_metrics.go"), []byte("invalid"), 0o644))
+
+		src, err := generate(dir, []string{"Metrics"}, "metrics_metrics.go")
+		require.NoError(t, err)
+		assert.Equal(t, expectedOutput, string(src))
+	})
+
+	t.Run("reservedParamNames", func(t *testing.T) {
+		dir := t.TempDir()
+		src := "package example\n\nimport (\n\t\"github.com/palantir/go-baseapp/appmetrics\"\n\t\"github.com/rcrowley/go-metrics\"\n)\n\n" +
+			"type M struct {\n\tC appmetrics.Tagged[metrics.Counter] `metric:\"c\" metric-tag-keys:\"fmt,strconv:int,metrics,m\"`\n}\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "m.go"), []byte(src), 0o644))
+
+		out, err := generate(dir, []string{"M"}, "m_metrics.go")
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "func (m *M) CByFmtAndStrconvAndMetricsAndM(fmtValue string, strconvValue int, metricsValue string, mValue string) metrics.Counter {")
+		assert.Contains(t, string(out), `return m.C.Tag("fmt:"+fmtValue, "strconv:"+strconv.Itoa(strconvValue), "metrics:"+metricsValue, "m:"+mValue)`)
+	})
+
+	t.Run("taggedAlias", func(t *testing.T) {
+		dir := t.TempDir()
+		src := "package example\n\nimport \"github.com/palantir/go-baseapp/appmetrics\"\n\n" +
+			"type M struct {\n\tQ appmetrics.TaggedFunctionalGauge `metric:\"q\" metric-tag-keys:\"queue\"`\n}\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "m.go"), []byte(src), 0o644))
+
+		out, err := generate(dir, []string{"M"}, "m_metrics.go")
+		require.NoError(t, err)
+		assert.Contains(t, string(out), "func (m *M) QByQueue(queue string) appmetrics.FunctionalGauge {")
+	})
+
+	t.Run("missingType", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "metrics.go"), []byte(testSource), 0o644))
+
+		_, err := generate(dir, []string{"Missing"}, "missing_metrics.go")
+		assert.EqualError(t, err, "type Missing not found")
+	})
+
+	t.Run("keysOnUntaggedField", func(t *testing.T) {
+		dir := t.TempDir()
+		src := "package example\n\ntype M struct {\n\tC int `metric:\"c\" metric-tag-keys:\"a\"`\n}\n"
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "m.go"), []byte(src), 0o644))
+
+		_, err := generate(dir, []string{"M"}, "m_metrics.go")
+		assert.EqualError(t, err, "M.C: metric-tag-keys tag appears on a non-Tagged field")
+	})
+}
+
+func TestParseTagKeys(t *testing.T) {
+	keys, err := parseTagKeys("status:int, method")
+	require.NoError(t, err)
+	assert.Equal(t, []tagKey{{key: "status", typ: "int"}, {key: "method", typ: "string"}}, keys)
+
+	_, err = parseTagKeys("a,a")
+	assert.Error(t, err, "duplicate keys should fail")
+
+	_, err = parseTagKeys("a:http.Method")
+	assert.Error(t, err, "qualified types should fail")
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command appmetricsgen generates name constants and typed accessor methods
+// for appmetrics structs. It is intended to be invoked by go:generate:
+//
+//	//go:generate go run github.com/palantir/go-baseapp/appmetrics/cmd/appmetricsgen -type Metrics
+//
+// For each field with the "metric" tag, appmetricsgen emits a constant
+// containing the metric name. The constant is named "MetricName" followed by
+// the field name.
+//
+// For each Tagged field that also has the "metric-tag-keys" tag, appmetricsgen
+// emits a method that accepts a typed value for each key and returns the
+// tagged metric. The tag value is a comma-separated list of keys, each
+// optionally followed by a colon and the Go type of the value. Keys without a
+// type accept strings. For example:
+//
+//	type Metrics struct {
+//		Responses appmetrics.Tagged[metrics.Counter] `metric:"responses" metric-tag-keys:"status:int,method"`
+//	}
+//
+// generates:
+//
+//	const MetricNameResponses = "responses"
+//
+//	func (m *Metrics) ResponsesByStatusAndMethod(status int, method string) metrics.Counter {
+//		return m.Responses.Tag("status:"+strconv.Itoa(status), "method:"+method)
+//	}
+//
+// Metrics in embedded structs are not included. Run appmetricsgen for the
+// embedded type in its own package to generate its constants and accessors.
+//
+// By default, the output is written to "<type>_metrics.go" in the package
+// directory, using the lower-case name of the first type.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("appmetricsgen: ")
+
+	typeNames := flag.String("type", "", "comma-separated list of metric struct type names; must be set")
+	output := flag.String("output", "", "output file name; default <dir>/<type>_metrics.go")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: appmetricsgen -type T [-output file] [directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	types := strings.Split(*typeNames, ",")
+
+	dir := "."
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		dir = flag.Arg(0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, strings.ToLower(types[0])+"_metrics.go")
+	}
+
+	src, err := generate(dir, types, filepath.Base(outputName))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(outputName, src, 0o644); err != nil {
+		log.Fatalf("writing output: %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"math"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// CounterFloat64 is a counter with fractional increments, like the cost of
+// requests in dollars or CPU seconds. Like a [metrics.Counter], it reports an
+// increasing total, but the total is a float64. Inc panics if the increment
+// is negative.
+//
+// Registries only accept the go-metrics types, so the counter also
+// implements [metrics.GaugeFloat64]. Value returns the total and Update
+// panics. Emitters in this module report the counter as a counter; other
+// emitters report the total as a gauge.
+type CounterFloat64 interface {
+	Clear()
+	Count() float64
+	Inc(float64)
+	Snapshot() metrics.GaugeFloat64
+	Update(float64)
+	Value() float64
+}
+
+// UpDownCounter is a counter that can increase and decrease, like the number
+// of open connections or the bytes held in a cache. Unlike a
+// [metrics.Gauge], concurrent changes add together instead of replacing each
+// other, and unlike a [metrics.Counter], emitters report the current value
+// instead of the change since the last report.
+//
+// The counter implements metrics.Gauge, so emitters report it like other
+// gauges. Update sets the value.
+type UpDownCounter interface {
+	Dec(int64)
+	Inc(int64)
+	Snapshot() metrics.Gauge
+	Update(int64)
+	Value() int64
+}
+
+// IntervalCounter is a counter that reports the change since the last report
+// instead of a running total, like the counters of statsd. Emitters in this
+// module report the count and then reset it to zero with Reset, so they do
+// not need to remember the previous total. If several emitters report the
+// same registry, each one only reports the changes since any emitter last
+// reported, so use interval counters with a single emitter.
+//
+// The counter implements [metrics.Counter], so emitters that do not know the
+// type report the count without resetting it. Count returns the change since
+// the last reset.
+type IntervalCounter interface {
+	Clear()
+	Count() int64
+	Dec(int64)
+	Inc(int64)
+	Reset() int64
+	Snapshot() metrics.Counter
+}
+
+// DurationGauge is a gauge that stores a [time.Duration], like the age of the
+// oldest item in a queue or the time since the last successful sync. Emitters
+// report the value in the unit expected by their backend instead of the
+// nanoseconds of a [metrics.Gauge].
+//
+// The gauge implements metrics.Gauge, so emitters that do not know the type
+// report it like other gauges. Value returns and Update sets the duration in
+// nanoseconds.
+type DurationGauge interface {
+	Duration() time.Duration
+	Snapshot() metrics.Gauge
+	Update(int64)
+	UpdateDuration(time.Duration)
+	Value() int64
+}
+
+var (
+	counterFloat64Type  = reflect.TypeOf((*CounterFloat64)(nil)).Elem()
+	upDownCounterType   = reflect.TypeOf((*UpDownCounter)(nil)).Elem()
+	durationGaugeType   = reflect.TypeOf((*DurationGauge)(nil)).Elem()
+	intervalCounterType = reflect.TypeOf((*IntervalCounter)(nil)).Elem()
+)
+
+// NewCounterFloat64 creates a CounterFloat64 with a total of zero.
+func NewCounterFloat64() CounterFloat64 {
+	return &counterFloat64{}
+}
+
+// counterFloat64 stores the bits of its total so that it can be updated
+// atomically.
+type counterFloat64 struct {
+	bits atomic.Uint64
+}
+
+func (c *counterFloat64) Clear() {
+	c.bits.Store(0)
+}
+
+func (c *counterFloat64) Count() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+func (c *counterFloat64) Inc(delta float64) {
+	if delta < 0 {
+		panic("negative increment for a CounterFloat64")
+	}
+	for {
+		old := c.bits.Load()
+		if c.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+func (c *counterFloat64) Snapshot() metrics.GaugeFloat64 {
+	return metrics.GaugeFloat64Snapshot(c.Count())
+}
+
+func (c *counterFloat64) Update(float64) {
+	panic("Update called on a CounterFloat64")
+}
+
+func (c *counterFloat64) Value() float64 {
+	return c.Count()
+}
+
+// NewUpDownCounter creates an UpDownCounter with a value of zero.
+func NewUpDownCounter() UpDownCounter {
+	return &upDownCounter{}
+}
+
+type upDownCounter struct {
+	value atomic.Int64
+}
+
+func (c *upDownCounter) Dec(delta int64)         { c.value.Add(-delta) }
+func (c *upDownCounter) Inc(delta int64)         { c.value.Add(delta) }
+func (c *upDownCounter) Snapshot() metrics.Gauge { return metrics.GaugeSnapshot(c.Value()) }
+func (c *upDownCounter) Update(v int64)          { c.value.Store(v) }
+func (c *upDownCounter) Value() int64            { return c.value.Load() }
+
+// NewIntervalCounter creates an IntervalCounter with a count of zero.
+func NewIntervalCounter() IntervalCounter {
+	return &intervalCounter{}
+}
+
+type intervalCounter struct {
+	count atomic.Int64
+}
+
+func (c *intervalCounter) Clear()                    { c.count.Store(0) }
+func (c *intervalCounter) Count() int64              { return c.count.Load() }
+func (c *intervalCounter) Dec(delta int64)           { c.count.Add(-delta) }
+func (c *intervalCounter) Inc(delta int64)           { c.count.Add(delta) }
+func (c *intervalCounter) Reset() int64              { return c.count.Swap(0) }
+func (c *intervalCounter) Snapshot() metrics.Counter { return metrics.CounterSnapshot(c.Count()) }
+
+// NewDurationGauge creates a DurationGauge with a value of zero.
+func NewDurationGauge() DurationGauge {
+	return &durationGauge{}
+}
+
+type durationGauge struct {
+	value atomic.Int64
+}
+
+func (g *durationGauge) Duration() time.Duration        { return time.Duration(g.value.Load()) }
+func (g *durationGauge) Snapshot() metrics.Gauge        { return metrics.GaugeSnapshot(g.Value()) }
+func (g *durationGauge) Update(v int64)                 { g.value.Store(v) }
+func (g *durationGauge) UpdateDuration(d time.Duration) { g.value.Store(int64(d)) }
+func (g *durationGauge) Value() int64                   { return g.value.Load() }
@@ -0,0 +1,83 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var customTypes sync.Map // reflect.Type -> func() any
+
+// RegisterMetricType adds a custom metric type that New can use for fields
+// with the "metric" tag. The type must be an interface type and newMetric
+// must return a new value that implements it. For example:
+//
+//	type SettableHistogram interface {
+//		metrics.Histogram
+//		Set(values []int64)
+//	}
+//
+//	func init() {
+//		appmetrics.RegisterMetricType(
+//			reflect.TypeOf((*SettableHistogram)(nil)).Elem(),
+//			func() any { return NewSettableHistogram() },
+//		)
+//	}
+//
+// Custom types support the "metric-tags", "metric-help", and "metric-unit"
+// tags, but they cannot be used with Tagged. Register adds custom metrics to
+// the registry like other metrics, so emitters report them if they implement
+// one of the go-metrics interfaces, like metrics.Histogram in the example.
+//
+// Call RegisterMetricType during initialization, before calling New with
+// structs that use the type. RegisterMetricType panics if typ is not an
+// interface, if newMetric is nil, or if typ is one of the built-in metric
+// types.
+func RegisterMetricType(typ reflect.Type, newMetric func() any) {
+	if typ == nil || typ.Kind() != reflect.Interface {
+		panic(fmt.Sprintf("appmetrics.RegisterMetricType: type %v is not an interface", typ))
+	}
+	if newMetric == nil {
+		panic("appmetrics.RegisterMetricType: newMetric is nil")
+	}
+	if isBuiltinMetric(typ) {
+		panic(fmt.Sprintf("appmetrics.RegisterMetricType: type %v is a built-in metric type", typ))
+	}
+	customTypes.Store(typ, newMetric)
+}
+
+func lookupMetricType(typ reflect.Type) (func() any, bool) {
+	v, ok := customTypes.Load(typ)
+	if !ok {
+		return nil, false
+	}
+	return v.(func() any), true
+}
+
+// newCustomMetric creates a metric with the factory for a custom type.
+func newCustomMetric(typ reflect.Type) (any, error) {
+	newMetric, ok := lookupMetricType(typ)
+	if !ok {
+		return nil, fmt.Errorf("type %s is not a registered metric type", typ)
+	}
+
+	value := newMetric()
+	if value == nil || !reflect.TypeOf(value).Implements(typ) {
+		return nil, fmt.Errorf("factory for type %s returned %T, which does not implement the type", typ, value)
+	}
+	return value, nil
+}
@@ -42,5 +42,11 @@
 //	metrics.M.Errors.Inc(1)
 //	metrics.M.ActiveWorkers.Update(len(workers))
 //
+// Use [NewCatalog] to describe the metrics in a struct for dashboards or for
+// monitoring systems that accept metric metadata. The "metric-help" and
+// "metric-unit" tags add descriptions and units to the catalog. [Describe]
+// returns the same information for each struct field, in field order, for
+// generating documentation.
+//
 // [go-metrics]: https://pkg.go.dev/github.com/rcrowley/go-metrics
 package appmetrics
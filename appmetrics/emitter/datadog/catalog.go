@@ -0,0 +1,186 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datadog
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/rcrowley/go-metrics"
+)
+
+// MetricMetadata describes a metric reported by the Emitter. The JSON fields
+// match the body of the Datadog metric metadata API, so each entry can be
+// sent to "/api/v1/metrics/{Name}" to update the metadata for the metric.
+type MetricMetadata struct {
+	Name        string   `json:"-" yaml:"name"`
+	Type        string   `json:"type" yaml:"type"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Unit        string   `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Tags        []string `json:"-" yaml:"tags,omitempty"`
+}
+
+// CatalogMetadata returns the metrics that the Emitter reports for the
+// metrics in the catalog. Each metric expands to the same series as in
+// EmitOnce: for example, a histogram produces ".avg", ".count", ".max",
+// ".median", ".min", ".sum", and ".95percentile" gauges, and a ".bucket"
+// count if it has buckets.
+//
+// Units must be valid Datadog unit names to be accepted by the metadata API.
+// Timers use the unit set by SetTimerUnit, except for the ".count" series.
+func CatalogMetadata(c appmetrics.Catalog) []MetricMetadata {
+	var mds []MetricMetadata
+	for _, e := range c.Metrics {
+		md := appmetrics.MetricMetadata{Help: e.Help, Unit: e.Unit}
+		mds = appendMetadata(mds, e.Name, e.Type, md, e.AllTagKeys(), len(e.Buckets) > 0)
+	}
+	return mds
+}
+
+// RegistryMetadata returns the metrics that the Emitter reports for the
+// metrics in the registry, using the metadata from the "metric-help" and
+// "metric-unit" tags of registered appmetrics structs. Because DogStatsD does
+// not send metadata, use this with the Datadog metric metadata API to
+// document the metrics reported by an application. Metrics without metadata
+// are not included.
+func RegistryMetadata(r metrics.Registry) []MetricMetadata {
+	seen := make(map[string]bool)
+
+	var mds []MetricMetadata
+	r.Each(func(name string, metric any) {
+		md, ok := appmetrics.LookupMetadata(name)
+		if !ok {
+			return
+		}
+
+		name, tags := tagsFromName(name)
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+
+		var keys []string
+		for _, t := range tags {
+			k, _, _ := strings.Cut(t, ":")
+			keys = append(keys, k)
+		}
+
+		var typ string
+		switch metric.(type) {
+		case appmetrics.IntervalCounter:
+			typ = appmetrics.TypeIntervalCounter
+		case metrics.Counter:
+			typ = appmetrics.TypeCounter
+		case appmetrics.CounterFloat64:
+			typ = appmetrics.TypeCounterFloat64
+		case appmetrics.DurationGauge:
+			typ = appmetrics.TypeDurationGauge
+		case appmetrics.MaxGauge:
+			typ = appmetrics.TypeMaxGauge
+		case appmetrics.MinGauge:
+			typ = appmetrics.TypeMinGauge
+		case metrics.Gauge, metrics.GaugeFloat64:
+			typ = appmetrics.TypeGauge
+		case metrics.Histogram:
+			typ = appmetrics.TypeHistogram
+		case metrics.Meter:
+			typ = appmetrics.TypeMeter
+		case metrics.Timer:
+			typ = appmetrics.TypeTimer
+		}
+		_, bucketed := metric.(appmetrics.Bucketed)
+		mds = appendMetadata(mds, name, typ, md, keys, bucketed)
+	})
+
+	sort.Slice(mds, func(i, j int) bool {
+		return mds[i].Name < mds[j].Name
+	})
+	return mds
+}
+
+// appendMetadata appends the metadata for each series reported for a metric.
+// Histograms and timers with buckets also report a ".bucket" count.
+func appendMetadata(mds []MetricMetadata, name, typ string, md appmetrics.MetricMetadata, tagKeys []string, bucketed bool) []MetricMetadata {
+	add := func(suffix, typ, unit string) {
+		mds = append(mds, MetricMetadata{
+			Name:        name + suffix,
+			Type:        typ,
+			Description: md.Help,
+			Unit:        unit,
+			Tags:        tagKeys,
+		})
+	}
+
+	switch typ {
+	case appmetrics.TypeCounter, appmetrics.TypeCounterFloat64, appmetrics.TypeIntervalCounter:
+		add("", "count", md.Unit)
+
+	case appmetrics.TypeGauge, appmetrics.TypeGaugeFloat64, appmetrics.TypeMaxGauge, appmetrics.TypeMinGauge:
+		add("", "gauge", md.Unit)
+
+	case appmetrics.TypeDurationGauge:
+		add("", "gauge", "millisecond")
+
+	case appmetrics.TypeHistogram:
+		for _, suffix := range []string{".avg", ".count", ".max", ".median", ".min", ".sum", ".95percentile"} {
+			unit := md.Unit
+			if suffix == ".count" {
+				unit = ""
+			}
+			add(suffix, "gauge", unit)
+		}
+
+	case appmetrics.TypeMeter:
+		for _, suffix := range []string{".avg", ".count", ".rate1", ".rate5", ".rate15"} {
+			add(suffix, "gauge", "")
+		}
+
+	case appmetrics.TypeTimer:
+		for _, suffix := range []string{".avg", ".count", ".max", ".median", ".min", ".sum", ".95percentile"} {
+			unit := timerUnitName()
+			if suffix == ".count" {
+				unit = ""
+			}
+			add(suffix, "gauge", unit)
+		}
+	}
+
+	if bucketed && (typ == appmetrics.TypeHistogram || typ == appmetrics.TypeTimer) {
+		tagKeys = append(tagKeys[:len(tagKeys):len(tagKeys)], "upper_bound")
+		add(".bucket", "count", "")
+	}
+	return mds
+}
+
+// timerUnitName returns the Datadog name of the current timer unit.
+func timerUnitName() string {
+	switch timerUnit {
+	case time.Nanosecond:
+		return "nanosecond"
+	case time.Microsecond:
+		return "microsecond"
+	case time.Millisecond:
+		return "millisecond"
+	case time.Second:
+		return "second"
+	case time.Minute:
+		return "minute"
+	case time.Hour:
+		return "hour"
+	}
+	return ""
+}
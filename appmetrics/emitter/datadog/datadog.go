@@ -19,7 +19,8 @@
 //
 //	metricName[tag1,tag2:value2,...]
 //
-// Global tags for all metrics can be set in the configuration.
+// Global tags for all metrics can be set in the configuration, which can also
+// rename and drop the tags of metrics with an appmetrics.TagMapping.
 //
 // Note that rcrowley/go-metrics and DogStatsd define counters in different
 // ways: counters in DogStatsd are reported over an interval and reset to zero
@@ -27,17 +28,37 @@
 // that are more like gauges with internal state. This package follows the
 // DogStatsd definition and reports the change in counter values between emmit
 // calls. The go-metrics behavior can be simulated at analysis time in Datadog
-// by taking cumulative sums.
+// by taking cumulative sums. DogStatsd counts are integers, so
+// appmetrics.CounterFloat64 metrics report the change in the whole part of
+// their totals; use units where most increments are at least one.
+// appmetrics.IntervalCounter metrics match the DogStatsd definition: the
+// emitter reports their counts and resets them.
+//
+// Histograms and timers that implement appmetrics.Bucketed, like those with
+// the "metric-buckets" tag, also report a ".bucket" count for each bucket with
+// an "upper_bound" tag, matching the series created by the Datadog
+// OpenMetrics integration for Prometheus histograms.
+//
+// appmetrics.DurationGauge metrics are reported as gauges in fractional
+// milliseconds, independent of the unit set by SetTimerUnit.
+// appmetrics.MaxGauge and appmetrics.MinGauge metrics are reported as gauges
+// and reset after each emit, so each value covers one interval.
+//
+// DogStatsd does not support metric metadata, so the help text and units from
+// the "metric-help" and "metric-unit" tags of appmetrics structs do not change
+// the reported metrics. Use RegistryMetadata or CatalogMetadata to send the
+// metadata to the Datadog API instead.
 package datadog
 
 import (
 	"context"
-	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/palantir/go-baseapp/appmetrics"
 	"github.com/palantir/go-baseapp/baseapp"
 	"github.com/pkg/errors"
 	"github.com/rcrowley/go-metrics"
@@ -59,15 +80,110 @@ func SetTimerUnit(unit time.Duration) {
 	timerUnit = unit
 }
 
+// EntityIDTag is the tag that the Datadog agent uses to find the Kubernetes
+// pod that sent a metric.
+const EntityIDTag = "dd.internal.entity_id"
+
 type Config struct {
 	Address  string        `yaml:"address" json:"address"`
 	Interval time.Duration `yaml:"interval" json:"interval"`
 	Tags     []string      `yaml:"tags" json:"tags"`
+
+	// Origin configures how the Datadog agent finds the container or pod
+	// that sent metrics, so it can add container and pod tags.
+	Origin OriginConfig `yaml:"origin" json:"origin"`
+
+	// DryRun logs the metrics that the emitter would send with the server
+	// logger instead of sending them to the address. Use it to check metric
+	// names and tags locally.
+	DryRun bool `yaml:"dry_run" json:"dry_run"`
+
+	// TagMapping renames and drops the tags of metrics. See
+	// Emitter.SetTagMapping.
+	TagMapping appmetrics.TagMapping `yaml:"tag_mapping" json:"tag_mapping"`
+}
+
+// OriginConfig configures the origin of metrics. By default, the client uses
+// origin detection, which reads the container ID from the cgroup of the
+// process, and the DD_ENTITY_ID environment variable. Setting these values
+// in the configuration avoids depending on the environment, for example
+// when the cgroup is not visible in the container.
+//
+// Origin detection over UDP requires Datadog agent version 6.35.0 or 7.35.0
+// or later. The version of the DogStatsd client used by this package does
+// not send the external data field set by the DD_EXTERNAL_ENV variable, so
+// the entity ID or container ID must identify the origin.
+type OriginConfig struct {
+	// Detection enables or disables origin detection. If unset, the client
+	// uses origin detection unless DD_ORIGIN_DETECTION_ENABLED is false.
+	Detection *bool `yaml:"detection" json:"detection"`
+
+	// ContainerID is the ID of the container that sends metrics. If set, it
+	// is used instead of the ID found by origin detection.
+	ContainerID string `yaml:"container_id" json:"container_id"`
+
+	// EntityID is the UID of the Kubernetes pod that sends metrics, usually
+	// set from metadata.uid with the downward API. It is sent in the
+	// EntityIDTag tag, which the agent prefers over the container ID. Do not
+	// also set the DD_ENTITY_ID environment variable, because the client
+	// adds the tag for the variable as well.
+	EntityID string `yaml:"entity_id" json:"entity_id"`
+}
+
+// ClientOptions returns the options for a DogStatsd client that uses the
+// tags and origin in the configuration. Use it to create clients for
+// NewEmitter that match the clients created by StartEmitter.
+func (c Config) ClientOptions() []statsd.Option {
+	tags := c.Tags
+	if c.Origin.EntityID != "" {
+		tags = append(tags[:len(tags):len(tags)], EntityIDTag+":"+c.Origin.EntityID)
+	}
+
+	opts := []statsd.Option{statsd.WithTags(tags)}
+	if d := c.Origin.Detection; d != nil {
+		if *d {
+			opts = append(opts, statsd.WithOriginDetection())
+		} else {
+			opts = append(opts, statsd.WithoutOriginDetection())
+		}
+	}
+	if c.Origin.ContainerID != "" {
+		opts = append(opts, statsd.WithContainerID(c.Origin.ContainerID))
+	}
+	return opts
 }
 
 // StartEmitter starts a goroutine that emits metrics from the server's
 // registry to the configured DogStatsd endpoint.
 func StartEmitter(s *baseapp.Server, c Config) error {
+	emitter, c, err := newServerEmitter(s, c)
+	if err != nil {
+		return err
+	}
+
+	go emitter.Emit(context.Background(), c.Interval)
+
+	return nil
+}
+
+// UnstartedEmitter returns an emitter for baseapp.WithUnstartedEmitters or
+// ServerBuilder.Emitters. Unlike StartEmitter, it creates the client when the
+// server starts, so it emits metrics from the server's final registry, and it
+// stops emitting when the server shuts down.
+func UnstartedEmitter(c Config) baseapp.EmitterFunc {
+	return func(ctx context.Context, s *baseapp.Server) error {
+		emitter, c, err := newServerEmitter(s, c)
+		if err != nil {
+			return err
+		}
+		emitter.Emit(ctx, c.Interval)
+		return nil
+	}
+}
+
+// newServerEmitter creates an emitter for the server's registry and returns
+// the configuration with defaults applied.
+func newServerEmitter(s *baseapp.Server, c Config) (*Emitter, Config, error) {
 	if c.Address == "" {
 		c.Address = DefaultAddress
 	}
@@ -75,22 +191,34 @@ func StartEmitter(s *baseapp.Server, c Config) error {
 		c.Interval = DefaultInterval
 	}
 
-	client, err := statsd.New(c.Address, statsd.WithTags(c.Tags))
+	var client *statsd.Client
+	var err error
+	if c.DryRun {
+		opts := append(c.ClientOptions(), statsd.WithoutTelemetry())
+		client, err = statsd.NewWithWriter(&logWriter{logger: s.Logger()}, opts...)
+	} else {
+		client, err = statsd.New(c.Address, c.ClientOptions()...)
+	}
 	if err != nil {
-		return errors.Wrap(err, "datadog: failed to create client")
+		return nil, c, errors.Wrap(err, "datadog: failed to create client")
 	}
-
 	emitter := NewEmitter(client, s.Registry())
-
-	go emitter.Emit(context.Background(), c.Interval)
-
-	return nil
+	emitter.SetTagMapping(c.TagMapping)
+	return emitter, c, nil
 }
 
+// EmitHook is called before each emission by Emit. It returns the context
+// for the emission and a function that is called with the result of flushing
+// the client. Hooks can use this to trace emissions, so that slow or stalled
+// emitters appear in the same tracing backend as requests.
+type EmitHook func(ctx context.Context) (context.Context, func(err error))
+
 type Emitter struct {
 	client   *statsd.Client
 	registry metrics.Registry
 	counters map[string]int64
+	hooks    []EmitHook
+	mapping  appmetrics.TagMapping
 }
 
 func NewEmitter(client *statsd.Client, registry metrics.Registry) *Emitter {
@@ -108,21 +236,64 @@ func (e *Emitter) Emit(ctx context.Context, interval time.Duration) {
 	for {
 		select {
 		case <-t.C:
-			e.EmitOnce()
+			e.emit(ctx)
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// AddEmitHook adds a hook that is called before each emission by Emit. Hooks
+// are called in the order they were added and the functions they return are
+// called in reverse order after the emission. Hooks must be added before
+// calling Emit.
+func (e *Emitter) AddEmitHook(h EmitHook) {
+	e.hooks = append(e.hooks, h)
+}
+
+// SetTagMapping sets the mapping applied to the tags of each metric before
+// it is reported. Counters that report the same series after the mapping are
+// added together by DogStatsd, while the last value reported for other
+// duplicate series wins. The mapping must be set before calling Emit.
+func (e *Emitter) SetTagMapping(m appmetrics.TagMapping) {
+	e.mapping = m
+}
+
+func (e *Emitter) emit(ctx context.Context) {
+	if len(e.hooks) == 0 {
+		e.EmitOnce()
+		return
+	}
+
+	var finishers []func(error)
+	for _, h := range e.hooks {
+		var finish func(error)
+		if ctx, finish = h(ctx); finish != nil {
+			finishers = append(finishers, finish)
+		}
+	}
+
+	e.EmitOnce()
+	err := e.Flush()
+
+	for i := len(finishers) - 1; i >= 0; i-- {
+		finishers[i](err)
+	}
+}
+
 func (e *Emitter) EmitOnce() {
-	e.registry.Each(func(name string, metric interface{}) {
-		name, tags := tagsFromName(name)
+	e.registry.Each(func(key string, metric interface{}) {
+		// Track counters by the registry name, which is unique even if the
+		// mapping reports several metrics with the same tags
+		name, tags := tagsFromName(e.mapping.MapName(key))
 
 		switch m := metric.(type) {
-		case metrics.Counter:
-			key := fmt.Sprintf("%s[%s]", name, strings.Join(tags, ","))
+		case appmetrics.IntervalCounter:
+			// Interval counters already report the change since the last
+			// emit, like DogStatsd counts
+			_ = e.client.Count(name, m.Reset(), tags, 1)
 
+		case metrics.Counter:
 			// DogStatds implements counts as per flush interval, while
 			// go-metrics implements counts as an increasing total. Reconcile
 			// this by reporting the difference in value between calls
@@ -130,6 +301,23 @@ func (e *Emitter) EmitOnce() {
 			value, e.counters[key] = value-e.counters[key], value
 			_ = e.client.Count(name, value, tags, 1)
 
+		case appmetrics.CounterFloat64:
+			// DogStatsd counts are integers, so report the change in the
+			// whole part of the total. Fractional increments are reported
+			// once they add up to a whole number.
+			value := int64(m.Count())
+			value, e.counters[key] = value-e.counters[key], value
+			_ = e.client.Count(name, value, tags, 1)
+
+		case appmetrics.DurationGauge:
+			_ = e.client.Gauge(name, float64(m.Duration())/float64(time.Millisecond), tags, 1)
+
+		case appmetrics.MaxGauge:
+			_ = e.client.Gauge(name, float64(m.Reset()), tags, 1)
+
+		case appmetrics.MinGauge:
+			_ = e.client.Gauge(name, float64(m.Reset()), tags, 1)
+
 		case metrics.Gauge:
 			_ = e.client.Gauge(name, float64(m.Value()), tags, 1)
 
@@ -137,6 +325,9 @@ func (e *Emitter) EmitOnce() {
 			_ = e.client.Gauge(name, m.Value(), tags, 1)
 
 		case metrics.Histogram:
+			if b, ok := m.(appmetrics.Bucketed); ok {
+				e.emitBuckets(key, name, tags, b.Buckets(), 1)
+			}
 			ms := m.Snapshot()
 			_ = e.client.Gauge(name+".avg", ms.Mean(), tags, 1)
 			_ = e.client.Gauge(name+".count", float64(ms.Count()), tags, 1)
@@ -155,6 +346,10 @@ func (e *Emitter) EmitOnce() {
 			_ = e.client.Gauge(name+".rate15", ms.Rate15(), tags, 1)
 
 		case metrics.Timer:
+			if b, ok := m.(appmetrics.Bucketed); ok {
+				// Bucket bounds are in seconds
+				e.emitBuckets(key, name, tags, b.Buckets(), float64(time.Second)/float64(timerUnit))
+			}
 			ms := m.Snapshot()
 			_ = e.client.Gauge(name+".avg", convertTime(ms.Mean()), tags, 1)
 			_ = e.client.Gauge(name+".count", float64(ms.Count()), tags, 1)
@@ -167,6 +362,26 @@ func (e *Emitter) EmitOnce() {
 	})
 }
 
+// emitBuckets reports the buckets of a histogram or timer as a ".bucket"
+// count with the "upper_bound" tag, like the Datadog OpenMetrics
+// integration. Like counters, buckets report the change in their cumulative
+// count since the last call, tracked with the registry key of the metric.
+// Bounds are multiplied by scale.
+func (e *Emitter) emitBuckets(key, name string, tags []string, b appmetrics.BucketCounts, scale float64) {
+	emit := func(bound string, count uint64) {
+		bucketTags := append(tags[:len(tags):len(tags)], "upper_bound:"+bound)
+		bucketKey := key + ".bucket:" + bound
+
+		value := int64(count)
+		value, e.counters[bucketKey] = value-e.counters[bucketKey], value
+		_ = e.client.Count(name+".bucket", value, bucketTags, 1)
+	}
+	for i, bound := range b.Bounds {
+		emit(strconv.FormatFloat(bound*scale, 'f', -1, 64), b.Counts[i])
+	}
+	emit("inf", b.Count)
+}
+
 func (e *Emitter) Flush() error {
 	return e.client.Flush()
 }
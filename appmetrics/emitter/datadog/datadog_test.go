@@ -15,12 +15,18 @@
 package datadog
 
 import (
+	"bytes"
+	"context"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/palantir/go-baseapp/appmetrics"
 	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTagsFromName(t *testing.T) {
@@ -49,6 +55,51 @@ func TestTagsFromName(t *testing.T) {
 	})
 }
 
+func TestClientOptions(t *testing.T) {
+	t.Setenv("DD_ENTITY_ID", "")
+
+	// The client stores the container ID globally, so don't set it here to
+	// avoid changing the messages in other tests
+	disabled := false
+	c := Config{
+		Tags: []string{"env:test"},
+		Origin: OriginConfig{
+			Detection: &disabled,
+			EntityID:  "pod-uid",
+		},
+	}
+
+	w := &MemoryWriter{}
+	client, err := statsd.NewWithWriter(w, append(c.ClientOptions(), statsd.WithoutTelemetry())...)
+	require.NoError(t, err)
+
+	e := NewEmitter(client, metrics.NewRegistry())
+	_ = client.Gauge("gauge", 1, nil, 1)
+	assert.NoError(t, e.Flush(), "emitter flush should complete")
+
+	assert.Equal(t, []string{"gauge:1|g|#env:test,dd.internal.entity_id:pod-uid\n"}, w.Messages)
+	assert.Equal(t, []string{"env:test"}, c.Tags, "configured tags should not change")
+}
+
+func TestDryRun(t *testing.T) {
+	var out bytes.Buffer
+	w := &logWriter{logger: zerolog.New(&out)}
+
+	disabled := false
+	c := Config{Tags: []string{"env:test"}, Origin: OriginConfig{Detection: &disabled}}
+	client, err := statsd.NewWithWriter(w, append(c.ClientOptions(), statsd.WithoutTelemetry())...)
+	require.NoError(t, err)
+
+	r := metrics.NewRegistry()
+	e := NewEmitter(client, r)
+	metrics.NewRegisteredCounter("requests[code:200]", r).Inc(3)
+
+	e.EmitOnce()
+	require.NoError(t, e.Flush())
+
+	assert.JSONEq(t, `{"level":"info","metric":"requests","type":"count","value":"3","tags":["env:test","code:200"],"message":"Dry run metric"}`, out.String())
+}
+
 func TestEmitCounts(t *testing.T) {
 	initialize := func() (*Emitter, *MemoryWriter, metrics.Registry) {
 		w := &MemoryWriter{}
@@ -83,6 +134,104 @@ func TestEmitCounts(t *testing.T) {
 		assert.Equal(t, int64(3), c.Count())
 		assert.Equal(t, []string{"counter:1|c\n", "counter:2|c\n"}, w.Messages)
 	})
+
+	t.Run("interval", func(t *testing.T) {
+		e, w, r := initialize()
+		c := appmetrics.NewIntervalCounter()
+		_ = r.Register("interval", c)
+
+		c.Inc(2)
+		e.EmitOnce()
+		assert.NoError(t, e.Flush(), "emitter flush should complete")
+		c.Inc(1)
+		e.EmitOnce()
+		assert.NoError(t, e.Flush(), "emitter flush should complete")
+
+		assert.Zero(t, c.Count(), "emit should reset the counter")
+		assert.Equal(t, []string{"interval:2|c\n", "interval:1|c\n"}, w.Messages)
+	})
+
+	t.Run("tagMapping", func(t *testing.T) {
+		e, w, r := initialize()
+		e.SetTagMapping(appmetrics.TagMapping{Rename: map[string]string{"status": "code"}, Drop: []string{"instance"}})
+		a := metrics.NewRegisteredCounter("counter[instance:a,status:200]", r)
+		b := metrics.NewRegisteredCounter("counter[instance:b,status:200]", r)
+
+		a.Inc(1)
+		b.Inc(2)
+		e.EmitOnce()
+		a.Inc(1)
+		e.EmitOnce()
+		assert.NoError(t, e.Flush(), "emitter flush should complete")
+
+		// the client adds counts for the same series, so it reports the sum
+		// of the changes to both counters
+		assert.Equal(t, []string{"counter:4|c|#code:200\n"}, w.Messages)
+	})
+}
+
+func TestEmitHooks(t *testing.T) {
+	w := &MemoryWriter{}
+	c, _ := statsd.NewWithWriter(w)
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("counter", r).Inc(1)
+
+	e := NewEmitter(c, r)
+
+	var calls []string
+	for _, name := range []string{"a", "b"} {
+		e.AddEmitHook(func(ctx context.Context) (context.Context, func(error)) {
+			calls = append(calls, "start "+name)
+			return ctx, func(err error) {
+				assert.NoError(t, err)
+				calls = append(calls, "finish "+name)
+			}
+		})
+	}
+
+	e.emit(context.Background())
+
+	assert.Equal(t, []string{"start a", "start b", "finish b", "finish a"}, calls)
+	assert.Equal(t, []string{"counter:1|c\n"}, w.Messages, "hooked emissions should flush")
+}
+
+func TestEmitBuckets(t *testing.T) {
+	type M struct {
+		Latency metrics.Timer `metric:"latency" metric-buckets:"0.01,0.1"`
+	}
+
+	w := &MemoryWriter{}
+	c, _ := statsd.NewWithWriter(w, statsd.WithoutTelemetry())
+	r := metrics.NewRegistry()
+	e := NewEmitter(c, r)
+
+	m := appmetrics.New[M]()
+	appmetrics.Register(r, m)
+	m.Latency.Update(5 * time.Millisecond)
+	m.Latency.Update(50 * time.Millisecond)
+	e.EmitOnce()
+	require.NoError(t, e.Flush())
+	m.Latency.Update(5 * time.Millisecond)
+	e.EmitOnce()
+	require.NoError(t, e.Flush())
+
+	var buckets []string
+	for _, msg := range w.Messages {
+		for _, line := range strings.Split(strings.TrimSpace(msg), "\n") {
+			if strings.HasPrefix(line, "latency.bucket:") {
+				buckets = append(buckets, line)
+			}
+		}
+	}
+	// The client aggregates counts, so the order within a flush may vary
+	assert.ElementsMatch(t, []string{
+		"latency.bucket:1|c|#upper_bound:10000000",
+		"latency.bucket:2|c|#upper_bound:100000000",
+		"latency.bucket:2|c|#upper_bound:inf",
+		"latency.bucket:1|c|#upper_bound:10000000",
+		"latency.bucket:1|c|#upper_bound:100000000",
+		"latency.bucket:1|c|#upper_bound:inf",
+	}, buckets)
 }
 
 type MemoryWriter struct {
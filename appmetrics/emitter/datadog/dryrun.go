@@ -0,0 +1,82 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datadog
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+var metricTypes = map[string]string{
+	"c":  "count",
+	"g":  "gauge",
+	"h":  "histogram",
+	"d":  "distribution",
+	"s":  "set",
+	"ms": "timing",
+}
+
+// logWriter is a writer for a DogStatsd client that logs each metric instead
+// of sending it over the network.
+type logWriter struct {
+	logger zerolog.Logger
+}
+
+func (w *logWriter) Write(b []byte) (int, error) {
+	for _, line := range strings.Split(string(b), "\n") {
+		if line != "" {
+			w.logLine(line)
+		}
+	}
+	return len(b), nil
+}
+
+func (w *logWriter) Close() error {
+	return nil
+}
+
+// logLine logs a line in the DogStatsd format:
+//
+//	name:value|type[|@rate][|#tag1,tag2][|c:container]
+//
+// Lines for events and service checks are logged as they are.
+func (w *logWriter) logLine(line string) {
+	fields := strings.Split(line, "|")
+	name, value, ok := strings.Cut(fields[0], ":")
+	if !ok || len(fields) < 2 || strings.HasPrefix(line, "_") {
+		w.logger.Info().Str("datagram", line).Msg("Dry run metric")
+		return
+	}
+
+	typ, ok := metricTypes[fields[1]]
+	if !ok {
+		typ = fields[1]
+	}
+
+	tags := []string{}
+	for _, f := range fields[2:] {
+		if t, ok := strings.CutPrefix(f, "#"); ok {
+			tags = strings.Split(t, ",")
+		}
+	}
+
+	w.logger.Info().
+		Str("metric", name).
+		Str("type", typ).
+		Str("value", value).
+		Strs("tags", tags).
+		Msg("Dry run metric")
+}
@@ -0,0 +1,446 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otel bridges the metrics in a rcrowley/go-metrics registry to the
+// OpenTelemetry SDK, so that applications that already export traces with
+// OpenTelemetry can export metrics over the same pipeline, like OTLP.
+//
+// The Producer reads the registry each time the SDK collects metrics. Add it
+// to a reader of the SDK's MeterProvider:
+//
+//	reader := sdkmetric.NewPeriodicReader(exporter, sdkmetric.WithProducer(otel.NewProducer(server.Registry())))
+//	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+//
+// To export the metrics of an appmetrics struct, register the struct with
+// the registry.
+//
+// It supports a special format for metric names to add metric-specific
+// attributes:
+//
+//	metricName[tag1,tag2:value2,...]
+//
+// If a tag does not have a value, the tag is used as both the attribute key
+// and the value. Metrics with the same base name are reported as data points
+// of one OpenTelemetry metric. Use WithTagMapping to rename or drop tags, for
+// example to match OpenTelemetry semantic conventions.
+//
+// The package translates between rcrowley/go-metrics types and OpenTelemetry
+// types as needed:
+//
+//   - metrics.Counter and appmetrics.CounterFloat64 metrics are reported as
+//     cumulative monotonic sums, like counter instruments. Because go-metrics
+//     counters may decrease, decreases appear as counter resets.
+//   - appmetrics.IntervalCounter metrics are reset after each collection and
+//     reported as cumulative monotonic sums of their counts.
+//   - appmetrics.UpDownCounter metrics are reported as cumulative
+//     non-monotonic sums, like up-down counter instruments.
+//   - metrics.Gauge and metrics.GaugeFloat64 metrics, including functional
+//     gauges, are reported as gauges, like observable gauge instruments.
+//   - appmetrics.DurationGauge metrics are reported as gauges of seconds.
+//   - appmetrics.MaxGauge and appmetrics.MinGauge metrics are reported as
+//     gauges and reset after each collection.
+//   - metrics.Meter metrics are reported as cumulative monotonic sums of the
+//     number of events.
+//   - Histograms and timers that implement appmetrics.Bucketed, like those
+//     with the "metric-buckets" tag, are reported as cumulative histograms,
+//     like histogram instruments. Other histograms and timers are reported as
+//     summaries using a configurable (per producer) set of quantiles.
+//     Timers always report seconds.
+//
+// Metrics defined in appmetrics structs with the "metric-help" and
+// "metric-unit" tags use the tag values as their description and unit.
+// Healthchecks are not reported.
+package otel
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/rcrowley/go-metrics"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// ScopeName is the name of the instrumentation scope of the metrics from a
+// Producer.
+const ScopeName = "github.com/palantir/go-baseapp/appmetrics/emitter/otel"
+
+// Producer is a sdkmetric.Producer that produces the metrics from a
+// metrics.Registry.
+type Producer struct {
+	registry metrics.Registry
+	start    time.Time
+
+	histogramQuantiles []float64
+	timerQuantiles     []float64
+	mapping            appmetrics.TagMapping
+
+	// intervals are the totals of interval counters, guarded by mu
+	mu        sync.Mutex
+	intervals map[string]int64
+}
+
+var _ sdkmetric.Producer = &Producer{}
+
+// ProducerOption configures a Producer.
+type ProducerOption func(*Producer)
+
+// WithHistogramQuantiles sets the quantiles reported in summaries of histogram
+// metrics. By default, use 0.5 and 0.95, the median and the 95th percentile.
+func WithHistogramQuantiles(qs []float64) ProducerOption {
+	return func(p *Producer) {
+		p.histogramQuantiles = append([]float64(nil), qs...)
+	}
+}
+
+// WithTimerQuantiles sets the quantiles reported in summaries of timer
+// metrics. By default, use 0.5 and 0.95, the median and the 95th percentile.
+func WithTimerQuantiles(qs []float64) ProducerOption {
+	return func(p *Producer) {
+		p.timerQuantiles = append([]float64(nil), qs...)
+	}
+}
+
+// WithTagMapping sets the mapping applied to the tags of each metric before
+// they become attributes. If several metrics report the same data point
+// after the mapping, only the first metric in name order is reported.
+func WithTagMapping(m appmetrics.TagMapping) ProducerOption {
+	return func(p *Producer) {
+		p.mapping = m
+	}
+}
+
+// NewProducer returns a Producer for the metrics in the registry. Cumulative
+// metrics use the time NewProducer was called as their start time.
+func NewProducer(r metrics.Registry, opts ...ProducerOption) *Producer {
+	p := &Producer{
+		registry:           r,
+		start:              time.Now(),
+		histogramQuantiles: []float64{0.5, 0.95},
+		timerQuantiles:     []float64{0.5, 0.95},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Produce returns the current values of the metrics in the registry. If
+// metrics with the same base name have different types, only the metrics
+// with the type of the first metric in name order are reported.
+func (p *Producer) Produce(ctx context.Context) ([]metricdata.ScopeMetrics, error) {
+	// Visit metrics in name order so that the same metrics win each time
+	// there is a conflict
+	var names []string
+	entries := make(map[string]any)
+	p.registry.Each(func(name string, metric any) {
+		names = append(names, name)
+		entries[name] = metric
+	})
+	sort.Strings(names)
+
+	b := batch{start: p.start, now: time.Now(), index: make(map[string]int)}
+	seen := make(map[dataPointKey]bool, len(names))
+	var hasIntervals bool
+	for _, name := range names {
+		base, attrs := attributesFromName(p.mapping.MapName(name))
+		key := dataPointKey{name: base, attrs: attrs.Equivalent()}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		md, _ := appmetrics.LookupMetadata(name)
+
+		switch m := entries[name].(type) {
+		case appmetrics.IntervalCounter:
+			hasIntervals = true
+			b.sum(base, md.Help, md.Unit, true, attrs, p.intervalTotal(name, m.Reset()))
+
+		case metrics.Counter:
+			b.sum(base, md.Help, md.Unit, true, attrs, m.Count())
+
+		case appmetrics.CounterFloat64:
+			b.sumFloat64(base, md.Help, md.Unit, true, attrs, m.Count())
+
+		case appmetrics.UpDownCounter:
+			b.sum(base, md.Help, md.Unit, false, attrs, m.Value())
+
+		case appmetrics.DurationGauge:
+			b.gaugeFloat64(base, md.Help, "s", attrs, m.Duration().Seconds())
+
+		case appmetrics.MaxGauge:
+			b.gauge(base, md.Help, md.Unit, attrs, m.Reset())
+
+		case appmetrics.MinGauge:
+			b.gauge(base, md.Help, md.Unit, attrs, m.Reset())
+
+		case metrics.Gauge:
+			b.gauge(base, md.Help, md.Unit, attrs, m.Value())
+
+		case metrics.GaugeFloat64:
+			b.gaugeFloat64(base, md.Help, md.Unit, attrs, m.Value())
+
+		case metrics.Meter:
+			// The meter reports a count of events, so the unit does not apply
+			b.sum(base, md.Help, "", true, attrs, m.Snapshot().Count())
+
+		case metrics.Histogram:
+			s := m.Snapshot()
+			if bm, ok := m.(appmetrics.Bucketed); ok {
+				b.histogram(base, md.Help, md.Unit, attrs, bm.Buckets(), float64(s.Min()), float64(s.Max()))
+			} else {
+				b.summary(base, md.Help, md.Unit, attrs, uint64(s.Count()), float64(s.Sum()), quantiles(s, p.histogramQuantiles, 1))
+			}
+
+		case metrics.Timer:
+			s := m.Snapshot()
+			if bm, ok := m.(appmetrics.Bucketed); ok {
+				b.histogram(base, md.Help, "s", attrs, bm.Buckets(), toSeconds(s.Min()), toSeconds(s.Max()))
+			} else {
+				b.summary(base, md.Help, "s", attrs, uint64(s.Count()), toSeconds(s.Sum()), quantiles(s, p.timerQuantiles, float64(time.Second)))
+			}
+		}
+	}
+
+	if hasIntervals {
+		p.pruneIntervals(names)
+	}
+
+	if len(b.metrics) == 0 {
+		return nil, nil
+	}
+	return []metricdata.ScopeMetrics{{
+		Scope:   instrumentation.Scope{Name: ScopeName},
+		Metrics: b.metrics,
+	}}, nil
+}
+
+// intervalTotal adds the count of an interval counter to its total and
+// returns the new total.
+func (p *Producer) intervalTotal(name string, count int64) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.intervals == nil {
+		p.intervals = make(map[string]int64)
+	}
+	p.intervals[name] += count
+	return p.intervals[name]
+}
+
+// pruneIntervals removes the totals of interval counters that no longer
+// exist in the registry. The names must be sorted.
+func (p *Producer) pruneIntervals(names []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name := range p.intervals {
+		if _, found := slices.BinarySearch(names, name); !found {
+			delete(p.intervals, name)
+		}
+	}
+}
+
+// dataPointKey identifies the data point of a metric in a batch.
+type dataPointKey struct {
+	name  string
+	attrs attribute.Distinct
+}
+
+// batch collects the metrics for one call to Produce.
+type batch struct {
+	start time.Time
+	now   time.Time
+
+	metrics []metricdata.Metrics
+	index   map[string]int
+	kinds   []string
+}
+
+// metric returns the metric with the name, creating it with newData if it
+// does not exist. It returns nil if the metric exists with a different kind.
+func (b *batch) metric(name, kind, description, unit string, newData func() metricdata.Aggregation) *metricdata.Metrics {
+	if i, ok := b.index[name]; ok {
+		if b.kinds[i] != kind {
+			return nil
+		}
+		return &b.metrics[i]
+	}
+
+	b.index[name] = len(b.metrics)
+	b.kinds = append(b.kinds, kind)
+	b.metrics = append(b.metrics, metricdata.Metrics{
+		Name:        name,
+		Description: description,
+		Unit:        unit,
+		Data:        newData(),
+	})
+	return &b.metrics[len(b.metrics)-1]
+}
+
+func (b *batch) sum(name, description, unit string, monotonic bool, attrs attribute.Set, value int64) {
+	kind := "sum"
+	if monotonic {
+		kind = "counter"
+	}
+	if m := b.metric(name, kind, description, unit, func() metricdata.Aggregation {
+		return metricdata.Sum[int64]{Temporality: metricdata.CumulativeTemporality, IsMonotonic: monotonic}
+	}); m != nil {
+		s := m.Data.(metricdata.Sum[int64])
+		s.DataPoints = append(s.DataPoints, metricdata.DataPoint[int64]{Attributes: attrs, StartTime: b.start, Time: b.now, Value: value})
+		m.Data = s
+	}
+}
+
+func (b *batch) sumFloat64(name, description, unit string, monotonic bool, attrs attribute.Set, value float64) {
+	kind := "sum_float64"
+	if monotonic {
+		kind = "counter_float64"
+	}
+	if m := b.metric(name, kind, description, unit, func() metricdata.Aggregation {
+		return metricdata.Sum[float64]{Temporality: metricdata.CumulativeTemporality, IsMonotonic: monotonic}
+	}); m != nil {
+		s := m.Data.(metricdata.Sum[float64])
+		s.DataPoints = append(s.DataPoints, metricdata.DataPoint[float64]{Attributes: attrs, StartTime: b.start, Time: b.now, Value: value})
+		m.Data = s
+	}
+}
+
+func (b *batch) gauge(name, description, unit string, attrs attribute.Set, value int64) {
+	if m := b.metric(name, "gauge", description, unit, func() metricdata.Aggregation {
+		return metricdata.Gauge[int64]{}
+	}); m != nil {
+		g := m.Data.(metricdata.Gauge[int64])
+		g.DataPoints = append(g.DataPoints, metricdata.DataPoint[int64]{Attributes: attrs, Time: b.now, Value: value})
+		m.Data = g
+	}
+}
+
+func (b *batch) gaugeFloat64(name, description, unit string, attrs attribute.Set, value float64) {
+	if m := b.metric(name, "gauge_float64", description, unit, func() metricdata.Aggregation {
+		return metricdata.Gauge[float64]{}
+	}); m != nil {
+		g := m.Data.(metricdata.Gauge[float64])
+		g.DataPoints = append(g.DataPoints, metricdata.DataPoint[float64]{Attributes: attrs, Time: b.now, Value: value})
+		m.Data = g
+	}
+}
+
+func (b *batch) histogram(name, description, unit string, attrs attribute.Set, bc appmetrics.BucketCounts, minValue, maxValue float64) {
+	if m := b.metric(name, "histogram", description, unit, func() metricdata.Aggregation {
+		return metricdata.Histogram[float64]{Temporality: metricdata.CumulativeTemporality}
+	}); m != nil {
+		// BucketCounts are cumulative, while OpenTelemetry counts the values
+		// in each bucket, including a final bucket for values above all bounds
+		counts := make([]uint64, len(bc.Bounds)+1)
+		var prev uint64
+		for i, c := range bc.Counts {
+			counts[i] = c - prev
+			prev = c
+		}
+		counts[len(bc.Bounds)] = bc.Count - prev
+
+		dp := metricdata.HistogramDataPoint[float64]{
+			Attributes:   attrs,
+			StartTime:    b.start,
+			Time:         b.now,
+			Count:        bc.Count,
+			Bounds:       bc.Bounds,
+			BucketCounts: counts,
+			Sum:          bc.Sum,
+		}
+		if bc.Count > 0 {
+			dp.Min = metricdata.NewExtrema(minValue)
+			dp.Max = metricdata.NewExtrema(maxValue)
+		}
+
+		h := m.Data.(metricdata.Histogram[float64])
+		h.DataPoints = append(h.DataPoints, dp)
+		m.Data = h
+	}
+}
+
+func (b *batch) summary(name, description, unit string, attrs attribute.Set, count uint64, sum float64, qs []metricdata.QuantileValue) {
+	if m := b.metric(name, "summary", description, unit, func() metricdata.Aggregation {
+		return metricdata.Summary{}
+	}); m != nil {
+		s := m.Data.(metricdata.Summary)
+		s.DataPoints = append(s.DataPoints, metricdata.SummaryDataPoint{
+			Attributes:     attrs,
+			StartTime:      b.start,
+			Time:           b.now,
+			Count:          count,
+			Sum:            sum,
+			QuantileValues: qs,
+		})
+		m.Data = s
+	}
+}
+
+// attributesFromName returns the base name and the attributes from the tags
+// of a go-metrics name.
+func attributesFromName(name string) (string, attribute.Set) {
+	start := strings.IndexRune(name, '[')
+	if start < 0 || name[len(name)-1] != ']' {
+		return sanitizeName(name), *attribute.EmptySet()
+	}
+
+	var kvs []attribute.KeyValue
+	for _, tag := range strings.Split(name[start+1:len(name)-1], ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(tag), ":")
+		if !ok {
+			value = key
+		}
+		if key != "" {
+			kvs = append(kvs, attribute.String(key, value))
+		}
+	}
+	return sanitizeName(name[:start]), attribute.NewSet(kvs...)
+}
+
+// sanitizeName replaces the characters that are not allowed in
+// OpenTelemetry instrument names with underscores.
+func sanitizeName(name string) string {
+	return strings.Map(func(c rune) rune {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '_', c == '.', c == '-', c == '/':
+		default:
+			return '_'
+		}
+		return c
+	}, name)
+}
+
+func toSeconds[N int64 | float64](n N) float64 {
+	return float64(n) / float64(time.Second)
+}
+
+// quantiles returns the quantiles of a histogram, dividing the values by
+// scale.
+func quantiles(h interface{ Percentiles([]float64) []float64 }, ps []float64, scale float64) []metricdata.QuantileValue {
+	qs := make([]metricdata.QuantileValue, len(ps))
+	for i, v := range h.Percentiles(ps) {
+		qs[i] = metricdata.QuantileValue{Quantile: ps[i], Value: v / scale}
+	}
+	return qs
+}
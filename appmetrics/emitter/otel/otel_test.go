@@ -0,0 +1,117 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type testMetrics struct {
+	Requests appmetrics.Tagged[metrics.Counter] `metric:"server.requests" metric-help:"Requests served"`
+	Workers  metrics.Gauge                      `metric:"workers"`
+	Active   appmetrics.UpDownCounter           `metric:"active"`
+	Latency  metrics.Timer                      `metric:"latency" metric-buckets:"0.1,1"`
+	Sizes    metrics.Histogram                  `metric:"sizes" metric-unit:"By"`
+	Age      appmetrics.DurationGauge           `metric:"age"`
+	Retries  appmetrics.IntervalCounter         `metric:"retries"`
+}
+
+func TestProducer(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := appmetrics.New[testMetrics]()
+	appmetrics.Register(r, m)
+
+	m.Requests.Tag("status:200", "api").Inc(3)
+	m.Workers.Update(4)
+	m.Active.Inc(2)
+	m.Latency.Update(50 * time.Millisecond)
+	m.Latency.Update(2 * time.Second)
+	m.Sizes.Update(100)
+	m.Age.UpdateDuration(250 * time.Millisecond)
+	m.Retries.Inc(2)
+
+	// a metric with a conflicting type for the same base name
+	metrics.GetOrRegisterGauge("workers[pool:a]", r).Update(1)
+	metrics.GetOrRegisterCounter("workers[pool:b]", r).Inc(1)
+
+	reader := sdkmetric.NewManualReader(sdkmetric.WithProducer(NewProducer(r, WithHistogramQuantiles([]float64{0.5}))))
+	_ = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, ScopeName, rm.ScopeMetrics[0].Scope.Name)
+
+	byName := make(map[string]metricdata.Metrics)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		byName[m.Name] = m
+	}
+
+	requests := byName["server.requests"]
+	assert.Equal(t, "Requests served", requests.Description)
+	sum := requests.Data.(metricdata.Sum[int64])
+	assert.True(t, sum.IsMonotonic)
+	require.Len(t, sum.DataPoints, 2, "the bare metric and the tagged series should be reported")
+	assert.Equal(t, int64(3), sum.DataPoints[1].Value)
+	assert.Equal(t, attribute.NewSet(attribute.String("api", "api"), attribute.String("status", "200")), sum.DataPoints[1].Attributes)
+
+	workers := byName["workers"].Data.(metricdata.Gauge[int64])
+	assert.Len(t, workers.DataPoints, 2, "metrics with a different type should be dropped")
+
+	active := byName["active"].Data.(metricdata.Sum[int64])
+	assert.False(t, active.IsMonotonic)
+	assert.Equal(t, int64(2), active.DataPoints[0].Value)
+
+	assert.Equal(t, "s", byName["latency"].Unit)
+	latency := byName["latency"].Data.(metricdata.Histogram[float64])
+	require.Len(t, latency.DataPoints, 1)
+	assert.Equal(t, []float64{0.1, 1}, latency.DataPoints[0].Bounds)
+	assert.Equal(t, []uint64{1, 0, 1}, latency.DataPoints[0].BucketCounts)
+	assert.Equal(t, uint64(2), latency.DataPoints[0].Count)
+	maxValue, _ := latency.DataPoints[0].Max.Value()
+	assert.Equal(t, 2.0, maxValue)
+
+	assert.Equal(t, "s", byName["age"].Unit)
+	age := byName["age"].Data.(metricdata.Gauge[float64])
+	assert.Equal(t, 0.25, age.DataPoints[0].Value)
+
+	assert.Equal(t, "By", byName["sizes"].Unit)
+	sizes := byName["sizes"].Data.(metricdata.Summary)
+	require.Len(t, sizes.DataPoints, 1)
+	assert.Equal(t, []metricdata.QuantileValue{{Quantile: 0.5, Value: 100}}, sizes.DataPoints[0].QuantileValues)
+
+	retries := byName["retries"].Data.(metricdata.Sum[int64])
+	assert.True(t, retries.IsMonotonic)
+	assert.Equal(t, int64(2), retries.DataPoints[0].Value)
+	assert.Zero(t, m.Retries.Count(), "collection should reset interval counters")
+
+	m.Retries.Inc(1)
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		if m.Name == "retries" {
+			assert.Equal(t, int64(3), m.Data.(metricdata.Sum[int64]).DataPoints[0].Value, "interval counters should report their total")
+		}
+	}
+}
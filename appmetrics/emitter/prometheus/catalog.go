@@ -0,0 +1,153 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"github.com/palantir/go-baseapp/appmetrics"
+)
+
+// MetricMetadata describes a metric family reported by the Collector. The
+// fields match the metadata returned by the Prometheus metadata API.
+type MetricMetadata struct {
+	Name   string   `json:"name" yaml:"name"`
+	Type   string   `json:"type" yaml:"type"`
+	Help   string   `json:"help" yaml:"help"`
+	Unit   string   `json:"unit,omitempty" yaml:"unit,omitempty"`
+	Labels []string `json:"labels,omitempty" yaml:"labels,omitempty"`
+}
+
+// CatalogMetadata returns the metric families that the Collector reports for
+// the metrics in the catalog. Names and labels are sanitized in the same way
+// as collected metrics, and each metric expands to the same series as in
+// Collect: for example, a timer produces a "_seconds" summary and
+// "_min_seconds" and "_max_seconds" metrics, and a histogram with a unit
+// produces series like "_bytes", "_min_bytes", and "_max_bytes". Histograms
+// and timers with buckets produce Prometheus histograms instead of summaries.
+// Global labels set with WithLabels are not included.
+//
+// If a catalog entry has no help text, the metadata uses the go-metrics type,
+// like the Collector. Pass the options of the Collector to use the same
+// types, labels, and help text; only WithCreatedTimestamps, WithHelp,
+// WithNativeHistograms, and WithTagMapping change the metadata.
+func CatalogMetadata(c appmetrics.Catalog, opts ...CollectorOption) []MetricMetadata {
+	var col Collector
+	for _, opt := range opts {
+		opt(&col)
+	}
+
+	// With created timestamps, counters and meters are Prometheus counters
+	counterType := "untyped"
+	if col.created {
+		counterType = "counter"
+	}
+	counterSuffix := func(name string) string {
+		if col.created {
+			return counterName(name)
+		}
+		return name
+	}
+
+	var mds []MetricMetadata
+	for _, e := range c.Metrics {
+		name := sanitizeName(e.Name)
+		if help, ok := col.help[e.Name]; ok {
+			e.Help = help
+		}
+
+		var labels []string
+		for _, k := range e.AllTagKeys() {
+			if k, ok := col.mapping.Key(k); ok {
+				labels = append(labels, sanitizeLabel(k))
+			}
+		}
+
+		// nameUnit is added to the end of each series name, after the suffix
+		nameUnit := unitSuffix(name, e.Unit)
+		add := func(suffix, nameUnit, typ, help, unit string) {
+			series := seriesName(name, suffix, nameUnit)
+			if typ == "counter" {
+				series = counterSuffix(series)
+			}
+			mds = append(mds, MetricMetadata{
+				Name:   series,
+				Type:   typ,
+				Help:   help,
+				Unit:   unit,
+				Labels: labels,
+			})
+		}
+
+		switch e.Type {
+		case appmetrics.TypeCounter:
+			add("", nameUnit, counterType, helpOrDefault(e.Help, "metrics.Counter"), e.Unit)
+
+		case appmetrics.TypeIntervalCounter:
+			add("", nameUnit, counterType, helpOrDefault(e.Help, "appmetrics.IntervalCounter"), e.Unit)
+
+		case appmetrics.TypeCounterFloat64:
+			add("", nameUnit, "counter", helpOrDefault(e.Help, "appmetrics.CounterFloat64"), e.Unit)
+
+		case appmetrics.TypeGauge:
+			add("", nameUnit, "gauge", helpOrDefault(e.Help, "metrics.Gauge"), e.Unit)
+
+		case appmetrics.TypeGaugeFloat64:
+			add("", nameUnit, "gauge", helpOrDefault(e.Help, "metrics.GaugeFloat64"), e.Unit)
+
+		case appmetrics.TypeMaxGauge:
+			add("", nameUnit, "gauge", helpOrDefault(e.Help, "appmetrics.MaxGauge"), e.Unit)
+
+		case appmetrics.TypeMinGauge:
+			add("", nameUnit, "gauge", helpOrDefault(e.Help, "appmetrics.MinGauge"), e.Unit)
+
+		case appmetrics.TypeDurationGauge:
+			add("", unitSuffix(name, "seconds"), "gauge", helpOrDefault(e.Help, "appmetrics.DurationGauge"), "seconds")
+
+		case appmetrics.TypeHistogram:
+			help := helpOrDefault(e.Help, "metrics.Histogram")
+			typ := distributionType(e)
+			if col.nativeSchema != nil {
+				typ = "histogram"
+			}
+			add("", nameUnit, typ, help, e.Unit)
+			add("min", nameUnit, "untyped", help, e.Unit)
+			add("max", nameUnit, "untyped", help, e.Unit)
+
+		case appmetrics.TypeMeter:
+			add("count", "", counterType, helpOrDefault(e.Help, "metrics.Meter"), "")
+
+		case appmetrics.TypeTimer:
+			help := helpOrDefault(e.Help, "metrics.Timer")
+			add("seconds", "", distributionType(e), help, "seconds")
+			add("min_seconds", "", "untyped", help, "seconds")
+			add("max_seconds", "", "untyped", help, "seconds")
+		}
+	}
+	return mds
+}
+
+// distributionType returns the Prometheus type of a histogram or timer.
+func distributionType(e appmetrics.CatalogEntry) string {
+	if len(e.Buckets) > 0 {
+		return "histogram"
+	}
+	return "summary"
+}
+
+func helpOrDefault(help, def string) string {
+	if help == "" {
+		return def
+	}
+	return help
+}
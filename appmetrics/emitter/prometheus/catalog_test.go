@@ -0,0 +1,44 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalogMetadata(t *testing.T) {
+	c := appmetrics.Catalog{Metrics: []appmetrics.CatalogEntry{
+		{Name: "api.requests", Type: appmetrics.TypeCounter, Help: "API requests", TagKeys: []string{"status-code"}},
+		{Name: "api.latency", Type: appmetrics.TypeTimer},
+	}}
+
+	assert.Equal(t, []MetricMetadata{
+		{Name: "api_requests", Type: "untyped", Help: "API requests", Labels: []string{"status_code"}},
+		{Name: "api_latency_seconds", Type: "summary", Help: "metrics.Timer", Unit: "seconds"},
+		{Name: "api_latency_min_seconds", Type: "untyped", Help: "metrics.Timer", Unit: "seconds"},
+		{Name: "api_latency_max_seconds", Type: "untyped", Help: "metrics.Timer", Unit: "seconds"},
+	}, CatalogMetadata(c))
+
+	mds := CatalogMetadata(c, WithHelp(map[string]string{"api.latency": "API latency"}))
+	assert.Equal(t, "API requests", mds[0].Help)
+	assert.Equal(t, "API latency", mds[1].Help, "help text should be set by name")
+
+	mds = CatalogMetadata(c, WithCreatedTimestamps(true))
+	assert.Equal(t, "api_requests_total", mds[0].Name)
+	assert.Equal(t, "counter", mds[0].Type, "counters should use the type reported with created timestamps")
+}
@@ -0,0 +1,323 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CollisionLabel is the label added to series from go-metrics names that
+// collide with another name after sanitization. Its value is the original
+// go-metrics name without tags.
+const CollisionLabel = "go_metric"
+
+const (
+	selfMetricDuration   = "appmetrics_collector_duration_seconds"
+	selfMetricSeries     = "appmetrics_collector_series"
+	selfMetricDropped    = "appmetrics_collector_dropped_series_total"
+	selfMetricCollisions = "appmetrics_collector_name_collisions"
+)
+
+// Reasons for dropping a series
+const (
+	dropConflict = "conflict"
+	dropLabels   = "labels"
+	dropInvalid  = "invalid"
+)
+
+// seriesDesc describes a series before it is checked for conflicts.
+type seriesDesc struct {
+	name          string
+	help          string
+	labels        prometheus.Labels
+	invalidLabels bool
+}
+
+// family is the type and help text of the first series exported with a name.
+// Prometheus requires all series with the same name to match.
+type family struct {
+	typ  string
+	help string
+}
+
+// typeSuffix marks the names with suffixes used by summaries and histograms,
+// like "_sum" and "_count".
+const typeSuffix = "suffix"
+
+// typeSuffixes are the suffixes of the additional names used by each type.
+var typeSuffixes = map[string][]string{
+	"summary":   {"_sum", "_count"},
+	"histogram": {"_sum", "_count", "_bucket"},
+}
+
+// typeLabels are the labels reserved by each type.
+var typeLabels = map[string]string{
+	"summary":   "quantile",
+	"histogram": "le",
+}
+
+// collection tracks the series exported by one call to Collect.
+type collection struct {
+	c   *Collector
+	ch  chan<- prometheus.Metric
+	now time.Time
+
+	families   map[string]family
+	series     map[string]bool
+	collisions map[string]bool
+	exported   int
+}
+
+// descFromName is like Collector.descFromName, but adds CollisionLabel to
+// series from base names that collide with another name.
+func (col *collection) descFromName(name string, help string, unit string) func(string) seriesDesc {
+	desc := col.c.descFromName(name, help, unit)
+
+	base := baseName(name)
+	if !col.collisions[base] {
+		return desc
+	}
+	return func(suffix string) seriesDesc {
+		d := desc(suffix)
+		if _, exists := d.labels[CollisionLabel]; exists {
+			d.invalidLabels = true
+		}
+		d.labels[CollisionLabel] = base
+		return d
+	}
+}
+
+func (col *collection) value(d seriesDesc, vt prometheus.ValueType, v float64) {
+	col.send(d, valueTypeName(vt), func(desc *prometheus.Desc) (prometheus.Metric, error) {
+		return prometheus.NewConstMetric(desc, vt, v)
+	})
+}
+
+// counter exports a counter, with a created timestamp if the collector has
+// WithCreatedTimestamps. The name is the go-metrics name of the counter.
+func (col *collection) counter(d seriesDesc, name string, v float64) {
+	var created time.Time
+	if col.c.created {
+		d.name = counterName(d.name)
+		created = col.c.createdTime(name, v)
+	}
+	col.send(d, "counter", func(desc *prometheus.Desc) (prometheus.Metric, error) {
+		var m prometheus.Metric
+		var err error
+		if col.c.created {
+			m, err = prometheus.NewConstMetricWithCreatedTimestamp(desc, prometheus.CounterValue, v, created)
+		} else {
+			m, err = prometheus.NewConstMetric(desc, prometheus.CounterValue, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return col.exemplar(m, name, 1), nil
+	})
+}
+
+func (col *collection) summary(d seriesDesc, count uint64, sum float64, qs map[float64]float64) {
+	col.send(d, "summary", func(desc *prometheus.Desc) (prometheus.Metric, error) {
+		return prometheus.NewConstSummary(desc, count, sum, qs)
+	})
+}
+
+// histogram exports a histogram with the classic buckets in b and, if native
+// is not nil, with native buckets. The name is the go-metrics name of the
+// histogram and mean is the mean of its values, which is used as the value
+// of exemplars.
+func (col *collection) histogram(d seriesDesc, name string, b appmetrics.BucketCounts, native *nativeBuckets, mean float64) {
+	buckets := make(map[float64]uint64, len(b.Bounds))
+	for i, bound := range b.Bounds {
+		buckets[bound] = b.Counts[i]
+	}
+	col.send(d, "histogram", func(desc *prometheus.Desc) (prometheus.Metric, error) {
+		m, err := prometheus.NewConstHistogram(desc, b.Count, b.Sum, buckets)
+		if err != nil {
+			return nil, err
+		}
+		if native != nil {
+			m = nativeHistogram{Metric: m, buckets: *native}
+		}
+		return col.exemplar(m, name, mean), nil
+	})
+}
+
+// exemplar adds the exemplar returned by the collector's ExemplarFunc for the
+// go-metrics name to a counter or histogram. It returns m unchanged if there
+// is no exemplar or if the exemplar is invalid.
+func (col *collection) exemplar(m prometheus.Metric, name string, value float64) prometheus.Metric {
+	if col.c.exemplars == nil {
+		return m
+	}
+	labels := col.c.exemplars(name)
+	if len(labels) == 0 {
+		return m
+	}
+	em, err := prometheus.NewMetricWithExemplars(m, prometheus.Exemplar{Value: value, Labels: labels})
+	if err != nil {
+		return m
+	}
+	return em
+}
+
+// send checks that the series is consistent with the series that were already
+// exported, then creates and exports the metric. It counts the series as
+// dropped if it fails the checks or if the metric is invalid.
+func (col *collection) send(d seriesDesc, typ string, newMetric func(*prometheus.Desc) (prometheus.Metric, error)) {
+	help, reason := col.check(d, typ)
+	if reason != "" {
+		col.c.drop(reason)
+		return
+	}
+
+	m, err := newMetric(prometheus.NewDesc(d.name, help, nil, d.labels))
+	if err != nil {
+		col.c.drop(dropInvalid)
+		return
+	}
+	if col.c.timestamps {
+		m = prometheus.NewMetricWithTimestamp(col.now, m)
+	}
+	col.ch <- m
+	col.exported++
+}
+
+// check returns the help text to use for the series or the reason to drop
+// it. Series are dropped if they have inconsistent labels, if they have the
+// same name and labels as a previous series, or if they have a different type
+// than previous series with the same name.
+func (col *collection) check(d seriesDesc, typ string) (string, string) {
+	if d.invalidLabels {
+		return "", dropLabels
+	}
+	if label, ok := typeLabels[typ]; ok {
+		if _, ok := d.labels[label]; ok {
+			return "", dropLabels
+		}
+	}
+
+	f, exists := col.families[d.name]
+	if exists && f.typ != typ {
+		return "", dropConflict
+	}
+	if !exists {
+		// Summaries and histograms also use names with suffixes, which must
+		// not conflict with other series
+		for _, suffix := range typeSuffixes[typ] {
+			if _, ok := col.families[d.name+suffix]; ok {
+				return "", dropConflict
+			}
+		}
+	}
+
+	key := seriesKey(d.name, d.labels)
+	if col.series[key] {
+		return "", dropConflict
+	}
+	col.series[key] = true
+
+	if !exists {
+		f = family{typ: typ, help: d.help}
+		col.families[d.name] = f
+		for _, suffix := range typeSuffixes[typ] {
+			col.families[d.name+suffix] = family{typ: typeSuffix}
+		}
+	}
+
+	// Prometheus also requires the same help text, but different help text is
+	// not worth dropping the series
+	return f.help, ""
+}
+
+// selfMetrics exports the metrics enabled by WithSelfMetrics.
+func (col *collection) selfMetrics(duration time.Duration) {
+	exported := col.exported
+
+	desc := func(name, help string, labels prometheus.Labels) seriesDesc {
+		for k, v := range col.c.labels {
+			if _, exists := labels[k]; !exists {
+				labels[k] = v
+			}
+		}
+		return seriesDesc{name: name, help: help, labels: labels}
+	}
+
+	col.value(
+		desc(selfMetricDuration, "Duration of the collection of go-metrics", prometheus.Labels{}),
+		prometheus.GaugeValue,
+		duration.Seconds(),
+	)
+	col.value(
+		desc(selfMetricSeries, "Number of series exported by the collection of go-metrics", prometheus.Labels{}),
+		prometheus.GaugeValue,
+		float64(exported),
+	)
+	col.value(
+		desc(selfMetricCollisions, "Number of go-metrics names that collide with another name after sanitization", prometheus.Labels{}),
+		prometheus.GaugeValue,
+		float64(len(col.collisions)),
+	)
+
+	col.c.mu.Lock()
+	dropped := make(map[string]uint64, len(col.c.dropped))
+	for reason, n := range col.c.dropped {
+		dropped[reason] = n
+	}
+	col.c.mu.Unlock()
+
+	for _, reason := range []string{dropConflict, dropLabels, dropInvalid} {
+		col.value(
+			desc(selfMetricDropped, "Number of go-metrics series dropped because they are not valid in Prometheus", prometheus.Labels{"reason": reason}),
+			prometheus.CounterValue,
+			float64(dropped[reason]),
+		)
+	}
+}
+
+// seriesKey returns a key that identifies a series by its name and labels.
+func seriesKey(name string, labels prometheus.Labels) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return name + "\xff" + strings.Join(pairs, "\xff")
+}
+
+// counterName adds the "_total" suffix that OpenMetrics requires for the
+// names of counters, unless the name already has it.
+func counterName(name string) string {
+	if strings.HasSuffix(name, "_total") {
+		return name
+	}
+	return name + "_total"
+}
+
+func valueTypeName(vt prometheus.ValueType) string {
+	switch vt {
+	case prometheus.CounterValue:
+		return "counter"
+	case prometheus.GaugeValue:
+		return "gauge"
+	default:
+		return "untyped"
+	}
+}
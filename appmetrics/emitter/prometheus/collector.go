@@ -20,13 +20,16 @@
 //	metricName[label1,label2:value2,...]
 //
 // Global labels for all metrics can be set in the configuration. If a label is
-// specified without a value, the label key is used as the value.
+// specified without a value, the label key is used as the value. Tags can be
+// renamed or dropped with an appmetrics.TagMapping.
 //
 // The package translates between rcrowley/go-metrics types and Prometheus
 // types as neeeded:
 //
 //   - metrics.Counter metrics are reported as untyped metrics because they may
 //     increase or decrease
+//   - appmetrics.IntervalCounter metrics are reset after each collection and
+//     reported like metrics.Counter metrics with the total of their counts
 //   - metrics.Histogram metrics are reported as Prometheus summaries using a
 //     configurable (per emitter) set of quantiles. The max and min values are
 //     also reported. Use Prometheus functions to compute the mean.
@@ -36,12 +39,58 @@
 //     seconds using a configurable (per emitter) set of quantiles. The max and
 //     min values are also reported. Use Prometheus functions to compute the
 //     mean and rates.
+//   - Histograms and timers that implement appmetrics.Bucketed, like those
+//     with the "metric-buckets" tag, are reported as Prometheus histograms
+//     instead of summaries. The max and min values are also reported.
+//   - With WithNativeHistograms, metrics.Histogram metrics are reported as
+//     Prometheus native histograms computed from their samples, in addition
+//     to any classic buckets.
+//   - appmetrics.DurationGauge metrics are reported as Prometheus gauges in
+//     fractional seconds.
+//   - appmetrics.MaxGauge and appmetrics.MinGauge metrics are reported as
+//     Prometheus gauges and reset after each collection, so each scrape
+//     reports the extreme value since the previous scrape. Only one
+//     Prometheus server should scrape collectors with these metrics.
+//
+// With WithCreatedTimestamps, metrics.Counter metrics and the counts of
+// metrics.Meter metrics are reported as Prometheus counters with the
+// OpenMetrics created timestamp and the "_total" suffix, so that Prometheus
+// can detect resets.
+//
+// WithExemplars links counters and histograms to traces with exemplars, like
+// the trace ID of the most recent request recorded by a TraceRecorder.
+//
+// Metrics defined in appmetrics structs with the "metric-help" tag use the
+// tag value as their help text, and WithHelp sets the help text of metrics by
+// name. Otherwise, the help text is the go-metrics type. Metrics with the
+// "metric-unit" tag have the unit added to the end of their names, like
+// "upload_size_bytes", unless the name already ends with the unit. Timers and
+// duration gauges always use seconds.
+//
+// Different go-metrics names may produce the same Prometheus series after
+// sanitization, like "requests.total" and "requests_total". The Prometheus
+// registry rejects a collection that contains duplicate or conflicting
+// series, so the collector exports the first series in name order and drops
+// the others. Series with invalid labels, like two tags that sanitize to the
+// same label name, are also dropped. Enable WithSelfMetrics to count dropped
+// series.
+//
+// Before dropping series, the collector tries to disambiguate them: if
+// different go-metrics base names sanitize to the same Prometheus name, like
+// "a.b" and "a/b", the series for all names except the first in name order
+// have the CollisionLabel label with their original base name. Use
+// WithCollisionHandler to report these collisions.
 package prometheus
 
 import (
+	"context"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/palantir/go-baseapp/appmetrics"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rcrowley/go-metrics"
 )
@@ -52,8 +101,44 @@ type Collector struct {
 	registry metrics.Registry
 
 	labels             prometheus.Labels
+	mapping            appmetrics.TagMapping
 	histogramQuantiles []float64
 	timerQuantiles     []float64
+	nativeSchema       *int32
+	exemplars          ExemplarFunc
+	help               map[string]string
+
+	timestamps  bool
+	created     bool
+	idleAfter   time.Duration
+	selfMetrics bool
+	onCollision func(name string, names []string)
+	collectHook func(ctx context.Context) func()
+
+	mu         sync.Mutex
+	series     map[string]seriesState
+	dropped    map[string]uint64
+	collisions map[string]string
+
+	// createdAfter is the time of the previous collection, or the time the
+	// collector was created before the first collection
+	createdAfter time.Time
+	counters     map[string]counterState
+
+	// intervals are the totals of interval counters
+	intervals map[string]int64
+}
+
+// seriesState tracks when the value of a tagged series last changed.
+type seriesState struct {
+	value      float64
+	lastChange time.Time
+}
+
+// counterState tracks the value and created timestamp of a counter.
+type counterState struct {
+	value   float64
+	created time.Time
 }
 
 func NewCollector(r metrics.Registry, opts ...CollectorOption) *Collector {
@@ -61,6 +146,7 @@ func NewCollector(r metrics.Registry, opts ...CollectorOption) *Collector {
 		registry:           r,
 		histogramQuantiles: []float64{0.5, 0.95},
 		timerQuantiles:     []float64{0.5, 0.95},
+		createdAfter:       time.Now(),
 	}
 
 	for _, opt := range opts {
@@ -91,6 +177,134 @@ func WithHistogramQuantiles(qs []float64) CollectorOption {
 	}
 }
 
+// WithNativeHistograms reports metrics.Histogram metrics as Prometheus
+// native histograms, which have sparse exponential buckets, so that
+// Prometheus can compute accurate quantiles without pre-chosen bucket
+// boundaries. The factor is the maximum ratio between the upper bounds of
+// adjacent buckets, like the NativeHistogramBucketFactor option of
+// client_golang histograms; 1.1 is a good default. A factor of 1 or less
+// disables native histograms, which is the default.
+//
+// The buckets are computed from the values in the sample of each histogram.
+// If the sample does not contain all values, as with the default exponentially
+// decaying sample, the bucket counts are scaled to the count of the
+// histogram, so the distribution is approximate. Histograms that implement
+// appmetrics.Bucketed also keep their classic buckets. Timers are not
+// affected because go-metrics timers do not expose their samples.
+//
+// Native histograms are only exported in the protobuf format, so Prometheus
+// must have native histograms enabled. Other formats report the count and
+// sum of histograms without classic buckets.
+func WithNativeHistograms(factor float64) CollectorOption {
+	return func(c *Collector) {
+		if factor <= 1 {
+			c.nativeSchema = nil
+			return
+		}
+		schema := nativeSchema(factor)
+		c.nativeSchema = &schema
+	}
+}
+
+// WithExemplars attaches the exemplars returned by fn to counters and to
+// histograms with classic or native buckets. Summaries and untyped metrics
+// cannot have exemplars, so use it with WithCreatedTimestamps to add
+// exemplars to metrics.Counter metrics. Counter exemplars have a value of 1
+// and histogram exemplars have the mean of the histogram's sample as their
+// value, since go-metrics does not record which value belongs to the traced
+// request. Exemplars with invalid labels are ignored.
+//
+// Exemplars are only exported in the OpenMetrics and protobuf formats. Set
+// the EnableOpenMetrics option of promhttp handlers to export them in text
+// responses.
+func WithExemplars(fn ExemplarFunc) CollectorOption {
+	return func(c *Collector) {
+		c.exemplars = fn
+	}
+}
+
+// WithTimestamps attaches the collection time as an explicit timestamp to all
+// samples. By default, samples do not have timestamps and Prometheus uses the
+// scrape time.
+//
+// Prometheus does not apply staleness handling to samples with explicit
+// timestamps: when a series disappears from the collection, for example
+// because a metric was unregistered or expired with WithIdleExpiration,
+// queries keep returning its last value for up to 5 minutes (the lookback
+// delta) instead of ending it at the next scrape. WithIdleExpiration does not
+// help for gauges, which never expire. Only enable timestamps if the samples
+// must carry the time they were collected, like when scrapes are delayed.
+func WithTimestamps(timestamps bool) CollectorOption {
+	return func(c *Collector) {
+		c.timestamps = timestamps
+	}
+}
+
+// WithCreatedTimestamps reports metrics.Counter metrics and the counts of
+// metrics.Meter metrics as Prometheus counters instead of untyped metrics,
+// and adds a created timestamp to all counters. Counter names get the
+// "_total" suffix, which OpenMetrics requires, unless they already end with
+// it; the unit, if any, comes before the suffix. The timestamp is exported as
+// the "_created" series in the OpenMetrics format and as part of the sample
+// in the protobuf format, which lets Prometheus handle process restarts and
+// counter resets without artifacts in rate().
+//
+// go-metrics does not record when metrics are created, so the collector uses
+// the time of the collection before it first saw the metric, or the time the
+// collector was created for metrics in the first collection. If the value of
+// a counter decreases, the collector treats it as a reset and uses the time
+// of the previous collection as the new created timestamp.
+func WithCreatedTimestamps(enabled bool) CollectorOption {
+	return func(c *Collector) {
+		c.created = enabled
+	}
+}
+
+// WithIdleExpiration stops exporting tagged series (metrics with labels in
+// their names) that have not been updated for at least d. This allows
+// Prometheus to mark the series as stale instead of scraping the last value
+// forever. The series is exported again as soon as it is updated.
+//
+// Updates are detected using the count for counters, histograms, meters, and
+// timers. Gauges report current state, so a steady value does not mean the
+// gauge is unused; gauges never expire.
+func WithIdleExpiration(d time.Duration) CollectorOption {
+	return func(c *Collector) {
+		c.idleAfter = d
+	}
+}
+
+// WithSelfMetrics exports metrics about the collector itself with each
+// collection:
+//
+//   - appmetrics_collector_duration_seconds: the duration of the collection
+//   - appmetrics_collector_series: the number of series exported
+//   - appmetrics_collector_name_collisions: the number of go-metrics names
+//     with the CollisionLabel label because they collide with another name
+//   - appmetrics_collector_dropped_series_total: the number of series dropped
+//     since the collector was created, with a "reason" label that is one of
+//     "conflict", for series that duplicate or conflict with another series
+//     after sanitization, "labels", for series with inconsistent labels, or
+//     "invalid", for series that Prometheus rejects for other reasons
+//
+// These metrics make slow collections and silently dropped series visible.
+func WithSelfMetrics(enabled bool) CollectorOption {
+	return func(c *Collector) {
+		c.selfMetrics = enabled
+	}
+}
+
+// WithCollisionHandler sets a function that is called when different
+// go-metrics names sanitize to the same Prometheus name. The function
+// receives the Prometheus name and the colliding go-metrics base names in
+// name order. It is called during collection, once for each distinct
+// collision, so it may log a warning without repeating it on every scrape.
+func WithCollisionHandler(fn func(name string, names []string)) CollectorOption {
+	return func(c *Collector) {
+		c.onCollision = fn
+	}
+}
+
 // WithTimerQuantiles sets the quantiles reported in summaries of timer
 // metrics. By default, use 0.5 and 0.95, the median and the 95th percentile.
 func WithTimerQuantiles(qs []float64) CollectorOption {
@@ -100,6 +314,46 @@ func WithTimerQuantiles(qs []float64) CollectorOption {
 	}
 }
 
+// WithTagMapping sets the mapping applied to the tags of each metric before
+// they become labels. Metrics that report the same series after the mapping
+// are handled like other duplicate series: the first metric in name order is
+// exported.
+func WithTagMapping(m appmetrics.TagMapping) CollectorOption {
+	return func(c *Collector) {
+		c.mapping = m
+	}
+}
+
+// WithHelp sets the help text of metrics by their go-metrics name, without
+// tags, like "server.requests". Help text from the map replaces the text from
+// the "metric-help" tag of appmetrics structs, so it can describe metrics
+// defined by other packages, like the server metrics of baseapp.
+//
+// Names are matched as they appear in the registry of the collector. If
+// metrics are registered in a prefixed child registry, like the registry of a
+// server created with baseapp.WithMetricsPrefix("app."), and the collector
+// reads the parent registry, the names in the map must include the prefix,
+// like "app.server.requests".
+func WithHelp(help map[string]string) CollectorOption {
+	return func(c *Collector) {
+		c.help = make(map[string]string, len(help))
+		for name, h := range help {
+			c.help[name] = h
+		}
+	}
+}
+
+// WithCollectHook sets a function that is called at the start of each
+// collection and returns a function that is called at the end. Use it to
+// trace collections, so that slow scrapes appear in the same tracing backend
+// as requests. Prometheus does not provide a context for collections, so the
+// hook receives a background context.
+func WithCollectHook(fn func(ctx context.Context) func()) CollectorOption {
+	return func(c *Collector) {
+		c.collectHook = fn
+	}
+}
+
 func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
 	// Send no descriptors to register as an "unchecked" collector: the set of
 	// metrics in a go-metrics registry is dynamic, so there's no way to report
@@ -107,53 +361,350 @@ func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	if c.collectHook != nil {
+		if finish := c.collectHook(context.Background()); finish != nil {
+			defer finish()
+		}
+	}
+
+	now := time.Now()
+
+	col := &collection{
+		c:        c,
+		ch:       ch,
+		now:      now,
+		families: make(map[string]family),
+		series:   make(map[string]bool),
+	}
+
+	var seen map[string]bool
+	if c.idleAfter > 0 {
+		seen = make(map[string]bool)
+		defer c.pruneSeries(seen)
+	}
+
+	// Visit metrics in name order so that the same series wins each time
+	// there is a conflict
+	var names []string
+	entries := make(map[string]any)
 	c.registry.Each(func(name string, metric any) {
+		names = append(names, name)
+		entries[name] = metric
+	})
+	sort.Strings(names)
+	col.collisions = c.findCollisions(names)
+
+	var hasIntervals bool
+
+	for _, name := range names {
+		metric := entries[name]
+		if c.idleAfter > 0 && strings.HasSuffix(name, "]") {
+			seen[name] = true
+			if c.isIdle(name, metric, now) {
+				continue
+			}
+		}
+
+		md := c.metadata(name)
+
 		switch m := metric.(type) {
+		case appmetrics.IntervalCounter:
+			hasIntervals = true
+			total := c.intervalTotal(name, m.Reset())
+			desc := col.descFromName(name, helpOrDefault(md.Help, "appmetrics.IntervalCounter"), md.Unit)
+			if c.created {
+				col.counter(desc(""), name, float64(total))
+			} else {
+				col.value(desc(""), prometheus.UntypedValue, float64(total))
+			}
+
 		case metrics.Counter:
-			desc := c.descFromName(name, "metrics.Counter")
-			ch <- prometheus.MustNewConstMetric(desc(""), prometheus.UntypedValue, float64(m.Count()))
+			desc := col.descFromName(name, helpOrDefault(md.Help, "metrics.Counter"), md.Unit)
+			if c.created {
+				col.counter(desc(""), name, float64(m.Count()))
+			} else {
+				col.value(desc(""), prometheus.UntypedValue, float64(m.Count()))
+			}
+
+		case appmetrics.CounterFloat64:
+			// Unlike metrics.Counter, these counters never decrease
+			desc := col.descFromName(name, helpOrDefault(md.Help, "appmetrics.CounterFloat64"), md.Unit)
+			col.counter(desc(""), name, m.Count())
+
+		case appmetrics.DurationGauge:
+			desc := col.descFromName(name, helpOrDefault(md.Help, "appmetrics.DurationGauge"), "seconds")
+			col.value(desc(""), prometheus.GaugeValue, m.Duration().Seconds())
+
+		case appmetrics.MaxGauge:
+			desc := col.descFromName(name, helpOrDefault(md.Help, "appmetrics.MaxGauge"), md.Unit)
+			col.value(desc(""), prometheus.GaugeValue, float64(m.Reset()))
+
+		case appmetrics.MinGauge:
+			desc := col.descFromName(name, helpOrDefault(md.Help, "appmetrics.MinGauge"), md.Unit)
+			col.value(desc(""), prometheus.GaugeValue, float64(m.Reset()))
 
 		case metrics.Gauge:
-			desc := c.descFromName(name, "metrics.Gauge")
-			ch <- prometheus.MustNewConstMetric(desc(""), prometheus.GaugeValue, float64(m.Value()))
+			desc := col.descFromName(name, helpOrDefault(md.Help, "metrics.Gauge"), md.Unit)
+			col.value(desc(""), prometheus.GaugeValue, float64(m.Value()))
 
 		case metrics.GaugeFloat64:
-			desc := c.descFromName(name, "metrics.GaugeFloat64")
-			ch <- prometheus.MustNewConstMetric(desc(""), prometheus.GaugeValue, m.Value())
+			desc := col.descFromName(name, helpOrDefault(md.Help, "metrics.GaugeFloat64"), md.Unit)
+			col.value(desc(""), prometheus.GaugeValue, m.Value())
 
 		case metrics.Histogram:
-			desc := c.descFromName(name, "metrics.Histogram")
+			desc := col.descFromName(name, helpOrDefault(md.Help, "metrics.Histogram"), md.Unit)
 
 			ms := m.Snapshot()
-			qs := getQuantiles(ms, c.histogramQuantiles)
-			ch <- prometheus.MustNewConstSummary(desc(""), uint64(ms.Count()), float64(ms.Sum()), qs)
-			ch <- prometheus.MustNewConstMetric(desc("min"), prometheus.UntypedValue, float64(ms.Min()))
-			ch <- prometheus.MustNewConstMetric(desc("max"), prometheus.UntypedValue, float64(ms.Max()))
+			b, bucketed := m.(appmetrics.Bucketed)
+			switch {
+			case c.nativeSchema != nil:
+				var counts appmetrics.BucketCounts
+				if bucketed {
+					counts = b.Buckets()
+				} else {
+					counts = appmetrics.BucketCounts{Count: uint64(ms.Count()), Sum: float64(ms.Sum())}
+				}
+				native := newNativeBuckets(ms.Sample().Values(), counts.Count, *c.nativeSchema)
+				col.histogram(desc(""), name, counts, &native, ms.Mean())
+			case bucketed:
+				col.histogram(desc(""), name, b.Buckets(), nil, ms.Mean())
+			default:
+				qs := getQuantiles(ms, c.histogramQuantiles)
+				col.summary(desc(""), uint64(ms.Count()), float64(ms.Sum()), qs)
+			}
+			col.value(desc("min"), prometheus.UntypedValue, float64(ms.Min()))
+			col.value(desc("max"), prometheus.UntypedValue, float64(ms.Max()))
 
 		case metrics.Meter:
-			desc := c.descFromName(name, "metrics.Meter")
+			// The meter reports a count of events, so the unit does not apply
+			desc := col.descFromName(name, helpOrDefault(md.Help, "metrics.Meter"), "")
 
 			ms := m.Snapshot()
-			ch <- prometheus.MustNewConstMetric(desc("count"), prometheus.UntypedValue, float64(ms.Count()))
+			if c.created {
+				col.counter(desc("count"), name, float64(ms.Count()))
+			} else {
+				col.value(desc("count"), prometheus.UntypedValue, float64(ms.Count()))
+			}
 
 		case metrics.Timer:
-			desc := c.descFromName(name, "metrics.Timer")
+			// Timers always report seconds, which are included in the suffixes
+			desc := col.descFromName(name, helpOrDefault(md.Help, "metrics.Timer"), "")
 
 			ms := m.Snapshot()
-			qs := getQuantiles(ms, c.timerQuantiles)
-			for q, v := range qs {
-				qs[q] = toSeconds(v)
+			if b, ok := m.(appmetrics.Bucketed); ok {
+				col.histogram(desc("seconds"), name, b.Buckets(), nil, ms.Mean()/float64(time.Second))
+			} else {
+				qs := getQuantiles(ms, c.timerQuantiles)
+				for q, v := range qs {
+					qs[q] = toSeconds(v)
+				}
+				col.summary(desc("seconds"), uint64(ms.Count()), toSeconds(ms.Sum()), qs)
 			}
+			col.value(desc("min_seconds"), prometheus.UntypedValue, toSeconds(ms.Min()))
+			col.value(desc("max_seconds"), prometheus.UntypedValue, toSeconds(ms.Max()))
+		}
+	}
+
+	if c.selfMetrics {
+		col.selfMetrics(time.Since(now))
+	}
+	if c.created || hasIntervals {
+		c.pruneCounters(names, now)
+	}
+}
+
+// createdTime returns the created timestamp of the counter with the name and
+// value, updating it if the counter is new or has decreased.
+func (c *Collector) createdTime(name string, value float64) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counters == nil {
+		c.counters = make(map[string]counterState)
+	}
 
-			ch <- prometheus.MustNewConstSummary(desc("seconds"), uint64(ms.Count()), toSeconds(ms.Sum()), qs)
-			ch <- prometheus.MustNewConstMetric(desc("min_seconds"), prometheus.UntypedValue, toSeconds(ms.Min()))
-			ch <- prometheus.MustNewConstMetric(desc("max_seconds"), prometheus.UntypedValue, toSeconds(ms.Max()))
+	state, ok := c.counters[name]
+	if !ok || value < state.value {
+		state.created = c.createdAfter
+	}
+	state.value = value
+	c.counters[name] = state
+	return state.created
+}
+
+// intervalTotal adds the count of an interval counter to its total and
+// returns the new total.
+func (c *Collector) intervalTotal(name string, count int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.intervals == nil {
+		c.intervals = make(map[string]int64)
+	}
+	c.intervals[name] += count
+	return c.intervals[name]
+}
+
+// pruneCounters removes state for counters that no longer exist in the
+// registry and records the time of the collection.
+func (c *Collector) pruneCounters(names []string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	for name := range c.counters {
+		if !seen[name] {
+			delete(c.counters, name)
 		}
-	})
+	}
+	for name := range c.intervals {
+		if !seen[name] {
+			delete(c.intervals, name)
+		}
+	}
+	if now.After(c.createdAfter) {
+		c.createdAfter = now
+	}
+}
+
+// isIdle returns true if the metric has not been updated within the idle
+// period. Gauges are never idle.
+func (c *Collector) isIdle(name string, metric any, now time.Time) bool {
+	var value float64
+	var interval bool
+	switch m := metric.(type) {
+	case appmetrics.IntervalCounter:
+		// The series changes if the counter has a count since the last reset
+		value, interval = float64(m.Count()), true
+	case metrics.Counter:
+		value = float64(m.Count())
+	case appmetrics.CounterFloat64:
+		value = m.Count()
+	case metrics.Histogram:
+		value = float64(m.Count())
+	case metrics.Meter:
+		value = float64(m.Count())
+	case metrics.Timer:
+		value = float64(m.Count())
+	default:
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.series == nil {
+		c.series = make(map[string]seriesState)
+	}
+	if interval {
+		value += float64(c.intervals[name])
+	}
+
+	state, ok := c.series[name]
+	if !ok || state.value != value {
+		c.series[name] = seriesState{value: value, lastChange: now}
+		return false
+	}
+	return now.Sub(state.lastChange) >= c.idleAfter
+}
+
+// pruneSeries removes state for series that no longer exist in the registry.
+func (c *Collector) pruneSeries(seen map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for name := range c.series {
+		if !seen[name] {
+			delete(c.series, name)
+		}
+	}
 }
 
-func (c *Collector) descFromName(name string, help string) func(string) *prometheus.Desc {
-	name, labels := labelsFromName(name)
+// drop counts a series that was dropped for the given reason.
+func (c *Collector) drop(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.dropped == nil {
+		c.dropped = make(map[string]uint64)
+	}
+	c.dropped[reason]++
+}
+
+// findCollisions returns the go-metrics base names that sanitize to the same
+// Prometheus name as an earlier base name. The names must be sorted.
+func (c *Collector) findCollisions(names []string) map[string]bool {
+	first := make(map[string]string, len(names))
+	var groups map[string][]string
+	for _, name := range names {
+		base := baseName(name)
+		promName := sanitizeName(base)
+
+		f, ok := first[promName]
+		if !ok {
+			first[promName] = base
+			continue
+		}
+		if f == base {
+			continue
+		}
+
+		if groups == nil {
+			groups = make(map[string][]string)
+		}
+		if len(groups[promName]) == 0 {
+			groups[promName] = []string{f}
+		}
+		if !slices.Contains(groups[promName], base) {
+			groups[promName] = append(groups[promName], base)
+		}
+	}
+
+	var collisions map[string]bool
+	for promName, bases := range groups {
+		if collisions == nil {
+			collisions = make(map[string]bool)
+		}
+		sort.Strings(bases)
+		for _, base := range bases[1:] {
+			collisions[base] = true
+		}
+		c.reportCollision(promName, bases)
+	}
+	return collisions
+}
+
+// reportCollision calls the collision handler if the collision is new.
+func (c *Collector) reportCollision(name string, names []string) {
+	if c.onCollision == nil {
+		return
+	}
+
+	key := strings.Join(names, "\xff")
+
+	c.mu.Lock()
+	if c.collisions == nil {
+		c.collisions = make(map[string]string)
+	}
+	isNew := c.collisions[name] != key
+	c.collisions[name] = key
+	c.mu.Unlock()
+
+	if isNew {
+		c.onCollision(name, names)
+	}
+}
+
+// descFromName returns a function that creates descriptors for the metric
+// with the given name and suffixes. If unit is not empty, it is added to the
+// end of each name, after the suffix, unless the base name already ends with
+// the unit.
+func (c *Collector) descFromName(name string, help string, unit string) func(string) seriesDesc {
+	name, labels, ok := labelsFromName(c.mapping.MapName(name))
+	unit = unitSuffix(name, unit)
 
 	// Add global labels, preferring metric labels if there's a duplicate
 	for k, v := range c.labels {
@@ -162,36 +713,80 @@ func (c *Collector) descFromName(name string, help string) func(string) *prometh
 		}
 	}
 
-	return func(suffix string) *prometheus.Desc {
-		fqName := name
-		if suffix != "" {
-			fqName += "_" + suffix
+	return func(suffix string) seriesDesc {
+		return seriesDesc{name: seriesName(name, suffix, unit), help: help, labels: labels, invalidLabels: !ok}
+	}
+}
+
+// unitSuffix returns the sanitized unit to add to the sanitized metric name,
+// or an empty string if the name already ends with the unit.
+func unitSuffix(name, unit string) string {
+	if unit == "" {
+		return ""
+	}
+	unit = sanitizeLabel(unit)
+	if strings.HasSuffix(name, "_"+unit) {
+		return ""
+	}
+	return unit
+}
+
+// seriesName joins a sanitized metric name, a suffix, and a unit.
+func seriesName(name, suffix, unit string) string {
+	for _, s := range []string{suffix, unit} {
+		if s != "" {
+			name += "_" + s
 		}
-		return prometheus.NewDesc(fqName, help, nil, labels)
 	}
+	return name
+}
+
+// metadata returns the metadata of the metric with the name, using the help
+// text set with WithHelp if there is one.
+func (c *Collector) metadata(name string) appmetrics.MetricMetadata {
+	md, _ := appmetrics.LookupMetadata(name)
+	if help, ok := c.help[baseName(name)]; ok {
+		md.Help = help
+	}
+	return md
+}
+
+// baseName returns the go-metrics name without tags.
+func baseName(name string) string {
+	if start := strings.IndexRune(name, '['); start >= 0 && name[len(name)-1] == ']' {
+		return name[:start]
+	}
+	return name
 }
 
 // labelsFromName extracts the labels from a metric name and returns the
-// sanitized base name and the sanitized labels.
-func labelsFromName(name string) (string, prometheus.Labels) {
+// sanitized base name and the sanitized labels. It returns false if the
+// labels are not consistent: a key is not a valid label name after
+// sanitization or two keys produce the same label name.
+func labelsFromName(name string) (string, prometheus.Labels, bool) {
 	labels := make(prometheus.Labels)
 
 	start := strings.IndexRune(name, '[')
 	if start < 0 || name[len(name)-1] != ']' {
-		return sanitizeName(name), labels
+		return sanitizeName(name), labels, true
 	}
 
+	valid := true
 	labelPairs := strings.Split(name[start+1:len(name)-1], ",")
 	for _, pair := range labelPairs {
 		key, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
-		if ok {
-			labels[sanitizeLabel(key)] = value
-		} else {
-			labels[sanitizeLabel(key)] = key
+		if !ok {
+			value = key
+		}
+
+		label := sanitizeLabel(key)
+		if _, exists := labels[label]; exists || label == "" || ('0' <= label[0] && label[0] <= '9') {
+			valid = false
 		}
+		labels[label] = value
 	}
 
-	return sanitizeName(name[:start]), labels
+	return sanitizeName(name[:start]), labels, valid
 }
 
 func sanitizeName(name string) string {
@@ -15,10 +15,13 @@
 package prometheus
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/rcrowley/go-metrics"
 )
@@ -35,10 +38,21 @@ func TestCollector(t *testing.T) {
 		metrics.NewRegisteredMeter("meter", r)
 		metrics.NewRegisteredTimer("timer", r)
 
+		d := appmetrics.NewDurationGauge()
+		d.UpdateDuration(1500 * time.Millisecond)
+		_ = r.Register("duration", d)
+
+		peak := appmetrics.NewMaxGauge()
+		peak.Update(7)
+		_ = r.Register("peak", peak)
+
 		expected := `
 # HELP counter metrics.Counter
 # TYPE counter untyped
 counter 0
+# HELP duration_seconds appmetrics.DurationGauge
+# TYPE duration_seconds gauge
+duration_seconds 1.5
 # HELP gauge metrics.Gauge
 # TYPE gauge gauge
 gauge 0
@@ -60,6 +74,9 @@ histogram_min 0
 # HELP meter_count metrics.Meter
 # TYPE meter_count untyped
 meter_count 0
+# HELP peak appmetrics.MaxGauge
+# TYPE peak gauge
+peak 7
 # HELP timer_max_seconds metrics.Timer
 # TYPE timer_max_seconds untyped
 timer_max_seconds 0
@@ -77,6 +94,9 @@ timer_seconds_count 0
 		if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
 			t.Error(err)
 		}
+		if v := peak.Value(); v != 0 {
+			t.Errorf("collection should reset max gauges, but value is %d", v)
+		}
 	})
 
 	t.Run("labels", func(t *testing.T) {
@@ -125,6 +145,134 @@ invalid_metric_names_are_fun_ 0
 		}
 	})
 
+	t.Run("tagMapping", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		c := NewCollector(r, WithTagMapping(appmetrics.TagMapping{
+			Rename: map[string]string{"status": "code"},
+			Drop:   []string{"instance"},
+		}))
+
+		metrics.NewRegisteredCounter("requests[instance:a,status:200]", r).Inc(1)
+		metrics.NewRegisteredCounter("requests[instance:b,status:200]", r).Inc(2)
+		metrics.NewRegisteredCounter("requests[instance:a,status:500]", r).Inc(3)
+
+		expected := `
+# HELP requests metrics.Counter
+# TYPE requests untyped
+requests{code="200"} 1
+requests{code="500"} 3
+`
+
+		if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("metadata", func(t *testing.T) {
+		type M struct {
+			Uploads appmetrics.Tagged[metrics.Counter] `metric:"collector.uploads" metric-tag-keys:"type" metric-help:"Uploaded files"`
+			Size    metrics.Gauge                      `metric:"collector.upload.size" metric-unit:"bytes"`
+			Queue   metrics.Gauge                      `metric:"collector.queue_bytes" metric-unit:"bytes"`
+			Latency metrics.Timer                      `metric:"collector.latency" metric-help:"Upload latency" metric-unit:"ms"`
+		}
+
+		r := metrics.NewRegistry()
+		c := NewCollector(r)
+
+		m := appmetrics.New[M]()
+		appmetrics.Register(r, m)
+		m.Uploads.Tag("type:image").Inc(2)
+		m.Size.Update(512)
+
+		expected := `
+# HELP collector_latency_max_seconds Upload latency
+# TYPE collector_latency_max_seconds untyped
+collector_latency_max_seconds 0
+# HELP collector_latency_min_seconds Upload latency
+# TYPE collector_latency_min_seconds untyped
+collector_latency_min_seconds 0
+# HELP collector_latency_seconds Upload latency
+# TYPE collector_latency_seconds summary
+collector_latency_seconds{quantile="0.5"} 0
+collector_latency_seconds{quantile="0.95"} 0
+collector_latency_seconds_sum 0
+collector_latency_seconds_count 0
+# HELP collector_queue_bytes metrics.Gauge
+# TYPE collector_queue_bytes gauge
+collector_queue_bytes 0
+# HELP collector_upload_size_bytes metrics.Gauge
+# TYPE collector_upload_size_bytes gauge
+collector_upload_size_bytes 512
+# HELP collector_uploads Uploaded files
+# TYPE collector_uploads untyped
+collector_uploads 0
+collector_uploads{type="image"} 2
+`
+
+		if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		type M struct {
+			Uploads metrics.Counter `metric:"collector.help.uploads" metric-help:"Uploaded files"`
+		}
+
+		r := metrics.NewRegistry()
+		c := NewCollector(r, WithHelp(map[string]string{
+			"collector.help.uploads":  "Files uploaded by clients",
+			"collector.help.requests": "Requests handled by the server",
+		}))
+
+		m := appmetrics.New[M]()
+		appmetrics.Register(r, m)
+		m.Uploads.Inc(1)
+		metrics.NewRegisteredCounter("collector.help.requests[code:200]", r).Inc(3)
+		metrics.NewRegisteredGauge("collector.help.queue", r).Update(2)
+
+		expected := `
+# HELP collector_help_queue metrics.Gauge
+# TYPE collector_help_queue gauge
+collector_help_queue 2
+# HELP collector_help_requests Requests handled by the server
+# TYPE collector_help_requests untyped
+collector_help_requests{code="200"} 3
+# HELP collector_help_uploads Files uploaded by clients
+# TYPE collector_help_uploads untyped
+collector_help_uploads 1
+`
+
+		if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("helpPrefix", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		c := NewCollector(r, WithHelp(map[string]string{
+			"app.collector.help.requests": "Requests handled by the server",
+			"collector.help.errors":       "Unprefixed names do not match",
+		}))
+
+		child := metrics.NewPrefixedChildRegistry(r, "app.")
+		metrics.NewRegisteredCounter("collector.help.requests", child).Inc(3)
+		metrics.NewRegisteredCounter("collector.help.errors", child).Inc(1)
+
+		expected := `
+# HELP app_collector_help_errors metrics.Counter
+# TYPE app_collector_help_errors untyped
+app_collector_help_errors 1
+# HELP app_collector_help_requests Requests handled by the server
+# TYPE app_collector_help_requests untyped
+app_collector_help_requests 3
+`
+
+		if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+			t.Error(err)
+		}
+	})
+
 	t.Run("histogramQuantiles", func(t *testing.T) {
 		r := metrics.NewRegistry()
 		c := NewCollector(r, WithHistogramQuantiles([]float64{0.25, 0.5, 0.75}))
@@ -190,4 +338,216 @@ timer_seconds_count 50
 			t.Error(err)
 		}
 	})
+
+	t.Run("buckets", func(t *testing.T) {
+		type M struct {
+			Size    metrics.Histogram `metric:"size" metric-buckets:"10,100"`
+			Latency metrics.Timer     `metric:"latency" metric-buckets:"0.01,0.1"`
+		}
+
+		r := metrics.NewRegistry()
+		c := NewCollector(r)
+
+		m := appmetrics.New[M]()
+		appmetrics.Register(r, m)
+		for _, v := range []int64{5, 50, 500} {
+			m.Size.Update(v)
+		}
+		m.Latency.Update(5 * time.Millisecond)
+		m.Latency.Update(50 * time.Millisecond)
+
+		expected := `
+# HELP latency_max_seconds metrics.Timer
+# TYPE latency_max_seconds untyped
+latency_max_seconds 0.05
+# HELP latency_min_seconds metrics.Timer
+# TYPE latency_min_seconds untyped
+latency_min_seconds 0.005
+# HELP latency_seconds metrics.Timer
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.01"} 1
+latency_seconds_bucket{le="0.1"} 2
+latency_seconds_bucket{le="+Inf"} 2
+latency_seconds_sum 0.055
+latency_seconds_count 2
+# HELP size metrics.Histogram
+# TYPE size histogram
+size_bucket{le="10"} 1
+size_bucket{le="100"} 2
+size_bucket{le="+Inf"} 3
+size_sum 555
+size_count 3
+# HELP size_max metrics.Histogram
+# TYPE size_max untyped
+size_max 500
+# HELP size_min metrics.Histogram
+# TYPE size_min untyped
+size_min 5
+`
+
+		if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("timestamps", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		c := NewCollector(r, WithTimestamps(true))
+
+		metrics.NewRegisteredCounter("counter", r).Inc(1)
+
+		reg := prometheus.NewPedanticRegistry()
+		reg.MustRegister(c)
+
+		mfs, err := reg.Gather()
+		if err != nil {
+			t.Fatalf("unexpected error gathering metrics: %v", err)
+		}
+		if len(mfs) != 1 || len(mfs[0].GetMetric()) != 1 {
+			t.Fatalf("expected exactly one metric, got %v", mfs)
+		}
+		if mfs[0].GetMetric()[0].TimestampMs == nil {
+			t.Error("expected metric to have a timestamp")
+		}
+	})
+
+	t.Run("idleExpiration", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		c := NewCollector(r, WithIdleExpiration(time.Hour))
+
+		active := metrics.NewRegisteredCounter("counter[subsystem:a]", r)
+		idle := metrics.NewRegisteredCounter("counter[subsystem:b]", r)
+		untagged := metrics.NewRegisteredCounter("untagged", r)
+		gauge := metrics.NewRegisteredGauge("gauge[subsystem:a]", r)
+
+		active.Inc(1)
+		idle.Inc(2)
+		untagged.Inc(3)
+		gauge.Update(4)
+
+		// the first collection records initial values for all series
+		if n := testutil.CollectAndCount(c); n != 4 {
+			t.Fatalf("expected 4 metrics on first collection, got %d", n)
+		}
+
+		// make all series appear idle by moving the last change into the past
+		c.mu.Lock()
+		for name, state := range c.series {
+			state.lastChange = state.lastChange.Add(-2 * time.Hour)
+			c.series[name] = state
+		}
+		c.mu.Unlock()
+
+		active.Inc(1)
+
+		expected := `
+# HELP counter metrics.Counter
+# TYPE counter untyped
+counter{subsystem="a"} 2
+# HELP gauge metrics.Gauge
+# TYPE gauge gauge
+gauge{subsystem="a"} 4
+# HELP untagged metrics.Counter
+# TYPE untagged untyped
+untagged 3
+`
+
+		if err := testutil.CollectAndCompare(c, strings.NewReader(expected)); err != nil {
+			t.Error(err)
+		}
+
+		idle.Inc(1)
+		if n := testutil.CollectAndCount(c, "counter"); n != 2 {
+			t.Errorf("expected idle series to be exported after it changed, got %d series", n)
+		}
+
+		r.Unregister("counter[subsystem:b]")
+		_ = testutil.CollectAndCount(c)
+
+		c.mu.Lock()
+		_, ok := c.series["counter[subsystem:b]"]
+		c.mu.Unlock()
+		if ok {
+			t.Error("expected state for unregistered series to be removed")
+		}
+	})
+
+	t.Run("conflicts", func(t *testing.T) {
+		r := metrics.NewRegistry()
+		c := NewCollector(r, WithSelfMetrics(true))
+
+		metrics.NewRegisteredCounter("requests.total", r).Inc(1)
+		metrics.NewRegisteredCounter("requests_total", r).Inc(2)
+		metrics.NewRegisteredHistogram("size", r, metrics.NewUniformSample(64))
+		metrics.NewRegisteredGauge("size.min", r).Update(3)
+		metrics.NewRegisteredCounter("size.count", r).Inc(4)
+		metrics.NewRegisteredCounter("errors[error-type:a,error_type:b]", r).Inc(5)
+		metrics.NewRegisteredCounter("errors[type:a]", r).Inc(6)
+
+		reg := prometheus.NewPedanticRegistry()
+		reg.MustRegister(c)
+		if _, err := reg.Gather(); err != nil {
+			t.Fatalf("unexpected error gathering metrics: %v", err)
+		}
+
+		expected := `
+# HELP appmetrics_collector_dropped_series_total Number of go-metrics series dropped because they are not valid in Prometheus
+# TYPE appmetrics_collector_dropped_series_total counter
+appmetrics_collector_dropped_series_total{reason="conflict"} 4
+appmetrics_collector_dropped_series_total{reason="invalid"} 0
+appmetrics_collector_dropped_series_total{reason="labels"} 2
+# HELP appmetrics_collector_name_collisions Number of go-metrics names that collide with another name after sanitization
+# TYPE appmetrics_collector_name_collisions gauge
+appmetrics_collector_name_collisions 1
+# HELP appmetrics_collector_series Number of series exported by the collection of go-metrics
+# TYPE appmetrics_collector_series gauge
+appmetrics_collector_series 6
+# HELP errors metrics.Counter
+# TYPE errors untyped
+errors{type="a"} 6
+# HELP requests_total metrics.Counter
+# TYPE requests_total untyped
+requests_total 1
+requests_total{go_metric="requests_total"} 2
+`
+
+		// Dropped series are counted across collections, including the one
+		// by Gather
+		if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "appmetrics_collector_dropped_series_total", "appmetrics_collector_name_collisions", "appmetrics_collector_series", "errors", "requests_total"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("collisions", func(t *testing.T) {
+		var reported [][]string
+		r := metrics.NewRegistry()
+		c := NewCollector(r, WithCollisionHandler(func(name string, names []string) {
+			reported = append(reported, append([]string{name}, names...))
+		}))
+
+		metrics.NewRegisteredCounter("jobs/done[queue:a]", r).Inc(1)
+		metrics.NewRegisteredCounter("jobs.done[queue:a]", r).Inc(2)
+		metrics.NewRegisteredCounter("jobs.done[queue:b]", r).Inc(3)
+		metrics.NewRegisteredTimer("jobs-done", r)
+
+		expected := `
+# HELP jobs_done metrics.Counter
+# TYPE jobs_done untyped
+jobs_done{go_metric="jobs.done",queue="a"} 2
+jobs_done{go_metric="jobs.done",queue="b"} 3
+jobs_done{go_metric="jobs/done",queue="a"} 1
+`
+
+		if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "jobs_done"); err != nil {
+			t.Error(err)
+		}
+		if n := testutil.CollectAndCount(c, "jobs_done_seconds"); n != 1 {
+			t.Errorf("expected first colliding name to be exported without a label, got %d series", n)
+		}
+
+		expectedReports := [][]string{{"jobs_done", "jobs-done", "jobs.done", "jobs/done"}}
+		if !reflect.DeepEqual(reported, expectedReports) {
+			t.Errorf("expected collisions %v to be reported once, got %v", expectedReports, reported)
+		}
+	})
 }
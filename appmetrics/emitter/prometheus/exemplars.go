@@ -0,0 +1,81 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDLabel is the exemplar label that contains the trace ID recorded by
+// a TraceRecorder.
+const TraceIDLabel = "trace_id"
+
+// ExemplarFunc returns the labels of the exemplar for the metric with the
+// go-metrics name, like a "trace_id" label, or nil if the metric has no
+// exemplar. It is called during collection, so it must be fast and safe for
+// concurrent use.
+type ExemplarFunc func(name string) prometheus.Labels
+
+// TraceRecorder records the trace ID of the most recent sampled request, so
+// that the Collector can link metrics to a trace with WithExemplars:
+//
+//	traces := prometheus.NewTraceRecorder()
+//	server.Mux().Use(traces.Handler())
+//	collector := prometheus.NewCollector(registry, prometheus.WithExemplars(traces.Exemplar))
+//
+// The recorder reads the OpenTelemetry span context of each request, so its
+// middleware must run after the tracing middleware that starts the span.
+type TraceRecorder struct {
+	traceID atomic.Pointer[trace.TraceID]
+}
+
+// NewTraceRecorder creates a TraceRecorder that has not recorded a trace.
+func NewTraceRecorder() *TraceRecorder {
+	return &TraceRecorder{}
+}
+
+// Handler returns middleware that records the trace ID of each request with
+// a sampled span after the request is handled. Requests without a sampled
+// span do not change the recorded trace.
+func (t *TraceRecorder) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+			t.Record(trace.SpanContextFromContext(r.Context()))
+		})
+	}
+}
+
+// Record records the trace ID of the span context if the span is sampled.
+func (t *TraceRecorder) Record(sc trace.SpanContext) {
+	if sc.IsValid() && sc.IsSampled() {
+		id := sc.TraceID()
+		t.traceID.Store(&id)
+	}
+}
+
+// Exemplar is an ExemplarFunc that returns the most recent trace ID in the
+// TraceIDLabel label for all metrics, or nil if no trace was recorded.
+func (t *TraceRecorder) Exemplar(name string) prometheus.Labels {
+	id := t.traceID.Load()
+	if id == nil {
+		return nil
+	}
+	return prometheus.Labels{TraceIDLabel: id.String()}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rcrowley/go-metrics"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestExemplars(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("requests", r).Inc(2)
+	metrics.NewRegisteredGauge("goroutines", r).Update(10)
+	h := metrics.NewRegisteredHistogram("size", r, metrics.NewUniformSample(100))
+	h.Update(1)
+	h.Update(3)
+
+	traces := NewTraceRecorder()
+	handler := traces.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(flags trace.TraceFlags) {
+		sc := trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{0x01, 0x02},
+			SpanID:     trace.SpanID{0x03},
+			TraceFlags: flags,
+		})
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(trace.ContextWithSpanContext(req.Context(), sc))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve(0)
+	if labels := traces.Exemplar("requests"); labels != nil {
+		t.Fatalf("unsampled traces should not be recorded, got %v", labels)
+	}
+	serve(trace.FlagsSampled)
+
+	promRegistry := prometheus.NewPedanticRegistry()
+	promRegistry.MustRegister(NewCollector(r,
+		WithCreatedTimestamps(true),
+		WithNativeHistograms(1.1),
+		WithExemplars(traces.Exemplar),
+	))
+
+	mfs, err := promRegistry.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error gathering metrics: %v", err)
+	}
+
+	const traceID = "01020000000000000000000000000000"
+	exemplars := make(map[string]*dto.Exemplar)
+	for _, mf := range mfs {
+		m := mf.GetMetric()[0]
+		switch {
+		case m.GetCounter() != nil:
+			exemplars[mf.GetName()] = m.GetCounter().GetExemplar()
+		case m.GetHistogram() != nil:
+			for _, b := range m.GetHistogram().GetBucket() {
+				if b.GetExemplar() != nil {
+					exemplars[mf.GetName()] = b.GetExemplar()
+				}
+			}
+		}
+	}
+
+	for name, value := range map[string]float64{"requests_total": 1, "size": 2} {
+		e := exemplars[name]
+		if e == nil {
+			t.Errorf("expected exemplar for %s", name)
+			continue
+		}
+		if len(e.GetLabel()) != 1 || e.GetLabel()[0].GetName() != TraceIDLabel || e.GetLabel()[0].GetValue() != traceID {
+			t.Errorf("unexpected exemplar labels for %s: %v", name, e.GetLabel())
+		}
+		if e.GetValue() != value {
+			t.Errorf("expected exemplar value %v for %s, got %v", value, name, e.GetValue())
+		}
+	}
+	if len(exemplars) != 2 {
+		t.Errorf("expected exemplars for counters and histograms only, got %v", exemplars)
+	}
+}
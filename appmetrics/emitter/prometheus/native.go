@@ -0,0 +1,187 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// nativeBuckets are the sparse buckets of a native histogram, computed from
+// the sample of a go-metrics histogram.
+type nativeBuckets struct {
+	schema        int32
+	zeroThreshold float64
+	zeroCount     uint64
+	positiveSpans []*dto.BucketSpan
+	positiveDelta []int64
+	negativeSpans []*dto.BucketSpan
+	negativeDelta []int64
+}
+
+// nativeHistogram adds native buckets to a constant histogram, which may also
+// have classic buckets.
+type nativeHistogram struct {
+	prometheus.Metric
+	buckets nativeBuckets
+}
+
+func (h nativeHistogram) Write(m *dto.Metric) error {
+	if err := h.Metric.Write(m); err != nil {
+		return err
+	}
+
+	b := h.buckets
+	m.Histogram.Schema = &b.schema
+	m.Histogram.ZeroThreshold = &b.zeroThreshold
+	m.Histogram.ZeroCount = &b.zeroCount
+	m.Histogram.PositiveSpan = b.positiveSpans
+	m.Histogram.PositiveDelta = b.positiveDelta
+	m.Histogram.NegativeSpan = b.negativeSpans
+	m.Histogram.NegativeDelta = b.negativeDelta
+
+	// A histogram without native buckets is only recognized as a native
+	// histogram if it has at least one span, so add an empty span like
+	// client_golang does
+	if len(b.positiveSpans) == 0 && len(b.negativeSpans) == 0 && b.zeroCount == 0 {
+		var offset int32
+		var length uint32
+		m.Histogram.PositiveSpan = []*dto.BucketSpan{{Offset: &offset, Length: &length}}
+	}
+	return nil
+}
+
+// nativeSchema returns the schema of native histograms with buckets that grow
+// by at most the given factor. It uses the same rules as the
+// NativeHistogramBucketFactor option of client_golang histograms.
+func nativeSchema(factor float64) int32 {
+	floor := math.Floor(math.Log2(math.Log2(factor)))
+	switch {
+	case floor <= -8:
+		return 8
+	case floor >= 4:
+		return -4
+	default:
+		return -int32(floor)
+	}
+}
+
+// nativeBucketKey returns the index of the native bucket that contains the
+// positive value v. Bucket i has the upper bound 2^(i*2^-schema).
+func nativeBucketKey(v float64, schema int32) int {
+	if schema > 0 {
+		return int(math.Ceil(math.Log2(v) * float64(int(1)<<schema)))
+	}
+
+	frac, exp := math.Frexp(v)
+	key := exp
+	if frac == 0.5 {
+		// exact powers of two are the upper bound of the previous bucket
+		key--
+	}
+	offset := (1 << -schema) - 1
+	return (key + offset) >> -schema
+}
+
+// newNativeBuckets computes native buckets from the values in the sample of a
+// histogram. If the sample contains fewer values than count, as it does for
+// histograms with reservoir samples, the bucket counts are scaled so that
+// they add up to count.
+func newNativeBuckets(values []int64, count uint64, schema int32) nativeBuckets {
+	b := nativeBuckets{
+		schema:        schema,
+		zeroThreshold: prometheus.DefNativeHistogramZeroThreshold,
+	}
+
+	var zeros int
+	positive := make(map[int]uint64)
+	negative := make(map[int]uint64)
+	for _, v := range values {
+		switch {
+		case v > 0:
+			positive[nativeBucketKey(float64(v), schema)]++
+		case v < 0:
+			negative[nativeBucketKey(-float64(v), schema)]++
+		default:
+			zeros++
+		}
+	}
+	if len(values) == 0 {
+		return b
+	}
+
+	// Scale the counts using the cumulative count, so that rounding errors
+	// do not change the total
+	var seen, scaled uint64
+	scale := func(n uint64) uint64 {
+		seen += n
+		next := uint64(math.Round(float64(seen) * float64(count) / float64(len(values))))
+		n, scaled = next-scaled, next
+		return n
+	}
+
+	negativeKeys := sortedKeys(negative)
+	negativeCounts := make([]uint64, len(negativeKeys))
+	for i, k := range negativeKeys {
+		negativeCounts[i] = scale(negative[k])
+	}
+	b.zeroCount = scale(uint64(zeros))
+	positiveKeys := sortedKeys(positive)
+	positiveCounts := make([]uint64, len(positiveKeys))
+	for i, k := range positiveKeys {
+		positiveCounts[i] = scale(positive[k])
+	}
+
+	b.negativeSpans, b.negativeDelta = encodeNativeBuckets(negativeKeys, negativeCounts)
+	b.positiveSpans, b.positiveDelta = encodeNativeBuckets(positiveKeys, positiveCounts)
+	return b
+}
+
+// encodeNativeBuckets encodes buckets with increasing keys as spans of
+// consecutive buckets and the difference between each count and the
+// previous count.
+func encodeNativeBuckets(keys []int, counts []uint64) ([]*dto.BucketSpan, []int64) {
+	var spans []*dto.BucketSpan
+	var deltas []int64
+
+	var prevKey int
+	var prevCount int64
+	for i, k := range keys {
+		if i == 0 || k != prevKey+1 {
+			offset := int32(k)
+			if i > 0 {
+				offset = int32(k - prevKey - 1)
+			}
+			spans = append(spans, &dto.BucketSpan{Offset: &offset, Length: new(uint32)})
+		}
+		*spans[len(spans)-1].Length++
+
+		deltas = append(deltas, int64(counts[i])-prevCount)
+		prevKey, prevCount = k, int64(counts[i])
+	}
+	return spans, deltas
+}
+
+func sortedKeys(m map[int]uint64) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestNativeHistograms(t *testing.T) {
+	r := metrics.NewRegistry()
+	h := metrics.NewRegisteredHistogram("size", r, metrics.NewUniformSample(100))
+	for _, v := range []int64{0, 1, 2, 3, 4, -2} {
+		h.Update(v)
+	}
+
+	promRegistry := prometheus.NewPedanticRegistry()
+	promRegistry.MustRegister(NewCollector(r, WithNativeHistograms(2)))
+
+	mfs, err := promRegistry.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error gathering metrics: %v", err)
+	}
+
+	var hist *dto.Histogram
+	for _, mf := range mfs {
+		if mf.GetName() == "size" {
+			if mf.GetType() != dto.MetricType_HISTOGRAM {
+				t.Fatalf("expected histogram type, got %s", mf.GetType())
+			}
+			hist = mf.GetMetric()[0].GetHistogram()
+		}
+	}
+	if hist == nil {
+		t.Fatal("histogram was not collected")
+	}
+
+	if hist.GetSchema() != 0 {
+		t.Errorf("expected schema 0 for factor 2, got %d", hist.GetSchema())
+	}
+	if hist.GetSampleCount() != 6 || hist.GetSampleSum() != 8 {
+		t.Errorf("expected count 6 and sum 8, got %d and %v", hist.GetSampleCount(), hist.GetSampleSum())
+	}
+	if hist.GetZeroCount() != 1 {
+		t.Errorf("expected zero count 1, got %d", hist.GetZeroCount())
+	}
+	if spans, deltas := spanPairs(hist.GetPositiveSpan()), hist.GetPositiveDelta(); !reflect.DeepEqual(spans, [][2]int{{0, 3}}) || !reflect.DeepEqual(deltas, []int64{1, 0, 1}) {
+		t.Errorf("unexpected positive buckets: spans %v, deltas %v", spans, deltas)
+	}
+	if spans, deltas := spanPairs(hist.GetNegativeSpan()), hist.GetNegativeDelta(); !reflect.DeepEqual(spans, [][2]int{{1, 1}}) || !reflect.DeepEqual(deltas, []int64{1}) {
+		t.Errorf("unexpected negative buckets: spans %v, deltas %v", spans, deltas)
+	}
+}
+
+func TestNewNativeBuckets(t *testing.T) {
+	b := newNativeBuckets([]int64{1, 1, 2, 16}, 40, 1)
+	if spans := spanPairs(b.positiveSpans); !reflect.DeepEqual(spans, [][2]int{{0, 1}, {1, 1}, {5, 1}}) {
+		t.Errorf("unexpected spans: %v", spans)
+	}
+	if !reflect.DeepEqual(b.positiveDelta, []int64{20, -10, 0}) {
+		t.Errorf("counts should be scaled to the total count: %v", b.positiveDelta)
+	}
+
+	for factor, schema := range map[float64]int32{1.1: 3, 1.0001: 8, 2: 0, 4: -1, 1e10: -4} {
+		if s := nativeSchema(factor); s != schema {
+			t.Errorf("expected schema %d for factor %v, got %d", schema, factor, s)
+		}
+	}
+	for v, key := range map[float64]int{1: 0, 1.2: 1, 1.5: 2, 2: 2, 3: 4, 4: 4} {
+		if k := nativeBucketKey(v, 1); k != key {
+			t.Errorf("expected key %d for value %v at schema 1, got %d", key, v, k)
+		}
+	}
+}
+
+func spanPairs(spans []*dto.BucketSpan) [][2]int {
+	var s [][2]int
+	for _, span := range spans {
+		s = append(s, [2]int{int(span.GetOffset()), int(span.GetLength())})
+	}
+	return s
+}
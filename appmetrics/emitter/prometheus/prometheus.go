@@ -16,20 +16,123 @@ package prometheus
 
 import (
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/palantir/go-baseapp/baseapp"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
 	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog/hlog"
+	"goji.io/pat"
 )
 
 type Config struct {
 	Labels             map[string]string `yaml:"labels" json:"labels"`
 	HistogramQuantiles []float64         `yaml:"histogram_quantiles" json:"histogram_quantiles"`
 	TimerQuantiles     []float64         `yaml:"timer_quantiles" json:"timer_quantiles"`
+
+	// Timestamps enables explicit timestamps on samples. See WithTimestamps.
+	Timestamps bool `yaml:"timestamps" json:"timestamps"`
+
+	// CreatedTimestamps reports counters and meters as Prometheus counters
+	// with created timestamps and writes the "_created" series in responses
+	// that use the OpenMetrics format. See WithCreatedTimestamps.
+	CreatedTimestamps bool `yaml:"created_timestamps" json:"created_timestamps"`
+
+	// TagMapping renames and drops the tags of metrics before they become
+	// labels. See WithTagMapping.
+	TagMapping appmetrics.TagMapping `yaml:"tag_mapping" json:"tag_mapping"`
+
+	// IdleExpiration stops exporting tagged series that are not updated for
+	// this duration. See WithIdleExpiration.
+	IdleExpiration time.Duration `yaml:"idle_expiration" json:"idle_expiration"`
+
+	// Help sets the help text of metrics by their go-metrics name. See
+	// WithHelp.
+	Help map[string]string `yaml:"help" json:"help"`
+
+	// NativeHistogramBucketFactor reports histograms as native histograms
+	// with buckets that grow by at most this factor. See
+	// WithNativeHistograms.
+	NativeHistogramBucketFactor float64 `yaml:"native_histogram_bucket_factor" json:"native_histogram_bucket_factor"`
+
+	// SelfMetrics enables metrics about the collector. See WithSelfMetrics.
+	SelfMetrics bool `yaml:"self_metrics" json:"self_metrics"`
+
+	// DryRun logs the series that the handler would return with the request
+	// logger and responds without a body. Use it to check metric names and
+	// labels locally.
+	DryRun bool `yaml:"dry_run" json:"dry_run"`
 }
 
 // NewHandler returns a new http.Handler that returns the metrics in the registry.
 func NewHandler(r metrics.Registry, config Config) http.Handler {
+	opts := config.options()
+	if config.DryRun {
+		return dryRunHandler(newGatherer(r, opts))
+	}
+	return Handler(r, opts...)
+}
+
+// Handler returns an http.Handler that serves the metrics in the registry to
+// Prometheus using a Collector with the options. If the collector has created
+// timestamps or exemplars, responses use the OpenMetrics format when the
+// client accepts it, so that the timestamps and exemplars are exported.
+//
+// Mount the handler on the server's mux or on an admin mux:
+//
+//	mux.Handle(pat.Get("/metrics"), prometheus.Handler(server.Registry()))
+//
+// To mount the handler on the server with a parameter, use
+// WithScrapeEndpoint.
+func Handler(r metrics.Registry, opts ...CollectorOption) http.Handler {
+	var c Collector
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	g := newGatherer(r, opts)
+	handler := promhttp.HandlerFor(g, promhttp.HandlerOpts{
+		EnableOpenMetrics: c.exemplars != nil,
+	})
+	if c.created {
+		return openMetricsHandler(g, handler)
+	}
+	return handler
+}
+
+// WithScrapeEndpoint returns a parameter that serves the metrics in the
+// server's registry at the path, using Handler with the options. The handler
+// is created when the endpoint is first requested, so it uses the final
+// registry of the server, including any prefix set by
+// baseapp.WithMetricsPrefix.
+func WithScrapeEndpoint(path string, opts ...CollectorOption) baseapp.Param {
+	return func(s *baseapp.Server) error {
+		var once sync.Once
+		var handler http.Handler
+		s.Mux().Handle(pat.Get(path), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() {
+				handler = Handler(s.Registry(), opts...)
+			})
+			handler.ServeHTTP(w, r)
+		}))
+		return nil
+	}
+}
+
+// newGatherer returns a Prometheus registry that contains a Collector for r.
+func newGatherer(r metrics.Registry, opts []CollectorOption) prometheus.Gatherer {
+	promRegistry := prometheus.NewRegistry()
+	promRegistry.MustRegister(NewCollector(r, opts...))
+	return promRegistry
+}
+
+// options returns the collector options for the configuration.
+func (config Config) options() []CollectorOption {
 	var opts []CollectorOption
 	if len(config.Labels) > 0 {
 		opts = append(opts, WithLabels(config.Labels))
@@ -40,11 +143,100 @@ func NewHandler(r metrics.Registry, config Config) http.Handler {
 	if len(config.TimerQuantiles) > 0 {
 		opts = append(opts, WithTimerQuantiles(config.TimerQuantiles))
 	}
+	if config.Timestamps {
+		opts = append(opts, WithTimestamps(true))
+	}
+	if config.CreatedTimestamps {
+		opts = append(opts, WithCreatedTimestamps(true))
+	}
+	if !config.TagMapping.IsZero() {
+		opts = append(opts, WithTagMapping(config.TagMapping))
+	}
+	if config.IdleExpiration > 0 {
+		opts = append(opts, WithIdleExpiration(config.IdleExpiration))
+	}
+	if len(config.Help) > 0 {
+		opts = append(opts, WithHelp(config.Help))
+	}
+	if config.SelfMetrics {
+		opts = append(opts, WithSelfMetrics(true))
+	}
+	if config.NativeHistogramBucketFactor > 1 {
+		opts = append(opts, WithNativeHistograms(config.NativeHistogramBucketFactor))
+	}
+	return opts
+}
 
-	collector := NewCollector(r, opts...)
+// openMetricsHandler returns a handler that writes the series from the
+// gatherer in the OpenMetrics format, including the "_created" series of
+// counters, if the client accepts it. Otherwise, it calls next. The promhttp
+// handler supports OpenMetrics, but never writes the "_created" series.
+func openMetricsHandler(g prometheus.Gatherer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format := expfmt.NegotiateIncludingOpenMetrics(r.Header)
+		if format.FormatType() != expfmt.TypeOpenMetrics {
+			next.ServeHTTP(w, r)
+			return
+		}
 
-	promRegistry := prometheus.NewRegistry()
-	promRegistry.MustRegister(collector)
+		mfs, err := g.Gather()
+		if err != nil {
+			http.Error(w, "An error has occurred while gathering metrics:\n\n"+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", string(format))
+		enc := expfmt.NewEncoder(w, format, expfmt.WithCreatedLines())
+		for _, mf := range mfs {
+			if err := enc.Encode(mf); err != nil {
+				hlog.FromRequest(r).Error().Err(err).Msg("Failed to encode metrics")
+				return
+			}
+		}
+		if closer, ok := enc.(expfmt.Closer); ok {
+			_ = closer.Close()
+		}
+	})
+}
+
+// dryRunHandler returns a handler that logs the series from the gatherer.
+func dryRunHandler(g prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger := hlog.FromRequest(r)
+
+		mfs, err := g.Gather()
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to gather metrics")
+		}
+
+		for _, mf := range mfs {
+			for _, m := range mf.GetMetric() {
+				labels := make([]string, 0, len(m.GetLabel()))
+				for _, l := range m.GetLabel() {
+					labels = append(labels, l.GetName()+":"+l.GetValue())
+				}
+
+				event := logger.Info().
+					Str("metric", mf.GetName()).
+					Str("type", strings.ToLower(mf.GetType().String())).
+					Strs("tags", labels)
+
+				switch {
+				case m.Counter != nil:
+					event = event.Float64("value", m.GetCounter().GetValue())
+				case m.Gauge != nil:
+					event = event.Float64("value", m.GetGauge().GetValue())
+				case m.Untyped != nil:
+					event = event.Float64("value", m.GetUntyped().GetValue())
+				case m.Summary != nil:
+					event = event.
+						Uint64("count", m.GetSummary().GetSampleCount()).
+						Float64("sum", m.GetSummary().GetSampleSum())
+				}
+				event.Msg("Dry run metric")
+			}
+		}
 
-	return promhttp.HandlerFor(promRegistry, promhttp.HandlerOpts{})
+		w.WriteHeader(http.StatusNoContent)
+	})
 }
@@ -0,0 +1,122 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+)
+
+func TestNewHandlerDryRun(t *testing.T) {
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("requests[code:200]", r).Inc(3)
+
+	var out bytes.Buffer
+	h := hlog.NewHandler(zerolog.New(&out))(NewHandler(r, Config{DryRun: true}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("expected empty response with status 204, got %d: %q", w.Code, w.Body.String())
+	}
+
+	expected := `{"level":"info","metric":"requests","type":"untyped","tags":["code:200"],"value":3,"message":"Dry run metric"}`
+	if got := strings.TrimSpace(out.String()); got != expected {
+		t.Errorf("unexpected log output:\n got: %s\nwant: %s", got, expected)
+	}
+}
+
+func TestNewHandlerCreatedTimestamps(t *testing.T) {
+	r := metrics.NewRegistry()
+	counter := metrics.NewRegisteredCounter("requests", r)
+	counter.Inc(3)
+	metrics.NewRegisteredMeter("events", r).Mark(1)
+
+	h := NewHandler(r, Config{CreatedTimestamps: true})
+	scrape := func(accept string) string {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+	created := regexp.MustCompile(`(?m)^requests_created (\S+)$`)
+
+	body := scrape("application/openmetrics-text; version=1.0.0")
+	for _, line := range []string{"# TYPE requests counter", "requests_total 3.0", "events_count_total 1.0", "# EOF"} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected OpenMetrics response to contain %q, got:\n%s", line, body)
+		}
+	}
+	first := created.FindStringSubmatch(body)
+	if first == nil {
+		t.Fatalf("expected OpenMetrics response to contain created series, got:\n%s", body)
+	}
+
+	if again := created.FindStringSubmatch(scrape("application/openmetrics-text; version=1.0.0")); again == nil || again[1] != first[1] {
+		t.Errorf("expected created timestamp %s to be stable, got %v", first[1], again)
+	}
+
+	counter.Dec(2)
+	if reset := created.FindStringSubmatch(scrape("application/openmetrics-text; version=1.0.0")); reset == nil || reset[1] == first[1] {
+		t.Errorf("expected created timestamp to change after a reset, got %v", reset)
+	}
+
+	body = scrape("text/plain")
+	if !strings.Contains(body, "# TYPE requests_total counter\nrequests_total 1\n") || strings.Contains(body, "_created") {
+		t.Errorf("expected text response with counter and without created series, got:\n%s", body)
+	}
+}
+
+func TestWithScrapeEndpoint(t *testing.T) {
+	traces := NewTraceRecorder()
+	s, err := baseapp.NewServer(baseapp.HTTPConfig{},
+		baseapp.WithRegistry(metrics.NewRegistry()),
+		baseapp.WithMetricsPrefix("app."),
+		WithScrapeEndpoint("/metrics", WithExemplars(traces.Exemplar)),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	metrics.GetOrRegisterCounter("requests", s.Registry()).Inc(2)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	w := httptest.NewRecorder()
+	s.HTTPServer().Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("expected OpenMetrics response with exemplars enabled, got %q", ct)
+	}
+	if body := w.Body.String(); !strings.Contains(body, "\napp_requests 2.0\n") {
+		t.Errorf("expected response to contain the prefixed counter, got:\n%s", body)
+	}
+}
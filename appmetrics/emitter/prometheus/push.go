@@ -0,0 +1,146 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultPushInterval = 15 * time.Second
+)
+
+// PushConfig configures a Pusher.
+type PushConfig struct {
+	// URL is the address of the Pushgateway, like "http://pushgateway:9091".
+	URL string `yaml:"url" json:"url"`
+
+	// Job is the value of the "job" label of pushed metrics. It is required.
+	Job string `yaml:"job" json:"job"`
+
+	// Instance is the value of the "instance" label of pushed metrics. If it
+	// is empty, metrics are grouped by job only, so each push replaces the
+	// metrics of all instances of the job.
+	Instance string `yaml:"instance" json:"instance"`
+
+	// Grouping are additional labels that identify the group of pushed
+	// metrics, along with the job and instance.
+	Grouping map[string]string `yaml:"grouping" json:"grouping"`
+
+	// Interval is the time between pushes by Emit. The default is
+	// DefaultPushInterval.
+	Interval time.Duration `yaml:"interval" json:"interval"`
+}
+
+// Pusher pushes the metrics in a registry to a Prometheus Pushgateway, for
+// batch jobs and short-lived workers that exit before Prometheus can scrape
+// them. Each push replaces the metrics of the pusher's group in the
+// Pushgateway.
+//
+// The Pushgateway rejects samples with timestamps, so do not use
+// WithTimestamps with a Pusher.
+type Pusher struct {
+	pusher   *push.Pusher
+	interval time.Duration
+}
+
+// NewPusher creates a Pusher for the metrics in the registry, using a
+// Collector with the options.
+func NewPusher(r metrics.Registry, c PushConfig, opts ...CollectorOption) (*Pusher, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("prometheus: pushgateway URL is required")
+	}
+	if c.Job == "" {
+		return nil, fmt.Errorf("prometheus: pushgateway job is required")
+	}
+	if c.Interval == 0 {
+		c.Interval = DefaultPushInterval
+	}
+
+	p := push.New(c.URL, c.Job).Gatherer(newGatherer(r, opts))
+	if c.Instance != "" {
+		p = p.Grouping("instance", c.Instance)
+	}
+	for name, value := range c.Grouping {
+		p = p.Grouping(name, value)
+	}
+	if err := p.Error(); err != nil {
+		return nil, fmt.Errorf("prometheus: invalid pushgateway grouping: %w", err)
+	}
+
+	return &Pusher{
+		pusher:   p.Client(&http.Client{Timeout: c.Interval}),
+		interval: c.Interval,
+	}, nil
+}
+
+// Push pushes the current metrics once. Batch jobs that do not start a
+// server should call Push before they exit.
+func (p *Pusher) Push(ctx context.Context) error {
+	if err := p.pusher.PushContext(ctx); err != nil {
+		return fmt.Errorf("prometheus: failed to push metrics: %w", err)
+	}
+	return nil
+}
+
+// Emit pushes metrics on the configured interval until the context is
+// canceled and then pushes them one last time, so that the Pushgateway has
+// the final values of a worker that is stopping. Failed pushes are logged
+// with the logger from the context.
+func (p *Pusher) Emit(ctx context.Context) {
+	t := time.NewTicker(p.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			p.emit(ctx)
+		case <-ctx.Done():
+			final, cancel := context.WithTimeout(context.WithoutCancel(ctx), p.interval)
+			p.emit(final)
+			cancel()
+			return
+		}
+	}
+}
+
+func (p *Pusher) emit(ctx context.Context) {
+	if err := p.Push(ctx); err != nil {
+		zerolog.Ctx(ctx).Error().Err(err).Msg("Failed to push metrics to Pushgateway")
+	}
+}
+
+// UnstartedPushEmitter returns an emitter for baseapp.WithUnstartedEmitters
+// or ServerBuilder.Emitters that pushes the metrics in the server's final
+// registry until the server shuts down. Failed pushes are logged with the
+// server's logger.
+func UnstartedPushEmitter(c PushConfig, opts ...CollectorOption) baseapp.EmitterFunc {
+	return func(ctx context.Context, s *baseapp.Server) error {
+		p, err := NewPusher(s.Registry(), c, opts...)
+		if err != nil {
+			return err
+		}
+		p.Emit(s.Logger().WithContext(ctx))
+		return nil
+	}
+}
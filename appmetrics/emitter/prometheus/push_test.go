@@ -0,0 +1,103 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/rcrowley/go-metrics"
+)
+
+func TestPusher(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		names  []string
+	}
+	requests := make(chan request, 10)
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var names []string
+		dec := expfmt.NewDecoder(r.Body, expfmt.ResponseFormat(r.Header))
+		for {
+			var mf dto.MetricFamily
+			if err := dec.Decode(&mf); err != nil {
+				break
+			}
+			names = append(names, mf.GetName())
+		}
+		select {
+		case requests <- request{method: r.Method, path: r.URL.Path, names: names}:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("jobs.processed", r).Inc(3)
+
+	p, err := NewPusher(r, PushConfig{
+		URL:      gateway.URL,
+		Job:      "reindex",
+		Instance: "worker-1",
+		Grouping: map[string]string{"shard": "a"},
+		Interval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating pusher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Emit(ctx)
+		close(done)
+	}()
+
+	req := <-requests
+	cancel()
+	<-done
+
+	if req.method != http.MethodPut {
+		t.Errorf("expected PUT request, got %s", req.method)
+	}
+
+	// The pusher adds grouping labels to the path in map order, so compare
+	// them without considering their order
+	grouping, ok := strings.CutPrefix(req.path, "/metrics/job/reindex/")
+	if !ok {
+		t.Fatalf("expected path for job reindex, got %s", req.path)
+	}
+	labels := make(map[string]string)
+	parts := strings.Split(grouping, "/")
+	for i := 0; i+1 < len(parts); i += 2 {
+		labels[parts[i]] = parts[i+1]
+	}
+	if expected := map[string]string{"instance": "worker-1", "shard": "a"}; len(parts)%2 != 0 || !maps.Equal(labels, expected) {
+		t.Errorf("expected grouping labels %v, got path %s", expected, req.path)
+	}
+	if len(req.names) != 1 || req.names[0] != "jobs_processed" {
+		t.Errorf("expected jobs_processed metric, got %v", req.names)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ExpvarName is the name of the expvar variable that contains the metrics
+// published with PublishExpvar.
+const ExpvarName = "appmetrics"
+
+var (
+	expvarOnce    sync.Once
+	expvarMetrics *expvar.Map
+)
+
+// PublishExpvar publishes the metrics in the struct m with the expvar
+// package, so that they appear in the JSON served by expvar.Handler, usually
+// at "/debug/vars". Use it to inspect live values during debugging without
+// a metrics backend.
+//
+// The metrics are published in the "appmetrics" variable, which contains an
+// object for each published struct with the values returned by Snapshot,
+// including the instances of tagged metrics. The key of a struct is the
+// prefix it was registered with, without a trailing ".", and the tags added
+// with WithTags, like "db[shard:1]". If the struct was registered without a
+// prefix, the key uses the name of the struct type instead. Publishing a
+// struct with the same key as a previous struct replaces it.
+//
+// Values are computed each time the variable is read. PublishExpvar panics if
+// the struct contains invalid metric definitions.
+func PublishExpvar[M any](m *M) {
+	v := reflect.ValueOf(m).Elem()
+	if v.Type().Kind() != reflect.Struct {
+		panic("appmetrics.PublishExpvar: type is not a struct pointer")
+	}
+	if _, err := getMetricFields(v.Type()); err != nil {
+		panic("appmetrics.PublishExpvar: " + err.Error())
+	}
+
+	expvarOnce.Do(func() {
+		expvarMetrics = expvar.NewMap(ExpvarName)
+	})
+	expvarMetrics.Set(expvarKey(m, v.Type()), expvarSnapshot(func() any { return Snapshot(m) }))
+}
+
+// expvarKey returns the key of a published struct.
+func expvarKey(m any, typ reflect.Type) string {
+	name := typ.String()
+
+	reg, ok := lookupRegistration(m)
+	if !ok {
+		return name
+	}
+	ro, err := newRegisterOptions(reg.opts)
+	if err != nil {
+		return name
+	}
+	if prefix := strings.TrimSuffix(ro.prefix, "."); prefix != "" {
+		name = prefix
+	}
+	return joinTags(name, ro.tags)
+}
+
+// expvarSnapshot is an expvar.Var that encodes the result of a function as
+// JSON. Unlike expvar.Func, it reports encoding errors, like NaN gauge
+// values, instead of writing invalid JSON.
+type expvarSnapshot func() any
+
+func (f expvarSnapshot) String() string {
+	b, err := json.Marshal(f())
+	if err != nil {
+		b, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	return string(b)
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"encoding/json"
+	"expvar"
+	"math"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ExpvarMetrics struct {
+	Temperature metrics.GaugeFloat64 `metric:"temperature"`
+}
+
+func TestPublishExpvar(t *testing.T) {
+	r := metrics.NewRegistry()
+
+	unregistered := New[SimpleMetrics]()
+	unregistered.FooCount.Inc(1)
+	PublishExpvar(unregistered)
+
+	db := New[DBMetrics]()
+	db.ComputeConnections = func() int64 { return 3 }
+	Register(r, db, WithPrefix("db."), WithTags("shard:1"))
+	db.Errors.Tag("timeout").Inc(2)
+	PublishExpvar(db)
+
+	read := func() map[string]map[string]any {
+		var vars map[string]map[string]any
+		require.NoError(t, json.Unmarshal([]byte(expvar.Get(ExpvarName).String()), &vars))
+		return vars
+	}
+
+	vars := read()
+	assert.Equal(t, float64(1), vars["appmetrics.SimpleMetrics"]["FooCount"], "unregistered structs should use the type name")
+	assert.Equal(t, map[string]any{"shard:1,timeout": float64(2)}, vars["db[shard:1]"]["Errors"])
+
+	unregistered.FooCount.Inc(1)
+	assert.Equal(t, float64(2), read()["appmetrics.SimpleMetrics"]["FooCount"], "values should be computed when read")
+
+	invalid := New[ExpvarMetrics]()
+	invalid.Temperature.Update(math.NaN())
+	PublishExpvar(invalid)
+	assert.Contains(t, read()["appmetrics.ExpvarMetrics"], "error", "encoding errors should produce valid JSON")
+}
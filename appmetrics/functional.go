@@ -0,0 +1,200 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// FunctionalHistogram is a [metrics.Histogram] that computes its values by
+// calling a function that returns a sample, like the sizes of the rows in a
+// table. The function is called each time the histogram is read, and all
+// statistics, including the count, describe the returned sample.
+//
+// The histogram implements metrics.Histogram so that emitters report it like
+// other histograms, but its Update and Clear methods panic.
+type FunctionalHistogram interface {
+	Count() int64
+	Max() int64
+	Mean() float64
+	Min() int64
+	Percentile(float64) float64
+	Percentiles([]float64) []float64
+	Sample() metrics.Sample
+	Snapshot() metrics.Histogram
+	StdDev() float64
+	Sum() int64
+	Variance() float64
+}
+
+// FunctionalTimer is a [metrics.Timer] that computes its values by calling a
+// function that returns a sample of durations, like the ages of the messages
+// in a queue. The function is called each time the timer is read, and all
+// statistics, including the count, describe the returned sample. Rates are
+// always zero.
+//
+// The timer implements metrics.Timer so that emitters report it like other
+// timers, but its Time and Update methods panic.
+type FunctionalTimer interface {
+	Count() int64
+	Max() int64
+	Mean() float64
+	Min() int64
+	Percentile(float64) float64
+	Percentiles([]float64) []float64
+	Rate1() float64
+	Rate5() float64
+	Rate15() float64
+	RateMean() float64
+	Snapshot() metrics.Timer
+	StdDev() float64
+	Sum() int64
+	Variance() float64
+}
+
+var (
+	functionalHistogramType = reflect.TypeOf((*FunctionalHistogram)(nil)).Elem()
+	functionalTimerType     = reflect.TypeOf((*FunctionalTimer)(nil)).Elem()
+)
+
+// functionalHistogram implements metrics.Histogram by reading a new sample
+// for each call.
+type functionalHistogram struct {
+	fn func() []int64
+}
+
+func newFunctionalHistogram(fn func() []int64) metrics.Histogram {
+	return functionalHistogram{fn: fn}
+}
+
+func (h functionalHistogram) Clear() {
+	panic("Clear called on a FunctionalHistogram")
+}
+
+func (h functionalHistogram) Count() int64                       { return h.Snapshot().Count() }
+func (h functionalHistogram) Max() int64                         { return h.Snapshot().Max() }
+func (h functionalHistogram) Mean() float64                      { return h.Snapshot().Mean() }
+func (h functionalHistogram) Min() int64                         { return h.Snapshot().Min() }
+func (h functionalHistogram) Percentile(p float64) float64       { return h.Snapshot().Percentile(p) }
+func (h functionalHistogram) Percentiles(ps []float64) []float64 { return h.Snapshot().Percentiles(ps) }
+func (h functionalHistogram) Sample() metrics.Sample             { return h.Snapshot().Sample() }
+func (h functionalHistogram) StdDev() float64                    { return h.Snapshot().StdDev() }
+func (h functionalHistogram) Sum() int64                         { return h.Snapshot().Sum() }
+func (h functionalHistogram) Variance() float64                  { return h.Snapshot().Variance() }
+
+func (h functionalHistogram) Snapshot() metrics.Histogram {
+	values := h.fn()
+	return metrics.NewHistogram(metrics.NewSampleSnapshot(int64(len(values)), values)).Snapshot()
+}
+
+func (h functionalHistogram) Update(int64) {
+	panic("Update called on a FunctionalHistogram")
+}
+
+// functionalTimer implements metrics.Timer by reading a new sample for each
+// call.
+type functionalTimer struct {
+	fn func() []time.Duration
+}
+
+func newFunctionalTimer(fn func() []time.Duration) metrics.Timer {
+	return functionalTimer{fn: fn}
+}
+
+func (t functionalTimer) Count() int64                       { return t.Snapshot().Count() }
+func (t functionalTimer) Max() int64                         { return t.Snapshot().Max() }
+func (t functionalTimer) Mean() float64                      { return t.Snapshot().Mean() }
+func (t functionalTimer) Min() int64                         { return t.Snapshot().Min() }
+func (t functionalTimer) Percentile(p float64) float64       { return t.Snapshot().Percentile(p) }
+func (t functionalTimer) Percentiles(ps []float64) []float64 { return t.Snapshot().Percentiles(ps) }
+func (t functionalTimer) Rate1() float64                     { return 0 }
+func (t functionalTimer) Rate5() float64                     { return 0 }
+func (t functionalTimer) Rate15() float64                    { return 0 }
+func (t functionalTimer) RateMean() float64                  { return 0 }
+func (t functionalTimer) StdDev() float64                    { return t.Snapshot().StdDev() }
+func (t functionalTimer) Stop()                              {}
+func (t functionalTimer) Sum() int64                         { return t.Snapshot().Sum() }
+func (t functionalTimer) Variance() float64                  { return t.Snapshot().Variance() }
+
+func (t functionalTimer) Snapshot() metrics.Timer {
+	durations := t.fn()
+	values := make([]int64, len(durations))
+	for i, d := range durations {
+		values[i] = int64(d)
+	}
+	return functionalTimerSnapshot{
+		Histogram: newFunctionalHistogram(func() []int64 { return values }).Snapshot(),
+	}
+}
+
+func (t functionalTimer) Time(func()) {
+	panic("Time called on a FunctionalTimer")
+}
+
+func (t functionalTimer) Update(time.Duration) {
+	panic("Update called on a FunctionalTimer")
+}
+
+func (t functionalTimer) UpdateSince(time.Time) {
+	panic("UpdateSince called on a FunctionalTimer")
+}
+
+// functionalTimerSnapshot is a read-only copy of a functionalTimer. It is
+// needed because metrics.TimerSnapshot requires a meter.
+type functionalTimerSnapshot struct {
+	metrics.Histogram
+}
+
+func (s functionalTimerSnapshot) Rate1() float64          { return 0 }
+func (s functionalTimerSnapshot) Rate5() float64          { return 0 }
+func (s functionalTimerSnapshot) Rate15() float64         { return 0 }
+func (s functionalTimerSnapshot) RateMean() float64       { return 0 }
+func (s functionalTimerSnapshot) Snapshot() metrics.Timer { return s }
+func (s functionalTimerSnapshot) Stop()                   {}
+
+func (s functionalTimerSnapshot) Time(func()) {
+	panic("Time called on a TimerSnapshot")
+}
+
+func (s functionalTimerSnapshot) Update(time.Duration) {
+	panic("Update called on a TimerSnapshot")
+}
+
+func (s functionalTimerSnapshot) UpdateSince(time.Time) {
+	panic("UpdateSince called on a TimerSnapshot")
+}
+
+// getSampleFunction returns the compute function of a functional histogram
+// or timer. See getGaugeFunction for how functions are found.
+func getSampleFunction[T int64 | time.Duration](v reflect.Value, fieldName string) (func() []T, error) {
+	name := GaugeFunctionPrefix + fieldName
+	m, isField, err := findFunction(v, name, reflect.TypeOf([]T(nil)))
+	if err != nil {
+		return nil, err
+	}
+	if m.Type().NumIn() != 0 {
+		return nil, fmt.Errorf("%s: function must take no parameters", name)
+	}
+
+	if isField {
+		// See getGaugeFunction for why fields are called through a wrapper
+		return func() []T { return m.Call(nil)[0].Interface().([]T) }, nil
+	}
+	return m.Interface().(func() []T), nil
+}
@@ -21,14 +21,17 @@ import (
 	"github.com/rcrowley/go-metrics"
 )
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 const (
-	GaugeFunctionPrefix = "Compute"
+	GaugeFunctionPrefix       = "Compute"
+	HealthcheckFunctionPrefix = "Check"
 )
 
 // FunctionalGauge is a [metrics.Gauge] that computes its value by calling a
 // function.
 //
-// A FunctionalGauge cannot be used as a [Tagged] metric.
+// To compute a value for each set of tags, use a [TaggedFunctionalGauge].
 type FunctionalGauge interface {
 	Snapshot() metrics.Gauge
 	Value() int64
@@ -37,14 +40,103 @@ type FunctionalGauge interface {
 // FunctionalGaugeFloat64 is a [metrics.GaugeFloat64] that computes its value
 // by calling a function.
 //
-// A FunctionalGaugeFloat64 cannot be used as a [Tagged] metric.
+// To compute a value for each set of tags, use a
+// [TaggedFunctionalGaugeFloat64].
 type FunctionalGaugeFloat64 interface {
 	Snapshot() metrics.GaugeFloat64
 	Value() float64
 }
 
+// TaggedFunctionalGauge is a [FunctionalGauge] with dynamic tags. Its compute
+// function receives the tags of the gauge, so one field can report a value
+// for each tag, like the length of each queue:
+//
+//	type M struct {
+//		QueueLength TaggedFunctionalGauge `metric:"queue_length"`
+//	}
+//
+//	func (m *M) ComputeQueueLength(tags ...string) int64 {
+//		// tags is []string{"queue:emails"}
+//		return queues.Length(strings.TrimPrefix(tags[0], "queue:"))
+//	}
+//
+//	m.QueueLength.Tag("queue:emails")
+//
+// The compute function receives the cleaned and sorted tags, including any
+// constant tags from the "metric-tags" tag. Unlike other Tagged metrics, the
+// gauge is only registered after the first call to Tag, because there is no
+// value for the base metric without tags.
+type TaggedFunctionalGauge = Tagged[FunctionalGauge]
+
+// TaggedFunctionalGaugeFloat64 is a [FunctionalGaugeFloat64] with dynamic
+// tags. See [TaggedFunctionalGauge] for details.
+type TaggedFunctionalGaugeFloat64 = Tagged[FunctionalGaugeFloat64]
+
 func getGaugeFunction[N int64 | float64, F func() N](v reflect.Value, fieldName string) (F, error) {
+	name, m, isField, err := findGaugeFunction[N](v, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	if m.Type().NumIn() != 0 {
+		return nil, fmt.Errorf("%s: function must take no parameters", name)
+	}
+
+	if isField {
+		// If the function is a field, return a wrapper that calls the current
+		// field value at the time of the the call. This is because the field
+		// value is nil when we discover the function as part of New()
+		return func() N { return m.Call(nil)[0].Interface().(N) }, nil
+	}
+	return m.Interface().(F), nil
+}
+
+func getTaggedGaugeFunction[N int64 | float64, F func(...string) N](v reflect.Value, fieldName string) (F, error) {
+	name, m, isField, err := findGaugeFunction[N](v, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	if m.Type().NumIn() != 1 || !m.Type().IsVariadic() || m.Type().In(0) != strSliceType {
+		return nil, fmt.Errorf("%s: function must take a single ...string parameter", name)
+	}
+
+	if isField {
+		// See getGaugeFunction for why fields are called through a wrapper
+		return func(tags ...string) N {
+			return m.CallSlice([]reflect.Value{reflect.ValueOf(tags)})[0].Interface().(N)
+		}, nil
+	}
+	return m.Interface().(F), nil
+}
+
+func getHealthcheckFunction(v reflect.Value, fieldName string) (func() error, error) {
+	name := HealthcheckFunctionPrefix + fieldName
+	m, _, err := findFunction(v, name, errorType)
+	if err != nil {
+		return nil, err
+	}
+	if m.Type().NumIn() != 0 {
+		return nil, fmt.Errorf("%s: function must take no parameters", name)
+	}
+
+	// Always call through a wrapper to convert the result to an error. See
+	// getGaugeFunction for why fields cannot be called directly.
+	return func() error {
+		err, _ := m.Call(nil)[0].Interface().(error)
+		return err
+	}, nil
+}
+
+// findGaugeFunction finds the compute method or function field for a
+// functional gauge field and checks that it returns a single value of type N.
+func findGaugeFunction[N int64 | float64](v reflect.Value, fieldName string) (string, reflect.Value, bool, error) {
 	name := GaugeFunctionPrefix + fieldName
+	m, isField, err := findFunction(v, name, reflect.TypeOf(N(0)))
+	return name, m, isField, err
+}
+
+// findFunction finds the method or function field with the given name and
+// checks that it returns a single value of type out.
+func findFunction(v reflect.Value, name string, out reflect.Type) (reflect.Value, bool, error) {
 	isField := false
 
 	m := v.Addr().MethodByName(name)
@@ -52,29 +144,19 @@ func getGaugeFunction[N int64 | float64, F func() N](v reflect.Value, fieldName
 		// A method does not exist, look for a field with the name instead
 		m = v.FieldByName(name)
 		if !m.IsValid() {
-			return nil, fmt.Errorf("%s: method or field does not exist", name)
+			return m, false, fmt.Errorf("%s: method or field does not exist", name)
 		}
 		if m.Type().Kind() != reflect.Func {
-			return nil, fmt.Errorf("%s: field must be a function", name)
+			return m, false, fmt.Errorf("%s: field must be a function", name)
 		}
 		isField = true
 	}
 
-	if m.Type().NumIn() != 0 {
-		return nil, fmt.Errorf("%s: function must take no parameters", name)
-	}
 	if m.Type().NumOut() != 1 {
-		return nil, fmt.Errorf("%s: function must return a single value", name)
-	}
-	if m.Type().Out(0) != reflect.TypeOf(N(0)) {
-		return nil, fmt.Errorf("%s: function must return a value of type %T", name, N(0))
+		return m, false, fmt.Errorf("%s: function must return a single value", name)
 	}
-
-	if isField {
-		// If the function is a field, return a wrapper that calls the current
-		// field value at the time of the the call. This is because the field
-		// value is nil when we discover the function as part of New()
-		return func() N { return m.Call(nil)[0].Interface().(N) }, nil
+	if m.Type().Out(0) != out {
+		return m, false, fmt.Errorf("%s: function must return a value of type %s", name, out)
 	}
-	return m.Interface().(F), nil
+	return m, isField, nil
 }
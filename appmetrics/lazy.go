@@ -0,0 +1,284 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	// MetricLazyTag delays creating a histogram, meter, or timer until the
+	// first update. See New.
+	MetricLazyTag = "metric-lazy"
+)
+
+// parseLazy returns the value of the "metric-lazy" tag of a field. The tag is
+// valid on histograms, meters, and timers, which allocate samples or start
+// goroutines when they are created. Tagged metrics already create instances
+// on first use, so the tag has no effect on them.
+func parseLazy(f reflect.StructField) (bool, error) {
+	s, ok := f.Tag.Lookup(MetricLazyTag)
+	if !ok {
+		return false, nil
+	}
+
+	typ := f.Type
+	if tagged, taggedType := isTagged(typ); tagged {
+		typ = taggedType
+	}
+	if typ != histogramType && typ != meterType && typ != timerType {
+		return false, fmt.Errorf("%s tag appears on type %s, which is not a histogram, meter, or timer", MetricLazyTag, f.Type)
+	}
+
+	lazy, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s tag: %q is not a boolean", MetricLazyTag, s)
+	}
+	return lazy, nil
+}
+
+// lazy creates a metric on first use. Until then, reads return the values of
+// an empty metric. After Stop, lazy metrics that were not created ignore
+// updates instead of creating the metric.
+type lazy[M any] struct {
+	newMetric func() M
+
+	m       atomic.Pointer[M]
+	mu      sync.Mutex
+	stopped bool
+}
+
+// load returns the metric if it was created.
+func (l *lazy[M]) load() (M, bool) {
+	if p := l.m.Load(); p != nil {
+		return *p, true
+	}
+	var zero M
+	return zero, false
+}
+
+// create returns the metric, creating it if needed. It returns false if the
+// metric was stopped before it was created.
+func (l *lazy[M]) create() (M, bool) {
+	if m, ok := l.load(); ok {
+		return m, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if m, ok := l.load(); ok {
+		return m, true
+	}
+	if l.stopped {
+		var zero M
+		return zero, false
+	}
+	m := l.newMetric()
+	l.m.Store(&m)
+	return m, true
+}
+
+func (l *lazy[M]) stop() {
+	l.mu.Lock()
+	l.stopped = true
+	l.mu.Unlock()
+
+	if m, ok := l.load(); ok {
+		if s, ok := any(m).(metrics.Stoppable); ok {
+			s.Stop()
+		}
+	}
+}
+
+type lazyHistogram struct {
+	lazy[metrics.Histogram]
+}
+
+func newLazyHistogram(newMetric func() metrics.Histogram) *lazyHistogram {
+	return &lazyHistogram{lazy[metrics.Histogram]{newMetric: newMetric}}
+}
+
+func (h *lazyHistogram) current() metrics.Histogram {
+	if m, ok := h.load(); ok {
+		return m
+	}
+	return metrics.NilHistogram{}
+}
+
+func (h *lazyHistogram) Clear()                             { h.current().Clear() }
+func (h *lazyHistogram) Count() int64                       { return h.current().Count() }
+func (h *lazyHistogram) Max() int64                         { return h.current().Max() }
+func (h *lazyHistogram) Mean() float64                      { return h.current().Mean() }
+func (h *lazyHistogram) Min() int64                         { return h.current().Min() }
+func (h *lazyHistogram) Percentile(p float64) float64       { return h.current().Percentile(p) }
+func (h *lazyHistogram) Percentiles(ps []float64) []float64 { return h.current().Percentiles(ps) }
+func (h *lazyHistogram) Sample() metrics.Sample             { return h.current().Sample() }
+func (h *lazyHistogram) Snapshot() metrics.Histogram        { return h.current().Snapshot() }
+func (h *lazyHistogram) StdDev() float64                    { return h.current().StdDev() }
+func (h *lazyHistogram) Sum() int64                         { return h.current().Sum() }
+func (h *lazyHistogram) Variance() float64                  { return h.current().Variance() }
+
+func (h *lazyHistogram) Update(v int64) {
+	if m, ok := h.create(); ok {
+		m.Update(v)
+	}
+}
+
+type lazyMeter struct {
+	lazy[metrics.Meter]
+}
+
+func newLazyMeter(newMetric func() metrics.Meter) *lazyMeter {
+	return &lazyMeter{lazy[metrics.Meter]{newMetric: newMetric}}
+}
+
+func (m *lazyMeter) current() metrics.Meter {
+	if meter, ok := m.load(); ok {
+		return meter
+	}
+	return metrics.NilMeter{}
+}
+
+func (m *lazyMeter) Count() int64            { return m.current().Count() }
+func (m *lazyMeter) Rate1() float64          { return m.current().Rate1() }
+func (m *lazyMeter) Rate5() float64          { return m.current().Rate5() }
+func (m *lazyMeter) Rate15() float64         { return m.current().Rate15() }
+func (m *lazyMeter) RateMean() float64       { return m.current().RateMean() }
+func (m *lazyMeter) Snapshot() metrics.Meter { return m.current().Snapshot() }
+func (m *lazyMeter) Stop()                   { m.stop() }
+
+func (m *lazyMeter) Mark(n int64) {
+	if meter, ok := m.create(); ok {
+		meter.Mark(n)
+	}
+}
+
+type lazyTimer struct {
+	lazy[metrics.Timer]
+}
+
+func newLazyTimer(newMetric func() metrics.Timer) *lazyTimer {
+	return &lazyTimer{lazy[metrics.Timer]{newMetric: newMetric}}
+}
+
+func (t *lazyTimer) current() metrics.Timer {
+	if m, ok := t.load(); ok {
+		return m
+	}
+	return metrics.NilTimer{}
+}
+
+func (t *lazyTimer) Count() int64                       { return t.current().Count() }
+func (t *lazyTimer) Max() int64                         { return t.current().Max() }
+func (t *lazyTimer) Mean() float64                      { return t.current().Mean() }
+func (t *lazyTimer) Min() int64                         { return t.current().Min() }
+func (t *lazyTimer) Percentile(p float64) float64       { return t.current().Percentile(p) }
+func (t *lazyTimer) Percentiles(ps []float64) []float64 { return t.current().Percentiles(ps) }
+func (t *lazyTimer) Rate1() float64                     { return t.current().Rate1() }
+func (t *lazyTimer) Rate5() float64                     { return t.current().Rate5() }
+func (t *lazyTimer) Rate15() float64                    { return t.current().Rate15() }
+func (t *lazyTimer) RateMean() float64                  { return t.current().RateMean() }
+func (t *lazyTimer) Snapshot() metrics.Timer            { return t.current().Snapshot() }
+func (t *lazyTimer) StdDev() float64                    { return t.current().StdDev() }
+func (t *lazyTimer) Stop()                              { t.stop() }
+func (t *lazyTimer) Sum() int64                         { return t.current().Sum() }
+func (t *lazyTimer) Variance() float64                  { return t.current().Variance() }
+
+func (t *lazyTimer) Time(f func()) {
+	if m, ok := t.create(); ok {
+		m.Time(f)
+		return
+	}
+	f()
+}
+
+func (t *lazyTimer) Update(d time.Duration) {
+	if m, ok := t.create(); ok {
+		m.Update(d)
+	}
+}
+
+func (t *lazyTimer) UpdateSince(ts time.Time) {
+	if m, ok := t.create(); ok {
+		m.UpdateSince(ts)
+	}
+}
+
+// lazyBucketedHistogram and lazyBucketedTimer are lazy metrics with buckets.
+// Before the metric is created, they report empty buckets with the bounds
+// from the "metric-buckets" tag.
+type lazyBucketedHistogram struct {
+	*lazyHistogram
+	bounds []float64
+}
+
+func (h lazyBucketedHistogram) Buckets() BucketCounts {
+	if m, ok := h.load(); ok {
+		return m.(Bucketed).Buckets()
+	}
+	return emptyBuckets(h.bounds)
+}
+
+type lazyBucketedTimer struct {
+	*lazyTimer
+	bounds []float64
+}
+
+func (t lazyBucketedTimer) Buckets() BucketCounts {
+	if m, ok := t.load(); ok {
+		return m.(Bucketed).Buckets()
+	}
+	return emptyBuckets(t.bounds)
+}
+
+func emptyBuckets(bounds []float64) BucketCounts {
+	return BucketCounts{Bounds: bounds, Counts: make([]uint64, len(bounds))}
+}
+
+// withLazy returns the metric for a field with the "metric-lazy" tag, which
+// creates the metric returned by newMetric on first use.
+func withLazy[M any](f metricField, newMetric func() M) (any, error) {
+	var bounds []float64
+	if tag := f.Tag.Get(MetricBucketsTag); tag != "" {
+		var err error
+		if bounds, err = parseBuckets(tag); err != nil {
+			return nil, err
+		}
+	}
+
+	switch newMetric := any(newMetric).(type) {
+	case func() metrics.Histogram:
+		if bounds != nil {
+			return lazyBucketedHistogram{newLazyHistogram(newMetric), bounds}, nil
+		}
+		return newLazyHistogram(newMetric), nil
+	case func() metrics.Meter:
+		return newLazyMeter(newMetric), nil
+	case func() metrics.Timer:
+		if bounds != nil {
+			return lazyBucketedTimer{newLazyTimer(newMetric), bounds}, nil
+		}
+		return newLazyTimer(newMetric), nil
+	}
+	return nil, fmt.Errorf("%s tag is not supported on type %s", MetricLazyTag, f.Type)
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type LazyMetrics struct {
+	Uploads metrics.Meter     `metric:"uploads" metric-lazy:"true"`
+	Latency metrics.Timer     `metric:"latency" metric-lazy:"true" metric-buckets:"0.1,1"`
+	Size    metrics.Histogram `metric:"size" metric-lazy:"true"`
+	Eager   metrics.Meter     `metric:"eager" metric-lazy:"false"`
+}
+
+func TestLazy(t *testing.T) {
+	r := metrics.NewRegistry()
+	m := New[LazyMetrics]()
+	Register(r, m)
+
+	isCreated := func(metric any) bool {
+		switch l := metric.(type) {
+		case *lazyMeter:
+			_, ok := l.load()
+			return ok
+		case lazyBucketedTimer:
+			_, ok := l.load()
+			return ok
+		case *lazyHistogram:
+			_, ok := l.load()
+			return ok
+		}
+		t.Fatalf("unexpected metric type %T", metric)
+		return false
+	}
+
+	assert.False(t, isCreated(m.Uploads))
+	assert.False(t, isCreated(m.Latency))
+	assert.False(t, isCreated(m.Size))
+	assert.IsType(t, &metrics.StandardMeter{}, m.Eager)
+
+	assert.Equal(t, int64(0), r.Get("uploads").(metrics.Meter).Snapshot().Count())
+	assert.Equal(t, []float64{0.1, 1}, r.Get("latency").(Bucketed).Buckets().Bounds, "buckets should be reported before the first update")
+	assert.False(t, isCreated(m.Uploads), "reads should not create the metric")
+
+	m.Uploads.Mark(2)
+	m.Latency.Update(500 * time.Millisecond)
+	m.Size.Update(10)
+
+	assert.True(t, isCreated(m.Uploads))
+	assert.Equal(t, int64(2), r.Get("uploads").(metrics.Meter).Count())
+	assert.Equal(t, []uint64{0, 1}, r.Get("latency").(Bucketed).Buckets().Counts)
+	assert.Equal(t, int64(10), r.Get("size").(metrics.Histogram).Snapshot().Max())
+
+	stopped := New[LazyMetrics]()
+	stopped.Uploads.Stop()
+	stopped.Uploads.Mark(1)
+	assert.False(t, isCreated(stopped.Uploads), "stopped metrics should not be created")
+
+	called := false
+	stopped.Latency.Stop()
+	stopped.Latency.Time(func() { called = true })
+	assert.True(t, called, "stopped timers should still call the function")
+
+	_, err := NewE[struct {
+		Count metrics.Counter `metric:"count" metric-lazy:"true"`
+	}]()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a histogram, meter, or timer")
+
+	_, err = NewE[struct {
+		Uploads metrics.Meter `metric:"uploads" metric-lazy:"yes please"`
+	}]()
+	assert.Error(t, err)
+}
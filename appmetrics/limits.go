@@ -0,0 +1,206 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	MetricMaxTagsTag = "metric-max-tags"
+	MetricTagTTLTag  = "metric-tag-ttl"
+)
+
+const (
+	MetricsKeyOverflowTags = "appmetrics.tags.overflow"
+	MetricsKeyEvictedTags  = "appmetrics.tags.evicted"
+)
+
+// OverflowTag is the tag used for Tagged metrics that receive new tags after
+// reaching the limit set by the "metric-max-tags" tag.
+const OverflowTag = "overflow"
+
+// tagLimits are the limits on the series of a Tagged metric.
+type tagLimits struct {
+	max int
+	lru bool
+	ttl time.Duration
+	now func() time.Time
+}
+
+func (l tagLimits) enabled() bool {
+	return l.max > 0 || l.ttl > 0
+}
+
+// taggedSeries tracks a series of a Tagged metric with limits. Different
+// tags may produce the same series, for example invalid tags in strict mode,
+// so each series records all of the cache keys that refer to it.
+type taggedSeries struct {
+	keys []string
+	used *atomic.Int64
+}
+
+// parseTagLimits returns the limits from the values of the "metric-max-tags"
+// and "metric-tag-ttl" tags.
+func parseTagLimits(maxTags, ttl string) (tagLimits, error) {
+	l := tagLimits{now: time.Now}
+
+	if maxTags != "" {
+		parts := strings.Split(maxTags, ",")
+		if len(parts) > 2 {
+			return l, fmt.Errorf("invalid %s tag: too many values", MetricMaxTagsTag)
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || n <= 0 {
+			return l, fmt.Errorf("invalid %s tag: limit must be a positive integer", MetricMaxTagsTag)
+		}
+		l.max = n
+
+		if len(parts) == 2 {
+			if strings.TrimSpace(parts[1]) != "lru" {
+				return l, fmt.Errorf("invalid %s tag: unknown policy %q", MetricMaxTagsTag, parts[1])
+			}
+			l.lru = true
+		}
+	}
+
+	if ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return l, fmt.Errorf("invalid %s tag: TTL must be a positive duration", MetricTagTTLTag)
+		}
+		l.ttl = d
+	}
+
+	return l, nil
+}
+
+// registerLimits adds the counters for overflowed and evicted tags to the
+// registry.
+func (m *taggedMetric[M]) registerLimits(r metrics.Registry) {
+	tag := "metric:" + joinTags(m.baseName(), m.tags)
+	m.overflowed = metrics.GetOrRegisterCounter(TaggedName(MetricsKeyOverflowTags, tag), r)
+	m.evicted = metrics.GetOrRegisterCounter(TaggedName(MetricsKeyEvictedTags, tag), r)
+}
+
+// limitedLookup is like lookup for metrics with limits. It tracks the series
+// that are created, evicts series that expired or were least recently used,
+// and returns the overflow series if there is no room for a new series.
+func (m *taggedMetric[M]) limitedLookup(key string, tags, cleanTags []string) M {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Another call may have registered the series while waiting for the lock
+	if metric, ok := m.cached(key); ok {
+		return metric
+	}
+
+	now := m.limits.now()
+	gen := m.generation()
+
+	name, cleanTags := m.seriesName(tags, cleanTags)
+
+	// The bare metric is always registered, so it does not count as a series
+	if name == joinTags(m.baseName(), m.tags) {
+		metric := m.getOrRegister(name, cleanTags)
+		m.cache.Store(key, m.newEntry(name, metric, gen, nil))
+		return metric
+	}
+
+	s, ok := m.series[name]
+	if !ok {
+		full := m.limits.max > 0 && len(m.series) >= m.limits.max
+		if m.limits.ttl > 0 && (full || now.Sub(m.lastSweep) >= m.limits.ttl/4) {
+			m.sweep(now)
+			full = m.limits.max > 0 && len(m.series) >= m.limits.max
+		}
+		if full {
+			if !m.limits.lru {
+				m.overflowed.Inc(1)
+				return m.overflow()
+			}
+			m.evictOldest()
+		}
+
+		s = &taggedSeries{used: new(atomic.Int64)}
+		if m.series == nil {
+			m.series = make(map[string]*taggedSeries)
+		}
+		m.series[name] = s
+	}
+
+	metric := m.getOrRegister(name, cleanTags)
+	s.used.Store(now.UnixNano())
+	if !slices.Contains(s.keys, key) {
+		s.keys = append(s.keys, key)
+	}
+	m.cache.Store(key, m.newEntry(name, metric, gen, s.used))
+	return metric
+}
+
+// overflow returns the series that reports values for tags that exceed the
+// limit. It is not cached so that tags get their own series once there is
+// room for them.
+func (m *taggedMetric[M]) overflow() M {
+	tags := cleanAndSortTags(append(m.tags[:len(m.tags):len(m.tags)], OverflowTag))
+	return m.getOrRegister(joinTags(m.baseName(), tags), tags)
+}
+
+// sweep evicts all series that were not used within the TTL. The caller must
+// hold the lock.
+func (m *taggedMetric[M]) sweep(now time.Time) {
+	m.lastSweep = now
+	cutoff := now.Add(-m.limits.ttl).UnixNano()
+	for name, s := range m.series {
+		if s.used.Load() <= cutoff {
+			m.evict(name, s)
+		}
+	}
+}
+
+// evictOldest evicts the least recently used series. Finding the series
+// requires a scan, but this only happens when adding a series to a metric at
+// its limit. The caller must hold the lock.
+func (m *taggedMetric[M]) evictOldest() {
+	var oldestName string
+	var oldest *taggedSeries
+	for name, s := range m.series {
+		if oldest == nil || s.used.Load() < oldest.used.Load() {
+			oldestName, oldest = name, s
+		}
+	}
+	if oldest != nil {
+		m.evict(oldestName, oldest)
+	}
+}
+
+// evict removes a series from the registry and the cache. The caller must
+// hold the lock.
+func (m *taggedMetric[M]) evict(name string, s *taggedSeries) {
+	m.r.Unregister(name)
+	for _, key := range s.keys {
+		m.cache.Delete(key)
+	}
+	delete(m.series, name)
+	m.evicted.Inc(1)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"slices"
+	"strings"
+)
+
+// TagMapping changes the tags of metric names before emitters report them,
+// so that metrics instrumented with one naming convention can match the
+// conventions of each backend. The emitters in this module accept the same
+// mapping in their configuration:
+//
+//	mapping := appmetrics.TagMapping{
+//		Rename: map[string]string{"status": "http.response.status_code"},
+//		Drop:   []string{"instance"},
+//	}
+//
+// Renamed plain tags, which use the tag as both key and value, keep the tag
+// as their value. Dropping tags can make different metrics report the same
+// series; emitters handle these like other duplicate series.
+type TagMapping struct {
+	// Rename maps tag keys to the keys reported by emitters.
+	Rename map[string]string `yaml:"rename" json:"rename"`
+
+	// Drop lists the tag keys that emitters do not report.
+	Drop []string `yaml:"drop" json:"drop"`
+}
+
+// IsZero returns true if the mapping does not change any tags.
+func (m TagMapping) IsZero() bool {
+	return len(m.Rename) == 0 && len(m.Drop) == 0
+}
+
+// Key returns the key reported for a tag key. It returns false if the key is
+// dropped.
+func (m TagMapping) Key(key string) (string, bool) {
+	if slices.Contains(m.Drop, key) {
+		return "", false
+	}
+	if k, ok := m.Rename[key]; ok {
+		return k, true
+	}
+	return key, true
+}
+
+// MapName applies the mapping to the tags of a metric name in the format
+// used by Tagged metrics, like "responses[type:api,status:200]". Tags keep
+// their order and names without tags are returned unchanged.
+func (m TagMapping) MapName(name string) string {
+	start := strings.IndexByte(name, '[')
+	if m.IsZero() || start < 0 || name[len(name)-1] != ']' {
+		return name
+	}
+
+	var tags []string
+	for _, tag := range strings.Split(name[start+1:len(name)-1], ",") {
+		k, v, ok := strings.Cut(tag, ":")
+		if !ok {
+			v = k
+		}
+
+		mapped, keep := m.Key(strings.TrimSpace(k))
+		switch {
+		case !keep:
+		case mapped == k && !ok:
+			tags = append(tags, tag)
+		default:
+			tags = append(tags, mapped+":"+v)
+		}
+	}
+	return joinTags(name[:start], tags)
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagMapping(t *testing.T) {
+	m := TagMapping{
+		Rename: map[string]string{"status": "code", "api": "endpoint"},
+		Drop:   []string{"instance"},
+	}
+
+	for name, expected := range map[string]string{
+		"requests":                             "requests",
+		"requests[status:200]":                 "requests[code:200]",
+		"requests[api,status:200]":             "requests[endpoint:api,code:200]",
+		"requests[instance:a,method:GET]":      "requests[method:GET]",
+		"requests[instance:a]":                 "requests",
+		"requests[method:GET,status:500,user]": "requests[method:GET,code:500,user]",
+		"invalid[status:200":                   "invalid[status:200",
+	} {
+		assert.Equal(t, expected, m.MapName(name), name)
+	}
+
+	k, ok := m.Key("status")
+	assert.True(t, ok)
+	assert.Equal(t, "code", k)
+	_, ok = m.Key("instance")
+	assert.False(t, ok)
+
+	assert.True(t, TagMapping{}.IsZero())
+	assert.Equal(t, "requests[status:200]", TagMapping{}.MapName("requests[status:200]"))
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MetricMetadata documents a metric. It contains the values of the
+// "metric-help" and "metric-unit" tags of a metric field.
+type MetricMetadata struct {
+	// Help describes the metric.
+	Help string
+
+	// Unit is the unit of the metric's values, like "bytes" or "requests".
+	// Units should be plural nouns. Timers always report durations, so
+	// emitters may ignore the unit of timer metrics.
+	Unit string
+}
+
+// registeredMetadata maps metric names to the MetricMetadata from registered
+// metrics structs.
+var registeredMetadata sync.Map
+
+// Metadata returns the metadata for the metrics in the struct m, keyed by
+// metric name. Metrics without the "metric-help" or "metric-unit" tags are
+// not included. Metadata panics if the struct contains invalid metric
+// definitions.
+func Metadata[M any](m *M) map[string]MetricMetadata {
+	v := reflect.ValueOf(m).Elem()
+	if v.Type().Kind() != reflect.Struct {
+		panic("appmetrics.Metadata: type is not a struct pointer")
+	}
+
+	fields, err := getMetricFields(v.Type())
+	if err != nil {
+		panic("appmetrics.Metadata: " + err.Error())
+	}
+
+	md := make(map[string]MetricMetadata)
+	for _, f := range fields {
+		if m, ok := fieldMetadata(f); ok {
+			md[f.name] = m
+		}
+	}
+	return md
+}
+
+// LookupMetadata returns the metadata for the metric with the given name from
+// the structs passed to Register. If name has tags, LookupMetadata uses the
+// metadata of the base name. Emitters use this to describe the metrics they
+// report.
+//
+// Metadata is global: if structs in different registries define metrics
+// with the same name, the metadata from the last registered struct is used.
+// Names are looked up without the prefix of a metrics.PrefixedRegistry.
+func LookupMetadata(name string) (MetricMetadata, bool) {
+	if i := strings.IndexByte(name, '['); i >= 0 && strings.HasSuffix(name, "]") {
+		name = name[:i]
+	}
+	if v, ok := registeredMetadata.Load(name); ok {
+		return v.(MetricMetadata), true
+	}
+	return MetricMetadata{}, false
+}
+
+func recordMetadata(fields []metricField, prefix string) {
+	for _, f := range fields {
+		if m, ok := fieldMetadata(f); ok {
+			registeredMetadata.Store(prefix+f.name, m)
+		}
+	}
+}
+
+func fieldMetadata(f metricField) (MetricMetadata, bool) {
+	m := MetricMetadata{
+		Help: f.Tag.Get(MetricHelpTag),
+		Unit: f.Tag.Get(MetricUnitTag),
+	}
+	return m, m != MetricMetadata{}
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type MetadataMetrics struct {
+	Uploads Tagged[metrics.Counter] `metric:"metadata.uploads" metric-tag-keys:"type" metric-help:"Uploaded files"`
+	Size    metrics.Histogram       `metric:"metadata.upload.size" metric-help:"Size of uploaded files" metric-unit:"bytes"`
+	Queue   metrics.Gauge           `metric:"metadata.queue"`
+}
+
+func TestMetadata(t *testing.T) {
+	m := New[MetadataMetrics]()
+
+	assert.Equal(t, map[string]MetricMetadata{
+		"metadata.uploads":     {Help: "Uploaded files"},
+		"metadata.upload.size": {Help: "Size of uploaded files", Unit: "bytes"},
+	}, Metadata(m))
+
+	_, ok := LookupMetadata("metadata.uploads")
+	assert.False(t, ok, "metadata exists before the struct is registered")
+
+	Register(metrics.NewRegistry(), m)
+
+	md, ok := LookupMetadata("metadata.upload.size")
+	assert.True(t, ok)
+	assert.Equal(t, MetricMetadata{Help: "Size of uploaded files", Unit: "bytes"}, md)
+
+	md, ok = LookupMetadata("metadata.uploads[type:image]")
+	assert.True(t, ok, "tagged names use the metadata of the base name")
+	assert.Equal(t, MetricMetadata{Help: "Uploaded files"}, md)
+
+	_, ok = LookupMetadata("metadata.queue")
+	assert.False(t, ok, "metrics without tags have no metadata")
+}
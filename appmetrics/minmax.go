@@ -0,0 +1,122 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"math"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// MaxGauge is a gauge that tracks the largest value passed to Update since it
+// was last reset, like the peak number of in-flight requests or the largest
+// batch in an interval. Emitters in this module report the maximum and then
+// reset the gauge with Reset, so each report covers the time since the
+// previous report. If several emitters report the same registry, they share
+// the interval.
+//
+// The gauge implements [metrics.Gauge], so emitters that do not know the type
+// report the maximum without resetting it. Max and Value return the maximum
+// and Reset returns it before resetting. All of them return zero if there
+// were no updates.
+type MaxGauge interface {
+	Max() int64
+	Reset() int64
+	Snapshot() metrics.Gauge
+	Update(int64)
+	Value() int64
+}
+
+// MinGauge is like [MaxGauge], but tracks the smallest value passed to
+// Update since it was last reset.
+type MinGauge interface {
+	Min() int64
+	Reset() int64
+	Snapshot() metrics.Gauge
+	Update(int64)
+	Value() int64
+}
+
+var (
+	maxGaugeType = reflect.TypeOf((*MaxGauge)(nil)).Elem()
+	minGaugeType = reflect.TypeOf((*MinGauge)(nil)).Elem()
+)
+
+// NewMaxGauge creates a MaxGauge with no updates.
+func NewMaxGauge() MaxGauge {
+	g := &maxGauge{extremeGauge{empty: math.MinInt64}}
+	g.value.Store(g.empty)
+	return g
+}
+
+// NewMinGauge creates a MinGauge with no updates.
+func NewMinGauge() MinGauge {
+	g := &minGauge{extremeGauge{empty: math.MaxInt64}}
+	g.value.Store(g.empty)
+	return g
+}
+
+type maxGauge struct{ extremeGauge }
+
+func (g *maxGauge) Max() int64 { return g.Value() }
+
+type minGauge struct{ extremeGauge }
+
+func (g *minGauge) Min() int64 { return g.Value() }
+
+// extremeGauge keeps the maximum or minimum of its updates. Its value is
+// empty, the smallest or largest int64, until the first update, so that
+// every value replaces it.
+type extremeGauge struct {
+	value atomic.Int64
+	empty int64
+}
+
+func (g *extremeGauge) Reset() int64 {
+	return g.report(g.value.Swap(g.empty))
+}
+
+func (g *extremeGauge) Snapshot() metrics.Gauge {
+	return metrics.GaugeSnapshot(g.Value())
+}
+
+func (g *extremeGauge) Update(v int64) {
+	for {
+		old := g.value.Load()
+		if !g.replaces(v, old) || g.value.CompareAndSwap(old, v) {
+			return
+		}
+	}
+}
+
+func (g *extremeGauge) Value() int64 {
+	return g.report(g.value.Load())
+}
+
+func (g *extremeGauge) replaces(v, old int64) bool {
+	if g.empty == math.MinInt64 {
+		return v > old
+	}
+	return v < old
+}
+
+func (g *extremeGauge) report(v int64) int64 {
+	if v == g.empty {
+		return 0
+	}
+	return v
+}
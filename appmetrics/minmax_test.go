@@ -0,0 +1,63 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinMaxGauges(t *testing.T) {
+	type M struct {
+		InFlight  MaxGauge         `metric:"requests.inflight.max"`
+		BatchSize Tagged[MaxGauge] `metric:"batch.size.max"`
+		Free      MinGauge         `metric:"pool.free.min"`
+	}
+
+	m := New[M]()
+	assert.Zero(t, m.InFlight.Value(), "gauges without updates should report zero")
+	assert.Zero(t, m.Free.Reset())
+
+	for _, v := range []int64{3, -1, 7, 5} {
+		m.InFlight.Update(v)
+		m.Free.Update(v)
+	}
+	assert.Equal(t, int64(7), m.InFlight.Max())
+	assert.Equal(t, int64(7), m.InFlight.Snapshot().Value())
+	assert.Equal(t, int64(-1), m.Free.Min())
+
+	assert.Equal(t, int64(7), m.InFlight.Reset())
+	assert.Zero(t, m.InFlight.Value(), "reset should start a new interval")
+	m.InFlight.Update(2)
+	assert.Equal(t, int64(2), m.InFlight.Value())
+
+	assert.Equal(t, int64(-1), m.Free.Reset())
+	m.Free.Update(4)
+	assert.Equal(t, int64(4), m.Free.Min())
+
+	r := metrics.NewRegistry()
+	Register(r, m)
+	m.BatchSize.Tag("queue:emails").Update(10)
+	assert.Implements(t, (*metrics.Gauge)(nil), r.Get("requests.inflight.max"))
+	assert.Implements(t, (*MinGauge)(nil), r.Get("pool.free.min"))
+	assert.Equal(t, map[string]any{"queue:emails": int64(10)}, Snapshot(m)["BatchSize"])
+	assert.Equal(t, int64(10), m.BatchSize.Tag("queue:emails").Value(), "snapshots should not reset gauges")
+
+	c := NewCatalog[M]()
+	assert.Equal(t, TypeMaxGauge, c.Metrics[0].Type)
+	assert.Equal(t, TypeMinGauge, c.Metrics[1].Type)
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"reflect"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// HistogramValue is the value of a histogram or a timer in a snapshot. Timer
+// values are durations in nanoseconds.
+type HistogramValue struct {
+	Count  int64   `json:"count"`
+	Min    int64   `json:"min"`
+	Max    int64   `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	P50    float64 `json:"p50"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+}
+
+// MeterValue is the value of a meter in a snapshot. Rates are events per
+// second.
+type MeterValue struct {
+	Count    int64   `json:"count"`
+	Rate1    float64 `json:"rate1"`
+	Rate5    float64 `json:"rate5"`
+	Rate15   float64 `json:"rate15"`
+	RateMean float64 `json:"rate_mean"`
+}
+
+// Snapshot returns the current values of the metrics in the struct m. The
+// result has the same structure as the struct: keys are field names and
+// nested structs with the "metric-prefix" tag are nested maps. Like with
+// JSON encoding, the fields of embedded structs without a prefix are added
+// to the map of the struct that embeds them.
+//
+// Counters and gauges are reported as int64 or float64 values, duration
+// gauges as time.Duration, histograms and timers as HistogramValue, and
+// meters as MeterValue. EWMA metrics report
+// their rate and healthchecks report their error message or nil if they are
+// healthy. Tagged metrics are maps
+// from the joined tags of each instance, like "method:GET,status:200", to the
+// value of that instance. Tagged metrics only report instances created by Tag
+// after the struct was registered.
+//
+// Snapshot panics if the struct contains invalid metric definitions.
+func Snapshot[M any](m *M) map[string]any {
+	v := reflect.ValueOf(m).Elem()
+	if v.Type().Kind() != reflect.Struct {
+		panic("appmetrics.Snapshot: type is not a struct pointer")
+	}
+
+	fields, err := getMetricFields(v.Type())
+	if err != nil {
+		panic("appmetrics.Snapshot: " + err.Error())
+	}
+
+	root := make(map[string]any)
+	for _, f := range fields {
+		metric := fieldMetric(v.FieldByIndex(f.Index))
+		if metric == nil {
+			continue
+		}
+
+		parent := root
+		typ := v.Type()
+		for _, i := range f.Index[:len(f.Index)-1] {
+			sf := typ.Field(i)
+			typ = sf.Type
+			if _, ok := sf.Tag.Lookup(MetricPrefixTag); !ok && sf.Anonymous {
+				continue
+			}
+
+			child, ok := parent[sf.Name].(map[string]any)
+			if !ok {
+				child = make(map[string]any)
+				parent[sf.Name] = child
+			}
+			parent = child
+		}
+
+		if t, ok := metric.(interface {
+			instances(fn func(tags string, metric any))
+		}); ok {
+			values := make(map[string]any)
+			t.instances(func(tags string, metric any) {
+				values[tags] = metricValue(metric)
+			})
+			parent[f.Name] = values
+		} else {
+			parent[f.Name] = metricValue(metric)
+		}
+	}
+	return root
+}
+
+func metricValue(metric any) any {
+	switch m := metric.(type) {
+	case metrics.Counter:
+		return m.Count()
+	case CounterFloat64:
+		return m.Count()
+	case DurationGauge:
+		return m.Duration()
+	case metrics.Gauge:
+		return m.Value()
+	case metrics.GaugeFloat64:
+		return m.Value()
+	case FunctionalGauge:
+		return m.Value()
+	case FunctionalGaugeFloat64:
+		return m.Value()
+	case metrics.Histogram:
+		return histogramValue(m.Snapshot())
+	case metrics.Timer:
+		return histogramValue(m.Snapshot())
+	case metrics.EWMA:
+		return m.Rate()
+	case metrics.Healthcheck:
+		if err := m.Error(); err != nil {
+			return err.Error()
+		}
+		return nil
+	case metrics.Meter:
+		s := m.Snapshot()
+		return MeterValue{
+			Count:    s.Count(),
+			Rate1:    s.Rate1(),
+			Rate5:    s.Rate5(),
+			Rate15:   s.Rate15(),
+			RateMean: s.RateMean(),
+		}
+	}
+	return nil
+}
+
+func histogramValue(s interface {
+	Count() int64
+	Min() int64
+	Max() int64
+	Mean() float64
+	StdDev() float64
+	Percentiles([]float64) []float64
+}) HistogramValue {
+	ps := s.Percentiles([]float64{0.5, 0.95, 0.99})
+	return HistogramValue{
+		Count:  s.Count(),
+		Min:    s.Min(),
+		Max:    s.Max(),
+		Mean:   s.Mean(),
+		StdDev: s.StdDev(),
+		P50:    ps[0],
+		P95:    ps[1],
+		P99:    ps[2],
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type SnapshotMetrics struct {
+	SimpleMetrics
+	Latency metrics.Histogram `metric:"latency" metric-sample:"uniform,10"`
+	Events  metrics.Meter     `metric:"events"`
+	Primary DBMetrics         `metric-prefix:"db.primary."`
+}
+
+func TestSnapshot(t *testing.T) {
+	m := New[SnapshotMetrics]()
+	m.Primary.ComputeConnections = func() int64 { return 3 }
+	Register(metrics.NewRegistry(), m)
+
+	m.FooCount.Inc(2)
+	m.ActiveWorkers.Update(5)
+	m.Latency.Update(10)
+	m.Latency.Update(20)
+	m.Events.Mark(4)
+	m.Primary.Queries.Inc(1)
+	m.Primary.Errors.Tag("timeout").Inc(3)
+	m.Primary.Errors.Tag("reason:closed", "retry:false").Inc(1)
+
+	s := Snapshot(m)
+
+	assert.Equal(t, int64(2), s["FooCount"], "embedded struct fields should be flattened")
+	assert.Equal(t, int64(0), s["BarCount"])
+	assert.Equal(t, int64(5), s["ActiveWorkers"])
+	assert.Equal(t, HistogramValue{Count: 2, Min: 10, Max: 20, Mean: 15, StdDev: 5, P50: 15, P95: 20, P99: 20}, s["Latency"])
+	assert.Equal(t, int64(4), s["Events"].(MeterValue).Count)
+
+	assert.Equal(t, map[string]any{
+		"Queries":     int64(1),
+		"Connections": int64(3),
+		"Errors": map[string]any{
+			"timeout":                   int64(3),
+			"reason:closed,retry:false": int64(1),
+		},
+	}, s["Primary"])
+}
@@ -15,9 +15,16 @@
 package appmetrics
 
 import (
+	"fmt"
 	"reflect"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 
 	"github.com/rcrowley/go-metrics"
 )
@@ -26,6 +33,19 @@ var (
 	strSliceType = reflect.TypeOf([]string(nil))
 )
 
+// DefaultMaxTagLength is the maximum length of tag values in strict mode if
+// no other length is set.
+const DefaultMaxTagLength = 128
+
+// InvalidTag is the tag used for metrics that receive invalid tags in strict
+// mode.
+const InvalidTag = "invalid"
+
+// maxRawKeys limits the number of distinct tag lists that a Tagged metric
+// remembers for fast lookups. Tag lists after the limit still work, but
+// allocate on each call.
+const maxRawKeys = 4096
+
 // Tagged is a metric with dynamic tags. The type M must be one of the
 // supported metric types. Tags are strings that can either be plain values or
 // key-value pairs where the key and value are separated by a colon.
@@ -42,6 +62,24 @@ var (
 //		return m.Responses.Tag("type:" + type, "status:" + strconv.Itoa(stats))
 //	}
 //
+// Instead of writing these functions by hand, you can list the tag keys and
+// their types in the "metric-tag-keys" tag and use the appmetricsgen command
+// to generate them:
+//
+//	struct M {
+//		Responses Tagged[metrics.Counter] `metric:"responses" metric-tag-keys:"type,status:int"`
+//	}
+//
+//	//go:generate go run github.com/palantir/go-baseapp/appmetrics/cmd/appmetricsgen -type M
+//
+// To reject unexpected tag keys, list the allowed keys in the
+// "metric-allowed-tag-keys" tag. Tag reports calls with other keys, or with
+// plain values, to a series with the single tag "invalid", as in strict mode:
+//
+//	struct M {
+//		Responses Tagged[metrics.Counter] `metric:"responses" metric-allowed-tag-keys:"type,status"`
+//	}
+//
 // Tags are added as a suffix to the base metric name: the tags are joined by
 // commas, then surrounded by square brackets. Using the previous example, the
 // full metric names might be:
@@ -49,8 +87,31 @@ var (
 //   - "responses[type:api,status:200]"
 //   - "responses[type:file,status:404]"
 //
+// Tag values that contain commas or square brackets produce names that
+// emitters cannot parse. Register metrics with WithStrictTags to reject these
+// values or with WithSanitizedTags to replace the invalid characters.
+//
 // Note that each unique combination of tags produces a separate metric in the
-// registry. For this reason avoid tags that can take many values, like IDs.
+// registry. For this reason avoid tags that can take many values, like IDs,
+// or limit the values with BoundedTag.
+//
+// To protect against unexpected tag values, set the "metric-max-tags" tag to
+// limit the number of series. Once a metric reaches the limit, Tag returns an
+// overflow series that has the static tags and OverflowTag for any new
+// combination of tags and increments the "appmetrics.tags.overflow" counter
+// for the metric. Add ",lru" to the limit to evict the least recently used
+// series instead:
+//
+//	struct M {
+//		Requests Tagged[metrics.Counter] `metric:"requests" metric-max-tags:"500"`
+//		Clients  Tagged[metrics.Counter] `metric:"clients" metric-max-tags:"1000,lru" metric-tag-ttl:"1h"`
+//	}
+//
+// The "metric-tag-ttl" tag evicts series that were not used for a duration.
+// Evicted series are removed from the registry, so emitters stop reporting
+// them, and the "appmetrics.tags.evicted" counter for the metric counts them.
+// If a series is used after eviction, Tag registers a new metric with a zero
+// value; updates that race with the eviction may be lost.
 type Tagged[M any] interface {
 	// Tag returns an instance of the metric that reports with the given tags.
 	// Tags may be either plain values or key-value pairs separated by a colon.
@@ -58,45 +119,411 @@ type Tagged[M any] interface {
 	Tag(tags ...string) M
 }
 
+// TagSet is a fixed list of tags for a Tagged metric. Looking up the metric
+// for a TagSet does not allocate once the metric exists, so use TagSets for
+// tags that are known in advance and used on hot paths:
+//
+//	ok := appmetrics.NewTagSet(m.Responses, "status:200")
+//	...
+//	ok.Metric().Inc(1)
+//
+// Tagged.Tag also avoids most work for repeated tags, but the variadic
+// arguments of each call usually allocate.
+type TagSet[M any] struct {
+	t    Tagged[M]
+	tags []string
+}
+
+// NewTagSet returns a TagSet for the metric with the given tags.
+func NewTagSet[M any](t Tagged[M], tags ...string) TagSet[M] {
+	return TagSet[M]{t: t, tags: slices.Clone(tags)}
+}
+
+// Metric returns the instance of the metric for the tags. It is equivalent
+// to calling Tag with the tags.
+func (s TagSet[M]) Metric() M {
+	return s.t.Tag(s.tags...)
+}
+
 type taggedMetric[M any] struct {
 	r         metrics.Registry
+	tracked   *TrackedRegistry
 	name      string
 	newMetric func() M
+	opts      registerOptions
+
+	// tags are the static tags of the metric, from the "metric-tags" tag of
+	// the field and from the WithTags option. fieldTags are only the tags of
+	// the field.
+	tags      []string
+	fieldTags []string
+
+	// newTagged, if set, creates metrics that depend on their tags, like
+	// tagged functional gauges. It is used instead of newMetric.
+	newTagged func(tags []string) M
+
+	// allowedKeys, if set, are the sorted keys that tags may use
+	allowedKeys []string
+
+	// cache maps keys built from the cleaned and sorted tags to the
+	// *taggedEntry of metrics that were already registered. This avoids
+	// building names and validating tags for repeated calls with the same
+	// tags.
+	cache sync.Map
+
+	// rawKeys maps hashes of the tags passed to Tag, before they are cleaned
+	// and sorted, to their keys in the cache, so that repeated calls with the
+	// same tags find cached metrics without allocating. The map is replaced,
+	// not modified, when adding keys, so readers do not lock; writers hold
+	// rawMu. New tag lists are rare once a metric is in use.
+	rawMu   sync.Mutex
+	rawKeys atomic.Pointer[map[uint64][]rawKey]
+	rawLen  int
+
+	// limits and series implement the "metric-max-tags" and
+	// "metric-tag-ttl" tags. series is guarded by mu.
+	limits     tagLimits
+	mu         sync.Mutex
+	series     map[string]*taggedSeries
+	lastSweep  time.Time
+	overflowed metrics.Counter
+	evicted    metrics.Counter
+}
+
+type rawKey struct {
+	tags []string
+	key  any
+}
+
+type taggedEntry[M any] struct {
+	name   string
+	metric M
+
+	// generation is the generation of the TrackedRegistry when the metric
+	// was last known to be registered. It is only set for tracked registries.
+	generation atomic.Uint64
+
+	// used is the time the series was last used in Unix nanoseconds. It is
+	// only set for metrics with limits.
+	used *atomic.Int64
 }
 
 func (m *taggedMetric[M]) Tag(tags ...string) M {
 	if m.r == nil {
+		tags = m.withStaticTags(tags)
+		if m.newTagged != nil {
+			return m.newTagged(cleanAndSortTags(tags))
+		}
 		return m.newMetric()
 	}
 
-	var name strings.Builder
-	name.WriteString(m.name)
+	// The fast path finds metrics for tags seen before without cleaning,
+	// sorting, or copying the tags
+	hash := hashTags(tags)
+	if key, ok := m.rawKey(hash, tags); ok {
+		if metric, ok := m.cached(key); ok {
+			return metric
+		}
+	}
+
+	raw := tags
+	tags = m.withStaticTags(tags)
+	cleanTags := cleanAndSortTags(tags)
+	key := tagCacheKey(cleanTags)
+	m.storeRawKey(hash, raw, key)
+
+	if metric, ok := m.cached(key); ok {
+		return metric
+	}
+
+	if m.limits.enabled() {
+		return m.limitedLookup(key, tags, cleanTags)
+	}
+
+	gen := m.generation()
+	name, metric := m.lookup(tags, cleanTags)
+	m.cache.Store(key, m.newEntry(name, metric, gen, nil))
+	return metric
+}
+
+// generation returns the generation of the registry, or zero if the registry
+// is not a TrackedRegistry. Load the generation before looking up a metric in
+// the registry, so that an entry never has a newer generation than the
+// registry state it was created from.
+func (m *taggedMetric[M]) generation() uint64 {
+	if m.tracked != nil {
+		return m.tracked.generation.Load()
+	}
+	return 0
+}
 
-	if tags := cleanAndSortTags(tags); len(tags) > 0 {
-		name.WriteString("[")
-		for i, t := range tags {
-			if i > 0 {
-				name.WriteString(",")
+func (m *taggedMetric[M]) newEntry(name string, metric M, gen uint64, used *atomic.Int64) *taggedEntry[M] {
+	e := &taggedEntry[M]{name: name, metric: metric, used: used}
+	e.generation.Store(gen)
+	return e
+}
+
+// withStaticTags returns the static tags of the metric followed by tags.
+func (m *taggedMetric[M]) withStaticTags(tags []string) []string {
+	if len(m.tags) > 0 {
+		return append(m.tags[:len(m.tags):len(m.tags)], tags...)
+	}
+	return tags
+}
+
+// cached returns the cached metric for the key. It checks that cached metrics
+// are still registered, in case they were removed from the registry after
+// they were cached. With a TrackedRegistry, it only checks the registry if
+// metrics were removed since the last check.
+func (m *taggedMetric[M]) cached(key any) (M, bool) {
+	var zero M
+
+	v, ok := m.cache.Load(key)
+	if !ok {
+		return zero, false
+	}
+	e := v.(*taggedEntry[M])
+
+	if m.tracked != nil {
+		if gen := m.tracked.generation.Load(); e.generation.Load() != gen {
+			if m.r.Get(e.name) != any(e.metric) {
+				return zero, false
 			}
-			name.WriteString(t)
+			e.generation.Store(gen)
+		}
+	} else if m.r.Get(e.name) != any(e.metric) {
+		return zero, false
+	}
+
+	if e.used != nil {
+		e.used.Store(m.limits.now().UnixNano())
+	}
+	return e.metric, true
+}
+
+// rawKey returns the cache key for tags that were passed to Tag before.
+func (m *taggedMetric[M]) rawKey(hash uint64, tags []string) (any, bool) {
+	if keys := m.rawKeys.Load(); keys != nil {
+		for _, k := range (*keys)[hash] {
+			if slices.Equal(k.tags, tags) {
+				return k.key, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func (m *taggedMetric[M]) storeRawKey(hash uint64, tags []string, key string) {
+	m.rawMu.Lock()
+	defer m.rawMu.Unlock()
+	if m.rawLen >= maxRawKeys {
+		return
+	}
+
+	var keys map[uint64][]rawKey
+	if old := m.rawKeys.Load(); old != nil {
+		for _, k := range (*old)[hash] {
+			if slices.Equal(k.tags, tags) {
+				return
+			}
+		}
+		keys = make(map[uint64][]rawKey, len(*old)+1)
+		for h, ks := range *old {
+			keys[h] = ks
+		}
+	} else {
+		keys = make(map[uint64][]rawKey)
+	}
+
+	// Copy the slice for the hash, since readers may still use the old one
+	keys[hash] = append(slices.Clip(keys[hash]), rawKey{tags: slices.Clone(tags), key: key})
+	m.rawKeys.Store(&keys)
+	m.rawLen++
+}
+
+// hashTags returns the FNV-1a hash of a list of tags. Each tag is followed by
+// a byte that cannot appear in valid UTF-8 so that no two lists have the same
+// input.
+func hashTags(tags []string) uint64 {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+	h := uint64(offset)
+	for _, t := range tags {
+		for i := 0; i < len(t); i++ {
+			h ^= uint64(t[i])
+			h *= prime
 		}
-		name.WriteString("]")
+		h ^= 0xff
+		h *= prime
 	}
+	return h
+}
 
-	return m.r.GetOrRegister(name.String(), m.newMetric).(M)
+// instances calls fn with the tags and the metric for each instance that is
+// cached and still registered.
+func (m *taggedMetric[M]) instances(fn func(tags string, metric any)) {
+	if m.r == nil {
+		return
+	}
+	m.cache.Range(func(_, v any) bool {
+		e := v.(*taggedEntry[M])
+		if m.r.Get(e.name) == any(e.metric) {
+			tags := strings.TrimPrefix(e.name, m.baseName())
+			tags = strings.TrimSuffix(strings.TrimPrefix(tags, "["), "]")
+			fn(tags, e.metric)
+		}
+		return true
+	})
 }
 
-func (m *taggedMetric[M]) register(r metrics.Registry) {
+// tagCacheKey returns a key that uniquely identifies a list of tags. Each tag
+// is prefixed by its length so that no two lists produce the same key.
+func tagCacheKey(tags []string) string {
+	n := 0
+	for _, t := range tags {
+		n += len(t) + 4
+	}
+
+	b := make([]byte, 0, n)
+	for _, t := range tags {
+		b = strconv.AppendInt(b, int64(len(t)), 10)
+		b = append(b, ':')
+		b = append(b, t...)
+	}
+	return string(b)
+}
+
+// lookup returns the name and the registered metric for the tags. The
+// original tags are only used to report invalid tags.
+func (m *taggedMetric[M]) lookup(tags, cleanTags []string) (string, M) {
+	name, cleanTags := m.seriesName(tags, cleanTags)
+	return name, m.getOrRegister(name, cleanTags)
+}
+
+// seriesName returns the name of the series for the tags and the tags used
+// in the name, which may differ from the tags if they are sanitized, if they
+// are invalid in strict mode, or if they use keys that are not allowed.
+func (m *taggedMetric[M]) seriesName(tags, cleanTags []string) (string, []string) {
+	if m.opts.sanitizeTags {
+		cleanTags = sanitizeTags(cleanTags, m.opts.maxTagLength)
+	}
+
+	var err error
+	if m.opts.strictTags {
+		err = validateTags(cleanTags, m.opts.maxTagLength)
+	}
+	if err == nil && m.allowedKeys != nil {
+		err = checkTagKeys(cleanTags, m.allowedKeys, m.tags)
+	}
+
+	switch {
+	case err != nil:
+		if m.opts.onInvalidTag != nil {
+			m.opts.onInvalidTag(m.baseName(), tags, err)
+		}
+		cleanTags = []string{InvalidTag}
+	case m.opts.strictTags:
+		cleanTags = dedupTags(cleanTags)
+	}
+
+	return joinTags(m.baseName(), cleanTags), cleanTags
+}
+
+// accepts returns true if the metric is an instance of the metric type.
+func (m *taggedMetric[M]) accepts(metric any) bool {
+	_, ok := metric.(M)
+	return ok
+}
+
+// baseName returns the name of the metric without tags, including the
+// prefix set by WithPrefix.
+func (m *taggedMetric[M]) baseName() string {
+	return m.opts.prefix + m.name
+}
+
+func (m *taggedMetric[M]) getOrRegister(name string, cleanTags []string) M {
+	if m.newTagged != nil {
+		return m.r.GetOrRegister(name, func() M { return m.newTagged(cleanTags) }).(M)
+	}
+	return m.r.GetOrRegister(name, m.newMetric).(M)
+}
+
+// TaggedName returns the name of the metric with the given base name and
+// tags, using the same format as Tagged metrics. It is useful for code that
+// looks up tagged metrics in registries that are not known in advance, like
+// the registry in a request context:
+//
+//	metrics.GetOrRegisterCounter(appmetrics.TaggedName("responses", "status:200"), registry)
+//
+// Tags are cleaned and sorted like the tags passed to Tagged.Tag, but are not
+// validated.
+func TaggedName(name string, tags ...string) string {
+	return joinTags(name, cleanAndSortTags(tags))
+}
+
+func joinTags(name string, tags []string) string {
+	if len(tags) == 0 {
+		return name
+	}
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteString("[")
+	for i, t := range tags {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(t)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// init sets the definition of the metric from the field.
+func (m *taggedMetric[M]) init(f metricField) {
+	m.name = f.name
+	m.tags = f.tags
+	m.fieldTags = f.tags
+	m.limits = f.limits
+	m.allowedKeys = f.allowedKeys
+}
+
+func (m *taggedMetric[M]) register(r metrics.Registry, opts registerOptions) {
 	m.r = r
+	m.tracked, _ = r.(*TrackedRegistry)
+	m.opts = opts
+	m.tags = mergeTags(m.fieldTags, opts.tags)
+	m.cache.Clear()
+
+	m.rawMu.Lock()
+	m.rawKeys.Store(nil)
+	m.rawLen = 0
+	m.rawMu.Unlock()
+
+	m.mu.Lock()
+	m.series = nil
+	m.mu.Unlock()
+
+	if m.limits.enabled() {
+		m.registerLimits(r)
+	}
 
-	// Add the bare metric immediately so emitters can find it in the registry
-	r.GetOrRegister(m.name, m.newMetric)
+	// Add the bare metric immediately so emitters can find it in the registry,
+	// unless the metric needs tags to compute its value
+	if m.newTagged == nil {
+		r.GetOrRegister(joinTags(m.baseName(), m.tags), m.newMetric)
+	}
 }
 
 // isTagged determines if typ is a Tagged instantiation and returns the
 // parameter type. As of Go 1.20, the reflect package does not support direct
 // access to type parameters.
 func isTagged(typ reflect.Type) (bool, reflect.Type) {
+	if t, ok := asTypedTagged(typ); ok {
+		return isTagged(t.taggedType())
+	}
 	if typ.Kind() != reflect.Interface {
 		return false, nil
 	}
@@ -127,3 +554,213 @@ func cleanAndSortTags(tags []string) []string {
 	sort.Strings(cleanTags)
 	return cleanTags
 }
+
+// ValidateTags checks that tags are valid in strict mode. Tags are valid if:
+//
+//   - Keys start with a letter or an underscore and contain only letters,
+//     digits, and underscores
+//   - Values, including plain values without a key, are non-empty, at most
+//     maxLength bytes long, and do not contain whitespace, control
+//     characters, commas, or square brackets
+//   - No key appears more than once with different values
+//
+// Leading and trailing whitespace is ignored. If maxLength is zero or
+// negative, values may have any length.
+func ValidateTags(tags []string, maxLength int) error {
+	return validateTags(cleanAndSortTags(tags), maxLength)
+}
+
+func validateTags(tags []string, maxLength int) error {
+	values := make(map[string]string, len(tags))
+	for _, t := range tags {
+		key, value, hasValue := strings.Cut(t, ":")
+		if !hasValue {
+			// plain values have no key and follow the rules for values
+			key, value = "", t
+		} else if !isValidTagKey(key) {
+			return fmt.Errorf("tag %q: invalid key", t)
+		}
+
+		if value == "" {
+			return fmt.Errorf("tag %q: empty value", t)
+		}
+		if maxLength > 0 && len(value) > maxLength {
+			return fmt.Errorf("tag %q: value is longer than %d bytes", t, maxLength)
+		}
+		if strings.IndexFunc(value, isInvalidTagValueRune) >= 0 {
+			return fmt.Errorf("tag %q: value contains invalid characters", t)
+		}
+
+		if !hasValue {
+			continue
+		}
+
+		if v, ok := values[key]; ok && v != value {
+			return fmt.Errorf("tag %q: conflicts with existing value for key %q", t, key)
+		}
+		values[key] = value
+	}
+	return nil
+}
+
+func isValidTagKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case '0' <= c && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isInvalidTagValueRune(c rune) bool {
+	switch c {
+	case ',', '[', ']':
+		return true
+	}
+	return unicode.IsSpace(c) || unicode.IsControl(c)
+}
+
+// SanitizeTags returns tags with the characters that are invalid in strict
+// mode replaced by underscores. Invalid characters in keys are replaced, keys
+// that start with a digit get an underscore prefix, and tags with an empty key
+// become plain values. In values, whitespace, control characters, commas, and
+// square brackets are replaced and values longer than maxLength bytes are
+// truncated. If maxLength is zero or negative, values may have any length.
+//
+// Like the tags passed to Tagged.Tag, the tags are cleaned and sorted. The
+// returned tags are valid unless they have empty values or conflicting values
+// for the same key.
+func SanitizeTags(tags []string, maxLength int) []string {
+	return sanitizeTags(cleanAndSortTags(tags), maxLength)
+}
+
+// sanitizeTags sanitizes cleaned and sorted tags. It returns tags unchanged if
+// they are already valid, and a new sorted slice otherwise.
+func sanitizeTags(tags []string, maxLength int) []string {
+	var out []string
+	for i, t := range tags {
+		s := sanitizeTag(t, maxLength)
+		if s != t && out == nil {
+			out = make([]string, i, len(tags))
+			copy(out, tags)
+		}
+		if out != nil {
+			out = append(out, s)
+		}
+	}
+	if out == nil {
+		return tags
+	}
+	return cleanAndSortTags(out)
+}
+
+func sanitizeTag(t string, maxLength int) string {
+	key, value, hasValue := strings.Cut(t, ":")
+	if !hasValue {
+		key, value = "", t
+	}
+
+	if key != "" && !isValidTagKey(key) {
+		key = strings.Map(func(c rune) rune {
+			if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+				return c
+			}
+			return '_'
+		}, key)
+		if '0' <= key[0] && key[0] <= '9' {
+			key = "_" + key
+		}
+	}
+
+	if strings.IndexFunc(value, isInvalidTagValueRune) >= 0 {
+		value = strings.Map(func(c rune) rune {
+			if isInvalidTagValueRune(c) {
+				return '_'
+			}
+			return c
+		}, value)
+	}
+	if maxLength > 0 && len(value) > maxLength {
+		value = strings.ToValidUTF8(value[:maxLength], "")
+	}
+
+	if key == "" {
+		return value
+	}
+	return key + ":" + value
+}
+
+// checkTagKeys checks that all tags have one of the allowed keys or are one
+// of the static tags. The allowed keys and static tags must be sorted.
+func checkTagKeys(tags, allowed, static []string) error {
+	for _, t := range tags {
+		if _, ok := slices.BinarySearch(static, t); ok {
+			continue
+		}
+		key, _, hasValue := strings.Cut(t, ":")
+		if !hasValue {
+			return fmt.Errorf("tag %q: value has no key", t)
+		}
+		if _, ok := slices.BinarySearch(allowed, key); !ok {
+			return fmt.Errorf("tag %q: key is not allowed", t)
+		}
+	}
+	return nil
+}
+
+// parseAllowedTagKeys returns the sorted keys from the
+// "metric-allowed-tag-keys" tag of a field, or nil if the field does not have
+// the tag.
+func parseAllowedTagKeys(f reflect.StructField) ([]string, error) {
+	s, ok := f.Tag.Lookup(MetricAllowedTagKeysTag)
+	if !ok {
+		return nil, nil
+	}
+	if tagged, _ := isTagged(f.Type); !tagged {
+		return nil, fmt.Errorf("%s tag appears on non-tagged type %s", MetricAllowedTagKeysTag, f.Type)
+	}
+	if _, typed := asTypedTagged(f.Type); typed {
+		return nil, fmt.Errorf("%s tag appears on typed tagged type %s", MetricAllowedTagKeysTag, f.Type)
+	}
+
+	keys := parseTagKeys(s)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("invalid %s tag: no keys", MetricAllowedTagKeysTag)
+	}
+	for _, k := range keys {
+		if !isValidTagKey(k) {
+			return nil, fmt.Errorf("invalid %s tag: invalid key %q", MetricAllowedTagKeysTag, k)
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys), nil
+}
+
+// mergeTags returns the cleaned, sorted, and deduplicated union of two lists
+// of cleaned and sorted tags.
+func mergeTags(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	if len(a) == 0 {
+		return b
+	}
+	return dedupTags(cleanAndSortTags(append(slices.Clone(a), b...)))
+}
+
+// dedupTags removes duplicate tags from a sorted slice.
+func dedupTags(tags []string) []string {
+	out := tags[:0]
+	for i, t := range tags {
+		if i == 0 || t != tags[i-1] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"sync/atomic"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// TrackedRegistry is a metrics.Registry that counts calls to Unregister and
+// UnregisterAll. Tagged metrics registered with a TrackedRegistry use the
+// count to find series they looked up before without checking that the
+// series are still in the registry, so repeated calls to Tagged.Tag do not
+// lock the registry or, for prefixed registries, build prefixed names.
+//
+// Tagged metrics registered with other registries check the registry on each
+// call, because they cannot detect when metrics are removed.
+//
+// Always remove metrics through the TrackedRegistry. If metrics are removed
+// from the underlying registry directly, Tagged metrics may keep returning
+// series that are no longer registered.
+type TrackedRegistry struct {
+	metrics.Registry
+	generation atomic.Uint64
+}
+
+// NewTrackedRegistry returns a TrackedRegistry that stores metrics in r.
+func NewTrackedRegistry(r metrics.Registry) *TrackedRegistry {
+	return &TrackedRegistry{Registry: r}
+}
+
+func (r *TrackedRegistry) Unregister(name string) {
+	r.Registry.Unregister(name)
+	r.generation.Add(1)
+}
+
+func (r *TrackedRegistry) UnregisterAll() {
+	r.Registry.UnregisterAll()
+	r.generation.Add(1)
+}
@@ -0,0 +1,155 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// TagType is a type for the values of a tag with a fixed key. Use tag types
+// with Tagged1, Tagged2, and Tagged3 to declare the keys of a metric once and
+// let the compiler check the tag values at each call site.
+//
+// A tag type is usually a named string or integer type with a TagKey method
+// that returns a constant:
+//
+//	type Status int
+//
+//	func (Status) TagKey() string { return "status" }
+//
+// TagKey is called on the zero value of the type. Values are formatted with
+// their String method if the type implements [fmt.Stringer] and with the
+// default format of their underlying type otherwise.
+type TagType interface {
+	TagKey() string
+}
+
+// Tagged1 is a Tagged metric with one tag of type A. Like Tagged, the type M
+// must be one of the supported metric types. For example:
+//
+//	type Method string
+//
+//	func (Method) TagKey() string { return "method" }
+//
+//	struct M {
+//		Requests appmetrics.Tagged1[metrics.Counter, Method] `metric:"requests"`
+//	}
+//
+//	m.Requests.Tag(Method(r.Method)).Inc(1)
+//
+// Typed tagged metrics are structs that must be created by New; their zero
+// value panics if used. They support the same struct tags as Tagged metrics,
+// except for "metric-tag-keys", because the tag types define the keys.
+type Tagged1[M any, A TagType] struct {
+	t Tagged[M]
+}
+
+// Tag returns an instance of the metric that reports with the given tag.
+func (t Tagged1[M, A]) Tag(a A) M {
+	return t.t.Tag(typedTag(a))
+}
+
+func (t Tagged1[M, A]) tagged() any            { return t.t }
+func (t *Tagged1[M, A]) setTagged(v any)       { t.t = v.(Tagged[M]) }
+func (Tagged1[M, A]) taggedType() reflect.Type { return reflect.TypeOf((*Tagged[M])(nil)).Elem() }
+func (Tagged1[M, A]) tagKeys() []string        { return []string{typedKey[A]()} }
+
+// Tagged2 is a Tagged metric with two tags of types A and B. See Tagged1 for
+// an example.
+type Tagged2[M any, A, B TagType] struct {
+	t Tagged[M]
+}
+
+// Tag returns an instance of the metric that reports with the given tags.
+func (t Tagged2[M, A, B]) Tag(a A, b B) M {
+	return t.t.Tag(typedTag(a), typedTag(b))
+}
+
+func (t Tagged2[M, A, B]) tagged() any            { return t.t }
+func (t *Tagged2[M, A, B]) setTagged(v any)       { t.t = v.(Tagged[M]) }
+func (Tagged2[M, A, B]) taggedType() reflect.Type { return reflect.TypeOf((*Tagged[M])(nil)).Elem() }
+func (Tagged2[M, A, B]) tagKeys() []string        { return []string{typedKey[A](), typedKey[B]()} }
+
+// Tagged3 is a Tagged metric with three tags of types A, B, and C. See
+// Tagged1 for an example.
+type Tagged3[M any, A, B, C TagType] struct {
+	t Tagged[M]
+}
+
+// Tag returns an instance of the metric that reports with the given tags.
+func (t Tagged3[M, A, B, C]) Tag(a A, b B, c C) M {
+	return t.t.Tag(typedTag(a), typedTag(b), typedTag(c))
+}
+
+func (t Tagged3[M, A, B, C]) tagged() any            { return t.t }
+func (t *Tagged3[M, A, B, C]) setTagged(v any)       { t.t = v.(Tagged[M]) }
+func (Tagged3[M, A, B, C]) taggedType() reflect.Type { return reflect.TypeOf((*Tagged[M])(nil)).Elem() }
+func (Tagged3[M, A, B, C]) tagKeys() []string {
+	return []string{typedKey[A](), typedKey[B](), typedKey[C]()}
+}
+
+// typedTagged is implemented by Tagged1, Tagged2, and Tagged3. The metric is
+// stored in an unexported field, so these methods allow New and other
+// functions to access it with reflection.
+type typedTagged interface {
+	// tagged returns the Tagged metric or nil if it was not created
+	tagged() any
+
+	// taggedType returns the type of the Tagged metric
+	taggedType() reflect.Type
+
+	// tagKeys returns the keys of the tag types
+	tagKeys() []string
+}
+
+// asTypedTagged returns the typedTagged value for typ if it is an
+// instantiation of Tagged1, Tagged2, or Tagged3.
+func asTypedTagged(typ reflect.Type) (typedTagged, bool) {
+	if typ.Kind() != reflect.Struct {
+		return nil, false
+	}
+	t, ok := reflect.Zero(typ).Interface().(typedTagged)
+	return t, ok
+}
+
+func typedKey[T TagType]() string {
+	var zero T
+	return zero.TagKey()
+}
+
+func typedTag[T TagType](v T) string {
+	return v.TagKey() + ":" + typedValue(v)
+}
+
+func typedValue(v any) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool())
+	}
+	return fmt.Sprint(v)
+}
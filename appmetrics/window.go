@@ -0,0 +1,179 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// WindowSample is a [metrics.Sample] that contains the values recorded in a
+// sliding time window, like the last minute. Unlike an exponentially-decaying
+// sample, values older than the window do not affect the statistics, so
+// percentiles reflect only recent behavior.
+//
+// The sample keeps at most a fixed number of values. If more values are
+// recorded within the window, the sample keeps the most recent values.
+type WindowSample struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu     sync.Mutex
+	count  int64
+	values []windowValue // ring buffer of values in time order
+	head   int
+	size   int
+}
+
+type windowValue struct {
+	t time.Time
+	v int64
+}
+
+// NewWindowSample returns a sample with the values recorded in the last
+// window, keeping at most reservoirSize values.
+func NewWindowSample(window time.Duration, reservoirSize int) metrics.Sample {
+	if metrics.UseNilMetrics {
+		return metrics.NilSample{}
+	}
+	return &WindowSample{
+		window: window,
+		now:    time.Now,
+		values: make([]windowValue, reservoirSize),
+	}
+}
+
+// Clear removes all values from the sample.
+func (s *WindowSample) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count = 0
+	s.head = 0
+	s.size = 0
+}
+
+// Count returns the number of values recorded, including values that are no
+// longer in the window.
+func (s *WindowSample) Count() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count
+}
+
+// Max returns the maximum value in the window.
+func (s *WindowSample) Max() int64 {
+	return metrics.SampleMax(s.Values())
+}
+
+// Mean returns the mean of the values in the window.
+func (s *WindowSample) Mean() float64 {
+	return metrics.SampleMean(s.Values())
+}
+
+// Min returns the minimum value in the window.
+func (s *WindowSample) Min() int64 {
+	return metrics.SampleMin(s.Values())
+}
+
+// Percentile returns an arbitrary percentile of the values in the window.
+func (s *WindowSample) Percentile(p float64) float64 {
+	return metrics.SamplePercentile(s.Values(), p)
+}
+
+// Percentiles returns a slice of arbitrary percentiles of the values in the
+// window.
+func (s *WindowSample) Percentiles(ps []float64) []float64 {
+	return metrics.SamplePercentiles(s.Values(), ps)
+}
+
+// Size returns the number of values in the window.
+func (s *WindowSample) Size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	return s.size
+}
+
+// Snapshot returns a read-only copy of the sample.
+func (s *WindowSample) Snapshot() metrics.Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	return metrics.NewSampleSnapshot(s.count, s.copyValues())
+}
+
+// StdDev returns the standard deviation of the values in the window.
+func (s *WindowSample) StdDev() float64 {
+	return metrics.SampleStdDev(s.Values())
+}
+
+// Sum returns the sum of the values in the window.
+func (s *WindowSample) Sum() int64 {
+	return metrics.SampleSum(s.Values())
+}
+
+// Update records a new value.
+func (s *WindowSample) Update(v int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.count++
+	if len(s.values) == 0 {
+		return
+	}
+	s.expire()
+
+	i := (s.head + s.size) % len(s.values)
+	s.values[i] = windowValue{t: s.now(), v: v}
+	if s.size < len(s.values) {
+		s.size++
+	} else {
+		// The buffer is full, so the new value replaced the oldest value
+		s.head = (s.head + 1) % len(s.values)
+	}
+}
+
+// Values returns a copy of the values in the window.
+func (s *WindowSample) Values() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expire()
+	return s.copyValues()
+}
+
+// Variance returns the variance of the values in the window.
+func (s *WindowSample) Variance() float64 {
+	return metrics.SampleVariance(s.Values())
+}
+
+// expire removes values that are older than the window. The caller must
+// hold the lock.
+func (s *WindowSample) expire() {
+	cutoff := s.now().Add(-s.window)
+	for s.size > 0 && !s.values[s.head].t.After(cutoff) {
+		s.head = (s.head + 1) % len(s.values)
+		s.size--
+	}
+}
+
+func (s *WindowSample) copyValues() []int64 {
+	values := make([]int64, s.size)
+	for i := range values {
+		values[i] = s.values[(s.head+i)%len(s.values)].v
+	}
+	return values
+}
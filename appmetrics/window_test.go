@@ -0,0 +1,73 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package appmetrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowSample(t *testing.T) {
+	now := time.Now()
+	newSample := func(size int) *WindowSample {
+		s := NewWindowSample(time.Minute, size).(*WindowSample)
+		s.now = func() time.Time { return now }
+		return s
+	}
+
+	t.Run("expire", func(t *testing.T) {
+		s := newSample(10)
+		s.Update(100)
+		now = now.Add(30 * time.Second)
+		s.Update(1)
+		s.Update(2)
+
+		assert.Equal(t, []int64{100, 1, 2}, s.Values())
+		assert.Equal(t, int64(100), s.Max())
+
+		now = now.Add(45 * time.Second)
+		assert.Equal(t, []int64{1, 2}, s.Values(), "old value should expire")
+		assert.Equal(t, int64(2), s.Max())
+		assert.Equal(t, int64(3), s.Count(), "count should include expired values")
+
+		now = now.Add(time.Minute)
+		assert.Equal(t, 0, s.Size())
+		assert.Equal(t, int64(0), s.Max())
+	})
+
+	t.Run("reservoir", func(t *testing.T) {
+		s := newSample(3)
+		for i := int64(1); i <= 5; i++ {
+			s.Update(i)
+		}
+		assert.Equal(t, []int64{3, 4, 5}, s.Values(), "sample should keep the most recent values")
+		assert.Equal(t, 4.0, s.Percentile(0.5))
+
+		snapshot := s.Snapshot()
+		s.Update(6)
+		assert.Equal(t, []int64{3, 4, 5}, snapshot.Values())
+		assert.Equal(t, int64(5), snapshot.Count())
+	})
+
+	t.Run("clear", func(t *testing.T) {
+		s := newSample(3)
+		s.Update(1)
+		s.Clear()
+		assert.Equal(t, int64(0), s.Count())
+		assert.Empty(t, s.Values())
+	})
+}
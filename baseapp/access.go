@@ -0,0 +1,255 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/hlog"
+	"goji.io"
+)
+
+const (
+	MetricsKeyAccessGranted = "server.access.granted"
+	MetricsKeyAccessDenied  = "server.access.denied"
+)
+
+// Identity is the authenticated user or client of a request. Authentication
+// middleware, like the session handling of an application that logs users in
+// with the oauth2 or saml packages, stores the identity in the request
+// context with WithIdentity so that routes can require permissions.
+type Identity struct {
+	// Subject identifies the user or client, like the subject of a token or
+	// the name ID of a SAML assertion.
+	Subject string
+
+	// Permissions are permissions granted directly to the identity.
+	Permissions []string
+
+	// Groups are the groups or roles of the identity, which an Authorizer
+	// may map to permissions.
+	Groups []string
+}
+
+type identityCtxKey struct{}
+
+// WithIdentity stores the identity of the request in the context.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityCtxKey{}, id)
+}
+
+// IdentityFromContext returns the identity stored in the context.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityCtxKey{}).(Identity)
+	return id, ok
+}
+
+// Authorizer decides if an identity has a permission. Authorize returns an
+// error only if it cannot make a decision, for example because a policy
+// service is unavailable.
+type Authorizer interface {
+	Authorize(ctx context.Context, id Identity, permission string) (bool, error)
+}
+
+// AuthorizerFunc is a function that implements Authorizer.
+type AuthorizerFunc func(ctx context.Context, id Identity, permission string) (bool, error)
+
+func (fn AuthorizerFunc) Authorize(ctx context.Context, id Identity, permission string) (bool, error) {
+	return fn(ctx, id, permission)
+}
+
+// NewGroupAuthorizer returns an Authorizer that allows identities that have
+// a permission directly or through one of their groups. The map contains the
+// permissions granted to each group.
+//
+// Granted permissions may end with "*" to match all permissions with the
+// same prefix. For example, "reports:*" grants both "reports:read" and
+// "reports:write", and "*" grants all permissions.
+func NewGroupAuthorizer(groups map[string][]string) Authorizer {
+	return AuthorizerFunc(func(ctx context.Context, id Identity, permission string) (bool, error) {
+		if matchPermission(id.Permissions, permission) {
+			return true, nil
+		}
+		for _, g := range id.Groups {
+			if matchPermission(groups[g], permission) {
+				return true, nil
+			}
+		}
+		return false, nil
+	})
+}
+
+func matchPermission(granted []string, permission string) bool {
+	for _, g := range granted {
+		if prefix, ok := strings.CutSuffix(g, "*"); ok {
+			if strings.HasPrefix(permission, prefix) {
+				return true
+			}
+		} else if g == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteOption configures a route registered with Server.Handle.
+type RouteOption func(*route)
+
+type route struct {
+	permissions []string
+	any         bool
+}
+
+// RequirePerm requires the identity of the request to have all of the
+// permissions.
+func RequirePerm(permissions ...string) RouteOption {
+	return func(r *route) {
+		r.permissions = append(r.permissions, permissions...)
+	}
+}
+
+// RequireAnyPerm requires the identity of the request to have at least one
+// of the permissions. It replaces the permissions set by other options.
+func RequireAnyPerm(permissions ...string) RouteOption {
+	return func(r *route) {
+		r.permissions = permissions
+		r.any = true
+	}
+}
+
+// Handle registers the handler for requests that match the pattern on the
+// server's mux, applying the route options. If the options require
+// permissions, Handle panics if the server does not have an Authorizer.
+func (s *Server) Handle(p goji.Pattern, h http.Handler, opts ...RouteOption) {
+	var rt route
+	for _, opt := range opts {
+		opt(&rt)
+	}
+	if len(rt.permissions) > 0 {
+		if s.authorizer == nil {
+			panic("baseapp: route requires permissions, but the server has no Authorizer")
+		}
+		h = rt.handler(s.authorizer, h)
+	}
+	s.mux.Handle(p, h)
+}
+
+// AuthorizeHandler returns middleware that checks the permissions required by
+// the options before calling the next handler. Use it to require permissions
+// for routes that are not registered with Server.Handle, like the routes of
+// a sub-mux.
+//
+// Requests without an identity in the context receive a 401 response and
+// requests whose identity lacks the permissions receive a 403 response,
+// both as problem details. Each decision is added to the request with
+// AddRequestEvent as an "access_granted" or "access_denied" event, denials
+// are logged, and the "server.access.granted" and "server.access.denied"
+// counters count decisions, tagged with the permission.
+func AuthorizeHandler(a Authorizer, opts ...RouteOption) func(http.Handler) http.Handler {
+	var rt route
+	for _, opt := range opts {
+		opt(&rt)
+	}
+	return func(next http.Handler) http.Handler {
+		return rt.handler(a, next)
+	}
+}
+
+func (rt route) handler(a Authorizer, next http.Handler) http.Handler {
+	if len(rt.permissions) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, ok := IdentityFromContext(r.Context())
+		if !ok {
+			deny(w, r, id, rt.required(), http.StatusUnauthorized, "The request is not authenticated")
+			return
+		}
+
+		granted, missing, err := rt.check(r.Context(), a, id)
+		if err != nil {
+			HandleRouteError(w, r, err)
+			return
+		}
+		if missing != "" {
+			detail := fmt.Sprintf("The request requires the %q permission", missing)
+			if rt.any && len(rt.permissions) > 1 {
+				detail = fmt.Sprintf("The request requires one of the %q permissions", rt.permissions)
+			}
+			deny(w, r, id, missing, http.StatusForbidden, detail)
+			return
+		}
+
+		for _, p := range granted {
+			CounterFromCtx(r.Context(), MetricsKeyAccessGranted, "permission:"+p).Inc(1)
+			AddRequestEvent(r.Context(), "access_granted", "subject", id.Subject, "permission", p)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// check returns the permissions that allow the request or, if the identity
+// does not have the required permissions, a description of the missing
+// permissions.
+func (rt route) check(ctx context.Context, a Authorizer, id Identity) (granted []string, missing string, err error) {
+	for _, p := range rt.permissions {
+		allowed, err := a.Authorize(ctx, id, p)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "failed to authorize permission %q", p)
+		}
+		switch {
+		case allowed && rt.any:
+			return []string{p}, "", nil
+		case allowed:
+			granted = append(granted, p)
+		case !rt.any:
+			return nil, p, nil
+		}
+	}
+	if rt.any {
+		return nil, rt.required(), nil
+	}
+	return granted, "", nil
+}
+
+// required describes the permissions of the route for denials. Routes that
+// accept any of several permissions join them with "|".
+func (rt route) required() string {
+	return strings.Join(rt.permissions, "|")
+}
+
+func deny(w http.ResponseWriter, r *http.Request, id Identity, permission string, status int, detail string) {
+	reason := "forbidden"
+	if status == http.StatusUnauthorized {
+		reason = "unauthenticated"
+	}
+
+	CounterFromCtx(r.Context(), MetricsKeyAccessDenied, "permission:"+permission, "reason:"+reason).Inc(1)
+	AddRequestEvent(r.Context(), "access_denied", "subject", id.Subject, "permission", permission, "reason", reason)
+	hlog.FromRequest(r).Info().
+		Str("subject", id.Subject).
+		Str("permission", permission).
+		Str("reason", reason).
+		Msg("Access denied")
+
+	WriteProblem(w, r, Problem{
+		Status: status,
+		Detail: detail,
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goji.io/pat"
+)
+
+func TestGroupAuthorizer(t *testing.T) {
+	a := NewGroupAuthorizer(map[string][]string{
+		"analysts": {"reports:read"},
+		"admins":   {"reports:*"},
+	})
+
+	for _, tc := range []struct {
+		id         Identity
+		permission string
+		allowed    bool
+	}{
+		{Identity{Groups: []string{"analysts"}}, "reports:read", true},
+		{Identity{Groups: []string{"analysts"}}, "reports:write", false},
+		{Identity{Groups: []string{"admins"}}, "reports:write", true},
+		{Identity{Groups: []string{"admins"}}, "users:write", false},
+		{Identity{Permissions: []string{"users:read"}}, "users:read", true},
+		{Identity{Permissions: []string{"*"}}, "users:write", true},
+		{Identity{Groups: []string{"unknown"}}, "reports:read", false},
+	} {
+		allowed, err := a.Authorize(context.Background(), tc.id, tc.permission)
+		require.NoError(t, err)
+		assert.Equal(t, tc.allowed, allowed, "%+v: %s", tc.id, tc.permission)
+	}
+}
+
+func TestServerHandle(t *testing.T) {
+	registry := metrics.NewRegistry()
+	authorizer := NewGroupAuthorizer(map[string][]string{
+		"analysts": {"reports:read"},
+		"admins":   {"reports:*", "users:*"},
+	})
+
+	// the identity middleware authenticates requests using the group in the
+	// X-Group header, which is enough to test authorization
+	identity := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithMetricsCtx(r.Context(), registry)
+			if group := r.Header.Get("X-Group"); group != "" {
+				ctx = WithIdentity(ctx, Identity{Subject: "user", Groups: []string{group}})
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	s, err := NewServer(HTTPConfig{}, WithMiddleware(identity), WithAuthorizer(authorizer))
+	require.NoError(t, err)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	s.Handle(pat.Get("/reports"), ok, RequirePerm("reports:read"))
+	s.Handle(pat.Get("/users"), ok, RequirePerm("reports:read", "users:read"))
+	s.Handle(pat.Get("/search"), ok, RequireAnyPerm("users:read", "reports:read"))
+	s.Handle(pat.Get("/public"), ok)
+
+	serve := func(path, group string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if group != "" {
+			r.Header.Set("X-Group", group)
+		}
+		w := httptest.NewRecorder()
+		s.Mux().ServeHTTP(w, r)
+		return w
+	}
+	count := func(name string) int64 {
+		if c, ok := registry.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve("/public", "").Code)
+	assert.Equal(t, http.StatusNoContent, serve("/reports", "analysts").Code)
+	assert.Equal(t, http.StatusNoContent, serve("/users", "admins").Code)
+	assert.Equal(t, http.StatusNoContent, serve("/search", "analysts").Code)
+	assert.Equal(t, int64(3), count("server.access.granted[permission:reports:read]"))
+	assert.Equal(t, int64(1), count("server.access.granted[permission:users:read]"))
+
+	w := serve("/reports", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, int64(1), count("server.access.denied[permission:reports:read,reason:unauthenticated]"))
+
+	w = serve("/users", "analysts")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "users:read", "the problem should name the missing permission")
+	assert.Equal(t, int64(1), count("server.access.denied[permission:users:read,reason:forbidden]"))
+
+	w = serve("/search", "guests")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, int64(1), count("server.access.denied[permission:users:read|reports:read,reason:forbidden]"))
+}
+
+func TestAuthorizeHandler(t *testing.T) {
+	failing := AuthorizerFunc(func(context.Context, Identity, string) (bool, error) {
+		return false, errors.New("policy service unavailable")
+	})
+	handler := AuthorizeHandler(failing, RequirePerm("reports:read"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(WithIdentity(r.Context(), Identity{Subject: "user"}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.False(t, strings.Contains(w.Body.String(), "policy"), "errors should not be sent to clients")
+
+	s, err := NewServer(HTTPConfig{})
+	require.NoError(t, err)
+	assert.Panics(t, func() {
+		s.Handle(pat.Get("/"), handler, RequirePerm("reports:read"))
+	}, "routes should not require permissions without an authorizer")
+}
@@ -0,0 +1,233 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admin provides an authenticated JSON API to inspect and change
+// runtime settings of a server, like the log level or maintenance mode.
+//
+// Each setting is a named value that implements Setting. The API exposes all
+// registered settings:
+//
+//   - GET {prefix}/settings returns an object with the value of each setting
+//   - GET {prefix}/settings/{name} returns the value of one setting
+//   - PUT {prefix}/settings/{name} replaces the value of one setting
+//
+// The package provides settings for the global log level, a maintenance mode
+// switch, and path-based ignore rules. Other components, like rate limiters,
+// can expose their parameters by implementing Setting or with NewSetting.
+//
+// Every change is written to the request logger as an audit event that
+// includes the setting, the principal that made the change, and the old and
+// new values. Audit events have no level, so they are logged even if the log
+// level is changed to hide other messages.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/hlog"
+	"goji.io"
+	"goji.io/pat"
+)
+
+const (
+	MetricsKeyChanges = "admin.changes"
+
+	// maxBodySize limits the size of new setting values.
+	maxBodySize = 1 << 20
+)
+
+// Setting is a value that can be changed at runtime. Implementations must be
+// safe for concurrent use.
+type Setting interface {
+	// Get returns the current value. The value must support encoding as
+	// JSON.
+	Get() any
+
+	// Set replaces the current value with the JSON-encoded value in data. It
+	// returns an error if the value is invalid.
+	Set(data []byte) error
+}
+
+// NewSetting returns a Setting that decodes values as T and uses the get and
+// set functions to access the value.
+func NewSetting[T any](get func() T, set func(T) error) Setting {
+	return &funcSetting[T]{get: get, set: set}
+}
+
+type funcSetting[T any] struct {
+	get func() T
+	set func(T) error
+}
+
+func (s *funcSetting[T]) Get() any {
+	return s.get()
+}
+
+func (s *funcSetting[T]) Set(data []byte) error {
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Wrap(err, "invalid value")
+	}
+	return s.set(v)
+}
+
+// Authorizer checks that a request may use the admin API. It returns the
+// principal that made the request, which is included in audit logs, or an
+// error if the request is not allowed.
+type Authorizer func(r *http.Request) (principal string, err error)
+
+// TokenAuthorizer returns an Authorizer that requires a bearer token in the
+// Authorization header. The principal is always "token".
+func TokenAuthorizer(token string) Authorizer {
+	return func(r *http.Request) (string, error) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			return "", errors.New("invalid admin token")
+		}
+		return "token", nil
+	}
+}
+
+// Handler serves the admin API. Mount it on a server with a wildcard
+// pattern:
+//
+//	h := admin.New(admin.TokenAuthorizer(token))
+//	h.Register("log_level", admin.LogLevel())
+//	server.Mux().Handle(pat.New("/admin/*"), h)
+type Handler struct {
+	authorize Authorizer
+	mux       *goji.Mux
+
+	mu       sync.Mutex
+	settings map[string]Setting
+}
+
+// New returns a Handler that uses authorize to check requests. All requests
+// are rejected if authorize is nil.
+func New(authorize Authorizer) *Handler {
+	h := &Handler{
+		authorize: authorize,
+		mux:       goji.SubMux(),
+		settings:  make(map[string]Setting),
+	}
+	h.mux.HandleFunc(pat.Get("/settings"), h.list)
+	h.mux.HandleFunc(pat.Get("/settings/:name"), h.get)
+	h.mux.HandleFunc(pat.Put("/settings/:name"), h.set)
+	return h
+}
+
+// Register adds a setting to the API. It replaces any existing setting with
+// the same name.
+func (h *Handler) Register(name string, s Setting) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.settings[name] = s
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.authorize == nil {
+		baseapp.WriteProblem(w, r, baseapp.Problem{Status: http.StatusUnauthorized})
+		return
+	}
+	principal, err := h.authorize(r)
+	if err != nil {
+		hlog.FromRequest(r).Warn().Err(err).Msg("Rejected unauthorized admin request")
+		baseapp.WriteProblem(w, r, baseapp.Problem{Status: http.StatusUnauthorized})
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	h.mux.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), principal)))
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	names := make([]string, 0, len(h.settings))
+	for name := range h.settings {
+		names = append(names, name)
+	}
+	h.mu.Unlock()
+	sort.Strings(names)
+
+	values := make(map[string]any, len(names))
+	for _, name := range names {
+		if s, ok := h.setting(name); ok {
+			values[name] = s.Get()
+		}
+	}
+	_ = baseapp.WriteJSONResponse(w, r, http.StatusOK, values)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	s, ok := h.setting(pat.Param(r, "name"))
+	if !ok {
+		baseapp.WriteProblem(w, r, baseapp.Problem{Status: http.StatusNotFound, Detail: "unknown setting"})
+		return
+	}
+	_ = baseapp.WriteJSONResponse(w, r, http.StatusOK, s.Get())
+}
+
+func (h *Handler) set(w http.ResponseWriter, r *http.Request) {
+	name := pat.Param(r, "name")
+	s, ok := h.setting(name)
+	if !ok {
+		baseapp.WriteProblem(w, r, baseapp.Problem{Status: http.StatusNotFound, Detail: "unknown setting"})
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		baseapp.WriteProblem(w, r, baseapp.Problem{Status: http.StatusBadRequest, Detail: "failed to read request body"})
+		return
+	}
+
+	// Serialize changes so the old value in the audit log is accurate
+	h.mu.Lock()
+	old := s.Get()
+	err = s.Set(data)
+	updated := s.Get()
+	h.mu.Unlock()
+
+	if err != nil {
+		baseapp.WriteProblem(w, r, baseapp.Problem{Status: http.StatusBadRequest, Detail: err.Error()})
+		return
+	}
+
+	// Log without a level so that audit events are never filtered, even if
+	// the change raised the log level
+	hlog.FromRequest(r).Log().
+		Bool("audit", true).
+		Str("setting", name).
+		Str("principal", principalFromCtx(r.Context())).
+		Interface("old", old).
+		Interface("new", updated).
+		Msg("Admin setting changed")
+	baseapp.CounterFromCtx(r.Context(), MetricsKeyChanges, "setting:"+name).Inc(1)
+
+	_ = baseapp.WriteJSONResponse(w, r, http.StatusOK, updated)
+}
+
+func (h *Handler) setting(name string) (Setting, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.settings[name]
+	return s, ok
+}
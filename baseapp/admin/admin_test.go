@@ -0,0 +1,124 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+	"github.com/stretchr/testify/assert"
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestHandler(t *testing.T) {
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+
+	maintenance := &Maintenance{}
+	rules := &IgnoreRules{}
+
+	h := New(TokenAuthorizer("secret"))
+	h.Register("log_level", LogLevel())
+	h.Register("maintenance", maintenance.Setting())
+	h.Register("ignore_rules", rules.Setting())
+
+	mux := goji.NewMux()
+	mux.Use(hlog.NewHandler(logger))
+	mux.Use(maintenance.Handler("/admin/"))
+	mux.Handle(pat.New("/admin/*"), h)
+	mux.HandleFunc(pat.Get("/api"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	defer zerolog.SetGlobalLevel(zerolog.GlobalLevel())
+
+	serve := func(method, path, token, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("unauthorized", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/admin/settings", "", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/admin/settings", "wrong", "").Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		zerolog.SetGlobalLevel(zerolog.InfoLevel)
+
+		w := serve(http.MethodGet, "/admin/settings", "secret", "")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"log_level": "info", "maintenance": {"enabled": false}, "ignore_rules": {}}`, w.Body.String())
+	})
+
+	t.Run("set", func(t *testing.T) {
+		logs.Reset()
+
+		w := serve(http.MethodPut, "/admin/settings/log_level", "secret", `"warn"`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+		assert.Contains(t, logs.String(), `"setting":"log_level","principal":"token","old":"info","new":"warn"`)
+
+		w = serve(http.MethodPut, "/admin/settings/log_level", "secret", `"loud"`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, zerolog.WarnLevel, zerolog.GlobalLevel())
+
+		w = serve(http.MethodPut, "/admin/settings/unknown", "secret", `1`)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("maintenance", func(t *testing.T) {
+		w := serve(http.MethodPut, "/admin/settings/maintenance", "secret", `{"enabled": true, "message": "upgrading"}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = serve(http.MethodGet, "/api", "", "")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "upgrading")
+
+		w = serve(http.MethodPut, "/admin/settings/maintenance", "secret", `{"enabled": false}`)
+		assert.Equal(t, http.StatusOK, w.Code, "admin API should be exempt from maintenance mode")
+		assert.Equal(t, http.StatusOK, serve(http.MethodGet, "/api", "", "").Code)
+	})
+}
+
+func TestIgnoreRules(t *testing.T) {
+	rules := &IgnoreRules{}
+	rules.SetRules(map[string]baseapp.IgnoreRule{
+		"/api/":       {Metrics: true},
+		"/api/health": {Logs: true, Metrics: true},
+	})
+
+	var ignored bool
+	handler := baseapp.NewIgnoreHandler()(rules.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ignored = baseapp.IsIgnored(r, baseapp.IgnoreRule{Logs: true})
+	})))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	assert.True(t, ignored, "longest prefix should apply")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/things", nil))
+	assert.False(t, ignored)
+}
@@ -0,0 +1,191 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rs/zerolog"
+)
+
+type principalCtxKey struct{}
+
+func withPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+func principalFromCtx(ctx context.Context) string {
+	p, _ := ctx.Value(principalCtxKey{}).(string)
+	return p
+}
+
+// LogLevel returns a Setting for the global zerolog level, as a level name
+// like "debug". The global level is the minimum level for all loggers, so
+// lowering it does not enable messages below the level of a logger created
+// with a higher level.
+func LogLevel() Setting {
+	return NewSetting(
+		func() string { return zerolog.GlobalLevel().String() },
+		func(s string) error {
+			level, err := zerolog.ParseLevel(s)
+			if err != nil {
+				return err
+			}
+			zerolog.SetGlobalLevel(level)
+			return nil
+		},
+	)
+}
+
+// MaintenanceState is the value of the Maintenance setting.
+type MaintenanceState struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message,omitempty"`
+}
+
+// Maintenance is a maintenance mode switch. When enabled, its middleware
+// rejects requests with status 503 and the maintenance message.
+type Maintenance struct {
+	mu    sync.RWMutex
+	state MaintenanceState
+}
+
+// State returns the current maintenance state.
+func (m *Maintenance) State() MaintenanceState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state
+}
+
+// SetState changes the maintenance state.
+func (m *Maintenance) SetState(s MaintenanceState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = s
+}
+
+// Setting returns a Setting for the maintenance state.
+func (m *Maintenance) Setting() Setting {
+	return NewSetting(m.State, func(s MaintenanceState) error {
+		m.SetState(s)
+		return nil
+	})
+}
+
+// Handler returns middleware that rejects requests while maintenance mode is
+// enabled. Requests with paths that start with one of the exempt prefixes,
+// like the admin API or health checks, are always allowed.
+func (m *Maintenance) Handler(exempt ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s := m.State()
+			if s.Enabled && !hasAnyPrefix(r.URL.Path, exempt) {
+				w.Header().Set("Retry-After", "60")
+				baseapp.WriteProblem(w, r, baseapp.Problem{
+					Status: http.StatusServiceUnavailable,
+					Detail: s.Message,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// IgnoreRules applies baseapp.IgnoreRule values to requests by path prefix,
+// so operators can silence noisy endpoints without a deploy.
+type IgnoreRules struct {
+	mu    sync.RWMutex
+	rules map[string]baseapp.IgnoreRule
+}
+
+// Rules returns the current rules, keyed by path prefix.
+func (ir *IgnoreRules) Rules() map[string]baseapp.IgnoreRule {
+	ir.mu.RLock()
+	defer ir.mu.RUnlock()
+
+	rules := make(map[string]baseapp.IgnoreRule, len(ir.rules))
+	for k, v := range ir.rules {
+		rules[k] = v
+	}
+	return rules
+}
+
+// SetRules replaces the current rules.
+func (ir *IgnoreRules) SetRules(rules map[string]baseapp.IgnoreRule) {
+	copied := make(map[string]baseapp.IgnoreRule, len(rules))
+	for k, v := range rules {
+		copied[k] = v
+	}
+
+	ir.mu.Lock()
+	defer ir.mu.Unlock()
+	ir.rules = copied
+}
+
+// Setting returns a Setting for the rules.
+func (ir *IgnoreRules) Setting() Setting {
+	return NewSetting(ir.Rules, func(rules map[string]baseapp.IgnoreRule) error {
+		ir.SetRules(rules)
+		return nil
+	})
+}
+
+// Handler returns middleware that calls baseapp.Ignore with the rule for the
+// longest prefix that matches the request path. It must be used after the
+// middleware returned by baseapp.NewIgnoreHandler.
+func (ir *IgnoreRules) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rule, ok := ir.match(r.URL.Path); ok {
+				baseapp.Ignore(r, rule)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (ir *IgnoreRules) match(path string) (baseapp.IgnoreRule, bool) {
+	ir.mu.RLock()
+	defer ir.mu.RUnlock()
+
+	prefixes := make([]string, 0, len(ir.rules))
+	for p := range ir.rules {
+		if strings.HasPrefix(path, p) {
+			prefixes = append(prefixes, p)
+		}
+	}
+	if len(prefixes) == 0 {
+		return baseapp.IgnoreRule{}, false
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+	return ir.rules[prefixes[0]], true
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
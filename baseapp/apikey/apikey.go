@@ -0,0 +1,401 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package apikey authenticates machine clients with API keys.
+//
+// An API key has two parts: a public ID, used to find the key in a Store, and
+// a secret. Stores only contain a hash of the secret, so a leaked store does
+// not reveal usable keys. Keys are formatted as "<id>.<secret>", where the ID
+// may start with a prefix, like "svc_", that makes keys easy to recognize in
+// secret scanners.
+//
+// Issue creates keys and Rotate replaces them while allowing a grace period
+// for clients to switch. The middleware returned by NewHandler verifies the
+// key of each request and stores the matching Record in the request context.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	MetricsKeyRequests = "server.apikey.requests"
+	MetricsKeyFailures = "server.apikey.failures"
+
+	// HeaderAPIKey is the header checked for keys if the request does not
+	// have a bearer token in the Authorization header.
+	HeaderAPIKey = "X-Api-Key"
+
+	// UnknownClient is the metric tag value of clients that are not listed
+	// with WithMetricClients.
+	UnknownClient = "unknown"
+)
+
+const (
+	idBytes     = 8
+	secretBytes = 32
+)
+
+var (
+	ErrMalformedKey = errors.New("apikey: malformed key")
+	ErrInvalidKey   = errors.New("apikey: invalid key")
+	ErrExpiredKey   = errors.New("apikey: expired key")
+	ErrNotFound     = errors.New("apikey: key not found")
+)
+
+// Key is an API key. Only return the full key to the client that owns it.
+type Key struct {
+	ID     string
+	Secret string
+}
+
+// String returns the key in the form sent by clients.
+func (k Key) String() string {
+	return k.ID + "." + k.Secret
+}
+
+// Generate creates a new random key. The ID of the key starts with prefix,
+// which must not contain a period.
+func Generate(prefix string) (Key, error) {
+	if strings.Contains(prefix, ".") {
+		return Key{}, fmt.Errorf("apikey: prefix %q contains a period", prefix)
+	}
+
+	b := make([]byte, idBytes+secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return Key{}, fmt.Errorf("apikey: failed to generate key: %w", err)
+	}
+	return Key{
+		ID:     prefix + hex.EncodeToString(b[:idBytes]),
+		Secret: base64.RawURLEncoding.EncodeToString(b[idBytes:]),
+	}, nil
+}
+
+// Parse splits a key sent by a client into its ID and secret. Keys contain
+// exactly one period, so other tokens, like JWTs, are malformed keys.
+func Parse(s string) (Key, error) {
+	id, secret, ok := strings.Cut(s, ".")
+	if !ok || id == "" || secret == "" || strings.Contains(secret, ".") {
+		return Key{}, ErrMalformedKey
+	}
+	return Key{ID: id, Secret: secret}, nil
+}
+
+// Hash returns the hash of a secret that is stored in a Record. Secrets are
+// random and long, so a fast hash is sufficient.
+func Hash(secret string) []byte {
+	h := sha256.Sum256([]byte(secret))
+	return h[:]
+}
+
+// Record is the stored form of a key.
+type Record struct {
+	// ID is the public ID of the key.
+	ID string `json:"id"`
+
+	// Hash is the hash of the key's secret, as returned by Hash.
+	Hash []byte `json:"hash"`
+
+	// Client identifies the owner of the key. It is logged with requests and
+	// used as a metric tag if it is listed with WithMetricClients.
+	Client string `json:"client"`
+
+	// Created is the time the key was issued. Expires is the time after which
+	// the key is rejected, or the zero time if the key does not expire.
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// Verify checks that secret matches the record and that the record has not
+// expired at the given time.
+func (r Record) Verify(secret string, now time.Time) error {
+	if subtle.ConstantTimeCompare(r.Hash, Hash(secret)) != 1 {
+		return ErrInvalidKey
+	}
+	if !r.Expires.IsZero() && !now.Before(r.Expires) {
+		return ErrExpiredKey
+	}
+	return nil
+}
+
+// Store stores key records. Get returns ErrNotFound if there is no record
+// with the ID.
+type Store interface {
+	Get(ctx context.Context, id string) (Record, error)
+	Put(ctx context.Context, r Record) error
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryStore is a Store that keeps records in memory. It is useful for tests
+// and for services that load a fixed set of keys from configuration.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]Record
+}
+
+// NewMemoryStore creates a MemoryStore that contains the records.
+func NewMemoryStore(records ...Record) *MemoryStore {
+	s := &MemoryStore{records: make(map[string]Record, len(records))}
+	for _, r := range records {
+		s.records[r.ID] = r
+	}
+	return s
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if r, ok := s.records[id]; ok {
+		return r, nil
+	}
+	return Record{}, ErrNotFound
+}
+
+func (s *MemoryStore) Put(ctx context.Context, r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[r.ID] = r
+	return nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+// Issue generates a key for client with the given ID prefix and stores its
+// record. If ttl is positive, the key expires after ttl. The returned key is
+// the only copy of the secret.
+func Issue(ctx context.Context, s Store, client, prefix string, ttl time.Duration) (Key, error) {
+	key, err := Generate(prefix)
+	if err != nil {
+		return Key{}, err
+	}
+
+	now := time.Now()
+	r := Record{
+		ID:      key.ID,
+		Hash:    Hash(key.Secret),
+		Client:  client,
+		Created: now,
+	}
+	if ttl > 0 {
+		r.Expires = now.Add(ttl)
+	}
+
+	if err := s.Put(ctx, r); err != nil {
+		return Key{}, fmt.Errorf("apikey: failed to store key: %w", err)
+	}
+	return key, nil
+}
+
+// Rotate issues a new key for the client of the key with the given ID and
+// makes the old key expire after grace, so that the client can switch to the
+// new key without downtime. The new key uses the same prefix and lifetime as
+// the old key. If the old key expires before the end of the grace period, its
+// expiration is not changed.
+func Rotate(ctx context.Context, s Store, id string, grace time.Duration) (Key, error) {
+	old, err := s.Get(ctx, id)
+	if err != nil {
+		return Key{}, err
+	}
+
+	var ttl time.Duration
+	if !old.Expires.IsZero() {
+		ttl = old.Expires.Sub(old.Created)
+	}
+
+	prefix := id[:max(len(id)-2*idBytes, 0)]
+	key, err := Issue(ctx, s, old.Client, prefix, ttl)
+	if err != nil {
+		return Key{}, err
+	}
+
+	expires := time.Now().Add(grace)
+	if old.Expires.IsZero() || expires.Before(old.Expires) {
+		old.Expires = expires
+		if err := s.Put(ctx, old); err != nil {
+			return Key{}, fmt.Errorf("apikey: failed to update old key: %w", err)
+		}
+	}
+	return key, nil
+}
+
+type recordCtxKey struct{}
+
+// FromContext returns the record of the key that authenticated the request.
+func FromContext(ctx context.Context) (Record, bool) {
+	r, ok := ctx.Value(recordCtxKey{}).(Record)
+	return r, ok
+}
+
+// Option configures the middleware returned by NewHandler.
+type Option func(*handler)
+
+// Optional passes requests without a key to the next handler instead of
+// rejecting them. Bearer tokens that are not in the form of an API key, like
+// OAuth tokens, are also passed to the next handler, so that other middleware
+// can authenticate them. Requests with invalid keys are always rejected.
+func Optional(optional bool) Option {
+	return func(h *handler) {
+		h.optional = optional
+	}
+}
+
+// WithLogField sets the name of the field that contains the client in request
+// logs. If the name is empty, the client is not logged. By default, the field
+// is named "api_client".
+func WithLogField(name string) Option {
+	return func(h *handler) {
+		h.logField = name
+	}
+}
+
+// WithMetricClients sets the clients that are used as tags of the
+// "server.apikey.requests" counter. Requests from other clients are tagged
+// with UnknownClient, so that the number of series stays bounded even if
+// keys are issued for many clients. By default, all clients are unknown.
+func WithMetricClients(clients ...string) Option {
+	return func(h *handler) {
+		h.metricClients = make(map[string]bool, len(clients))
+		for _, c := range clients {
+			h.metricClients[c] = true
+		}
+	}
+}
+
+type handler struct {
+	store         Store
+	optional      bool
+	logField      string
+	metricClients map[string]bool
+}
+
+// NewHandler returns middleware that authenticates requests with the keys in
+// store. Clients send keys as bearer tokens in the Authorization header or in
+// the X-Api-Key header. Requests without a valid key receive a 401 response.
+//
+// The middleware stores the record of the key in the request context and
+// adds the client to the request logger. It counts authenticated requests in
+// the "server.apikey.requests" counter, tagged with the client (see
+// WithMetricClients), and rejected requests in the "server.apikey.failures"
+// counter, tagged with the reason.
+func NewHandler(store Store, opts ...Option) func(http.Handler) http.Handler {
+	h := &handler{
+		store:    store,
+		logField: "api_client",
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, bearer := requestKey(r)
+			if h.optional {
+				// Leave bearer tokens that are not API keys to other middleware
+				if _, err := Parse(token); token == "" || (bearer && err != nil) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			if token == "" {
+				h.reject(w, r, "missing", "The request does not include an API key")
+				return
+			}
+
+			record, err := h.verify(r.Context(), token)
+			if err != nil {
+				switch {
+				case errors.Is(err, ErrExpiredKey):
+					h.reject(w, r, "expired", "The API key has expired")
+				case errors.Is(err, ErrMalformedKey), errors.Is(err, ErrInvalidKey), errors.Is(err, ErrNotFound):
+					h.reject(w, r, "invalid", "The API key is invalid")
+				default:
+					baseapp.HandleRouteError(w, r, err)
+				}
+				return
+			}
+
+			baseapp.CounterFromCtx(r.Context(), MetricsKeyRequests, "client:"+h.metricClient(record.Client)).Inc(1)
+			if h.logField != "" {
+				hlog.FromRequest(r).UpdateContext(func(c zerolog.Context) zerolog.Context {
+					return c.Str(h.logField, record.Client)
+				})
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), recordCtxKey{}, record)))
+		})
+	}
+}
+
+func (h *handler) verify(ctx context.Context, token string) (Record, error) {
+	key, err := Parse(token)
+	if err != nil {
+		return Record{}, err
+	}
+	record, err := h.store.Get(ctx, key.ID)
+	if err != nil {
+		return Record{}, err
+	}
+	if err := record.Verify(key.Secret, time.Now()); err != nil {
+		return Record{}, err
+	}
+	return record, nil
+}
+
+// metricClient returns the metric tag value of the client.
+func (h *handler) metricClient(client string) string {
+	if h.metricClients[client] {
+		return client
+	}
+	return UnknownClient
+}
+
+func (h *handler) reject(w http.ResponseWriter, r *http.Request, reason, detail string) {
+	baseapp.CounterFromCtx(r.Context(), MetricsKeyFailures, "reason:"+reason).Inc(1)
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	baseapp.WriteProblem(w, r, baseapp.Problem{
+		Status: http.StatusUnauthorized,
+		Detail: detail,
+	})
+}
+
+// requestKey returns the key sent with the request, if any, and true if the
+// key is a bearer token.
+func requestKey(r *http.Request) (string, bool) {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token), true
+		}
+	}
+	return strings.TrimSpace(r.Header.Get(HeaderAPIKey)), false
+}
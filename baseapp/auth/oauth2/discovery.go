@@ -0,0 +1,369 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"golang.org/x/oauth2"
+)
+
+const (
+	MetricsKeyDiscoveryFetches  = "oauth2.discovery.fetches"
+	MetricsKeyDiscoveryFailures = "oauth2.discovery.failures"
+
+	DefaultDiscoveryRefreshInterval = time.Hour
+	DefaultDiscoveryMaxStale        = 24 * time.Hour
+
+	discoveryPath = "/.well-known/openid-configuration"
+
+	// minimum time between refreshes caused by unknown key IDs
+	minKeyRefreshInterval = time.Minute
+
+	// timeout for refreshes that run in the background
+	backgroundRefreshTimeout = 30 * time.Second
+)
+
+// ProviderMetadata contains the fields of an OpenID Connect discovery
+// document used by this package and its clients.
+type ProviderMetadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	UserInfoEndpoint      string   `json:"userinfo_endpoint,omitempty"`
+	JWKSURI               string   `json:"jwks_uri"`
+	RevocationEndpoint    string   `json:"revocation_endpoint,omitempty"`
+	EndSessionEndpoint    string   `json:"end_session_endpoint,omitempty"`
+	ScopesSupported       []string `json:"scopes_supported,omitempty"`
+}
+
+// JSONWebKey is a public key from a JSON Web Key Set.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Elliptic curve keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is a set of public keys used to verify tokens.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// Key returns the key with the given ID.
+func (s *JSONWebKeySet) Key(kid string) (JSONWebKey, bool) {
+	for _, k := range s.Keys {
+		if k.KeyID == kid {
+			return k, true
+		}
+	}
+	return JSONWebKey{}, false
+}
+
+type DiscoveryOption func(*Discovery)
+
+// WithDiscoveryClient sets the HTTP client used to fetch documents. The
+// default is http.DefaultClient.
+func WithDiscoveryClient(client *http.Client) DiscoveryOption {
+	return func(d *Discovery) {
+		d.client = client
+	}
+}
+
+// WithDiscoveryRegistry sets the registry for discovery metrics. The default
+// is metrics.DefaultRegistry.
+func WithDiscoveryRegistry(registry metrics.Registry) DiscoveryOption {
+	return func(d *Discovery) {
+		d.registry = registry
+	}
+}
+
+// WithRefreshInterval sets how long documents are used before they are
+// refreshed. Each refresh adds up to 10% random jitter to the interval so
+// that replicas do not refresh at the same time.
+func WithRefreshInterval(interval time.Duration) DiscoveryOption {
+	return func(d *Discovery) {
+		d.refreshInterval = interval
+	}
+}
+
+// WithMaxStale sets how long documents may be used after they should have
+// been refreshed if refreshing fails.
+func WithMaxStale(maxStale time.Duration) DiscoveryOption {
+	return func(d *Discovery) {
+		d.maxStale = maxStale
+	}
+}
+
+// Discovery fetches and caches the OpenID Connect discovery document and the
+// JSON Web Key Set of an identity provider. A single Discovery is safe for
+// concurrent use and should be shared by all components that use the same
+// provider, like the login handler and token validation middleware.
+//
+// Cached documents are served while they are refreshed in the background
+// (stale-while-revalidate). If refreshing fails, the cached documents are
+// used until they are older than the maximum staleness. Each fetch is counted
+// in the "oauth2.discovery.fetches" counter and each failure in the
+// "oauth2.discovery.failures" counter, both tagged with the document, like
+// "oauth2.discovery.failures[document:jwks]".
+type Discovery struct {
+	issuer          string
+	client          *http.Client
+	registry        metrics.Registry
+	refreshInterval time.Duration
+	maxStale        time.Duration
+
+	metadata *cachedDocument[*ProviderMetadata]
+	keys     *cachedDocument[*JSONWebKeySet]
+}
+
+// NewDiscovery creates a Discovery for the provider with the given issuer
+// URL. Documents are fetched when they are first used.
+func NewDiscovery(issuer string, opts ...DiscoveryOption) *Discovery {
+	d := &Discovery{
+		issuer:          strings.TrimSuffix(issuer, "/"),
+		client:          http.DefaultClient,
+		registry:        metrics.DefaultRegistry,
+		refreshInterval: DefaultDiscoveryRefreshInterval,
+		maxStale:        DefaultDiscoveryMaxStale,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	d.metadata = &cachedDocument[*ProviderMetadata]{d: d, name: "metadata", fetch: d.fetchMetadata}
+	d.keys = &cachedDocument[*JSONWebKeySet]{d: d, name: "jwks", fetch: d.fetchKeys}
+	return d
+}
+
+// Metadata returns the provider's discovery document.
+func (d *Discovery) Metadata(ctx context.Context) (*ProviderMetadata, error) {
+	return d.metadata.get(ctx)
+}
+
+// Endpoint returns the OAuth2 endpoint of the provider, for use in an
+// oauth2.Config.
+func (d *Discovery) Endpoint(ctx context.Context) (oauth2.Endpoint, error) {
+	m, err := d.Metadata(ctx)
+	if err != nil {
+		return oauth2.Endpoint{}, err
+	}
+	return oauth2.Endpoint{
+		AuthURL:  m.AuthorizationEndpoint,
+		TokenURL: m.TokenEndpoint,
+	}, nil
+}
+
+// Keys returns the provider's JSON Web Key Set.
+func (d *Discovery) Keys(ctx context.Context) (*JSONWebKeySet, error) {
+	return d.keys.get(ctx)
+}
+
+// Key returns the key with the given ID. If the cached key set does not
+// contain the key, Key refreshes the key set in case the provider rotated its
+// keys. These refreshes happen at most once per minute.
+func (d *Discovery) Key(ctx context.Context, kid string) (JSONWebKey, bool, error) {
+	keys, err := d.Keys(ctx)
+	if err != nil {
+		return JSONWebKey{}, false, err
+	}
+	if k, ok := keys.Key(kid); ok {
+		return k, true, nil
+	}
+
+	if time.Since(d.keys.lastFetch()) < minKeyRefreshInterval {
+		return JSONWebKey{}, false, nil
+	}
+	if keys, err = d.keys.refresh(ctx); err != nil {
+		return JSONWebKey{}, false, err
+	}
+	k, ok := keys.Key(kid)
+	return k, ok, nil
+}
+
+func (d *Discovery) fetchMetadata(ctx context.Context) (*ProviderMetadata, error) {
+	var m ProviderMetadata
+	if err := d.fetchJSON(ctx, d.issuer+discoveryPath, &m); err != nil {
+		return nil, err
+	}
+	if m.Issuer != d.issuer {
+		return nil, errors.Errorf("discovery document issuer %q does not match %q", m.Issuer, d.issuer)
+	}
+	return &m, nil
+}
+
+func (d *Discovery) fetchKeys(ctx context.Context) (*JSONWebKeySet, error) {
+	m, err := d.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if m.JWKSURI == "" {
+		return nil, errors.New("discovery document does not contain a jwks_uri")
+	}
+
+	var keys JSONWebKeySet
+	if err := d.fetchJSON(ctx, m.JWKSURI, &keys); err != nil {
+		return nil, err
+	}
+	return &keys, nil
+}
+
+func (d *Discovery) fetchJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to fetch %s", url)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to fetch %s: unexpected status %d", url, res.StatusCode)
+	}
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return errors.Wrapf(err, "failed to decode %s", url)
+	}
+	return nil
+}
+
+// cachedDocument caches a document and refreshes it as needed.
+type cachedDocument[T any] struct {
+	d     *Discovery
+	name  string
+	fetch func(ctx context.Context) (T, error)
+
+	// fetchMu is held while fetching to avoid concurrent requests
+	fetchMu sync.Mutex
+
+	mu        sync.Mutex
+	value     T
+	ok        bool
+	fetched   time.Time
+	refreshAt time.Time
+}
+
+func (c *cachedDocument[T]) get(ctx context.Context) (T, error) {
+	c.mu.Lock()
+	value, ok, fetched, refreshAt := c.value, c.ok, c.fetched, c.refreshAt
+	c.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case ok && now.Before(refreshAt):
+		return value, nil
+
+	case ok && now.Sub(refreshAt) < c.d.maxStale:
+		go c.refreshInBackground(fetched)
+		return value, nil
+	}
+	return c.refresh(ctx)
+}
+
+// refresh fetches the document, unless another caller fetched it while this
+// caller waited.
+func (c *cachedDocument[T]) refresh(ctx context.Context) (T, error) {
+	start := time.Now()
+
+	c.fetchMu.Lock()
+	defer c.fetchMu.Unlock()
+
+	c.mu.Lock()
+	if c.ok && c.fetched.After(start) {
+		value := c.value
+		c.mu.Unlock()
+		return value, nil
+	}
+	c.mu.Unlock()
+
+	return c.doFetch(ctx)
+}
+
+// refreshInBackground fetches the document if no other fetch is in progress
+// and the document was not updated since it was last read.
+func (c *cachedDocument[T]) refreshInBackground(fetched time.Time) {
+	if !c.fetchMu.TryLock() {
+		return
+	}
+	defer c.fetchMu.Unlock()
+
+	if !c.lastFetch().Equal(fetched) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), backgroundRefreshTimeout)
+	defer cancel()
+	_, _ = c.doFetch(ctx)
+}
+
+// doFetch fetches the document and updates the cache. The caller must hold
+// fetchMu.
+func (c *cachedDocument[T]) doFetch(ctx context.Context) (T, error) {
+	tag := "document:" + c.name
+	metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyDiscoveryFetches, tag), c.d.registry).Inc(1)
+
+	value, err := c.fetch(ctx)
+	if err != nil {
+		metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyDiscoveryFailures, tag), c.d.registry).Inc(1)
+
+		// Use the cached value if it is not too stale
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.ok && time.Since(c.refreshAt) < c.d.maxStale {
+			return c.value, nil
+		}
+		var zero T
+		return zero, errors.Wrapf(err, "oauth2: failed to fetch %s", c.name)
+	}
+
+	now := time.Now()
+	interval := c.d.refreshInterval
+	if jitter := int64(interval / 10); jitter > 0 {
+		interval += time.Duration(rand.Int63n(jitter))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value, c.ok = value, true
+	c.fetched, c.refreshAt = now, now.Add(interval)
+	return value, nil
+}
+
+func (c *cachedDocument[T]) lastFetch() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.fetched
+}
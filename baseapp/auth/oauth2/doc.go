@@ -14,4 +14,6 @@
 
 // Package oauth2 implements an http.Handler that performs the 3-leg OAuth2
 // authentication flow.
+// It also provides a logout handler that revokes tokens and
+// a cache for OpenID Connect discovery documents.
 package oauth2
@@ -18,7 +18,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 
+	"github.com/palantir/go-baseapp/baseapp"
 	"golang.org/x/oauth2"
 )
 
@@ -33,6 +35,16 @@ var (
 	ErrInvalidState = errors.New("oauth2: invalid state value")
 )
 
+// RedirectURLError is returned when the redirect URL computed for a request
+// is not one of the URLs registered with WithRedirectURLs.
+type RedirectURLError struct {
+	URL string
+}
+
+func (err RedirectURLError) Error() string {
+	return fmt.Sprintf("oauth2: redirect URL %q is not registered with the provider", err.URL)
+}
+
 // Login contains information about the result of a successful auth flow.
 type Login struct {
 	Token  *oauth2.Token
@@ -57,8 +69,10 @@ type handler struct {
 	onError ErrorCallback
 	onLogin LoginCallback
 
-	forceTLS bool
-	store    StateStore
+	forceTLS       bool
+	publicURL      string
+	registeredURLs map[string]bool
+	store          StateStore
 }
 
 // NewHandler returns an http.Hander that implements the 3-leg OAuth2 flow on a
@@ -84,6 +98,10 @@ func DefaultErrorCallback(w http.ResponseWriter, r *http.Request, err error) {
 		http.Error(w, "invalid state parameter", http.StatusBadRequest)
 		return
 	}
+	if _, ok := err.(RedirectURLError); ok {
+		http.Error(w, "oauth2 redirect URL is not registered", http.StatusInternalServerError)
+		return
+	}
 	if _, ok := err.(LoginError); ok {
 		http.Error(w, fmt.Sprintf("oauth2 error: %v", err.Error()), http.StatusBadRequest)
 		return
@@ -96,16 +114,45 @@ func DefaultLoginCallback(w http.ResponseWriter, r *http.Request, login *Login)
 }
 
 // ForceTLS determines if generated URLs always use HTTPS. By default, the
-// protocol of the request is used.
+// protocol and host of the request are used, as reported by
+// baseapp.ExternalURL.
 func ForceTLS(forceTLS bool) Param {
 	return func(h *handler) {
 		h.forceTLS = forceTLS
 	}
 }
 
+// WithPublicURL sets the URL used to compute the redirect URL sent to the
+// provider. The scheme and host of the public URL replace the values from the
+// request, while the path is always the path of the request. This is usually
+// the PublicURL field of baseapp.HTTPConfig. If the public URL is empty, the
+// scheme and host from baseapp.ExternalURL are used instead.
+//
+// The RedirectURL field of the oauth2.Config is always replaced by the
+// computed URL.
+func WithPublicURL(publicURL string) Param {
+	return func(h *handler) {
+		h.publicURL = publicURL
+	}
+}
+
+// WithRedirectURLs sets the redirect URLs registered with the provider. If
+// set, the handler calls the error callback with a RedirectURLError instead
+// of starting the flow when the computed redirect URL is not in the list.
+// This catches mismatches that the provider would otherwise report with a
+// generic error after the user leaves the application.
+func WithRedirectURLs(urls ...string) Param {
+	return func(h *handler) {
+		h.registeredURLs = make(map[string]bool, len(urls))
+		for _, u := range urls {
+			h.registeredURLs[u] = true
+		}
+	}
+}
+
 // WithStore sets the StateStore used to create and verify OAuth2 states. The
 // default state store uses a static value, is insecure, and is not suitable
-// for production use.
+// for production use. Use a CookieStateStore or a SessionStateStore instead.
 func WithStore(ss StateStore) Param {
 	return func(h *handler) {
 		h.store = ss
@@ -129,7 +176,12 @@ func OnLogin(c LoginCallback) Param {
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// copy config for modification
 	conf := *h.config
-	conf.RedirectURL = redirectURL(r, h.forceTLS)
+	redirect, err := h.redirectURL(r)
+	if err != nil {
+		h.onError(w, r, err)
+		return
+	}
+	conf.RedirectURL = redirect
 
 	// if the provider returned an error, abort the processes
 	if r.FormValue(queryError) != "" {
@@ -178,14 +230,24 @@ func isInitial(r *http.Request) bool {
 	return r.FormValue(queryCode) == ""
 }
 
-func redirectURL(r *http.Request, forceTLS bool) string {
-	u := *r.URL
-	u.Host = r.Host
+func (h *handler) redirectURL(r *http.Request) (string, error) {
+	ext := baseapp.ExternalURL(r)
+	if h.publicURL != "" {
+		public, err := url.Parse(h.publicURL)
+		if err != nil {
+			return "", fmt.Errorf("oauth2: invalid public URL: %w", err)
+		}
+		if public.Scheme == "" || public.Host == "" {
+			return "", fmt.Errorf("oauth2: invalid public URL %q: scheme and host are required", h.publicURL)
+		}
+		ext = public
+	}
 
-	if forceTLS || r.TLS != nil {
+	u := *r.URL
+	u.Host = ext.Host
+	u.Scheme = ext.Scheme
+	if h.forceTLS {
 		u.Scheme = "https"
-	} else {
-		u.Scheme = "http"
 	}
 
 	q := u.Query()
@@ -194,5 +256,9 @@ func redirectURL(r *http.Request, forceTLS bool) string {
 	q.Del(querySessionState)
 	u.RawQuery = q.Encode()
 
-	return u.String()
+	redirect := u.String()
+	if h.registeredURLs != nil && !h.registeredURLs[redirect] {
+		return "", RedirectURLError{URL: redirect}
+	}
+	return redirect, nil
 }
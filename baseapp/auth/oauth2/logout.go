@@ -0,0 +1,201 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"golang.org/x/oauth2"
+)
+
+const (
+	MetricsKeyRevocations        = "oauth2.revocations"
+	MetricsKeyRevocationFailures = "oauth2.revocation_failures"
+)
+
+// RevocationError is passed to the error callback of a logout handler when
+// the provider fails to revoke a token.
+type RevocationError struct {
+	// TokenType is the type of token that was not revoked, either
+	// "refresh_token" or "access_token".
+	TokenType string
+	Err       error
+}
+
+func (err RevocationError) Error() string {
+	return fmt.Sprintf("oauth2: failed to revoke %s: %v", err.TokenType, err.Err)
+}
+
+func (err RevocationError) Unwrap() error {
+	return err.Err
+}
+
+// TokenFunc returns the token for the session of a request. It returns a nil
+// token if the session has no token.
+type TokenFunc func(r *http.Request) (*oauth2.Token, error)
+
+// LogoutCallback is called after any tokens are revoked. It should clear the
+// session and send a response.
+type LogoutCallback func(w http.ResponseWriter, r *http.Request)
+
+type LogoutParam func(*logoutHandler)
+
+type logoutHandler struct {
+	config        *oauth2.Config
+	token         TokenFunc
+	revocationURL string
+	client        *http.Client
+
+	onError  ErrorCallback
+	onLogout LogoutCallback
+}
+
+// NewLogoutHandler returns an http.Handler that logs out a session. If a
+// revocation URL is set with WithRevocationURL, the handler first revokes the
+// refresh and access tokens of the session, as described by RFC 7009, so
+// that the tokens cannot be used if the session is stolen. It then calls the
+// logout callback, which should clear the session.
+//
+// If revoking a token fails, the handler calls the error callback with a
+// RevocationError instead of the logout callback. Revocations are counted in
+// the "oauth2.revocations" counter and failures in the
+// "oauth2.revocation_failures" counter, both tagged with the token type, like
+// "oauth2.revocations[token:refresh_token]", using the registry from the
+// request context.
+func NewLogoutHandler(c *oauth2.Config, token TokenFunc, params ...LogoutParam) http.Handler {
+	h := &logoutHandler{
+		config:   c,
+		token:    token,
+		client:   http.DefaultClient,
+		onError:  DefaultErrorCallback,
+		onLogout: DefaultLogoutCallback,
+	}
+
+	for _, p := range params {
+		p(h)
+	}
+
+	return h
+}
+
+func DefaultLogoutCallback(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// WithRevocationURL sets the RFC 7009 token revocation endpoint of the
+// provider. If the provider supports OpenID Connect discovery, this is the
+// RevocationEndpoint of the ProviderMetadata. If the URL is empty, tokens are
+// not revoked.
+func WithRevocationURL(url string) LogoutParam {
+	return func(h *logoutHandler) {
+		h.revocationURL = url
+	}
+}
+
+// WithRevocationClient sets the HTTP client used to revoke tokens. The
+// default is http.DefaultClient.
+func WithRevocationClient(client *http.Client) LogoutParam {
+	return func(h *logoutHandler) {
+		h.client = client
+	}
+}
+
+// OnLogoutError sets the error callback.
+func OnLogoutError(c ErrorCallback) LogoutParam {
+	return func(h *logoutHandler) {
+		h.onError = c
+	}
+}
+
+// OnLogout sets the logout callback.
+func OnLogout(c LogoutCallback) LogoutParam {
+	return func(h *logoutHandler) {
+		h.onLogout = c
+	}
+}
+
+func (h *logoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.revocationURL != "" {
+		tok, err := h.token(r)
+		if err != nil {
+			h.onError(w, r, err)
+			return
+		}
+
+		if tok != nil {
+			// revoke the refresh token first, as some providers also revoke
+			// the associated access tokens
+			for _, t := range []struct{ hint, value string }{
+				{"refresh_token", tok.RefreshToken},
+				{"access_token", tok.AccessToken},
+			} {
+				if t.value == "" {
+					continue
+				}
+				if err := h.revoke(r.Context(), t.hint, t.value); err != nil {
+					baseapp.CounterFromCtx(r.Context(), MetricsKeyRevocationFailures, "token:"+t.hint).Inc(1)
+					h.onError(w, r, RevocationError{TokenType: t.hint, Err: err})
+					return
+				}
+				baseapp.CounterFromCtx(r.Context(), MetricsKeyRevocations, "token:"+t.hint).Inc(1)
+			}
+		}
+	}
+
+	h.onLogout(w, r)
+}
+
+func (h *logoutHandler) revoke(ctx context.Context, hint, token string) error {
+	form := url.Values{
+		"token":           {token},
+		"token_type_hint": {hint},
+	}
+
+	inParams := h.config.Endpoint.AuthStyle == oauth2.AuthStyleInParams
+	if inParams {
+		form.Set("client_id", h.config.ClientID)
+		if h.config.ClientSecret != "" {
+			form.Set("client_secret", h.config.ClientSecret)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.revocationURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if !inParams {
+		req.SetBasicAuth(url.QueryEscape(h.config.ClientID), url.QueryEscape(h.config.ClientSecret))
+	}
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = res.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<16))
+
+	// RFC 7009 uses 200 for successful revocations and for invalid tokens
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	return nil
+}
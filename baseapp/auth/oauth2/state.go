@@ -15,7 +15,14 @@
 package oauth2
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"net/http"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/palantir/go-baseapp/baseapp/securecookie"
+	"github.com/pkg/errors"
 )
 
 // StateStore generates and verifies the state parameter for OAuth2 flows.
@@ -43,3 +50,53 @@ func (ss insecureStateStore) GenerateState(w http.ResponseWriter, r *http.Reques
 func (ss insecureStateStore) VerifyState(r *http.Request, state string) (bool, error) {
 	return insecureState == state, nil
 }
+
+// CookieStateStore is a StateStore that keeps the state in a cookie encrypted
+// by a securecookie.Codec. The state is valid until the codec's maximum age,
+// so use a codec with a short maximum age, like 10 minutes.
+type CookieStateStore struct {
+	Codec *securecookie.Codec
+
+	// CookieName is the name of the state cookie. If empty, the name
+	// "oauth2_state" is used.
+	CookieName string
+}
+
+func (ss *CookieStateStore) GenerateState(w http.ResponseWriter, r *http.Request) (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate state value")
+	}
+
+	state := hex.EncodeToString(b)
+	cookie := &http.Cookie{
+		Name:     ss.cookieName(),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   baseapp.ExternalURL(r).Scheme == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+	if err := ss.Codec.SetCookie(w, cookie, []byte(state)); err != nil {
+		return "", errors.Wrap(err, "failed to set state cookie")
+	}
+	return state, nil
+}
+
+func (ss *CookieStateStore) VerifyState(r *http.Request, expected string) (bool, error) {
+	state, err := ss.Codec.Cookie(r, ss.cookieName())
+	switch {
+	case err == http.ErrNoCookie:
+		return false, errors.New("no state cookie found in the request")
+	case err != nil:
+		// invalid and expired cookies are treated as invalid states
+		return false, nil
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), state) == 1, nil
+}
+
+func (ss *CookieStateStore) cookieName() string {
+	if ss.CookieName == "" {
+		return "oauth2_state"
+	}
+	return ss.CookieName
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package saml
+
+import (
+	"fmt"
+
+	"github.com/crewjam/saml"
+)
+
+// RequiredAttribute is an attribute that assertions must contain for a login
+// to succeed.
+type RequiredAttribute struct {
+	// Name matches the Name or FriendlyName of the attribute.
+	Name string
+
+	// Validate, if set, checks the values of the attribute. Assertions
+	// contain at least one value for each required attribute.
+	Validate func(values []string) error
+}
+
+// AttributeError is the error passed to the ErrorCallback, in Error.Err, when
+// an assertion does not meet the attribute requirements.
+type AttributeError struct {
+	// Attribute is the name of the attribute that failed the requirement.
+	Attribute string
+
+	// Err is the error returned by the validation function, or nil if the
+	// attribute was missing.
+	Err error
+}
+
+func (e AttributeError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("assertion is missing required attribute %q", e.Attribute)
+	}
+	return fmt.Sprintf("assertion attribute %q is invalid: %v", e.Attribute, e.Err)
+}
+
+func (e AttributeError) Unwrap() error {
+	return e.Err
+}
+
+// AttributeValues returns the values of the attribute in the assertion with
+// the given Name or FriendlyName.
+func AttributeValues(assertion *saml.Assertion, name string) []string {
+	var values []string
+	for _, stmt := range assertion.AttributeStatements {
+		for _, attr := range stmt.Attributes {
+			if attr.Name != name && attr.FriendlyName != name {
+				continue
+			}
+			for _, v := range attr.Values {
+				values = append(values, v.Value)
+			}
+		}
+	}
+	return values
+}
+
+func checkAttributes(assertion *saml.Assertion, required []RequiredAttribute) error {
+	for _, req := range required {
+		values := AttributeValues(assertion, req.Name)
+		if len(values) == 0 {
+			return AttributeError{Attribute: req.Name}
+		}
+		if req.Validate != nil {
+			if err := req.Validate(values); err != nil {
+				return AttributeError{Attribute: req.Name, Err: err}
+			}
+		}
+	}
+	return nil
+}
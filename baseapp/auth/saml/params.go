@@ -161,6 +161,36 @@ func WithLogoutPath(path string) Param {
 	}
 }
 
+// WithACSEndpoint adds an assertion consumer service endpoint with the given
+// index and path to the generated metadata. Identity providers may send
+// responses to any endpoint in the metadata, for example when the
+// authentication request sets an AssertionConsumerServiceIndex. Register the
+// handler returned by ACSHandler at each path.
+//
+// Indices 1 and 2 are used by the endpoints at the path set by WithACSPath
+// and cannot be used for other endpoints.
+func WithACSEndpoint(index int, path string) Param {
+	return func(sp *ServiceProvider) error {
+		if path == "" {
+			return errors.New("ACS endpoints must have a path")
+		}
+		sp.acsEndpoints = append(sp.acsEndpoints, acsEndpoint{index: index, path: path})
+		return nil
+	}
+}
+
+// WithDestinationValidation requires all SAML responses to have a
+// Destination that matches the URL of the ACS endpoint that received them.
+// The URL uses the scheme and host from baseapp.ExternalURL, so services
+// behind a proxy should use baseapp.NewForwardedHandler. By default, the
+// Destination is only checked if it is present or if the response is signed.
+func WithDestinationValidation(validate bool) Param {
+	return func(sp *ServiceProvider) error {
+		sp.validateDestination = validate
+		return nil
+	}
+}
+
 func WithForceTLS(force bool) Param {
 	return func(sp *ServiceProvider) error {
 		sp.forceTLS = force
@@ -182,6 +212,21 @@ func WithErrorCallback(ecb ErrorCallback) Param {
 	}
 }
 
+// WithRequiredAttributes rejects logins whose assertions do not contain the
+// attributes or whose attribute values fail validation. Rejected logins call
+// the ErrorCallback with an AttributeError and do not call the LoginCallback.
+func WithRequiredAttributes(attrs ...RequiredAttribute) Param {
+	return func(sp *ServiceProvider) error {
+		for _, a := range attrs {
+			if a.Name == "" {
+				return errors.New("required attributes must have a name")
+			}
+		}
+		sp.requiredAttributes = append(sp.requiredAttributes, attrs...)
+		return nil
+	}
+}
+
 func WithIDStore(store IDStore) Param {
 	return func(sp *ServiceProvider) error {
 		sp.idStore = store
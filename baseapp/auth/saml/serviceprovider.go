@@ -15,11 +15,12 @@
 package saml
 
 import (
+	"encoding/base64"
 	"encoding/xml"
 	"net/http"
-	"net/url"
 
 	"github.com/crewjam/saml"
+	"github.com/palantir/go-baseapp/baseapp"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/hlog"
 )
@@ -61,17 +62,33 @@ type ServiceProvider struct {
 	acsPath      string
 	metadataPath string
 	logoutPath   string
+	acsEndpoints []acsEndpoint
 
-	forceTLS          bool
-	disableEncryption bool
+	forceTLS            bool
+	disableEncryption   bool
+	validateDestination bool
 
 	onError ErrorCallback
 	onLogin LoginCallback
 	idStore IDStore
+
+	requiredAttributes []RequiredAttribute
 }
 
 type Param func(sp *ServiceProvider) error
 
+// acsEndpoint is an additional assertion consumer service endpoint.
+type acsEndpoint struct {
+	index int
+	path  string
+}
+
+// Indices used by crewjam/saml for the endpoints at the ACS path
+const (
+	acsPostIndex     = 1
+	acsArtifactIndex = 2
+)
+
 // NewServiceProvider returns a ServiceProvider. The configuration of the ServiceProvider
 // is a result of combinging settings provided to this method and values parsed from the IDP's metadata.
 func NewServiceProvider(params ...Param) (*ServiceProvider, error) {
@@ -98,6 +115,14 @@ func NewServiceProvider(params ...Param) (*ServiceProvider, error) {
 		return nil, errors.New("ACS Path and Metadatda path must be provided")
 	}
 
+	indices := map[int]bool{acsPostIndex: true, acsArtifactIndex: true}
+	for _, e := range sp.acsEndpoints {
+		if indices[e.index] {
+			return nil, errors.Errorf("ACS endpoint index %d is already in use", e.index)
+		}
+		indices[e.index] = true
+	}
+
 	if sp.onError == nil {
 		sp.onError = DefaultErrorCallback
 	}
@@ -126,12 +151,8 @@ func (s *ServiceProvider) getSAMLSettingsForRequest(r *http.Request) *saml.Servi
 	// make a copy in case different requests have different host headers
 	newSP := *s.sp
 
-	u := url.URL{
-		Host:   r.Host,
-		Scheme: "http",
-	}
-
-	if s.forceTLS || r.TLS != nil {
+	u := *baseapp.ExternalURL(r)
+	if s.forceTLS {
 		u.Scheme = "https"
 	}
 
@@ -173,13 +194,27 @@ func (s *ServiceProvider) DoAuth(w http.ResponseWriter, r *http.Request) {
 }
 
 // ACSHandler returns an http.Handler which is capable of validating and processing SAML Responses.
+// Register the handler at the path set by WithACSPath and at the path of each endpoint added with
+// WithACSEndpoint. Responses are validated against the URL of the endpoint that received them.
 func (s *ServiceProvider) ACSHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		sp := s.getSAMLSettingsForRequest(r)
+		for _, e := range s.acsEndpoints {
+			if r.URL.Path == e.path {
+				sp.AcsURL.Path = e.path
+			}
+		}
+
 		if err := r.ParseForm(); err != nil {
 			s.onError(w, r, newError(errors.Wrap(err, "could not parse ACS form"), http.StatusForbidden))
 			return
 		}
+		if s.validateDestination {
+			if err := checkDestination(r, sp.AcsURL.String()); err != nil {
+				s.onError(w, r, newError(err, http.StatusForbidden))
+				return
+			}
+		}
 		id, err := s.idStore.GetID(r)
 		if err != nil {
 			s.onError(w, r, newError(errors.Wrap(err, "could not retrieve id"), http.StatusForbidden))
@@ -195,11 +230,41 @@ func (s *ServiceProvider) ACSHandler() http.Handler {
 			return
 		}
 
+		if err := checkAttributes(assertion, s.requiredAttributes); err != nil {
+			s.onError(w, r, newError(err, http.StatusForbidden))
+			return
+		}
+
 		s.onLogin(w, r, assertion)
 	})
 
 }
 
+// checkDestination returns an error if the SAML response in the request does
+// not have a Destination or if the Destination does not match the URL of the
+// ACS endpoint. crewjam/saml only checks the Destination if it is present or
+// if the response is signed.
+func checkDestination(r *http.Request, acsURL string) error {
+	data, err := base64.StdEncoding.DecodeString(r.PostForm.Get("SAMLResponse"))
+	if err != nil {
+		return errors.Wrap(err, "failed to decode SAML response")
+	}
+
+	var resp saml.Response
+	if err := xml.Unmarshal(data, &resp); err != nil {
+		return errors.Wrap(err, "failed to parse SAML response")
+	}
+
+	switch resp.Destination {
+	case "":
+		return errors.New("SAML response has no Destination")
+	case acsURL:
+		return nil
+	default:
+		return errors.Errorf("SAML response Destination %q does not match ACS URL %q", resp.Destination, acsURL)
+	}
+}
+
 // MetadataHandler returns an http.Handler which sends the generated metadata XML in response to a request
 func (s *ServiceProvider) MetadataHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -212,6 +277,22 @@ func (s *ServiceProvider) MetadataHandler() http.Handler {
 			// remove SingleLogoutService elements if the logout path is not set
 			metadata.SPSSODescriptors[0].SSODescriptor.SingleLogoutServices = nil
 		}
+		if len(s.acsEndpoints) > 0 {
+			// add the indexed endpoints, which crewjam/saml does not support
+			u := *baseapp.ExternalURL(r)
+			if s.forceTLS {
+				u.Scheme = "https"
+			}
+			descriptor := &metadata.SPSSODescriptors[0]
+			for _, e := range s.acsEndpoints {
+				u.Path = e.path
+				descriptor.AssertionConsumerServices = append(descriptor.AssertionConsumerServices, saml.IndexedEndpoint{
+					Binding:  saml.HTTPPostBinding,
+					Location: u.String(),
+					Index:    e.index,
+				})
+			}
+		}
 		if s.disableEncryption {
 			// remove encryption keys from metadata
 			role := &(metadata.SPSSODescriptors[0].SSODescriptor.RoleDescriptor)
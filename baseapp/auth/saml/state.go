@@ -17,6 +17,9 @@ package saml
 import (
 	"net/http"
 	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/palantir/go-baseapp/baseapp/securecookie"
 )
 
 // IDStore stores the request id for SAML auth flows
@@ -59,3 +62,46 @@ func (c cookieIDStore) GetID(r *http.Request) (string, error) {
 
 	return cookie.Value, nil
 }
+
+// secureCookieIDStore is an IDStore that keeps the request ID in a cookie
+// encrypted by a securecookie.Codec.
+type secureCookieIDStore struct {
+	codec *securecookie.Codec
+}
+
+// NewSecureCookieIDStore returns an IDStore that keeps the request ID in a
+// cookie encrypted and authenticated by codec, so clients cannot read or
+// change it. IDs expire after the codec's maximum age, so use a codec with a
+// short maximum age, like 5 minutes.
+func NewSecureCookieIDStore(codec *securecookie.Codec) IDStore {
+	return secureCookieIDStore{codec: codec}
+}
+
+func (c secureCookieIDStore) StoreID(w http.ResponseWriter, r *http.Request, id string) error {
+	cookie := &http.Cookie{
+		Name:     "saml_id",
+		HttpOnly: true,
+		Secure:   baseapp.ExternalURL(r).Scheme == "https",
+		Path:     "/",
+	}
+	if cookie.Secure {
+		// the IdP posts the response from a different site, and browsers
+		// only send cross-site cookies that are secure
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	return c.codec.SetCookie(w, cookie, []byte(id))
+}
+
+func (c secureCookieIDStore) GetID(r *http.Request) (string, error) {
+	id, err := c.codec.Cookie(r, "saml_id")
+	switch {
+	case err == http.ErrNoCookie:
+		return "", nil
+	case err == securecookie.ErrExpired:
+		// expired IDs are treated as missing
+		return "", nil
+	case err != nil:
+		return "", err
+	}
+	return string(id), nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+// ServerBuilder creates a Server with the recommended defaults of
+// DefaultParams. Each method changes one part of the defaults, so the
+// options of a server are discoverable without knowing which parameters
+// DefaultParams returns:
+//
+//	server, err := baseapp.NewServerBuilder(config.Server).
+//		Logger(logger).
+//		MetricsPrefix("myapp.").
+//		Emitters(datadog.UnstartedEmitter(config.Datadog)).
+//		Build()
+//
+// Unless they are changed, the server uses a no-op logger, a new metrics
+// registry, and the default middleware created with the final logger and
+// registry. It logs UTC timestamps with nanosecond precision, logs errors
+// with RichErrorMarshalFunc, and records server and runtime metrics.
+type ServerBuilder struct {
+	config     HTTPConfig
+	logger     zerolog.Logger
+	registry   metrics.Registry
+	prefix     string
+	middleware []func(http.Handler) http.Handler
+	emitters   []EmitterFunc
+	params     []Param
+	preset     MiddlewarePreset
+}
+
+// NewServerBuilder returns a ServerBuilder for a server with the
+// configuration.
+func NewServerBuilder(c HTTPConfig) *ServerBuilder {
+	return &ServerBuilder{
+		config: c,
+		logger: zerolog.Nop(),
+	}
+}
+
+// Logger sets the root logger of the server. See WithLogger.
+func (b *ServerBuilder) Logger(logger zerolog.Logger) *ServerBuilder {
+	b.logger = logger
+	return b
+}
+
+// Registry sets the metrics registry of the server. See WithRegistry.
+func (b *ServerBuilder) Registry(registry metrics.Registry) *ServerBuilder {
+	b.registry = registry
+	return b
+}
+
+// MetricsPrefix sets a prefix for the names of all metrics in the server's
+// registry. See WithMetricsPrefix.
+func (b *ServerBuilder) MetricsPrefix(prefix string) *ServerBuilder {
+	b.prefix = prefix
+	return b
+}
+
+// Middleware replaces the default middleware of the server. See
+// WithMiddleware.
+func (b *ServerBuilder) Middleware(middleware ...func(http.Handler) http.Handler) *ServerBuilder {
+	b.middleware = middleware
+	return b
+}
+
+// Preset sets the preset of the server, replacing the preset from the
+// configuration. See WithMiddlewarePreset.
+func (b *ServerBuilder) Preset(p MiddlewarePreset) *ServerBuilder {
+	b.preset = p
+	return b
+}
+
+// Emitters adds emitters that start when the server starts. See
+// WithUnstartedEmitters.
+func (b *ServerBuilder) Emitters(emitters ...EmitterFunc) *ServerBuilder {
+	b.emitters = append(b.emitters, emitters...)
+	return b
+}
+
+// With adds parameters that are applied after the parameters for the other
+// settings of the builder. Use it for settings that do not have a method,
+// like TLS certificates or dependency checks.
+func (b *ServerBuilder) With(params ...Param) *ServerBuilder {
+	b.params = append(b.params, params...)
+	return b
+}
+
+// Params returns the parameters for the settings of the builder, in the
+// order they are applied by Build.
+func (b *ServerBuilder) Params() []Param {
+	registry := b.registry
+	if registry == nil {
+		registry = metrics.NewRegistry()
+	}
+
+	params := []Param{
+		WithLogger(b.logger),
+		WithRegistry(registry),
+		WithMetricsPrefix(b.prefix),
+		WithUTCNanoTime(),
+		WithErrorLogging(RichErrorMarshalFunc),
+		WithMetrics(),
+	}
+	if b.middleware != nil {
+		params = append(params, WithMiddleware(b.middleware...))
+	}
+	if b.preset != "" {
+		params = append(params, WithMiddlewarePreset(b.preset))
+	}
+	if len(b.emitters) > 0 {
+		params = append(params, WithUnstartedEmitters(b.emitters...))
+	}
+	return append(params, b.params...)
+}
+
+// Build creates the server. See NewServer.
+func (b *ServerBuilder) Build() (*Server, error) {
+	return NewServer(b.config, b.Params()...)
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goji.io/pat"
+)
+
+func TestServerBuilder(t *testing.T) {
+	registry := metrics.NewRegistry()
+	s, err := NewServerBuilder(HTTPConfig{}).
+		Registry(registry).
+		MetricsPrefix("app.").
+		Build()
+	require.NoError(t, err)
+
+	RegisterDefaultMetrics(s.Registry())
+	s.Mux().HandleFunc(pat.Get("/"), func(w http.ResponseWriter, r *http.Request) {})
+	s.HTTPServer().Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	c, ok := registry.Get("app." + MetricsKeyRequests).(metrics.Counter)
+	require.True(t, ok, "default middleware should use the prefixed registry")
+	assert.Equal(t, int64(1), c.Count())
+	assert.Nil(t, registry.Get(MetricsKeyRequests))
+
+	s, err = NewServer(HTTPConfig{}, WithMetricsPrefix("app."), WithRegistry(registry))
+	require.NoError(t, err)
+	s.Registry().GetOrRegister("counter", metrics.NewCounter())
+	assert.NotNil(t, registry.Get("app.counter"), "prefix should not depend on the order of parameters")
+}
+
+func TestServerBuilderEmitters(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan metrics.Registry, 1)
+	s, err := NewServerBuilder(HTTPConfig{Address: l.Addr().String()}).
+		MetricsPrefix("app.").
+		Emitters(func(ctx context.Context, s *Server) error {
+			started <- s.Registry()
+			return nil
+		}).
+		With(WithListenFunc(func(network, addr string) (net.Listener, error) { return l, nil })).
+		Build()
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() { done <- s.Start() }()
+
+	assert.Same(t, s.Registry(), <-started, "emitter should use the server's final registry")
+
+	require.NoError(t, s.HTTPServer().Close())
+	assert.Equal(t, http.ErrServerClosed, <-done)
+}
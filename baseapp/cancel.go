@@ -0,0 +1,84 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Reasons returned by CancelReason.
+const (
+	CancelReasonClient  = "client"
+	CancelReasonTimeout = "timeout"
+)
+
+type cancelCtxKey struct{}
+
+// cancelState tracks why a request stopped early. client is the request
+// context from before AccessHandler, which the HTTP server cancels when the
+// client disconnects.
+type cancelState struct {
+	client   context.Context
+	timedOut atomic.Bool
+}
+
+// IsClientGone returns true if the client of the request has disconnected or
+// canceled the request. Handlers can check it to stop expensive work whose
+// result will never be sent.
+//
+// Contexts derived from the request, like those with a timeout, are also
+// canceled when the client disconnects, but they are canceled for other
+// reasons too. IsClientGone uses the request context saved by AccessHandler
+// to only report client cancellations. If ctx is not from AccessHandler,
+// IsClientGone reports if ctx was canceled instead of exceeding a deadline.
+func IsClientGone(ctx context.Context) bool {
+	if s, ok := ctx.Value(cancelCtxKey{}).(*cancelState); ok {
+		return s.client.Err() == context.Canceled
+	}
+	return ctx.Err() == context.Canceled
+}
+
+// RecordTimeout records that the request stopped because of a server timeout
+// if the deadline of ctx has passed. Middleware that applies timeouts to the
+// contexts of requests, like the deadline package, calls it after the
+// handler returns so that AccessHandler callbacks can distinguish timeouts
+// from client disconnects with CancelReason. RecordTimeout does nothing if
+// ctx is not from AccessHandler.
+func RecordTimeout(ctx context.Context) {
+	if ctx.Err() != context.DeadlineExceeded {
+		return
+	}
+	if s, ok := ctx.Value(cancelCtxKey{}).(*cancelState); ok {
+		s.timedOut.Store(true)
+	}
+}
+
+// CancelReason returns why the request of ctx stopped before completion:
+// CancelReasonClient if the client is gone, CancelReasonTimeout if the
+// context exceeded its deadline or a timeout was recorded with
+// RecordTimeout, or an empty string if the request was not canceled.
+func CancelReason(ctx context.Context) string {
+	if IsClientGone(ctx) {
+		return CancelReasonClient
+	}
+	if s, ok := ctx.Value(cancelCtxKey{}).(*cancelState); ok && s.timedOut.Load() {
+		return CancelReasonTimeout
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return CancelReasonTimeout
+	}
+	return ""
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelReason(t *testing.T) {
+	registry := metrics.NewRegistry()
+	RegisterDefaultMetrics(registry)
+
+	var buf bytes.Buffer
+	var clientGone bool
+	serve := func(h http.HandlerFunc) map[string]any {
+		buf.Reset()
+		handler := NewMetricsHandler(registry)(AccessHandler(RecordRequest)(h))
+
+		ctx, cancel := context.WithCancel(zerolog.New(&buf).WithContext(context.Background()))
+		defer cancel()
+		// the handlers cancel the request like the server does when the
+		// client disconnects
+		r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(context.WithValue(ctx, cancelFuncKey{}, cancel))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		return entry
+	}
+	count := func(reason string) int64 {
+		return registry.Get(appmetrics.TaggedName(MetricsKeyRequestsCanceled, "reason:"+reason)).(metrics.Counter).Count()
+	}
+
+	entry := serve(func(w http.ResponseWriter, r *http.Request) {
+		clientGone = IsClientGone(r.Context())
+	})
+	assert.False(t, clientGone)
+	assert.NotContains(t, entry, "canceled")
+
+	entry = serve(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Hour)
+		defer cancel()
+
+		r.Context().Value(cancelFuncKey{}).(context.CancelFunc)()
+		clientGone = IsClientGone(ctx)
+	})
+	assert.True(t, clientGone, "derived contexts should report that the client is gone")
+	assert.Equal(t, CancelReasonClient, entry["canceled"])
+	assert.Equal(t, int64(1), count(CancelReasonClient))
+
+	entry = serve(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), time.Nanosecond)
+		defer cancel()
+
+		<-ctx.Done()
+		clientGone = IsClientGone(ctx)
+		RecordTimeout(ctx)
+	})
+	assert.False(t, clientGone, "timeouts should not report that the client is gone")
+	assert.Equal(t, CancelReasonTimeout, entry["canceled"])
+	assert.Equal(t, int64(1), count(CancelReasonTimeout))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, IsClientGone(ctx), "contexts without AccessHandler should use their own error")
+}
+
+type cancelFuncKey struct{}
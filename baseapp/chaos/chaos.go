@@ -0,0 +1,224 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaos provides middleware that injects faults into requests for
+// resilience testing.
+//
+// Faults are only injected when the configuration enables them and the
+// request has the X-Chaos-Token header with the configured token, so that
+// game days can target a staging service without affecting other traffic
+// and without modifying handlers. Each rule matches requests by method and
+// path and injects latency, an error response, or a connection reset into a
+// percentage of the matching requests.
+//
+//	mw, err := chaos.NewHandler(config.Chaos)
+//	if err != nil {
+//		return err
+//	}
+//	server.Mux().Use(mw)
+package chaos
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	MetricsKeyInjected = "server.chaos.injected"
+
+	// HeaderToken is the header that must contain the configured token for a
+	// request to receive faults.
+	HeaderToken = "X-Chaos-Token"
+)
+
+// Fault types, used in the "fault" tag of the injected faults counter.
+const (
+	FaultLatency = "latency"
+	FaultError   = "error"
+	FaultReset   = "reset"
+)
+
+// Config contains options for fault injection. It is usually embedded in a
+// larger configuration struct.
+type Config struct {
+	// Enabled enables fault injection. If it is false, the middleware passes
+	// all requests to the next handler.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Token is the value requests must send in the X-Chaos-Token header to
+	// receive faults. It is required if fault injection is enabled.
+	Token string `yaml:"token" json:"token"`
+
+	// Rules are checked in order and the first rule that matches a request
+	// and is selected by its percentage applies.
+	Rules []Rule `yaml:"rules" json:"rules"`
+}
+
+// Rule injects faults into a percentage of the requests it matches.
+type Rule struct {
+	// Name identifies the rule in metrics and logs.
+	Name string `yaml:"name" json:"name"`
+
+	// Methods and PathPrefixes limit the requests that match the rule. If
+	// either is empty, the rule matches requests with any method or path.
+	Methods      []string `yaml:"methods" json:"methods"`
+	PathPrefixes []string `yaml:"path_prefixes" json:"pathPrefixes"`
+
+	// Percent is the percentage of matching requests, between 0 and 100,
+	// that receive the faults of the rule.
+	Percent float64 `yaml:"percent" json:"percent"`
+
+	// Latency delays the request before it is handled or before the error
+	// or reset is injected.
+	Latency time.Duration `yaml:"latency" json:"latency"`
+
+	// Status is the status of an error response sent instead of calling the
+	// handler. It must be a 4xx or 5xx status.
+	Status int `yaml:"status" json:"status"`
+
+	// Reset closes the connection without a response instead of calling the
+	// handler. It cannot be used with Status.
+	Reset bool `yaml:"reset" json:"reset"`
+}
+
+func (r Rule) validate() error {
+	switch {
+	case r.Percent < 0 || r.Percent > 100:
+		return errors.Errorf("percent %v is not between 0 and 100", r.Percent)
+	case r.Latency < 0:
+		return errors.Errorf("latency %v is negative", r.Latency)
+	case r.Status != 0 && (r.Status < 400 || r.Status > 599):
+		return errors.Errorf("status %d is not an error status", r.Status)
+	case r.Status != 0 && r.Reset:
+		return errors.New("status and reset cannot both be set")
+	case r.Latency == 0 && r.Status == 0 && !r.Reset:
+		return errors.New("rule does not inject a fault")
+	}
+	return nil
+}
+
+func (r Rule) matches(req *http.Request) bool {
+	if len(r.Methods) > 0 && !baseapp.MatchMethod(r.Methods...)(req) {
+		return false
+	}
+	return len(r.PathPrefixes) == 0 || baseapp.MatchPathPrefix(r.PathPrefixes...)(req)
+}
+
+// NewHandler returns middleware that injects the faults of the configured
+// rules. It returns an error if fault injection is enabled without a token or
+// if a rule is invalid. The middleware must run after the default middleware,
+// which adds the logger and metrics registry to the request context.
+//
+// Each injected fault is counted in the "server.chaos.injected" counter,
+// tagged with the rule name and the fault type: "latency", "error", or
+// "reset", and is added to the request with AddRequestEvent as a
+// "chaos_fault" event. If the connection of a request cannot be hijacked, as
+// with HTTP/2, a reset is replaced by a 502 response.
+func NewHandler(c Config) (func(http.Handler) http.Handler, error) {
+	if !c.Enabled {
+		return func(next http.Handler) http.Handler { return next }, nil
+	}
+	if c.Token == "" {
+		return nil, errors.New("chaos: token is required when fault injection is enabled")
+	}
+	for i, r := range c.Rules {
+		if err := r.validate(); err != nil {
+			return nil, errors.Wrapf(err, "chaos: invalid rule %d (%s)", i, r.Name)
+		}
+	}
+
+	token := []byte(c.Token)
+	rules := c.Rules
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(r.Header.Get(HeaderToken)), token) != 1 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			for _, rule := range rules {
+				if rule.matches(r) && rand.Float64()*100 < rule.Percent {
+					inject(w, r, rule, next)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// inject applies the faults of the rule to the request.
+func inject(w http.ResponseWriter, r *http.Request, rule Rule, next http.Handler) {
+	if rule.Latency > 0 {
+		record(r, rule, FaultLatency)
+
+		t := time.NewTimer(rule.Latency)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			return
+		}
+	}
+
+	switch {
+	case rule.Status != 0:
+		record(r, rule, FaultError)
+		baseapp.WriteProblem(w, r, baseapp.Problem{
+			Status: rule.Status,
+			Detail: fmt.Sprintf("Fault injected by chaos rule %q", rule.Name),
+		})
+
+	case rule.Reset:
+		record(r, rule, FaultReset)
+		if !reset(w) {
+			baseapp.WriteProblem(w, r, baseapp.Problem{Status: http.StatusBadGateway})
+		}
+
+	default:
+		next.ServeHTTP(w, r)
+	}
+}
+
+// reset closes the connection of the request, discarding unsent data so
+// that the client receives a TCP reset. It returns false if the connection
+// cannot be hijacked.
+func reset(w http.ResponseWriter) bool {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return false
+	}
+	if tc, ok := conn.(*net.TCPConn); ok {
+		_ = tc.SetLinger(0)
+	}
+	_ = conn.Close()
+	return true
+}
+
+func record(r *http.Request, rule Rule, fault string) {
+	baseapp.CounterFromCtx(r.Context(), MetricsKeyInjected, "rule:"+rule.Name, "fault:"+fault).Inc(1)
+	baseapp.AddRequestEvent(r.Context(), "chaos_fault", "rule", rule.Name, "fault", fault)
+	hlog.FromRequest(r).Info().
+		Str("rule", rule.Name).
+		Str("fault", fault).
+		Msg("Injected fault")
+}
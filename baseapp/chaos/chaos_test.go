@@ -0,0 +1,109 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	mw, err := NewHandler(Config{
+		Enabled: true,
+		Token:   "secret",
+		Rules: []Rule{
+			{Name: "slow", PathPrefixes: []string{"/slow"}, Percent: 100, Latency: 50 * time.Millisecond},
+			{Name: "fail", Methods: []string{http.MethodPost}, Percent: 100, Status: http.StatusServiceUnavailable},
+			{Name: "never", Percent: 0, Status: http.StatusInternalServerError},
+			{Name: "reset", PathPrefixes: []string{"/reset"}, Percent: 100, Reset: true},
+		},
+	})
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(method, path, token string) (int, time.Duration) {
+		r := httptest.NewRequest(method, path, nil)
+		r = r.WithContext(baseapp.WithMetricsCtx(r.Context(), registry))
+		if token != "" {
+			r.Header.Set(HeaderToken, token)
+		}
+		w := httptest.NewRecorder()
+		start := time.Now()
+		handler.ServeHTTP(w, r)
+		return w.Code, time.Since(start)
+	}
+	count := func(rule, fault string) int64 {
+		if c, ok := registry.Get(MetricsKeyInjected + "[fault:" + fault + ",rule:" + rule + "]").(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	code, _ := serve(http.MethodPost, "/", "")
+	assert.Equal(t, http.StatusOK, code, "requests without the token should not receive faults")
+	code, _ = serve(http.MethodPost, "/", "wrong")
+	assert.Equal(t, http.StatusOK, code, "requests with the wrong token should not receive faults")
+
+	code, _ = serve(http.MethodPost, "/", "secret")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, int64(1), count("fail", FaultError))
+
+	code, d := serve(http.MethodGet, "/slow", "secret")
+	assert.Equal(t, http.StatusOK, code)
+	assert.GreaterOrEqual(t, d, 50*time.Millisecond)
+	assert.Equal(t, int64(1), count("slow", FaultLatency))
+
+	code, _ = serve(http.MethodGet, "/", "secret")
+	assert.Equal(t, http.StatusOK, code, "rules with 0 percent should not inject faults")
+
+	srv := httptest.NewServer(mw(http.NotFoundHandler()))
+	defer srv.Close()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/reset", nil)
+	require.NoError(t, err)
+	req.Header.Set(HeaderToken, "secret")
+	res, err := http.DefaultClient.Do(req)
+	if err == nil {
+		_ = res.Body.Close()
+	}
+	assert.Error(t, err, "reset requests should not receive a response")
+}
+
+func TestNewHandler(t *testing.T) {
+	_, err := NewHandler(Config{Rules: []Rule{{Percent: 200}}})
+	assert.NoError(t, err, "disabled configurations should not be validated")
+
+	_, err = NewHandler(Config{Enabled: true})
+	assert.Error(t, err, "enabled configurations should require a token")
+
+	for _, rule := range []Rule{
+		{Percent: 101, Status: http.StatusInternalServerError},
+		{Percent: 10, Status: http.StatusOK},
+		{Percent: 10, Status: http.StatusBadGateway, Reset: true},
+		{Percent: 10},
+	} {
+		_, err = NewHandler(Config{Enabled: true, Token: "secret", Rules: []Rule{rule}})
+		assert.Error(t, err, "%+v", rule)
+	}
+}
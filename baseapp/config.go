@@ -15,8 +15,10 @@
 package baseapp
 
 import (
+	"net"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -27,6 +29,14 @@ type TLSConfig struct {
 
 // HTTPConfig contains options for HTTP servers. It is usually embedded in a
 // larger configuration struct.
+//
+// Address may be a comma-separated list of addresses, in which case the server
+// listens on all of them using the same port. Addresses may be IPv4 or IPv6
+// addresses or host names. Entries that include a port, like "[::1]:8443",
+// use that port instead of the configured port.
+//
+// Preset selects a MiddlewarePreset, like "production" or "development". If
+// it is empty, the server uses the default settings of NewServer.
 type HTTPConfig struct {
 	Address   string     `yaml:"address" json:"address"`
 	Port      int        `yaml:"port" json:"port"`
@@ -34,6 +44,33 @@ type HTTPConfig struct {
 	TLSConfig *TLSConfig `yaml:"tls_config" json:"tlsConfig"`
 
 	ShutdownWaitTime *time.Duration `yaml:"shutdown_wait_time" json:"shutdownWaitTime"`
+
+	Preset MiddlewarePreset `yaml:"preset" json:"preset"`
+}
+
+// Addresses returns the network addresses, in "host:port" form, that the
+// server listens on. Empty entries in the address list are ignored. If the
+// list has no entries, the server listens on all interfaces.
+func (c HTTPConfig) Addresses() []string {
+	port := strconv.Itoa(c.Port)
+
+	var addrs []string
+	for _, host := range strings.Split(c.Address, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err == nil {
+			addrs = append(addrs, host)
+			continue
+		}
+		host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+		addrs = append(addrs, net.JoinHostPort(host, port))
+	}
+	if len(addrs) == 0 {
+		addrs = append(addrs, net.JoinHostPort("", port))
+	}
+	return addrs
 }
 
 // SetValuesFromEnv sets values in the configuration from corresponding
@@ -44,6 +81,10 @@ func (c *HTTPConfig) SetValuesFromEnv(prefix string) {
 	setIntFromEnv("PORT", prefix, &c.Port)
 	setStringFromEnv("PUBLIC_URL", prefix, &c.PublicURL)
 
+	if v, ok := os.LookupEnv(prefix + "PRESET"); ok {
+		c.Preset = MiddlewarePreset(v)
+	}
+
 	var d time.Duration
 	if setDurationFromEnv("SHUTDOWN_WAIT_TIME", prefix, &d) {
 		c.ShutdownWaitTime = &d
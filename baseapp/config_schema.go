@@ -0,0 +1,286 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SchemaDraft is the JSON Schema dialect of the schemas created by
+// ConfigSchema.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the strings accepted by time.ParseDuration.
+const durationPattern = `^[-+]?(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h))+$|^[-+]?0$`
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Schema is a JSON Schema document. It marshals to JSON with encoding/json.
+type Schema map[string]interface{}
+
+// SchemaProvider is implemented by configuration types that decode in a
+// custom way, like types with an UnmarshalYAML method. ConfigSchema uses the
+// returned schema for values of the type instead of inspecting its fields.
+type SchemaProvider interface {
+	ConfigSchema() Schema
+}
+
+var schemaProviderType = reflect.TypeOf((*SchemaProvider)(nil)).Elem()
+
+type schemaOptions struct {
+	tag           string
+	title         string
+	allowUnknown  bool
+	typeOverrides map[reflect.Type]Schema
+}
+
+// SchemaOption configures the schema created by ConfigSchema.
+type SchemaOption func(*schemaOptions)
+
+// WithSchemaTag sets the struct tag that contains the names of fields in the
+// configuration file. The default is "yaml". Use "json" for configuration
+// files decoded with encoding/json.
+func WithSchemaTag(tag string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.tag = tag
+	}
+}
+
+// WithSchemaTitle sets the title of the schema.
+func WithSchemaTitle(title string) SchemaOption {
+	return func(o *schemaOptions) {
+		o.title = title
+	}
+}
+
+// WithUnknownFields allows or rejects object properties that do not match a
+// field. By default, unknown properties are rejected, matching
+// yaml.UnmarshalStrict.
+func WithUnknownFields(allow bool) SchemaOption {
+	return func(o *schemaOptions) {
+		o.allowUnknown = allow
+	}
+}
+
+// WithTypeSchema sets the schema for all values of a type. Use it for types
+// from other packages that decode in a custom way and do not implement
+// SchemaProvider.
+func WithTypeSchema(typ reflect.Type, s Schema) SchemaOption {
+	return func(o *schemaOptions) {
+		if o.typeOverrides == nil {
+			o.typeOverrides = make(map[reflect.Type]Schema)
+		}
+		o.typeOverrides[typ] = s
+	}
+}
+
+// ConfigSchema returns a JSON Schema for configuration files that decode into
+// the type of config, which is usually the application's composed
+// configuration struct containing HTTPConfig, LoggingConfig, and other
+// configuration types. Deployment tooling can use the schema to validate
+// configuration files and editors can use it to complete field names.
+//
+// The schema is created by inspecting the type with reflection. Struct fields
+// use the names in the "yaml" struct tag, or another tag set by
+// WithSchemaTag, and fields with the name "-" are omitted. Fields are
+// optional because configuration types are usually partially filled by
+// defaults or environment variables. Durations accept strings in the format
+// of time.ParseDuration or integer nanoseconds. Recursive types are allowed
+// to contain any value at the point of recursion.
+func ConfigSchema(config interface{}, opts ...SchemaOption) (Schema, error) {
+	o := schemaOptions{tag: "yaml"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	typ := reflect.TypeOf(config)
+	if typ == nil {
+		return nil, errors.New("config must not be nil")
+	}
+
+	g := schemaGenerator{opts: o, visiting: make(map[reflect.Type]bool)}
+	s, err := g.schema(typ)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create schema for %s", typ)
+	}
+
+	s["$schema"] = SchemaDraft
+	if o.title != "" {
+		s["title"] = o.title
+	}
+	return s, nil
+}
+
+type schemaGenerator struct {
+	opts     schemaOptions
+	visiting map[reflect.Type]bool
+}
+
+func (g *schemaGenerator) schema(typ reflect.Type) (Schema, error) {
+	if s, ok := g.opts.typeOverrides[typ]; ok {
+		return copySchema(s), nil
+	}
+	if typ.Kind() == reflect.Ptr {
+		return g.schema(typ.Elem())
+	}
+	if reflect.PointerTo(typ).Implements(schemaProviderType) {
+		return copySchema(reflect.New(typ).Interface().(SchemaProvider).ConfigSchema()), nil
+	}
+	if typ == durationType {
+		return Schema{"type": []string{"string", "integer"}, "pattern": durationPattern}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Schema{"type": "integer"}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return Schema{"type": "integer", "minimum": 0}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}, nil
+
+	case reflect.String:
+		return Schema{"type": "string"}, nil
+
+	case reflect.Interface:
+		return Schema{}, nil
+
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string"}, nil
+		}
+		items, err := g.schema(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return Schema{"type": "array", "items": items}, nil
+
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, errors.Errorf("map key type %s is not a string", typ.Key())
+		}
+		values, err := g.schema(typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return Schema{"type": "object", "additionalProperties": values}, nil
+
+	case reflect.Struct:
+		if g.visiting[typ] {
+			return Schema{}, nil
+		}
+		g.visiting[typ] = true
+		defer delete(g.visiting, typ)
+
+		props := make(Schema)
+		if err := g.addFields(props, typ); err != nil {
+			return nil, err
+		}
+		s := Schema{"type": "object", "properties": props}
+		if !g.opts.allowUnknown {
+			s["additionalProperties"] = false
+		}
+		return s, nil
+	}
+	return nil, errors.Errorf("unsupported type %s", typ)
+}
+
+// addFields adds the schemas of the fields of a struct to props. Inline
+// fields add their fields to the same properties.
+func (g *schemaGenerator) addFields(props Schema, typ reflect.Type) error {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+
+		name, inline, skip := g.fieldName(f)
+		if skip {
+			continue
+		}
+
+		if inline {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() != reflect.Struct {
+				return errors.Errorf("field %s: inline field is not a struct", f.Name)
+			}
+			if err := g.addFields(props, ft); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+
+		s, err := g.schema(f.Type)
+		if err != nil {
+			return errors.Wrapf(err, "field %s", f.Name)
+		}
+		props[name] = s
+	}
+	return nil
+}
+
+// fieldName returns the name of the property for a field, following the
+// rules of the decoder for the configured tag.
+func (g *schemaGenerator) fieldName(f reflect.StructField) (name string, inline, skip bool) {
+	tag := f.Tag.Get(g.opts.tag)
+	if tag == "-" {
+		return "", false, true
+	}
+
+	name, flags, _ := strings.Cut(tag, ",")
+	for _, flag := range strings.Split(flags, ",") {
+		if flag == "inline" {
+			return "", true, false
+		}
+	}
+
+	// encoding/json inlines embedded structs without a name, while yaml.v2
+	// requires the inline flag
+	if g.opts.tag == "json" && f.Anonymous && name == "" {
+		return "", true, false
+	}
+
+	if name == "" {
+		name = f.Name
+		if g.opts.tag == "yaml" {
+			name = strings.ToLower(name)
+		}
+	}
+	return name, false, false
+}
+
+// copySchema returns a shallow copy of s, so callers can add keys without
+// modifying schemas returned by providers or options.
+func copySchema(s Schema) Schema {
+	c := make(Schema, len(s))
+	for k, v := range s {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,108 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLevel string
+
+func (testLevel) ConfigSchema() Schema {
+	return Schema{"enum": []string{"debug", "info"}}
+}
+
+type testNode struct {
+	Name     string      `yaml:"name"`
+	Children []*testNode `yaml:"children"`
+}
+
+type testSchemaConfig struct {
+	Server  HTTPConfig    `yaml:"server" json:"server"`
+	Logging LoggingConfig `yaml:"logging" json:"logging"`
+
+	App struct {
+		Message string            `yaml:"message" json:"message"`
+		Timeout time.Duration     `yaml:"timeout" json:"timeout"`
+		Labels  map[string]string `yaml:"labels" json:"labels"`
+		Level   testLevel         `yaml:"level" json:"level"`
+		Secret  string            `yaml:"-" json:"-"`
+		Count   uint              `yaml:"" json:"Count"`
+	} `yaml:"app" json:"app"`
+
+	Common testNode `yaml:",inline"`
+}
+
+func TestConfigSchema(t *testing.T) {
+	s, err := ConfigSchema(testSchemaConfig{}, WithSchemaTitle("test"))
+	require.NoError(t, err)
+
+	assert.Equal(t, SchemaDraft, s["$schema"])
+	assert.Equal(t, "test", s["title"])
+	assert.Equal(t, false, s["additionalProperties"])
+
+	props := s["properties"].(Schema)
+	assert.Contains(t, props, "server")
+	assert.Contains(t, props, "logging")
+	assert.Contains(t, props, "name", "inline fields should be added to the parent")
+	assert.Contains(t, props, "children")
+
+	server := props["server"].(Schema)["properties"].(Schema)
+	assert.Equal(t, Schema{"type": "integer"}, server["port"])
+	assert.Equal(t, "object", server["tls_config"].(Schema)["type"], "pointers should use the element schema")
+
+	app := props["app"].(Schema)["properties"].(Schema)
+	assert.Equal(t, Schema{"type": "string"}, app["message"])
+	assert.Equal(t, Schema{"type": "object", "additionalProperties": Schema{"type": "string"}}, app["labels"])
+	assert.Equal(t, Schema{"enum": []string{"debug", "info"}}, app["level"])
+	assert.Equal(t, Schema{"type": "integer", "minimum": 0}, app["count"])
+	assert.NotContains(t, app, "secret")
+
+	pattern := regexp.MustCompile(app["timeout"].(Schema)["pattern"].(string))
+	for _, d := range []string{"5s", "1h30m", "1.5ms", "0"} {
+		assert.True(t, pattern.MatchString(d), d)
+	}
+	assert.False(t, pattern.MatchString("5 seconds"))
+
+	node := props["children"].(Schema)["items"].(Schema)
+	children := node["properties"].(Schema)["children"].(Schema)["items"].(Schema)
+	assert.Equal(t, Schema{}, children, "recursive types should allow any value")
+
+	_, err = json.Marshal(s)
+	assert.NoError(t, err)
+
+	s, err = ConfigSchema(&testSchemaConfig{}, WithSchemaTag("json"), WithUnknownFields(true))
+	require.NoError(t, err)
+	props = s["properties"].(Schema)
+	assert.NotContains(t, s, "additionalProperties")
+	assert.Contains(t, props["server"].(Schema)["properties"], "publicUrl")
+	assert.Contains(t, props, "Common", "json should not inline named fields")
+
+	s, err = ConfigSchema(testSchemaConfig{}, WithTypeSchema(reflect.TypeOf(LoggingConfig{}), Schema{"type": "object"}))
+	require.NoError(t, err)
+	assert.Equal(t, Schema{"type": "object"}, s["properties"].(Schema)["logging"])
+
+	_, err = ConfigSchema(struct {
+		Ch chan int `yaml:"ch"`
+	}{})
+	assert.Error(t, err)
+}
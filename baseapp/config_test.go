@@ -57,6 +57,7 @@ func TestSetValuesFromEnv(t *testing.T) {
 				"TLS_CERT_FILE":      "/path/to/cert.crt",
 				"TLS_KEY_FILE":       "/path/to/key.pem",
 				"SHUTDOWN_WAIT_TIME": "5m",
+				"PRESET":             "development",
 			},
 			Output: func(c *HTTPConfig) {
 				c.Address = "127.0.0.1"
@@ -68,6 +69,7 @@ func TestSetValuesFromEnv(t *testing.T) {
 				}
 				d := 5 * time.Minute
 				c.ShutdownWaitTime = &d
+				c.Preset = MiddlewarePresetDevelopment
 			},
 		},
 		"withPrefix": {
@@ -129,3 +131,60 @@ func TestSetValuesFromEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestAddresses(t *testing.T) {
+	tests := map[string]struct {
+		Address string
+		Port    int
+		Output  []string
+	}{
+		"empty": {
+			Port:   8080,
+			Output: []string{":8080"},
+		},
+		"single": {
+			Address: "127.0.0.1",
+			Port:    8080,
+			Output:  []string{"127.0.0.1:8080"},
+		},
+		"multiple": {
+			Address: "127.0.0.1, 10.0.0.5",
+			Port:    8080,
+			Output:  []string{"127.0.0.1:8080", "10.0.0.5:8080"},
+		},
+		"ipv6": {
+			Address: "0.0.0.0,::",
+			Port:    8080,
+			Output:  []string{"0.0.0.0:8080", "[::]:8080"},
+		},
+		"ipv6Brackets": {
+			Address: "[::1]",
+			Port:    8080,
+			Output:  []string{"[::1]:8080"},
+		},
+		"explicitPort": {
+			Address: "127.0.0.1,[::1]:8443,localhost:9000",
+			Port:    8080,
+			Output:  []string{"127.0.0.1:8080", "[::1]:8443", "localhost:9000"},
+		},
+		"trailingComma": {
+			Address: "127.0.0.1,",
+			Port:    8080,
+			Output:  []string{"127.0.0.1:8080"},
+		},
+		"onlyCommas": {
+			Address: " , ",
+			Port:    8080,
+			Output:  []string{":8080"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := HTTPConfig{Address: test.Address, Port: test.Port}
+			if addrs := c.Addresses(); !reflect.DeepEqual(test.Output, addrs) {
+				t.Errorf("incorrect addresses\nexpected: %q\n  actual: %q", test.Output, addrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package consumers runs message consumer loops alongside a server.
+//
+// A consumer combines a poll function, which reads a batch of messages from a
+// queue or log, and a handle function, which processes a single message. The
+// queue client is provided by the application, so consumers work with any
+// system (Kafka, SQS, database outboxes, etc.) that can be polled.
+//
+// Consumers registered with a server start when the server starts and stop
+// polling when the server begins a graceful shutdown. The shutdown waits for
+// in-progress messages to finish before the HTTP server stops.
+package consumers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	MetricsKeyMessages = "consumer.messages"
+	MetricsKeyErrors   = "consumer.errors"
+	MetricsKeyPanics   = "consumer.panics"
+	MetricsKeyLag      = "consumer.lag"
+	MetricsKeyDuration = "consumer.duration"
+
+	DefaultIdleInterval = time.Second
+)
+
+type consumerMetrics struct {
+	Messages appmetrics.Tagged[metrics.Counter] `metric:"consumer.messages"`
+	Errors   appmetrics.Tagged[metrics.Counter] `metric:"consumer.errors"`
+	Panics   appmetrics.Tagged[metrics.Counter] `metric:"consumer.panics"`
+	Lag      appmetrics.Tagged[metrics.Timer]   `metric:"consumer.lag"`
+	Duration appmetrics.Tagged[metrics.Timer]   `metric:"consumer.duration"`
+}
+
+// Message is a single message returned by a poll function.
+type Message struct {
+	// ID identifies the message in logs. It is optional.
+	ID string
+
+	// Body is the message content.
+	Body []byte
+
+	// Headers contains message attributes, such as trace propagation data.
+	Headers map[string]string
+
+	// Timestamp is the time the message was produced. If set, it is used to
+	// record consumer lag.
+	Timestamp time.Time
+
+	// Value is an optional client-specific representation of the message,
+	// such as the original message type from the queue library.
+	Value any
+}
+
+// PollFunc reads the next batch of messages. It should block until messages
+// are available, the context is canceled, or a client-specific timeout
+// expires. The context is canceled when the group is paused or drained.
+// Returning an empty batch without an error is allowed.
+type PollFunc func(ctx context.Context) ([]Message, error)
+
+// HandleFunc processes a single message.
+type HandleFunc func(ctx context.Context, m Message) error
+
+// Consumer defines a message consumer loop.
+type Consumer struct {
+	// Name identifies the consumer in logs and metrics. It is required.
+	Name string
+
+	// Poll reads messages. It is required.
+	Poll PollFunc
+
+	// Handle processes messages. It is required.
+	Handle HandleFunc
+
+	// ExtractContext, if set, is called before handling each message and may
+	// return a new context, for example with trace information extracted
+	// from the message headers.
+	ExtractContext func(ctx context.Context, m Message) context.Context
+
+	// IdleInterval is the time to wait after a poll returns no messages or an
+	// error. If zero, DefaultIdleInterval is used.
+	IdleInterval time.Duration
+}
+
+// Group manages a set of consumers.
+type Group struct {
+	logger   zerolog.Logger
+	registry metrics.Registry
+	metrics  *consumerMetrics
+
+	consumers []Consumer
+
+	mu          sync.Mutex
+	paused      bool
+	resume      chan struct{}
+	pauseCtx    context.Context
+	pauseCancel context.CancelFunc
+	cancel      context.CancelFunc
+	stopped     chan struct{}
+
+	// polling tracks calls to poll functions and handling tracks messages
+	// returned by polls that are not yet handled
+	polling  sync.WaitGroup
+	handling sync.WaitGroup
+}
+
+// New creates a Group that provides logger and registry to consumers.
+func New(logger zerolog.Logger, registry metrics.Registry) *Group {
+	m := appmetrics.New[consumerMetrics]()
+	appmetrics.Register(registry, m)
+
+	pauseCtx, pauseCancel := context.WithCancel(context.Background())
+	return &Group{
+		logger:      logger,
+		registry:    registry,
+		metrics:     m,
+		resume:      make(chan struct{}),
+		pauseCtx:    pauseCtx,
+		pauseCancel: pauseCancel,
+	}
+}
+
+// Register creates a Group using the logger and registry of the server and
+// adds the consumers. The consumers start when the server starts and drain
+// when the server begins a graceful shutdown.
+//
+// Because the server only calls shutdown functions if it has a
+// ShutdownWaitTime, servers without one never drain the group. Their
+// consumers stop, possibly in the middle of handling a message, when the
+// process exits. Use New and call Run and Drain directly to control the
+// lifetime of the group in other ways.
+func Register(s *baseapp.Server, consumers ...Consumer) (*Group, error) {
+	g := New(s.Logger(), s.Registry())
+	for _, c := range consumers {
+		if err := g.Add(c); err != nil {
+			return nil, err
+		}
+	}
+
+	s.OnStart(func(*baseapp.Server) {
+		go g.Run(context.Background())
+	})
+	s.OnShutdown(g.Drain)
+
+	return g, nil
+}
+
+// Add adds a consumer to the group. Consumers must be added before calling
+// Run.
+func (g *Group) Add(c Consumer) error {
+	if c.Name == "" {
+		return errors.New("consumers: consumer name is required")
+	}
+	if c.Poll == nil || c.Handle == nil {
+		return errors.Errorf("consumers: consumer %s: poll and handle functions are required", c.Name)
+	}
+	if c.IdleInterval == 0 {
+		c.IdleInterval = DefaultIdleInterval
+	}
+	g.consumers = append(g.consumers, c)
+	return nil
+}
+
+// Run runs all consumers until the context is canceled or the group is
+// drained. It blocks until all consumers exit.
+func (g *Group) Run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	g.mu.Lock()
+	g.cancel = cancel
+	g.stopped = make(chan struct{})
+	stopped := g.stopped
+	g.mu.Unlock()
+
+	defer close(stopped)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, c := range g.consumers {
+		wg.Add(1)
+		go func(c Consumer) {
+			defer wg.Done()
+			g.loop(ctx, c)
+		}(c)
+	}
+	wg.Wait()
+}
+
+// Pause stops all consumers from polling for new messages and cancels the
+// context of polls in progress. Messages that are already being handled are
+// not interrupted.
+func (g *Group) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		g.paused = true
+		g.pauseCancel()
+	}
+}
+
+// Resume restarts polling after a call to Pause.
+func (g *Group) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		g.paused = false
+		g.pauseCtx, g.pauseCancel = context.WithCancel(context.Background())
+		close(g.resume)
+		g.resume = make(chan struct{})
+	}
+}
+
+// Drain pauses polling, waits for polls in progress to return and for the
+// returned messages to be handled, and then stops all consumers. It returns
+// an error if the context expires before handling completes.
+func (g *Group) Drain(ctx context.Context) error {
+	g.Pause()
+
+	done := make(chan struct{})
+	go func() {
+		// Polls add to handling before they finish, so handling is
+		// complete once both groups are done
+		g.polling.Wait()
+		g.handling.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "consumers: drain did not complete")
+	}
+
+	g.mu.Lock()
+	cancel, stopped := g.cancel, g.stopped
+	g.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "consumers: consumers did not stop")
+		}
+	}
+	return nil
+}
+
+// waitIfPaused blocks while the group is paused. It returns a context that is
+// canceled when the group is paused again, or false if ctx is canceled.
+func (g *Group) waitIfPaused(ctx context.Context) (context.Context, bool) {
+	for {
+		g.mu.Lock()
+		paused, resume, pauseCtx := g.paused, g.resume, g.pauseCtx
+		if !paused {
+			// track the poll while holding the lock so that Drain cannot
+			// miss it
+			g.polling.Add(1)
+		}
+		g.mu.Unlock()
+
+		if !paused {
+			return pauseCtx, true
+		}
+		select {
+		case <-resume:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+func (g *Group) loop(ctx context.Context, c Consumer) {
+	logger := g.logger.With().Str("consumer", c.Name).Logger()
+
+	for ctx.Err() == nil {
+		pauseCtx, ok := g.waitIfPaused(ctx)
+		if !ok {
+			return
+		}
+
+		msgs := g.poll(ctx, pauseCtx, c, logger)
+		g.handling.Add(len(msgs))
+		g.polling.Done()
+
+		for _, m := range msgs {
+			g.handle(ctx, c, logger, m)
+			g.handling.Done()
+		}
+
+		if len(msgs) == 0 {
+			t := time.NewTimer(c.IdleInterval)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+		}
+	}
+}
+
+// poll calls the poll function with a context that is canceled when either
+// ctx or pauseCtx is canceled.
+func (g *Group) poll(ctx, pauseCtx context.Context, c Consumer, logger zerolog.Logger) []Message {
+	pollCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stop := context.AfterFunc(pauseCtx, cancel)
+	defer stop()
+
+	msgs, err := c.Poll(pollCtx)
+	if err != nil {
+		if pollCtx.Err() == nil {
+			g.metrics.Errors.Tag("consumer:" + c.Name).Inc(1)
+			logger.Error().Err(err).Msg("Failed to poll for messages")
+		}
+		return nil
+	}
+	return msgs
+}
+
+func (g *Group) handle(ctx context.Context, c Consumer, logger zerolog.Logger, m Message) {
+	if m.ID != "" {
+		logger = logger.With().Str("message_id", m.ID).Logger()
+	}
+
+	// Handling uses a context that is not canceled when the group stops so
+	// that messages in progress complete during a drain
+	hctx := logger.WithContext(context.WithoutCancel(ctx))
+	hctx = baseapp.WithMetricsCtx(hctx, g.registry)
+	if c.ExtractContext != nil {
+		hctx = c.ExtractContext(hctx, m)
+	}
+
+	tag := "consumer:" + c.Name
+	if !m.Timestamp.IsZero() {
+		g.metrics.Lag.Tag(tag).Update(time.Since(m.Timestamp))
+	}
+
+	start := time.Now()
+	err := handleMessage(hctx, c.Handle, m)
+	elapsed := time.Since(start)
+
+	g.metrics.Messages.Tag(tag).Inc(1)
+	g.metrics.Duration.Tag(tag).Update(elapsed)
+
+	if err != nil {
+		if _, ok := err.(panicError); ok {
+			g.metrics.Panics.Tag(tag).Inc(1)
+		}
+		g.metrics.Errors.Tag(tag).Inc(1)
+		logger.Error().Err(err).Dur("elapsed", elapsed).Msg("Failed to handle message")
+	}
+}
+
+type panicError struct {
+	error
+}
+
+func (err panicError) Cause() error {
+	return err.error
+}
+
+func handleMessage(ctx context.Context, handle HandleFunc, m Message) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = panicError{errors.Errorf("panic: %v", v)}
+		}
+	}()
+	return handle(ctx, m)
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consumers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+	r := metrics.NewRegistry()
+	g := New(zerolog.Nop(), r)
+
+	var polls, handled atomic.Int64
+	release := make(chan struct{})
+
+	err := g.Add(Consumer{
+		Name: "test",
+		Poll: func(ctx context.Context) ([]Message, error) {
+			if polls.Add(1) > 1 {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			return []Message{
+				{ID: "1", Timestamp: time.Now()},
+				{ID: "2"},
+				{ID: "3"},
+			}, nil
+		},
+		Handle: func(ctx context.Context, m Message) error {
+			<-release
+			handled.Add(1)
+			switch m.ID {
+			case "2":
+				return errors.New("handle failed")
+			case "3":
+				panic("handle panicked")
+			}
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	go g.Run(context.Background())
+
+	// wait for the first poll to start handling
+	require.Eventually(t, func() bool { return polls.Load() > 0 }, time.Second, time.Millisecond)
+
+	drained := make(chan error)
+	go func() { drained <- g.Drain(context.Background()) }()
+
+	select {
+	case <-drained:
+		t.Fatal("drain completed before messages were handled")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	require.NoError(t, <-drained)
+
+	assert.Equal(t, int64(3), handled.Load())
+	assert.Equal(t, int64(3), metrics.GetOrRegisterCounter("consumer.messages[consumer:test]", r).Count())
+	assert.Equal(t, int64(2), metrics.GetOrRegisterCounter("consumer.errors[consumer:test]", r).Count())
+	assert.Equal(t, int64(1), metrics.GetOrRegisterCounter("consumer.panics[consumer:test]", r).Count())
+	assert.Equal(t, int64(1), metrics.GetOrRegisterTimer("consumer.lag[consumer:test]", r).Count())
+}
+
+func TestDrainBlockingPoll(t *testing.T) {
+	g := New(zerolog.Nop(), metrics.NewRegistry())
+
+	polling := make(chan struct{})
+	err := g.Add(Consumer{
+		Name: "blocking",
+		Poll: func(ctx context.Context) ([]Message, error) {
+			close(polling)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+		Handle: func(ctx context.Context, m Message) error {
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		g.Run(context.Background())
+	}()
+	<-polling
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	require.NoError(t, g.Drain(ctx), "drain should cancel polls in progress")
+	<-stopped
+}
@@ -0,0 +1,319 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deadline propagates request deadlines between services.
+//
+// Clients send the time they are willing to wait for a response in the
+// X-Request-Timeout header, or in the Grpc-Timeout header used by gRPC
+// gateways. The middleware returned by NewHandler reads the header, clamps
+// the timeout by the server's policy, and applies it to the request context.
+// The Transport sends the time remaining before the context deadline with
+// outbound requests, so that each service in a call graph stops work that
+// the original client has already abandoned.
+//
+//	handler = deadline.NewHandler(deadline.WithMax(30*time.Second))(handler)
+//
+//	client := &http.Client{
+//		Transport: deadline.NewTransport(http.DefaultTransport, deadline.WithReserve(10*time.Millisecond)),
+//	}
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	MetricsKeyRequests = "server.deadline.requests"
+	MetricsKeyInvalid  = "server.deadline.invalid"
+	MetricsKeyExpired  = "client.deadline.expired"
+
+	// HeaderTimeout is the header that contains the timeout of a request as a
+	// duration, like "250ms" or "1.5s".
+	HeaderTimeout = "X-Request-Timeout"
+
+	// HeaderGRPCTimeout is the header that contains the timeout of a gRPC
+	// request, like "250m" for 250 milliseconds.
+	HeaderGRPCTimeout = "Grpc-Timeout"
+)
+
+// Parse parses the value of the X-Request-Timeout header, a duration in the
+// format of time.ParseDuration. Timeouts must be positive.
+func Parse(s string) (time.Duration, bool) {
+	d, err := time.ParseDuration(strings.TrimSpace(s))
+	return d, err == nil && d > 0
+}
+
+// ParseGRPC parses the value of the Grpc-Timeout header: up to eight digits
+// followed by a unit, one of "H", "M", "S", "m", "u", or "n". Unlike
+// durations, "m" means milliseconds. Timeouts must be positive.
+func ParseGRPC(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || len(s) > 9 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(s[:len(s)-1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	var unit time.Duration
+	switch s[len(s)-1] {
+	case 'H':
+		unit = time.Hour
+	case 'M':
+		unit = time.Minute
+	case 'S':
+		unit = time.Second
+	case 'm':
+		unit = time.Millisecond
+	case 'u':
+		unit = time.Microsecond
+	case 'n':
+		unit = time.Nanosecond
+	default:
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// Format formats a timeout for the X-Request-Timeout header. The timeout is
+// rounded down to whole milliseconds, with a minimum of one millisecond.
+func Format(d time.Duration) string {
+	return strconv.FormatInt(max(d.Milliseconds(), 1), 10) + "ms"
+}
+
+// formatGRPC formats a timeout for the Grpc-Timeout header, which allows at
+// most eight digits.
+func formatGRPC(d time.Duration) string {
+	if ms := max(d.Milliseconds(), 1); ms < 1e8 {
+		return strconv.FormatInt(ms, 10) + "m"
+	}
+	return strconv.FormatInt(min(int64(d/time.Second), 1e8-1), 10) + "S"
+}
+
+// Remaining returns the time remaining before the deadline of the context. It
+// returns false if the context has no deadline.
+func Remaining(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// Option configures the middleware returned by NewHandler.
+type Option func(*handler)
+
+// WithMax sets the maximum timeout of requests. Longer client timeouts are
+// reduced to the maximum. If the request does not have a timeout, the
+// maximum is used as the default unless WithDefault sets a different value.
+// By default, there is no maximum.
+func WithMax(d time.Duration) Option {
+	return func(h *handler) {
+		h.max = d
+	}
+}
+
+// WithMin sets the minimum timeout of requests. Shorter client timeouts are
+// increased to the minimum, so that requests always have enough time to do
+// useful work. By default, there is no minimum.
+func WithMin(d time.Duration) Option {
+	return func(h *handler) {
+		h.min = d
+	}
+}
+
+// WithDefault sets the timeout of requests that do not have a timeout header
+// or have an invalid header. By default, the maximum set by WithMax is used,
+// and if there is no maximum, these requests do not get a deadline.
+func WithDefault(d time.Duration) Option {
+	return func(h *handler) {
+		h.def = d
+	}
+}
+
+// WithHeaders sets the headers checked for timeouts, in order. The
+// Grpc-Timeout header is parsed with ParseGRPC and all other headers are
+// parsed with Parse. By default, the middleware checks the X-Request-Timeout
+// and Grpc-Timeout headers.
+func WithHeaders(names ...string) Option {
+	return func(h *handler) {
+		h.headers = names
+	}
+}
+
+type handler struct {
+	min     time.Duration
+	max     time.Duration
+	def     time.Duration
+	headers []string
+}
+
+// NewHandler returns middleware that applies the timeout sent by the client
+// to the request context. The deadline never extends an existing deadline of
+// the context.
+//
+// The middleware counts requests in the "server.deadline.requests" counter,
+// tagged with the source of the timeout: "header", "default", or "none", and
+// with "clamped:true" if the policy changed the client's timeout. Requests
+// with invalid timeout headers are counted in the "server.deadline.invalid"
+// counter and use the default timeout. If the deadline passes before the
+// handler returns, the middleware records the timeout with
+// baseapp.RecordTimeout.
+func NewHandler(opts ...Option) func(http.Handler) http.Handler {
+	h := &handler{
+		headers: []string{HeaderTimeout, HeaderGRPCTimeout},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.def == 0 {
+		h.def = h.max
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout, source, clamped := h.timeout(r)
+			baseapp.CounterFromCtx(r.Context(), MetricsKeyRequests, "source:"+source, "clamped:"+strconv.FormatBool(clamped)).Inc(1)
+
+			if timeout > 0 {
+				ctx, cancel := context.WithTimeout(r.Context(), timeout)
+				defer cancel()
+				r = r.WithContext(ctx)
+			}
+			next.ServeHTTP(w, r)
+			baseapp.RecordTimeout(r.Context())
+		})
+	}
+}
+
+// timeout returns the timeout for the request, the source of the timeout,
+// and true if the timeout was changed by the policy.
+func (h *handler) timeout(r *http.Request) (time.Duration, string, bool) {
+	for _, name := range h.headers {
+		v := r.Header.Get(name)
+		if v == "" {
+			continue
+		}
+
+		parse := Parse
+		if http.CanonicalHeaderKey(name) == HeaderGRPCTimeout {
+			parse = ParseGRPC
+		}
+		d, ok := parse(v)
+		if !ok {
+			baseapp.CounterFromCtx(r.Context(), MetricsKeyInvalid).Inc(1)
+			break
+		}
+
+		clamped := d
+		if h.max > 0 {
+			clamped = min(clamped, h.max)
+		}
+		if h.min > 0 {
+			clamped = max(clamped, h.min)
+		}
+		return clamped, "header", clamped != d
+	}
+
+	if h.def > 0 {
+		return h.def, "default", false
+	}
+	return 0, "none", false
+}
+
+// TransportOption configures a Transport.
+type TransportOption func(*Transport)
+
+// WithReserve sets the time subtracted from the remaining time of a request
+// before sending it, to allow for network latency and for processing the
+// response. The default is zero.
+func WithReserve(d time.Duration) TransportOption {
+	return func(t *Transport) {
+		t.reserve = d
+	}
+}
+
+// WithHeader sets the header used to send the remaining time. The default is
+// X-Request-Timeout. If the header is Grpc-Timeout, the time uses the gRPC
+// format.
+func WithHeader(name string) TransportOption {
+	return func(t *Transport) {
+		t.header = name
+	}
+}
+
+// Transport is an http.RoundTripper that sends the time remaining before the
+// deadline of the request context with each request.
+//
+// If the remaining time, minus the reserve, is not positive, the transport
+// returns context.DeadlineExceeded without sending the request and increments
+// the "client.deadline.expired" counter, tagged with the host of the request
+// and using the registry from the request context. Requests without a
+// deadline are sent unchanged.
+type Transport struct {
+	base    http.RoundTripper
+	reserve time.Duration
+	header  string
+}
+
+// NewTransport returns a Transport that sends requests with base. If base is
+// nil, the transport uses http.DefaultTransport.
+func NewTransport(base http.RoundTripper, opts ...TransportOption) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &Transport{
+		base:   base,
+		header: HeaderTimeout,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	remaining, ok := Remaining(req.Context())
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	remaining -= t.reserve
+	if remaining <= 0 {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		registry := baseapp.MetricsCtx(req.Context())
+		metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyExpired, "host:"+req.URL.Host), registry).Inc(1)
+		return nil, context.DeadlineExceeded
+	}
+
+	// RoundTrippers must not modify the request, so set the header on a copy
+	value := Format(remaining)
+	if http.CanonicalHeaderKey(t.header) == HeaderGRPCTimeout {
+		value = formatGRPC(remaining)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(t.header, value)
+	return t.base.RoundTrip(req)
+}
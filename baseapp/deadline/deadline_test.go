@@ -0,0 +1,153 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	d, ok := Parse("1.5s")
+	assert.True(t, ok)
+	assert.Equal(t, 1500*time.Millisecond, d)
+
+	for _, s := range []string{"", "0", "-1s", "100", "2S"} {
+		_, ok := Parse(s)
+		assert.False(t, ok, s)
+	}
+
+	for s, expected := range map[string]time.Duration{
+		"250m": 250 * time.Millisecond,
+		"2S":   2 * time.Second,
+		"1H":   time.Hour,
+		"10u":  10 * time.Microsecond,
+	} {
+		d, ok := ParseGRPC(s)
+		assert.True(t, ok, s)
+		assert.Equal(t, expected, d, s)
+	}
+	for _, s := range []string{"", "m", "0m", "123456789m", "5s", "1.5S"} {
+		_, ok := ParseGRPC(s)
+		assert.False(t, ok, s)
+	}
+
+	assert.Equal(t, "1500ms", Format(1500*time.Millisecond))
+	assert.Equal(t, "1ms", Format(time.Microsecond))
+	assert.Equal(t, "250m", formatGRPC(250*time.Millisecond))
+	assert.Equal(t, "100000S", formatGRPC(100000*time.Second))
+}
+
+func TestHandler(t *testing.T) {
+	serve := func(h func(http.Handler) http.Handler, header, value string) (time.Duration, bool, metrics.Registry) {
+		var remaining time.Duration
+		var hasDeadline bool
+		handler := h(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remaining, hasDeadline = Remaining(r.Context())
+		}))
+
+		registry := metrics.NewRegistry()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(baseapp.WithMetricsCtx(r.Context(), registry))
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		return remaining, hasDeadline, registry
+	}
+	count := func(r metrics.Registry, name string) int64 {
+		if c, ok := r.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	h := NewHandler(WithMax(10*time.Second), WithMin(time.Second))
+
+	remaining, ok, r := serve(h, HeaderTimeout, "5s")
+	require.True(t, ok)
+	assert.InDelta(t, 5*time.Second, remaining, float64(time.Second))
+	assert.Equal(t, int64(1), count(r, "server.deadline.requests[clamped:false,source:header]"))
+
+	remaining, _, r = serve(h, HeaderTimeout, "1m")
+	assert.InDelta(t, 10*time.Second, remaining, float64(time.Second), "long timeouts should be reduced")
+	assert.Equal(t, int64(1), count(r, "server.deadline.requests[clamped:true,source:header]"))
+
+	remaining, _, _ = serve(h, HeaderGRPCTimeout, "100m")
+	assert.InDelta(t, time.Second, remaining, float64(100*time.Millisecond), "short timeouts should be increased")
+
+	remaining, _, r = serve(h, HeaderTimeout, "soon")
+	assert.InDelta(t, 10*time.Second, remaining, float64(time.Second))
+	assert.Equal(t, int64(1), count(r, MetricsKeyInvalid))
+	assert.Equal(t, int64(1), count(r, "server.deadline.requests[clamped:false,source:default]"))
+
+	_, ok, r = serve(NewHandler(), "", "")
+	assert.False(t, ok, "requests should not have a deadline without a default")
+	assert.Equal(t, int64(1), count(r, "server.deadline.requests[clamped:false,source:none]"))
+}
+
+func TestTransport(t *testing.T) {
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(HeaderTimeout)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, WithReserve(100*time.Millisecond))}
+	registry := metrics.NewRegistry()
+
+	do := func(timeout time.Duration) error {
+		ctx := baseapp.WithMetricsCtx(context.Background(), registry)
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+
+		res, err := client.Do(req)
+		if err == nil {
+			_ = res.Body.Close()
+		}
+		return err
+	}
+
+	require.NoError(t, do(5*time.Second))
+	require.True(t, strings.HasSuffix(received, "ms"), received)
+	d, ok := Parse(received)
+	require.True(t, ok)
+	assert.InDelta(t, 4900*time.Millisecond, d, float64(time.Second))
+
+	received = ""
+	require.NoError(t, do(0))
+	assert.Empty(t, received, "requests without a deadline should not have a timeout")
+
+	err := do(50 * time.Millisecond)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	host := strings.TrimPrefix(srv.URL, "http://")
+	c, _ := registry.Get("client.deadline.expired[host:" + host + "]").(metrics.Counter)
+	require.NotNil(t, c)
+	assert.Equal(t, int64(1), c.Count())
+}
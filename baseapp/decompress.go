@@ -0,0 +1,223 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MetricsKeyDecompressedRequests = "server.requests.decompressed"
+	MetricsKeyDecompressionErrors  = "server.requests.decompression_errors"
+
+	// DefaultMaxDecompressedSize is the default maximum size of decompressed
+	// request bodies.
+	DefaultMaxDecompressedSize = 32 << 20
+
+	// DefaultMaxCompressionRatio is the default maximum ratio of the
+	// decompressed size to the compressed size of request bodies.
+	DefaultMaxCompressionRatio = 100
+
+	// minRatioCheckSize is the decompressed size after which the handler
+	// checks the compression ratio. Small bodies may have high ratios without
+	// being dangerous.
+	minRatioCheckSize = 64 << 10
+)
+
+// ErrCompressionRatio is returned when reading a request body that exceeds
+// the maximum compression ratio.
+var ErrCompressionRatio = errors.New("request body exceeds the maximum compression ratio")
+
+// DecompressOption configures the middleware returned by
+// NewDecompressHandler.
+type DecompressOption func(*decompressHandler)
+
+// WithMaxDecompressedSize sets the maximum size of decompressed request
+// bodies. The default is DefaultMaxDecompressedSize. If size is zero or
+// negative, the size is not limited.
+func WithMaxDecompressedSize(size int64) DecompressOption {
+	return func(h *decompressHandler) {
+		h.maxSize = size
+	}
+}
+
+// WithMaxCompressionRatio sets the maximum ratio of the decompressed size to
+// the compressed size of request bodies. The default is
+// DefaultMaxCompressionRatio. If ratio is zero or negative, the ratio is not
+// limited.
+func WithMaxCompressionRatio(ratio float64) DecompressOption {
+	return func(h *decompressHandler) {
+		h.maxRatio = ratio
+	}
+}
+
+type decompressHandler struct {
+	maxSize  int64
+	maxRatio float64
+}
+
+// NewDecompressHandler returns middleware that decompresses request bodies
+// with the "gzip" or "deflate" content encoding, so that handlers always read
+// uncompressed bodies. The middleware removes the Content-Encoding and
+// Content-Length headers from decompressed requests.
+//
+// To protect against compression bombs, reading the body fails with an
+// *http.MaxBytesError if the decompressed body is larger than the maximum
+// size and with ErrCompressionRatio if the body decompresses to more than the
+// maximum ratio of its compressed size. JSONHandler responds to both errors
+// with status 413.
+//
+// Requests with other content encodings receive a 415 response and requests
+// with invalid compressed bodies receive a 400 response. Decompressed
+// requests are counted in the "server.requests.decompressed" counter, tagged
+// with the encoding, and failures in the
+// "server.requests.decompression_errors" counter, tagged with a reason like
+// "encoding", "invalid", "size", or "ratio".
+func NewDecompressHandler(opts ...DecompressOption) func(http.Handler) http.Handler {
+	h := &decompressHandler{
+		maxSize:  DefaultMaxDecompressedSize,
+		maxRatio: DefaultMaxCompressionRatio,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			compressed := &countingReader{r: r.Body}
+
+			var body io.ReadCloser
+			var err error
+			switch encoding {
+			case "gzip", "x-gzip":
+				encoding = "gzip"
+				body, err = gzip.NewReader(compressed)
+			case "deflate":
+				body, err = zlib.NewReader(compressed)
+			default:
+				CounterFromCtx(ctx, MetricsKeyDecompressionErrors, "reason:encoding").Inc(1)
+				WriteProblem(w, r, Problem{
+					Status: http.StatusUnsupportedMediaType,
+					Detail: "unsupported content encoding: " + encoding,
+				})
+				return
+			}
+			if err != nil {
+				CounterFromCtx(ctx, MetricsKeyDecompressionErrors, "reason:invalid").Inc(1)
+				WriteProblem(w, r, Problem{
+					Status: http.StatusBadRequest,
+					Detail: "invalid " + encoding + " request body",
+				})
+				return
+			}
+
+			CounterFromCtx(ctx, MetricsKeyDecompressedRequests, "encoding:"+encoding).Inc(1)
+
+			r2 := r.Clone(ctx)
+			r2.Body = &decompressedBody{
+				ctx:        ctx,
+				r:          body,
+				orig:       r.Body,
+				compressed: compressed,
+				maxSize:    h.maxSize,
+				maxRatio:   h.maxRatio,
+			}
+			r2.ContentLength = -1
+			r2.Header.Del("Content-Encoding")
+			r2.Header.Del("Content-Length")
+
+			next.ServeHTTP(w, r2)
+		})
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decompressedBody reads a decompressed request body and enforces the size
+// and ratio limits.
+type decompressedBody struct {
+	ctx        context.Context
+	r          io.ReadCloser
+	orig       io.ReadCloser
+	compressed *countingReader
+	maxSize    int64
+	maxRatio   float64
+
+	n   int64
+	err error
+}
+
+func (b *decompressedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	// Read one byte past the limit to detect bodies that are too large
+	if b.maxSize > 0 && int64(len(p)) > b.maxSize-b.n+1 {
+		p = p[:b.maxSize-b.n+1]
+	}
+
+	n, err := b.r.Read(p)
+	b.n += int64(n)
+
+	switch {
+	case b.maxSize > 0 && b.n > b.maxSize:
+		n -= int(b.n - b.maxSize)
+		b.fail("size", &http.MaxBytesError{Limit: b.maxSize})
+		return n, b.err
+
+	case b.maxRatio > 0 && b.n > minRatioCheckSize && float64(b.n) > b.maxRatio*float64(b.compressed.n):
+		b.fail("ratio", ErrCompressionRatio)
+		return n, b.err
+	}
+
+	if err != nil && err != io.EOF {
+		err = errors.Wrap(err, "failed to decompress request body")
+	}
+	return n, err
+}
+
+func (b *decompressedBody) fail(reason string, err error) {
+	CounterFromCtx(b.ctx, MetricsKeyDecompressionErrors, "reason:"+reason).Inc(1)
+	b.err = err
+}
+
+func (b *decompressedBody) Close() error {
+	_ = b.r.Close()
+	return b.orig.Close()
+}
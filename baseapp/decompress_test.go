@@ -0,0 +1,135 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bluekeyes/hatpear"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompressHandler(t *testing.T) {
+	registry := metrics.NewRegistry()
+	count := func(name string) int64 {
+		if c, ok := registry.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	gzipData := func(data []byte) []byte {
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		_, _ = zw.Write(data)
+		_ = zw.Close()
+		return b.Bytes()
+	}
+
+	var body []byte
+	var readErr error
+	var headers http.Header
+	handler := NewMetricsHandler(registry)(NewDecompressHandler(WithMaxDecompressedSize(1 << 20))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, readErr = io.ReadAll(r.Body)
+	})))
+
+	serve := func(encoding string, data []byte) *httptest.ResponseRecorder {
+		body, readErr, headers = nil, nil, nil
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(data))
+		r.Header.Set("Content-Encoding", encoding)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		w := serve("gzip", gzipData([]byte("hello")))
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, readErr)
+		assert.Equal(t, "hello", string(body))
+		assert.Empty(t, headers.Get("Content-Encoding"))
+		assert.Equal(t, int64(1), count("server.requests.decompressed[encoding:gzip]"))
+	})
+
+	t.Run("deflate", func(t *testing.T) {
+		var b bytes.Buffer
+		zw := zlib.NewWriter(&b)
+		_, _ = zw.Write([]byte("hello"))
+		_ = zw.Close()
+
+		serve("deflate", b.Bytes())
+		require.NoError(t, readErr)
+		assert.Equal(t, "hello", string(body))
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		serve("identity", []byte("plain"))
+		require.NoError(t, readErr)
+		assert.Equal(t, "plain", string(body))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		w := serve("br", []byte("data"))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Nil(t, headers, "handler should not be called")
+		assert.Equal(t, int64(1), count("server.requests.decompression_errors[reason:encoding]"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		w := serve("gzip", []byte("not gzip"))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, int64(1), count("server.requests.decompression_errors[reason:invalid]"))
+	})
+
+	t.Run("tooLarge", func(t *testing.T) {
+		data := make([]byte, 2<<20)
+		_, _ = rand.New(rand.NewSource(1)).Read(data)
+		serve("gzip", gzipData(data))
+
+		var maxBytesErr *http.MaxBytesError
+		require.ErrorAs(t, readErr, &maxBytesErr)
+		assert.Len(t, body, 1<<20, "body should be truncated at the limit")
+		assert.Equal(t, int64(1), count("server.requests.decompression_errors[reason:size]"))
+	})
+
+	t.Run("ratio", func(t *testing.T) {
+		serve("gzip", gzipData(make([]byte, 512<<10)))
+		assert.ErrorIs(t, readErr, ErrCompressionRatio)
+		assert.Equal(t, int64(1), count("server.requests.decompression_errors[reason:ratio]"))
+	})
+
+	t.Run("jsonHandler", func(t *testing.T) {
+		h := hatpear.Catch(HandleRouteError)(NewDecompressHandler()(JSONHandler(func(ctx context.Context, req map[string]any) (map[string]any, error) {
+			return req, nil
+		})))
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(gzipData([]byte(`{"a":"`+strings.Repeat("0", 512<<10)+`"}`))))
+		r.Header.Set("Content-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	DefaultDependencyCheckTimeout = 5 * time.Second
+)
+
+// DependencyCheck verifies that a dependency of the server is available.
+type DependencyCheck struct {
+	// Name identifies the dependency in logs and errors.
+	Name string
+
+	// Check returns an error if the dependency is not available.
+	Check func(ctx context.Context) error
+
+	// Timeout limits the duration of the check. If zero, the check uses
+	// DefaultDependencyCheckTimeout.
+	Timeout time.Duration
+
+	// Optional marks dependencies that are not required for the server to
+	// function. Failures of optional checks are logged but not returned.
+	Optional bool
+}
+
+// DependencyError is returned by CheckDependencies when one or more required
+// dependencies are not available.
+type DependencyError struct {
+	Failures map[string]error
+}
+
+func (err *DependencyError) Error() string {
+	names := make([]string, 0, len(err.Failures))
+	for name := range err.Failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("dependency checks failed: %s", strings.Join(names, ", "))
+}
+
+// CheckDependencies runs the checks concurrently and logs the result of each
+// one using the logger from the context. It returns a *DependencyError if any
+// required check fails.
+func CheckDependencies(ctx context.Context, checks ...DependencyCheck) error {
+	logger := zerolog.Ctx(ctx)
+
+	type result struct {
+		err     error
+		elapsed time.Duration
+	}
+	results := make([]result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c DependencyCheck) {
+			defer wg.Done()
+
+			timeout := c.Timeout
+			if timeout == 0 {
+				timeout = DefaultDependencyCheckTimeout
+			}
+
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := runDependencyCheck(cctx, c.Check)
+			results[i] = result{err: err, elapsed: time.Since(start)}
+		}(i, c)
+	}
+	wg.Wait()
+
+	var failures map[string]error
+	for i, c := range checks {
+		res := results[i]
+
+		var event *zerolog.Event
+		switch {
+		case res.err == nil:
+			event = logger.Info()
+		case c.Optional:
+			event = logger.Warn().Err(res.err)
+		default:
+			event = logger.Error().Err(res.err)
+			if failures == nil {
+				failures = make(map[string]error)
+			}
+			failures[c.Name] = res.err
+		}
+
+		event.Str("dependency", c.Name).
+			Bool("available", res.err == nil).
+			Bool("optional", c.Optional).
+			Dur("elapsed", res.elapsed).
+			Msg("Dependency check")
+	}
+
+	if len(failures) > 0 {
+		return &DependencyError{Failures: failures}
+	}
+	return nil
+}
+
+func runDependencyCheck(ctx context.Context, check func(context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		// Recover in the goroutine running the check; a panic here is not
+		// visible to the caller and would otherwise crash the process
+		defer func() {
+			if v := recover(); v != nil {
+				done <- errors.Errorf("panic: %v", v)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "check did not complete")
+	}
+}
+
+// HTTPDependencyCheck returns a check that sends a GET request to url and
+// fails if the request fails or returns a 5XX status code.
+func HTTPDependencyCheck(name, url string) DependencyCheck {
+	return DependencyCheck{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return err
+			}
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			_ = res.Body.Close()
+			if res.StatusCode >= 500 {
+				return errors.Errorf("unexpected status code: %d", res.StatusCode)
+			}
+			return nil
+		},
+	}
+}
+
+// DialDependencyCheck returns a check that opens and closes a TCP connection
+// to addr.
+func DialDependencyCheck(name, addr string) DependencyCheck {
+	return DependencyCheck{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
+
+// DNSDependencyCheck returns a check that fails if host does not resolve to
+// at least one address.
+func DNSDependencyCheck(name, host string) DependencyCheck {
+	return DependencyCheck{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+			if err != nil {
+				return err
+			}
+			if len(addrs) == 0 {
+				return errors.Errorf("no addresses found for %s", host)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("unavailable") }
+	hang := func(ctx context.Context) error { <-ctx.Done(); time.Sleep(time.Second); return nil }
+
+	t.Run("success", func(t *testing.T) {
+		err := CheckDependencies(context.Background(),
+			DependencyCheck{Name: "db", Check: ok},
+			DependencyCheck{Name: "cache", Check: fail, Optional: true},
+		)
+		assert.NoError(t, err)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		err := CheckDependencies(context.Background(),
+			DependencyCheck{Name: "db", Check: fail},
+			DependencyCheck{Name: "idp", Check: hang, Timeout: 10 * time.Millisecond},
+			DependencyCheck{Name: "cache", Check: ok},
+		)
+
+		var derr *DependencyError
+		require.ErrorAs(t, err, &derr)
+		assert.Len(t, derr.Failures, 2)
+		assert.Contains(t, derr.Failures, "db")
+		assert.Contains(t, derr.Failures, "idp")
+		assert.Equal(t, "dependency checks failed: db, idp", err.Error())
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		err := CheckDependencies(context.Background(),
+			DependencyCheck{Name: "db", Check: func(ctx context.Context) error { panic("boom") }},
+		)
+
+		var derr *DependencyError
+		require.ErrorAs(t, err, &derr)
+		require.Contains(t, derr.Failures, "db")
+		assert.EqualError(t, derr.Failures["db"], "panic: boom")
+	})
+}
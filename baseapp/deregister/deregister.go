@@ -0,0 +1,258 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deregister removes a server from load balancers and service
+// registries before it drains during a graceful shutdown.
+//
+// Without deregistration, a load balancer may keep sending new requests to a
+// server after it stops accepting connections. A Hook calls user-provided
+// functions, like ALB target deregistration or a Consul deregister call, and
+// waits for the load balancer to confirm the server was removed before the
+// server stops accepting requests. The hook also provides a health check
+// handler that fails once deregistration begins, for load balancers that
+// only use health checks to route traffic.
+package deregister
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	MetricsKeyDuration = "deregister.duration"
+	MetricsKeyErrors   = "deregister.errors"
+	MetricsKeyTimeouts = "deregister.timeouts"
+
+	// DefaultPollInterval is the interval between confirmation checks if a
+	// Target does not set one.
+	DefaultPollInterval = time.Second
+)
+
+type deregisterMetrics struct {
+	Duration appmetrics.Tagged[metrics.Timer]   `metric:"deregister.duration"`
+	Errors   appmetrics.Tagged[metrics.Counter] `metric:"deregister.errors"`
+	Timeouts appmetrics.Tagged[metrics.Counter] `metric:"deregister.timeouts"`
+}
+
+// Target is a load balancer or registry that routes traffic to the server.
+type Target struct {
+	// Name identifies the target in logs and metrics. It is required.
+	Name string
+
+	// Deregister starts removing the server from the target. It is required.
+	Deregister func(ctx context.Context) error
+
+	// Confirm, if set, reports whether the target stopped routing traffic
+	// to the server. It is called every PollInterval after Deregister
+	// returns until it returns true, returns an error, or the wait times
+	// out. If Confirm is nil, the hook waits for Delay instead.
+	Confirm func(ctx context.Context) (bool, error)
+
+	// PollInterval is the time between calls to Confirm. If zero,
+	// DefaultPollInterval is used.
+	PollInterval time.Duration
+
+	// Delay is the time to wait after deregistration is confirmed, or after
+	// Deregister returns if Confirm is nil. Use it to cover delays in
+	// propagating the change to the target's nodes.
+	Delay time.Duration
+
+	// Timeout limits the total time spent deregistering from the target. If
+	// zero, the hook waits until the shutdown context expires.
+	Timeout time.Duration
+}
+
+// Hook deregisters a server from a set of targets.
+//
+// For each target, the hook records the time spent deregistering in the
+// "deregister.duration" timer, failures in the "deregister.errors" counter,
+// and waits that time out in the "deregister.timeouts" counter. All metrics
+// are tagged with the target name, like "deregister.duration[target:alb]".
+type Hook struct {
+	logger  zerolog.Logger
+	metrics *deregisterMetrics
+	targets []Target
+
+	draining atomic.Bool
+	once     sync.Once
+	err      error
+}
+
+// New creates a Hook for the targets that logs progress with logger and
+// records metrics in registry.
+func New(logger zerolog.Logger, registry metrics.Registry, targets ...Target) (*Hook, error) {
+	targets = append([]Target(nil), targets...)
+	for i, t := range targets {
+		if t.Name == "" {
+			return nil, errors.New("deregister: target name is required")
+		}
+		if t.Deregister == nil {
+			return nil, errors.Errorf("deregister: target %s: deregister function is required", t.Name)
+		}
+		if t.PollInterval == 0 {
+			targets[i].PollInterval = DefaultPollInterval
+		}
+	}
+
+	m := appmetrics.New[deregisterMetrics]()
+	appmetrics.Register(registry, m)
+
+	return &Hook{
+		logger:  logger,
+		metrics: m,
+		targets: targets,
+	}, nil
+}
+
+// Register creates a Hook using the logger and registry of the server and
+// deregisters from the targets when the server begins a graceful shutdown,
+// before the server stops accepting requests.
+//
+// Because the server calls shutdown functions in reverse order, call Register
+// after registering other shutdown functions, like those added by the
+// consumers and scheduler packages, so that deregistration starts first. As
+// with other shutdown functions, the hook only runs if the server has a
+// ShutdownWaitTime, which must be long enough to deregister and drain.
+func Register(s *baseapp.Server, targets ...Target) (*Hook, error) {
+	h, err := New(s.Logger(), s.Registry(), targets...)
+	if err != nil {
+		return nil, err
+	}
+	s.OnShutdown(h.Deregister)
+	return h, nil
+}
+
+// Draining returns true once deregistration has started.
+func (h *Hook) Draining() bool {
+	return h.draining.Load()
+}
+
+// HealthHandler returns a handler for load balancer health checks. It
+// responds with status 200 until deregistration starts and with status 503
+// afterwards.
+func (h *Hook) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusOK
+		if h.Draining() {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+	})
+}
+
+// Deregister deregisters from all targets concurrently and waits until each
+// target confirms the server was removed or times out. It returns an error
+// that includes all failures and timeouts. Deregister only runs once; later
+// calls return the result of the first call.
+func (h *Hook) Deregister(ctx context.Context) error {
+	h.once.Do(func() {
+		h.draining.Store(true)
+
+		errs := make([]error, len(h.targets))
+
+		var wg sync.WaitGroup
+		for i, t := range h.targets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = h.deregister(ctx, t)
+			}()
+		}
+		wg.Wait()
+
+		h.err = stderrors.Join(errs...)
+	})
+	return h.err
+}
+
+func (h *Hook) deregister(ctx context.Context, t Target) error {
+	tag := "target:" + t.Name
+	logger := h.logger.With().Str("target", t.Name).Logger()
+	start := time.Now()
+
+	if t.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.Timeout)
+		defer cancel()
+	}
+
+	err := h.wait(ctx, logger, t)
+	elapsed := time.Since(start)
+	h.metrics.Duration.Tag(tag).Update(elapsed)
+
+	switch {
+	case err == nil:
+		logger.Info().Dur("elapsed", elapsed).Msg("Deregistration complete")
+		return nil
+	case ctx.Err() != nil && stderrors.Is(err, ctx.Err()):
+		h.metrics.Timeouts.Tag(tag).Inc(1)
+		logger.Warn().Dur("elapsed", elapsed).Msg("Timed out waiting for deregistration")
+	default:
+		h.metrics.Errors.Tag(tag).Inc(1)
+		logger.Error().Err(err).Dur("elapsed", elapsed).Msg("Deregistration failed")
+	}
+	return errors.Wrapf(err, "deregister: target %s", t.Name)
+}
+
+func (h *Hook) wait(ctx context.Context, logger zerolog.Logger, t Target) error {
+	logger.Info().Msg("Deregistering from target")
+	if err := t.Deregister(ctx); err != nil {
+		return err
+	}
+
+	if t.Confirm != nil {
+		logger.Info().Msg("Waiting for target to confirm deregistration")
+
+		ticker := time.NewTicker(t.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			ok, err := t.Confirm(ctx)
+			if err != nil {
+				return err
+			}
+			if ok {
+				break
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+
+	if t.Delay > 0 {
+		timer := time.NewTimer(t.Delay)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
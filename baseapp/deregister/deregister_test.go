@@ -0,0 +1,119 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deregister
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHook(t *testing.T) {
+	count := func(r metrics.Registry, name string) int64 {
+		if c, ok := r.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	t.Run("confirmed", func(t *testing.T) {
+		var deregistered atomic.Bool
+		var checks atomic.Int32
+
+		registry := metrics.NewRegistry()
+		h, err := New(zerolog.Nop(), registry, Target{
+			Name: "alb",
+			Deregister: func(ctx context.Context) error {
+				deregistered.Store(true)
+				return nil
+			},
+			Confirm: func(ctx context.Context) (bool, error) {
+				return checks.Add(1) == 3, nil
+			},
+			PollInterval: time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		health := func() int {
+			w := httptest.NewRecorder()
+			h.HealthHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+			return w.Code
+		}
+		assert.Equal(t, http.StatusOK, health())
+
+		require.NoError(t, h.Deregister(context.Background()))
+		assert.True(t, deregistered.Load())
+		assert.Equal(t, int32(3), checks.Load())
+		assert.Equal(t, http.StatusServiceUnavailable, health())
+		assert.Equal(t, int64(1), registry.Get("deregister.duration[target:alb]").(metrics.Timer).Count())
+
+		require.NoError(t, h.Deregister(context.Background()), "later calls return the first result")
+		assert.Equal(t, int32(3), checks.Load())
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		h, err := New(zerolog.Nop(), registry, Target{
+			Name:       "consul",
+			Deregister: func(ctx context.Context) error { return nil },
+			Confirm: func(ctx context.Context) (bool, error) {
+				return false, nil
+			},
+			PollInterval: time.Millisecond,
+			Timeout:      10 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		err = h.Deregister(context.Background())
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, int64(1), count(registry, "deregister.timeouts[target:consul]"))
+		assert.Zero(t, count(registry, "deregister.errors[target:consul]"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		h, err := New(zerolog.Nop(), registry,
+			Target{
+				Name:       "failing",
+				Deregister: func(ctx context.Context) error { return errors.New("boom") },
+			},
+			Target{
+				Name:       "delayed",
+				Deregister: func(ctx context.Context) error { return nil },
+				Delay:      time.Millisecond,
+			},
+		)
+		require.NoError(t, err)
+
+		err = h.Deregister(context.Background())
+		assert.EqualError(t, err, "deregister: target failing: boom")
+		assert.Equal(t, int64(1), count(registry, "deregister.errors[target:failing]"))
+		assert.Zero(t, count(registry, "deregister.errors[target:delayed]"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := New(zerolog.Nop(), metrics.NewRegistry(), Target{Name: "missing"})
+		assert.Error(t, err)
+	})
+}
@@ -16,8 +16,13 @@ package baseapp
 
 import (
 	"context"
+	stderrors "errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/bluekeyes/hatpear"
 	"github.com/palantir/go-baseapp/pkg/errfmt"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
@@ -30,6 +35,69 @@ type httpError interface {
 	StatusCode() int
 }
 
+const (
+	MetricsKeyBackpressure = "server.backpressure"
+)
+
+// RetryableError is an error that indicates the server is overloaded or a
+// client exceeded a limit and that the request may succeed if retried later.
+// Code that does not know about HTTP can return it to reject work, and
+// HandleRouteError converts it to a response with a Retry-After header.
+//
+// Return RetryableError as a value, not a pointer. It may be wrapped with
+// fmt.Errorf or the errors package.
+type RetryableError struct {
+	// After is the minimum time the client should wait before retrying. If
+	// zero, the response does not include a Retry-After header.
+	After time.Duration
+
+	// Status is the HTTP status of the response, usually 429 if a client
+	// exceeded a limit or 503 if the server is overloaded. If zero, 503 is
+	// used.
+	Status int
+
+	// Err is the underlying cause, if any.
+	Err error
+}
+
+func (e RetryableError) Error() string {
+	msg := "retryable error"
+	if e.After > 0 {
+		msg += ": retry after " + e.After.String()
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e RetryableError) Unwrap() error {
+	return e.Err
+}
+
+func (e RetryableError) StatusCode() int {
+	if e.Status == 0 {
+		return http.StatusServiceUnavailable
+	}
+	return e.Status
+}
+
+// retryAfter formats a duration as the value of a Retry-After header. The
+// header only supports whole seconds, so durations are rounded up.
+func retryAfter(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
+
+// isPanic returns true if err is from a panic recovered by NewRecoverHandler
+// or hatpear.Recover.
+func isPanic(err error) bool {
+	if perr := (PanicError{}); stderrors.As(err, &perr) {
+		return true
+	}
+	perr := hatpear.PanicError{}
+	return stderrors.As(err, &perr)
+}
+
 // RichErrorMarshalFunc is a zerolog error marshaller that formats the error as
 // a string that includes a stack trace, if one is available.
 func RichErrorMarshalFunc(err error) interface{} {
@@ -39,21 +107,55 @@ func RichErrorMarshalFunc(err error) interface{} {
 // HandleRouteError is a hatpear error handler that logs the error and sends
 // an error response to the client. If the error has a `StatusCode` function
 // this will be called and converted to an appropriate HTTP status code error.
+//
+// The log entry includes the type of the root cause of the error in the
+// "error_type" field. The handler counts errors in the "baseapp.route_errors"
+// counter, tagged with the status of the response, and counts errors from
+// recovered panics in the "baseapp.panics" counter.
+//
+// If the error is or wraps a RetryableError, the response uses the status of
+// that error and includes a Retry-After header. The handler logs these errors
+// as warnings and increments the "server.backpressure" counter, tagged with
+// the status, like "server.backpressure[status:503]".
 func HandleRouteError(w http.ResponseWriter, r *http.Request, err error) {
+	setRouteError(r.Context(), err)
+
+	if isPanic(err) {
+		CounterFromCtx(r.Context(), MetricsKeyPanics).Inc(1)
+	}
+
 	var log *zerolog.Event
+	var statusCode int
+
 	// Either the deadline has passed or the request was canceled
 	// 499 is an NGINX style response code for 'Client Closed Connection'
 	// and is a non-standard, but widely used, HTTP status code
 	if cerr := r.Context().Err(); cerr == context.Canceled {
 		log = hlog.FromRequest(r).Debug()
-		WriteJSON(w, 499, map[string]string{
+		statusCode = 499
+		WriteJSON(w, statusCode, map[string]string{
 			"error": "Client Closed Connection",
 		})
+	} else if rerr := (RetryableError{}); stderrors.As(err, &rerr) {
+		log = hlog.FromRequest(r).Warn().Err(err)
+
+		statusCode = rerr.StatusCode()
+		CounterFromCtx(r.Context(), MetricsKeyBackpressure, "status:"+strconv.Itoa(statusCode)).Inc(1)
+
+		if rerr.After > 0 {
+			w.Header().Set("Retry-After", retryAfter(rerr.After))
+		}
+
+		rid, _ := hlog.IDFromRequest(r)
+		WriteJSON(w, statusCode, map[string]string{
+			"error":      http.StatusText(statusCode),
+			"request_id": rid.String(),
+		})
 	} else {
 		log = hlog.FromRequest(r).Error().Err(err)
 
 		cause := errors.Cause(err)
-		statusCode := http.StatusInternalServerError
+		statusCode = http.StatusInternalServerError
 		if aerr, ok := cause.(httpError); ok {
 			statusCode = aerr.StatusCode()
 		}
@@ -65,6 +167,12 @@ func HandleRouteError(w http.ResponseWriter, r *http.Request, err error) {
 		})
 	}
 
+	CounterFromCtx(r.Context(), MetricsKeyRouteErrors, "status:"+strconv.Itoa(statusCode)).Inc(1)
+
+	if root, ok := errfmt.RootCause(err); ok {
+		log = log.Str("error_type", root.Type)
+	}
+
 	log.Str("method", r.Method).
 		Str("path", r.URL.String()).
 		Msg("Unhandled error while serving route")
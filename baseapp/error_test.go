@@ -0,0 +1,78 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bluekeyes/hatpear"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleRouteErrorRetryable(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	send := func(err error) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(WithMetricsCtx(r.Context(), registry))
+		w := httptest.NewRecorder()
+		HandleRouteError(w, r, err)
+		return w
+	}
+	count := func(name string) int64 {
+		if c, ok := registry.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	w := send(fmt.Errorf("queue full: %w", RetryableError{After: 1500 * time.Millisecond}))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+	assert.Equal(t, int64(1), count("server.backpressure[status:503]"))
+
+	w = send(errors.Wrap(RetryableError{Status: http.StatusTooManyRequests, Err: errors.New("quota exceeded")}, "rejected"))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, int64(1), count("server.backpressure[status:429]"))
+
+	w = send(errors.New("boom"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestHandleRouteErrorMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	handler := NewMetricsHandler(registry)(hatpear.Catch(HandleRouteError)(hatpear.Recover()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		hatpear.Store(r, notFoundError{})
+	}))))
+
+	for _, path := range []string{"/panic", "/missing", "/missing"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, int64(1), registry.Get("baseapp.panics").(metrics.Counter).Count())
+	assert.Equal(t, int64(1), registry.Get("baseapp.route_errors[status:500]").(metrics.Counter).Count())
+	assert.Equal(t, int64(2), registry.Get("baseapp.route_errors[status:404]").(metrics.Counter).Count())
+}
@@ -0,0 +1,300 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package errorbudget provides middleware that sheds traffic when the error
+// rate of a dependency exceeds a threshold.
+//
+// A Guard watches pairs of error and total counters in a metrics registry.
+// When the ratio of new errors to new requests for any pair exceeds its
+// threshold, the guard starts rejecting a fraction of requests with 503
+// responses, increasing the fraction at each check while the error rate stays
+// high and decreasing it once the rate recovers. This reduces the load on a
+// struggling dependency without manual intervention. Requests that match the
+// critical matcher are never rejected.
+//
+//	guard := errorbudget.NewGuard(server.Registry(), []errorbudget.Budget{
+//		{Name: "database", Errors: "db.errors", Total: "db.queries", Threshold: 0.5},
+//	}, errorbudget.WithCritical(baseapp.MatchPathPrefix("/api/health")))
+//
+//	go guard.Run(ctx, 10*time.Second)
+//	handler = guard.Handler()(handler)
+package errorbudget
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	MetricsKeyShedFraction = "server.errorbudget.shed_fraction"
+	MetricsKeyShed         = "server.errorbudget.shed"
+)
+
+// ErrShed is the cause of the RetryableError used to reject requests.
+var ErrShed = errors.New("errorbudget: request rejected to protect dependencies")
+
+// Budget defines the acceptable error rate for a dependency.
+type Budget struct {
+	// Name identifies the budget in log messages.
+	Name string
+
+	// Errors and Total are the names of metrics in the registry that count
+	// failed requests and all requests to the dependency. The metrics must
+	// have a Count method, like counters, meters, and timers. Missing metrics
+	// have a count of zero.
+	Errors string
+	Total  string
+
+	// Threshold is the ratio of errors to total requests, between 0 and 1,
+	// above which the guard sheds traffic.
+	Threshold float64
+
+	// MinRequests is the minimum number of requests between checks needed to
+	// evaluate the budget. It prevents a few errors during periods of low
+	// traffic from triggering the guard.
+	MinRequests int64
+}
+
+type Option func(*Guard)
+
+// WithCritical sets a matcher for requests that are never rejected, like
+// health checks or requests that do not use the protected dependencies.
+func WithCritical(m baseapp.RequestMatcher) Option {
+	return func(g *Guard) {
+		g.critical = m
+	}
+}
+
+// WithStep sets the amount by which the shed fraction increases or decreases
+// at each check. The default is 0.1.
+func WithStep(step float64) Option {
+	return func(g *Guard) {
+		g.step = step
+	}
+}
+
+// WithMaxFraction sets the maximum fraction of requests that the guard
+// rejects. The default is 0.9, so that some requests always reach the
+// dependencies and the guard can detect when they recover.
+func WithMaxFraction(fraction float64) Option {
+	return func(g *Guard) {
+		g.maxFraction = fraction
+	}
+}
+
+// WithRetryAfter sets the value of the Retry-After header on rejected
+// requests. The default is the interval passed to Run, or one second if Run
+// is not used.
+func WithRetryAfter(d time.Duration) Option {
+	return func(g *Guard) {
+		g.retryAfter = d
+	}
+}
+
+// WithLogger sets the logger used to report changes in the shed fraction.
+// By default, changes are not logged.
+func WithLogger(logger zerolog.Logger) Option {
+	return func(g *Guard) {
+		g.logger = logger
+	}
+}
+
+// Guard sheds traffic when dependencies exceed their error budgets.
+//
+// The guard reports the current shed fraction in the
+// "server.errorbudget.shed_fraction" gauge and counts rejected requests in
+// the "server.errorbudget.shed" counter. Rejected requests are handled by
+// baseapp.HandleRouteError as a baseapp.RetryableError, so they are also
+// counted as backpressure.
+type Guard struct {
+	registry    metrics.Registry
+	budgets     []Budget
+	critical    baseapp.RequestMatcher
+	step        float64
+	maxFraction float64
+	retryAfter  time.Duration
+	logger      zerolog.Logger
+
+	fractionGauge metrics.GaugeFloat64
+	shed          metrics.Counter
+
+	mu       sync.Mutex
+	fraction float64
+	last     map[string]int64
+	cancel   context.CancelFunc
+}
+
+// NewGuard creates a Guard that watches the budgets using the metrics in
+// registry. It registers the guard's metrics in the same registry.
+func NewGuard(registry metrics.Registry, budgets []Budget, opts ...Option) *Guard {
+	g := &Guard{
+		registry:    registry,
+		budgets:     budgets,
+		critical:    func(*http.Request) bool { return false },
+		step:        0.1,
+		maxFraction: 0.9,
+		logger:      zerolog.Nop(),
+		last:        make(map[string]int64),
+
+		fractionGauge: metrics.GetOrRegisterGaugeFloat64(MetricsKeyShedFraction, registry),
+		shed:          metrics.GetOrRegisterCounter(MetricsKeyShed, registry),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	// Record the initial counts so the first check only sees new requests
+	for _, b := range budgets {
+		g.last[b.Errors] = g.count(b.Errors)
+		g.last[b.Total] = g.count(b.Total)
+	}
+
+	return g
+}
+
+// Handler returns middleware that rejects the current fraction of
+// non-critical requests.
+func (g *Guard) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if f := g.Fraction(); f > 0 && !g.critical(r) && rand.Float64() < f {
+				g.shed.Inc(1)
+				baseapp.HandleRouteError(w, r, baseapp.RetryableError{
+					After: g.retryAfterDuration(),
+					Err:   ErrShed,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Fraction returns the current fraction of non-critical requests that the
+// guard rejects.
+func (g *Guard) Fraction() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.fraction
+}
+
+// Run calls Check at the given interval until the context is canceled or Stop
+// is called.
+func (g *Guard) Run(ctx context.Context, interval time.Duration) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	g.mu.Lock()
+	g.cancel = cancel
+	if g.retryAfter == 0 {
+		g.retryAfter = interval
+	}
+	g.mu.Unlock()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			g.Check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the current call to Run.
+func (g *Guard) Stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		g.cancel()
+	}
+}
+
+// Check evaluates the budgets using the requests counted since the last check
+// and updates the shed fraction. If any budget is exceeded, the fraction
+// increases by the step, up to the maximum. Otherwise, it decreases by the
+// step. Check returns the new fraction.
+func (g *Guard) Check() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	// Multiple budgets may use the same metric, so read all counts before
+	// updating the previous values
+	counts := make(map[string]int64, len(g.last))
+	for name := range g.last {
+		counts[name] = g.count(name)
+	}
+
+	var breached []string
+	for _, b := range g.budgets {
+		errs := counts[b.Errors] - g.last[b.Errors]
+		total := counts[b.Total] - g.last[b.Total]
+		if total <= 0 || total < b.MinRequests {
+			continue
+		}
+		if float64(errs)/float64(total) > b.Threshold {
+			breached = append(breached, b.Name)
+		}
+	}
+
+	g.last = counts
+
+	prev := g.fraction
+	if len(breached) > 0 {
+		g.fraction = min(g.fraction+g.step, g.maxFraction)
+	} else {
+		g.fraction = max(g.fraction-g.step, 0)
+	}
+	// Avoid leaving a tiny fraction from floating point error
+	if g.fraction < g.step/2 {
+		g.fraction = 0
+	}
+	g.fractionGauge.Update(g.fraction)
+
+	switch {
+	case prev == 0 && g.fraction > 0:
+		g.logger.Warn().Strs("budgets", breached).Float64("fraction", g.fraction).Msg("Error budget exceeded, shedding traffic")
+	case prev > 0 && g.fraction == 0:
+		g.logger.Info().Msg("Error budgets recovered, stopped shedding traffic")
+	}
+
+	return g.fraction
+}
+
+func (g *Guard) count(name string) int64 {
+	if m, ok := g.registry.Get(name).(interface{ Count() int64 }); ok {
+		return m.Count()
+	}
+	return 0
+}
+
+func (g *Guard) retryAfterDuration() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.retryAfter == 0 {
+		return time.Second
+	}
+	return g.retryAfter
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errorbudget
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuard(t *testing.T) {
+	registry := metrics.NewRegistry()
+	errs := metrics.GetOrRegisterCounter("db.errors", registry)
+	total := metrics.GetOrRegisterCounter("db.queries", registry)
+
+	// counts before the guard is created are ignored
+	errs.Inc(100)
+	total.Inc(100)
+
+	g := NewGuard(registry, []Budget{
+		{Name: "database", Errors: "db.errors", Total: "db.queries", Threshold: 0.5, MinRequests: 10},
+	}, WithStep(0.5), WithMaxFraction(1), WithRetryAfter(5*time.Second), WithCritical(baseapp.MatchPathPrefix("/health")))
+
+	assert.Zero(t, g.Check(), "no requests should not shed traffic")
+
+	errs.Inc(5)
+	total.Inc(5)
+	assert.Zero(t, g.Check(), "too few requests should not shed traffic")
+
+	errs.Inc(8)
+	total.Inc(10)
+	assert.Equal(t, 0.5, g.Check())
+
+	errs.Inc(8)
+	total.Inc(10)
+	assert.Equal(t, 1.0, g.Check())
+	assert.Equal(t, 1.0, registry.Get(MetricsKeyShedFraction).(metrics.GaugeFloat64).Value())
+
+	handler := g.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r = r.WithContext(baseapp.WithMetricsCtx(r.Context(), registry))
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("/api/items")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serve("/health").Code, "critical requests should not be rejected")
+	assert.Equal(t, int64(1), registry.Get(MetricsKeyShed).(metrics.Counter).Count())
+
+	total.Inc(10)
+	assert.Equal(t, 0.5, g.Check())
+	total.Inc(10)
+	assert.Zero(t, g.Check())
+	assert.Equal(t, http.StatusOK, serve("/api/items").Code)
+}
+
+func TestGuardSharedMetric(t *testing.T) {
+	registry := metrics.NewRegistry()
+	total := metrics.GetOrRegisterCounter("requests", registry)
+	readErrs := metrics.GetOrRegisterCounter("read.errors", registry)
+	metrics.GetOrRegisterCounter("write.errors", registry)
+
+	g := NewGuard(registry, []Budget{
+		{Name: "write", Errors: "write.errors", Total: "requests", Threshold: 0.1},
+		{Name: "read", Errors: "read.errors", Total: "requests", Threshold: 0.1},
+	})
+
+	total.Inc(10)
+	readErrs.Inc(5)
+	assert.InDelta(t, 0.1, g.Check(), 1e-9)
+}
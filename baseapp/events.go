@@ -0,0 +1,102 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// RequestEvent is a named event that occurred while handling a request.
+type RequestEvent struct {
+	Name string
+	Time time.Time
+
+	// Fields contains alternating keys and values, as passed to
+	// AddRequestEvent.
+	Fields []any
+}
+
+// RequestEventHook is called for each event added to a request. Hooks can
+// use this to record events in other systems, like adding span events to the
+// active trace.
+type RequestEventHook func(ctx context.Context, e RequestEvent)
+
+type requestEventsCtxKey struct{}
+type requestEventHookCtxKey struct{}
+
+type requestEvents struct {
+	mu     sync.Mutex
+	events []RequestEvent
+}
+
+// AddRequestEvent records an event for the request with the given context.
+// Fields are alternating keys and values, like "cache", "miss", "attempts",
+// 3. The event is included in the "events" field of the access log entry for
+// the request and is passed to the hook set by WithRequestEventHook, if any.
+//
+// Events are only recorded in the access log for requests handled by
+// AccessHandler. AddRequestEvent is safe to call from multiple goroutines.
+func AddRequestEvent(ctx context.Context, name string, fields ...any) {
+	e := RequestEvent{
+		Name:   name,
+		Time:   time.Now(),
+		Fields: fields,
+	}
+
+	if events, ok := ctx.Value(requestEventsCtxKey{}).(*requestEvents); ok {
+		events.mu.Lock()
+		events.events = append(events.events, e)
+		events.mu.Unlock()
+	}
+	if hook, ok := ctx.Value(requestEventHookCtxKey{}).(RequestEventHook); ok {
+		hook(ctx, e)
+	}
+}
+
+// RequestEvents returns the events recorded for the request with the given
+// context, in the order they were added.
+func RequestEvents(ctx context.Context) []RequestEvent {
+	events, ok := ctx.Value(requestEventsCtxKey{}).(*requestEvents)
+	if !ok {
+		return nil
+	}
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	return append([]RequestEvent(nil), events.events...)
+}
+
+// WithRequestEventHook returns a context in which AddRequestEvent also calls
+// hook. Tracing middleware can use this to add request events to spans.
+func WithRequestEventHook(ctx context.Context, hook RequestEventHook) context.Context {
+	return context.WithValue(ctx, requestEventHookCtxKey{}, hook)
+}
+
+// requestEventsArray formats events for logging. The time of each event is
+// reported relative to the start of the request.
+func requestEventsArray(events []RequestEvent, start time.Time) *zerolog.Array {
+	arr := zerolog.Arr()
+	for _, e := range events {
+		arr = arr.Dict(zerolog.Dict().
+			Str("name", e.Name).
+			Dur("at", e.Time.Sub(start)).
+			Fields(e.Fields))
+	}
+	return arr
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddRequestEvent(t *testing.T) {
+	var logs bytes.Buffer
+	var hooked []string
+
+	tracing := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := WithRequestEventHook(r.Context(), func(ctx context.Context, e RequestEvent) {
+				hooked = append(hooked, e.Name)
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	handler := hlog.NewHandler(zerolog.New(&logs))(tracing(AccessHandler(LogRequest)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		AddRequestEvent(r.Context(), "cache_miss", "key", "users/1")
+		AddRequestEvent(r.Context(), "retry", "attempt", 2)
+	}))))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []string{"cache_miss", "retry"}, hooked)
+
+	var entry struct {
+		Events []map[string]any `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	require.Len(t, entry.Events, 2)
+	assert.Equal(t, "cache_miss", entry.Events[0]["name"])
+	assert.Equal(t, "users/1", entry.Events[0]["key"])
+	assert.Equal(t, "retry", entry.Events[1]["name"])
+	assert.Equal(t, float64(2), entry.Events[1]["attempt"])
+	assert.Contains(t, entry.Events[0], "at")
+
+	// events outside of a request are ignored
+	AddRequestEvent(context.Background(), "ignored")
+	assert.Empty(t, RequestEvents(context.Background()))
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+type externalURLCtxKey struct{}
+
+// ExternalURL returns the scheme and host that clients used to make the
+// request, which may differ from the values seen by the server if the request
+// passed through a proxy. The returned URL only has the Scheme and Host
+// fields set; callers can set other fields to build absolute URLs.
+//
+// If the request was handled by the middleware returned by
+// NewForwardedHandler, ExternalURL uses the values computed from the
+// forwarding headers. Otherwise, it uses r.Host and the scheme of the
+// connection.
+func ExternalURL(r *http.Request) *url.URL {
+	if u, ok := r.Context().Value(externalURLCtxKey{}).(url.URL); ok {
+		return &u
+	}
+	return directURL(r)
+}
+
+// NewForwardedHandler returns middleware that computes the external scheme
+// and host of requests from the Forwarded header (RFC 7239) or from the
+// X-Forwarded-Proto, X-Forwarded-Host, and X-Forwarded-Port headers. Handlers
+// can get the result with ExternalURL.
+//
+// Clients can set forwarding headers to any value, so the middleware only
+// uses them if the request comes from an address in one of the trusted
+// prefixes. With the Forwarded header, the middleware also uses the values
+// added by earlier proxies if they are trusted. With X-Forwarded-* headers,
+// it only uses the last value of each header, which is the value set by the
+// nearest proxy.
+//
+// The middleware does not modify the request URL, r.Host, or r.RemoteAddr.
+func NewForwardedHandler(trusted ...netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		addr = addr.Unmap()
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u := directURL(r)
+			if addr, ok := remoteAddr(r.RemoteAddr); ok && isTrusted(addr) {
+				if r.Header.Get("Forwarded") != "" {
+					applyForwarded(u, r.Header.Values("Forwarded"), isTrusted)
+				} else {
+					applyXForwarded(u, r.Header)
+				}
+			}
+			r = r.WithContext(context.WithValue(r.Context(), externalURLCtxKey{}, *u))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func directURL(r *http.Request) *url.URL {
+	u := &url.URL{Scheme: "http", Host: r.Host}
+	if r.TLS != nil {
+		u.Scheme = "https"
+	}
+	return u
+}
+
+// applyForwarded sets the scheme and host of u from the elements of the
+// Forwarded header. Each proxy appends an element that describes the request
+// it received, so the elements are read from last to first, continuing as
+// long as the client of the proxy that added the element was also trusted.
+func applyForwarded(u *url.URL, headers []string, isTrusted func(netip.Addr) bool) {
+	var elements []string
+	for _, h := range headers {
+		elements = append(elements, strings.Split(h, ",")...)
+	}
+
+	for i := len(elements) - 1; i >= 0; i-- {
+		params := parseForwardedElement(elements[i])
+		if proto, ok := params["proto"]; ok {
+			if scheme, ok := validScheme(proto); ok {
+				u.Scheme = scheme
+			}
+		}
+		if host, ok := params["host"]; ok && validHost(host) {
+			u.Host = host
+		}
+
+		addr, ok := forwardedNode(params["for"])
+		if !ok || !isTrusted(addr) {
+			return
+		}
+	}
+}
+
+// applyXForwarded sets the scheme and host of u from the X-Forwarded-*
+// headers.
+func applyXForwarded(u *url.URL, h http.Header) {
+	if proto := lastValue(h, "X-Forwarded-Proto"); proto != "" {
+		if scheme, ok := validScheme(proto); ok {
+			u.Scheme = scheme
+		}
+	}
+	if host := lastValue(h, "X-Forwarded-Host"); host != "" && validHost(host) {
+		u.Host = host
+	}
+	if port := lastValue(h, "X-Forwarded-Port"); port != "" {
+		if n, err := strconv.Atoi(port); err == nil && n > 0 && n < 1<<16 {
+			hostname := u.Hostname()
+			if strings.Contains(hostname, ":") {
+				hostname = "[" + hostname + "]"
+			}
+			if (u.Scheme == "http" && n == 80) || (u.Scheme == "https" && n == 443) {
+				u.Host = hostname
+			} else {
+				u.Host = hostname + ":" + port
+			}
+		}
+	}
+}
+
+// parseForwardedElement returns the parameters of an element of the
+// Forwarded header. Parameter names are case-insensitive and values may be
+// quoted strings.
+func parseForwardedElement(element string) map[string]string {
+	params := make(map[string]string)
+	for _, pair := range strings.Split(element, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+			if unquoted, err := strconv.Unquote(v); err == nil {
+				v = unquoted
+			} else {
+				v = v[1 : len(v)-1]
+			}
+		}
+		params[strings.ToLower(k)] = v
+	}
+	return params
+}
+
+// forwardedNode returns the address of a node in the "for" parameter of the
+// Forwarded header. Obfuscated and unknown nodes have no address.
+func forwardedNode(node string) (netip.Addr, bool) {
+	if strings.HasPrefix(node, "[") {
+		if end := strings.IndexByte(node, ']'); end > 0 {
+			node = node[1:end]
+		}
+	} else if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	addr, err := netip.ParseAddr(node)
+	return addr, err == nil
+}
+
+func remoteAddr(addr string) (netip.Addr, bool) {
+	if ap, err := netip.ParseAddrPort(addr); err == nil {
+		return ap.Addr(), true
+	}
+	a, err := netip.ParseAddr(addr)
+	return a, err == nil
+}
+
+func lastValue(h http.Header, name string) string {
+	values := h.Values(name)
+	if len(values) == 0 {
+		return ""
+	}
+	parts := strings.Split(values[len(values)-1], ",")
+	return strings.TrimSpace(parts[len(parts)-1])
+}
+
+func validScheme(proto string) (string, bool) {
+	switch strings.ToLower(proto) {
+	case "http":
+		return "http", true
+	case "https":
+		return "https", true
+	}
+	return "", false
+}
+
+// validHost returns true if host is a host name or address with an optional
+// port that is safe to use in a URL.
+func validHost(host string) bool {
+	if host == "" || strings.ContainsAny(host, "/\\@?#% \t") {
+		return false
+	}
+	u, err := url.Parse("http://" + host)
+	return err == nil && u.Host == host && u.Hostname() != ""
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalURL(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("fd00::/8"),
+	}
+
+	tests := map[string]struct {
+		RemoteAddr string
+		TLS        bool
+		Header     http.Header
+		URL        string
+	}{
+		"direct": {
+			RemoteAddr: "203.0.113.5:1234",
+			URL:        "http://app.internal:8080",
+		},
+		"directTLS": {
+			RemoteAddr: "203.0.113.5:1234",
+			TLS:        true,
+			URL:        "https://app.internal:8080",
+		},
+		"untrustedProxy": {
+			RemoteAddr: "203.0.113.5:1234",
+			Header:     http.Header{"Forwarded": {"proto=https;host=evil.example.com"}},
+			URL:        "http://app.internal:8080",
+		},
+		"forwarded": {
+			RemoteAddr: "10.1.2.3:1234",
+			Header:     http.Header{"Forwarded": {`for=198.51.100.7;proto=https;host="app.example.com"`}},
+			URL:        "https://app.example.com",
+		},
+		"forwardedIPv6Proxy": {
+			RemoteAddr: "[fd00::1]:1234",
+			Header:     http.Header{"Forwarded": {"For=198.51.100.7;Proto=HTTPS;Host=app.example.com:8443"}},
+			URL:        "https://app.example.com:8443",
+		},
+		"forwardedChain": {
+			RemoteAddr: "10.1.2.3:1234",
+			Header: http.Header{"Forwarded": {
+				"for=198.51.100.7;proto=https;host=app.example.com",
+				`for="10.9.9.9:4000";proto=http;host=lb.internal`,
+			}},
+			URL: "https://app.example.com",
+		},
+		"forwardedChainUntrusted": {
+			RemoteAddr: "10.1.2.3:1234",
+			Header: http.Header{"Forwarded": {
+				"for=192.0.2.1;proto=https;host=evil.example.com, for=198.51.100.7;proto=https;host=app.example.com",
+			}},
+			URL: "https://app.example.com",
+		},
+		"forwardedInvalid": {
+			RemoteAddr: "10.1.2.3:1234",
+			Header:     http.Header{"Forwarded": {"proto=ftp;host=evil.example.com/path"}},
+			URL:        "http://app.internal:8080",
+		},
+		"xForwarded": {
+			RemoteAddr: "10.1.2.3:1234",
+			Header: http.Header{
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"app.example.com"},
+			},
+			URL: "https://app.example.com",
+		},
+		"xForwardedPort": {
+			RemoteAddr: "10.1.2.3:1234",
+			Header: http.Header{
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"app.example.com:80"},
+				"X-Forwarded-Port":  {"8443"},
+			},
+			URL: "https://app.example.com:8443",
+		},
+		"xForwardedDefaultPort": {
+			RemoteAddr: "10.1.2.3:1234",
+			Header: http.Header{
+				"X-Forwarded-Proto": {"https"},
+				"X-Forwarded-Host":  {"app.example.com"},
+				"X-Forwarded-Port":  {"443"},
+			},
+			URL: "https://app.example.com",
+		},
+		"xForwardedLastValue": {
+			RemoteAddr: "10.1.2.3:1234",
+			Header: http.Header{
+				"X-Forwarded-Proto": {"http, https"},
+				"X-Forwarded-Host":  {"evil.example.com, app.example.com"},
+			},
+			URL: "https://app.example.com",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/saml/acs", nil)
+			r.Host = "app.internal:8080"
+			r.RemoteAddr = test.RemoteAddr
+			if test.TLS {
+				r.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range test.Header {
+				r.Header[k] = v
+			}
+
+			var external string
+			h := NewForwardedHandler(trusted...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				external = ExternalURL(r).String()
+			}))
+			h.ServeHTTP(httptest.NewRecorder(), r)
+
+			assert.Equal(t, test.URL, external)
+		})
+	}
+
+	t.Run("withoutMiddleware", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Host = "app.internal"
+		r.Header.Set("Forwarded", "proto=https;host=app.example.com")
+
+		assert.Equal(t, "http://app.internal", ExternalURL(r).String())
+	})
+}
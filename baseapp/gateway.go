@@ -0,0 +1,127 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	// GatewayRequestIDHeader is the header that NewGatewayHandler uses to
+	// send the request ID to gRPC services. The grpc-gateway forwards headers
+	// with the "Grpc-Metadata-" prefix as metadata, so services find the ID in
+	// the "x-request-id" metadata key.
+	GatewayRequestIDHeader = "Grpc-Metadata-X-Request-Id"
+
+	// StatusClientClosedRequest is the non-standard status used for requests
+	// canceled by the client.
+	StatusClientClosedRequest = 499
+)
+
+// GRPCCode is a gRPC status code. The values match the codes defined in
+// google.golang.org/grpc/codes, so convert a status with
+// baseapp.GRPCCode(status.Code(err)).
+type GRPCCode uint32
+
+var grpcCodes = []struct {
+	name   string
+	status int
+}{
+	{"OK", http.StatusOK},
+	{"CANCELLED", StatusClientClosedRequest},
+	{"UNKNOWN", http.StatusInternalServerError},
+	{"INVALID_ARGUMENT", http.StatusBadRequest},
+	{"DEADLINE_EXCEEDED", http.StatusGatewayTimeout},
+	{"NOT_FOUND", http.StatusNotFound},
+	{"ALREADY_EXISTS", http.StatusConflict},
+	{"PERMISSION_DENIED", http.StatusForbidden},
+	{"RESOURCE_EXHAUSTED", http.StatusTooManyRequests},
+	{"FAILED_PRECONDITION", http.StatusBadRequest},
+	{"ABORTED", http.StatusConflict},
+	{"OUT_OF_RANGE", http.StatusBadRequest},
+	{"UNIMPLEMENTED", http.StatusNotImplemented},
+	{"INTERNAL", http.StatusInternalServerError},
+	{"UNAVAILABLE", http.StatusServiceUnavailable},
+	{"DATA_LOSS", http.StatusInternalServerError},
+	{"UNAUTHENTICATED", http.StatusUnauthorized},
+}
+
+// String returns the name of the code, like "NOT_FOUND".
+func (c GRPCCode) String() string {
+	if int(c) < len(grpcCodes) {
+		return grpcCodes[c].name
+	}
+	return "CODE(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// HTTPStatus returns the HTTP status for the code, using the same mapping as
+// the grpc-gateway. Unknown codes map to 500.
+func (c GRPCCode) HTTPStatus() int {
+	if int(c) < len(grpcCodes) {
+		return grpcCodes[c].status
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCProblem returns the problem for a gRPC status with the given code and
+// message. The message becomes the detail and the name of the code is
+// included as the "grpc_code" extension.
+func GRPCProblem(code GRPCCode, message string) Problem {
+	p := Problem{
+		Status:     code.HTTPStatus(),
+		Detail:     message,
+		Extensions: map[string]interface{}{"grpc_code": code.String()},
+	}
+	if p.Status == StatusClientClosedRequest {
+		p.Title = "Client Closed Request"
+	}
+	return p
+}
+
+// WriteGRPCProblem writes a problem details response for a gRPC status. Use
+// it as the error handler of a grpc-gateway mux so that errors from the
+// gateway match errors from other handlers on the server:
+//
+//	gw := runtime.NewServeMux(runtime.WithErrorHandler(
+//		func(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+//			s := status.Convert(err)
+//			baseapp.WriteGRPCProblem(w, r, baseapp.GRPCCode(s.Code()), s.Message())
+//		},
+//	))
+func WriteGRPCProblem(w http.ResponseWriter, r *http.Request, code GRPCCode, message string) {
+	WriteProblem(w, r, GRPCProblem(code, message))
+}
+
+// NewGatewayHandler wraps a grpc-gateway mux, or any other handler that
+// transcodes requests to gRPC, for mounting on a baseapp server:
+//
+//	server.Mux().Handle(pat.New("/api/*"), baseapp.NewGatewayHandler(gw))
+//
+// Requests to the gateway pass through the server middleware, so they have
+// the same logging, metrics, and request IDs as other requests. The handler
+// forwards the request ID to the gRPC service in the GatewayRequestIDHeader
+// header, replacing any value sent by the client.
+func NewGatewayHandler(gw http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(GatewayRequestIDHeader)
+		if id, ok := hlog.IDFromRequest(r); ok {
+			r.Header.Set(GatewayRequestIDHeader, id.String())
+		}
+		gw.ServeHTTP(w, r)
+	})
+}
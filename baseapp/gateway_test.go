@@ -0,0 +1,68 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog/hlog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGRPCCode(t *testing.T) {
+	assert.Equal(t, "NOT_FOUND", GRPCCode(5).String())
+	assert.Equal(t, http.StatusNotFound, GRPCCode(5).HTTPStatus())
+	assert.Equal(t, http.StatusUnauthorized, GRPCCode(16).HTTPStatus())
+	assert.Equal(t, "CODE(42)", GRPCCode(42).String())
+	assert.Equal(t, http.StatusInternalServerError, GRPCCode(42).HTTPStatus())
+}
+
+func TestWriteGRPCProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteGRPCProblem(w, httptest.NewRequest(http.MethodGet, "/", nil), 3, "name is required")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"title": "Bad Request",
+		"status": 400,
+		"detail": "name is required",
+		"grpc_code": "INVALID_ARGUMENT"
+	}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteGRPCProblem(w, nil, 1, "canceled")
+	assert.Equal(t, StatusClientClosedRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"title":"Client Closed Request"`)
+}
+
+func TestGatewayHandler(t *testing.T) {
+	var id string
+	gw := NewGatewayHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = r.Header.Get(GatewayRequestIDHeader)
+	}))
+	handler := hlog.RequestIDHandler("rid", "X-Request-ID")(gw)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/things", nil)
+	r.Header.Set(GatewayRequestIDHeader, "spoofed")
+	handler.ServeHTTP(w, r)
+
+	assert.NotEmpty(t, id)
+	assert.NotEqual(t, "spoofed", id)
+	assert.Equal(t, w.Header().Get("X-Request-ID"), id)
+}
@@ -0,0 +1,149 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	stderrors "errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+// Group runs several servers in one process, like a public API server, an
+// admin server, and a metrics server that listen on different ports. Servers
+// created with Group.NewServer share the group's logger and registry:
+//
+//	group := baseapp.NewGroup(logger, registry)
+//	api, err := group.NewServer(config.Server)
+//	admin, err := group.NewServer(config.Admin, baseapp.WithMetricsPrefix("admin."))
+//
+//	err = group.Start()
+//
+// Start starts the servers in the order they were added and stops all of
+// them when the process receives SIGINT or SIGTERM, when Shutdown is called,
+// or when any server fails. Servers stop in the reverse of the order they
+// were added, so that a server added first, like an admin server with health
+// checks, stops accepting requests last.
+type Group struct {
+	logger   zerolog.Logger
+	registry metrics.Registry
+	servers  []*Server
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewGroup creates an empty Group with a logger and registry for servers
+// created with Group.NewServer. If the registry is nil, the servers use the
+// default registry of NewServer.
+func NewGroup(logger zerolog.Logger, registry metrics.Registry) *Group {
+	return &Group{
+		logger:   logger,
+		registry: registry,
+		stop:     make(chan struct{}),
+	}
+}
+
+// NewServer creates a server with the group's logger and registry and adds
+// it to the group. The parameters are applied after the logger and registry,
+// so they may replace them or add a metrics prefix.
+func (g *Group) NewServer(c HTTPConfig, params ...Param) (*Server, error) {
+	shared := []Param{WithLogger(g.logger)}
+	if g.registry != nil {
+		shared = append(shared, WithRegistry(g.registry))
+	}
+	params = append(shared, params...)
+
+	s, err := NewServer(c, params...)
+	if err != nil {
+		return nil, err
+	}
+	g.Add(s)
+	return s, nil
+}
+
+// Add adds servers to the group. Servers must be added before the group
+// starts.
+func (g *Group) Add(servers ...*Server) {
+	g.servers = append(g.servers, servers...)
+}
+
+// Servers returns the servers in the group in the order they were added.
+func (g *Group) Servers() []*Server {
+	return g.servers
+}
+
+// Start starts all servers in the group and blocks until they stop. It
+// returns nil if the servers stopped because of a signal or a call to
+// Shutdown. Otherwise, it returns an error that includes the failures of all
+// servers, including errors from stopping the other servers.
+//
+// Each server stops like a server started with Server.Start: servers with a
+// ShutdownWaitTime call their shutdown functions and wait for active
+// requests, while servers without one close immediately. Unlike
+// Server.Start, the group handles signals once for all servers.
+func (g *Group) Start() error {
+	errs := make(chan error, len(g.servers))
+	for _, s := range g.servers {
+		go func(s *Server) {
+			errs <- s.start()
+		}(s)
+	}
+
+	// SIGKILL and SIGSTOP cannot be caught, so don't bother adding them here
+	interrupt := make(chan os.Signal, 2)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	var failures []error
+	running := len(g.servers)
+
+	select {
+	case <-interrupt:
+		g.logger.Info().Msg("Caught interrupt, gracefully shutting down servers")
+	case <-g.stop:
+	case err := <-errs:
+		running--
+		if err != http.ErrServerClosed {
+			g.logger.Error().Err(err).Msg("Server failed, shutting down servers")
+			failures = append(failures, err)
+		}
+	}
+
+	for i := len(g.servers) - 1; i >= 0; i-- {
+		if err := g.servers[i].shutdown(); err != nil {
+			failures = append(failures, err)
+		}
+	}
+	for ; running > 0; running-- {
+		if err := <-errs; err != nil && err != http.ErrServerClosed {
+			failures = append(failures, err)
+		}
+	}
+	return stderrors.Join(failures...)
+}
+
+// Shutdown stops the servers of a group started with Start. It returns
+// immediately; Start returns once all servers have stopped.
+func (g *Group) Shutdown() {
+	g.stopOnce.Do(func() {
+		close(g.stop)
+	})
+}
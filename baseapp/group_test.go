@@ -0,0 +1,90 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+	wait := time.Second
+	config := HTTPConfig{Address: "127.0.0.1", ShutdownWaitTime: &wait}
+
+	var mu sync.Mutex
+	var stopped []string
+	onShutdown := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			stopped = append(stopped, name)
+			return nil
+		}
+	}
+
+	newGroup := func(t *testing.T, params ...Param) *Group {
+		stopped = nil
+		g := NewGroup(zerolog.Nop(), metrics.NewRegistry())
+
+		admin, err := g.NewServer(config, WithMetricsPrefix("admin."))
+		require.NoError(t, err)
+		admin.OnShutdown(onShutdown("admin"))
+
+		api, err := g.NewServer(config, params...)
+		require.NoError(t, err)
+		api.OnShutdown(onShutdown("api"))
+
+		assert.Equal(t, []*Server{admin, api}, g.Servers())
+		return g
+	}
+
+	t.Run("shutdown", func(t *testing.T) {
+		g := newGroup(t)
+
+		done := make(chan error, 1)
+		go func() { done <- g.Start() }()
+		g.Shutdown()
+
+		select {
+		case err := <-done:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("group did not stop")
+		}
+		assert.Equal(t, []string{"api", "admin"}, stopped, "servers should stop in reverse order")
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		g := newGroup(t, WithDependencyChecks(DependencyCheck{
+			Name: "database",
+			Check: func(ctx context.Context) error {
+				return errors.New("connection refused")
+			},
+		}))
+
+		err := g.Start()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "dependency checks failed")
+		assert.Equal(t, []string{"api", "admin"}, stopped, "all servers should stop when one fails")
+	})
+}
@@ -0,0 +1,29 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package handoff restarts a server without dropping connections by passing
+// its listening sockets to a new process.
+//
+// When a server registered with Register receives SIGUSR2, it starts a new
+// copy of the current executable with the same arguments. The new process
+// inherits the listening sockets as extra file descriptors and serves on them
+// instead of opening new ones. Once the new process starts serving, it
+// notifies the old process, which then begins a graceful shutdown: shutdown
+// functions run and in-flight requests drain while the new process accepts
+// all new connections. If the new process does not become ready within the
+// timeout, it is killed and the old process keeps serving.
+//
+// The package only supports Unix systems. Servers must use a
+// ShutdownWaitTime so that the old process drains before it exits.
+package handoff
@@ -0,0 +1,325 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package handoff
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// EnvListeners is the environment variable that lists the addresses of
+	// inherited listeners, in the order of their file descriptors.
+	EnvListeners = "BASEAPP_HANDOFF_LISTENERS"
+
+	// EnvReadyFD is the environment variable that contains the file
+	// descriptor the new process uses to signal it is ready.
+	EnvReadyFD = "BASEAPP_HANDOFF_READY_FD"
+
+	// DefaultTimeout is the default time to wait for a new process to
+	// become ready.
+	DefaultTimeout = 30 * time.Second
+
+	MetricsKeyUpgrades = "handoff.upgrades"
+	MetricsKeyFailures = "handoff.failures"
+)
+
+// the first file descriptor passed with exec.Cmd.ExtraFiles
+const firstExtraFD = 3
+
+// Handoff passes listeners between a process and the process that replaces
+// it.
+type Handoff struct {
+	logger zerolog.Logger
+
+	// Timeout is the maximum time to wait for a new process to become ready.
+	// If zero, DefaultTimeout is used.
+	Timeout time.Duration
+
+	mu        sync.Mutex
+	inherited map[string]net.Listener
+	listeners map[string]net.Listener
+	ready     *os.File
+	upgrading bool
+}
+
+// New creates a Handoff and loads any listeners inherited from a parent
+// process. Call New once, early in the process, before creating the server.
+func New(logger zerolog.Logger) (*Handoff, error) {
+	h := &Handoff{
+		logger:    logger,
+		inherited: make(map[string]net.Listener),
+		listeners: make(map[string]net.Listener),
+	}
+
+	if addrs := os.Getenv(EnvListeners); addrs != "" {
+		for i, addr := range strings.Split(addrs, ",") {
+			f := os.NewFile(uintptr(firstExtraFD+i), "listener:"+addr)
+			l, err := net.FileListener(f)
+			_ = f.Close()
+			if err != nil {
+				return nil, errors.Wrapf(err, "handoff: failed to inherit listener for %s", addr)
+			}
+			h.inherited[addr] = l
+		}
+	}
+
+	if fd := os.Getenv(EnvReadyFD); fd != "" {
+		n, err := strconv.Atoi(fd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "handoff: invalid %s", EnvReadyFD)
+		}
+		h.ready = os.NewFile(uintptr(n), "ready")
+	}
+
+	// Children of this process must not inherit the variables directly
+	_ = os.Unsetenv(EnvListeners)
+	_ = os.Unsetenv(EnvReadyFD)
+
+	return h, nil
+}
+
+// Inherited returns true if the process inherited listeners from a parent.
+func (h *Handoff) Inherited() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.inherited) > 0 || h.ready != nil
+}
+
+// Listen returns the inherited listener for addr, if one exists, or creates
+// a new listener. The listener is passed to the new process during an
+// upgrade. Use Listen with baseapp.WithListenFunc.
+func (h *Handoff) Listen(network, addr string) (net.Listener, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	l, ok := h.inherited[addr]
+	if ok {
+		delete(h.inherited, addr)
+	} else {
+		var err error
+		if l, err = net.Listen(network, addr); err != nil {
+			return nil, err
+		}
+	}
+
+	h.listeners[addr] = l
+	return l, nil
+}
+
+// Ready notifies the parent process, if any, that this process is ready to
+// serve requests. It closes inherited listeners that the process did not use.
+func (h *Handoff) Ready() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for addr, l := range h.inherited {
+		_ = l.Close()
+		delete(h.inherited, addr)
+	}
+
+	if h.ready == nil {
+		return nil
+	}
+	defer func() {
+		_ = h.ready.Close()
+		h.ready = nil
+	}()
+
+	_, err := io.WriteString(h.ready, "ready\n")
+	return errors.Wrap(err, "handoff: failed to notify parent")
+}
+
+// Upgrade starts a new copy of the current process that inherits the
+// listeners and waits until it calls Ready. If the new process exits or does
+// not become ready within the timeout, Upgrade kills it and returns an error.
+// Upgrade does not stop the current process.
+func (h *Handoff) Upgrade(ctx context.Context) error {
+	h.mu.Lock()
+	if h.upgrading {
+		h.mu.Unlock()
+		return errors.New("handoff: upgrade already in progress")
+	}
+	h.upgrading = true
+
+	var addrs []string
+	var files []*os.File
+	for addr, l := range h.listeners {
+		fl, ok := l.(interface{ File() (*os.File, error) })
+		if !ok {
+			h.mu.Unlock()
+			return h.finish(files, errors.Errorf("handoff: listener for %s does not support file descriptors", addr))
+		}
+		f, err := fl.File()
+		if err != nil {
+			h.mu.Unlock()
+			return h.finish(files, errors.Wrapf(err, "handoff: failed to get file for %s", addr))
+		}
+		addrs = append(addrs, addr)
+		files = append(files, f)
+	}
+	h.mu.Unlock()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return h.finish(files, errors.Wrap(err, "handoff: failed to create pipe"))
+	}
+	defer func() { _ = r.Close() }()
+
+	exe, err := os.Executable()
+	if err != nil {
+		_ = w.Close()
+		return h.finish(files, errors.Wrap(err, "handoff: failed to find executable"))
+	}
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, w)
+	cmd.Env = append(os.Environ(),
+		EnvListeners+"="+strings.Join(addrs, ","),
+		fmt.Sprintf("%s=%d", EnvReadyFD, firstExtraFD+len(files)),
+	)
+
+	h.logger.Info().Strs("addresses", addrs).Msg("Starting new process for handoff")
+	if err := cmd.Start(); err != nil {
+		_ = w.Close()
+		return h.finish(files, errors.Wrap(err, "handoff: failed to start new process"))
+	}
+
+	// The child has its own copies now, so the parent's copies can be closed
+	// to detect when the child closes the pipe without writing
+	_ = w.Close()
+	for _, f := range files {
+		_ = f.Close()
+	}
+	files = nil
+
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ready := make(chan error, 1)
+	go func() {
+		line, err := io.ReadAll(io.LimitReader(r, 64))
+		switch {
+		case err != nil:
+			ready <- err
+		case strings.TrimSpace(string(line)) != "ready":
+			ready <- errors.New("new process exited before it was ready")
+		default:
+			ready <- nil
+		}
+	}()
+
+	select {
+	case err = <-ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err != nil {
+		_ = cmd.Process.Kill()
+		go func() { _ = cmd.Wait() }()
+		return h.finish(nil, errors.Wrap(err, "handoff: new process did not become ready"))
+	}
+
+	// The new process is not a child that this process needs to wait for,
+	// but release its resources
+	_ = cmd.Process.Release()
+
+	h.logger.Info().Int("pid", cmd.Process.Pid).Msg("New process is ready")
+	return h.finish(nil, nil)
+}
+
+func (h *Handoff) finish(files []*os.File, err error) error {
+	for _, f := range files {
+		_ = f.Close()
+	}
+	h.mu.Lock()
+	h.upgrading = false
+	h.mu.Unlock()
+	return err
+}
+
+// Register integrates a Handoff with the server lifecycle. The server must be
+// created with baseapp.WithListenFunc(h.Listen).
+//
+// When the server starts, it notifies the parent process, if any, that it is
+// ready. When the process receives SIGUSR2, it upgrades to a new process
+// and, if the upgrade succeeds, sends itself SIGTERM to start a graceful
+// shutdown. Successful upgrades are counted in the "handoff.upgrades" counter
+// and failures in the "handoff.failures" counter.
+func Register(s *baseapp.Server, h *Handoff) {
+	upgrades := metrics.GetOrRegisterCounter(MetricsKeyUpgrades, s.Registry())
+	failures := metrics.GetOrRegisterCounter(MetricsKeyFailures, s.Registry())
+
+	signals := make(chan os.Signal, 1)
+	stop := make(chan struct{})
+
+	s.OnStart(func(s *baseapp.Server) {
+		if err := h.Ready(); err != nil {
+			h.logger.Error().Err(err).Msg("Failed to notify parent process")
+		}
+
+		signal.Notify(signals, syscall.SIGUSR2)
+		go func() {
+			for {
+				select {
+				case <-signals:
+				case <-stop:
+					return
+				}
+
+				if err := h.Upgrade(context.Background()); err != nil {
+					failures.Inc(1)
+					h.logger.Error().Err(err).Msg("Handoff failed, continuing to serve")
+					continue
+				}
+				upgrades.Inc(1)
+
+				h.logger.Info().Msg("Handoff complete, shutting down")
+				_ = syscall.Kill(os.Getpid(), syscall.SIGTERM)
+				return
+			}
+		}()
+	})
+
+	s.OnShutdown(func(context.Context) error {
+		signal.Stop(signals)
+		close(stop)
+		return nil
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package handoff
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpgrade(t *testing.T) {
+	const addr = "127.0.0.1:0"
+
+	h, err := New(zerolog.Nop())
+	require.NoError(t, err)
+
+	if h.Inherited() {
+		// This is the new process started by the upgrade below
+		l, err := h.Listen("tcp", addr)
+		require.NoError(t, err)
+		require.NoError(t, h.Ready())
+
+		conn, err := l.Accept()
+		require.NoError(t, err)
+		_, _ = conn.Write([]byte("new process"))
+		_ = conn.Close()
+		return
+	}
+
+	l, err := h.Listen("tcp", addr)
+	require.NoError(t, err)
+
+	// Run only this test in the new process and hide its output
+	args, stdout := os.Args, os.Stdout
+	os.Args = []string{os.Args[0], "-test.run=^TestUpgrade$"}
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Args, os.Stdout = args, stdout }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, h.Upgrade(ctx))
+
+	// Stop accepting in this process, like a graceful shutdown
+	listenAddr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	conn, err := net.Dial("tcp", listenAddr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Second)))
+	b, err := io.ReadAll(conn)
+	require.NoError(t, err)
+	assert.Equal(t, "new process", string(b))
+}
+
+func TestUpgradeNotReady(t *testing.T) {
+	h, err := New(zerolog.Nop())
+	require.NoError(t, err)
+	if h.Inherited() {
+		// exit without calling Ready
+		return
+	}
+
+	_, err = h.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	args, stdout := os.Args, os.Stdout
+	os.Args = []string{os.Args[0], "-test.run=^TestUpgradeNotReady$"}
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Args, os.Stdout = args, stdout }()
+
+	err = h.Upgrade(context.Background())
+	assert.ErrorContains(t, err, "new process did not become ready")
+}
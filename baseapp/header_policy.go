@@ -0,0 +1,243 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	MetricsKeyDisallowedHeaders = "server.headers.disallowed"
+)
+
+// HeaderPolicy defines the headers that responses must and must not have.
+// It is usually embedded in a larger configuration struct.
+type HeaderPolicy struct {
+	// StripPrefixes are prefixes of headers that are removed from responses,
+	// like "X-Internal-", so that internal details do not leak to clients.
+	// Prefixes are compared without considering case.
+	StripPrefixes []string `yaml:"strip_prefixes" json:"stripPrefixes"`
+
+	// Disallowed are headers that handlers must not set. They are removed
+	// from responses and reported.
+	Disallowed []string `yaml:"disallowed" json:"disallowed"`
+
+	// Defaults are headers set on responses that do not have them, like a
+	// Cache-Control header.
+	Defaults map[string]string `yaml:"defaults" json:"defaults"`
+
+	// RequestIDHeader is the header that contains the ID of the request, as
+	// set by hlog.RequestIDHandler. If responses do not have it, it is set
+	// from the request context. If it is empty, the header is not required.
+	RequestIDHeader string `yaml:"request_id_header" json:"requestIdHeader"`
+}
+
+// DefaultHeaderPolicy returns a policy that strips "X-Internal-" headers,
+// disallows the "Server" and "X-Powered-By" headers, sets "Cache-Control:
+// no-store" on responses without caching headers, and requires the
+// "X-Request-ID" header used by the default middleware.
+func DefaultHeaderPolicy() HeaderPolicy {
+	return HeaderPolicy{
+		StripPrefixes:   []string{"X-Internal-"},
+		Disallowed:      []string{"Server", "X-Powered-By"},
+		Defaults:        map[string]string{"Cache-Control": "no-store"},
+		RequestIDHeader: "X-Request-ID",
+	}
+}
+
+// NewHeaderPolicyHandler returns middleware that enforces the policy on the
+// headers of each response when the handler sends the status or first
+// writes the body. Add it as the last middleware, so that it sees the
+// headers set by handlers and by the other middleware.
+//
+// Each disallowed header is logged as a warning with the request logger and
+// counted in the "server.headers.disallowed" counter, tagged with the name of
+// the header. This middleware must be used after the middleware that adds a
+// metrics registry and logger to the request context.
+func NewHeaderPolicyHandler(p HeaderPolicy) func(http.Handler) http.Handler {
+	policy := p
+	policy.Disallowed = make([]string, len(p.Disallowed))
+	for i, h := range p.Disallowed {
+		policy.Disallowed[i] = http.CanonicalHeaderKey(h)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(wrapHeaderPolicyWriter(w, r, &policy), r)
+		})
+	}
+}
+
+// apply enforces the policy on the headers of the response to r.
+func (p *HeaderPolicy) apply(h http.Header, r *http.Request) {
+	for name := range h {
+		for _, prefix := range p.StripPrefixes {
+			if len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+				h.Del(name)
+				break
+			}
+		}
+	}
+
+	for _, name := range p.Disallowed {
+		if _, ok := h[name]; !ok {
+			continue
+		}
+		h.Del(name)
+
+		hlog.FromRequest(r).Warn().
+			Str("header", name).
+			Msg("Removed disallowed response header")
+		CounterFromCtx(r.Context(), MetricsKeyDisallowedHeaders, "header:"+name).Inc(1)
+	}
+
+	for name, value := range p.Defaults {
+		if h.Get(name) == "" {
+			h.Set(name, value)
+		}
+	}
+
+	if p.RequestIDHeader != "" && h.Get(p.RequestIDHeader) == "" {
+		if id, ok := hlog.IDFromRequest(r); ok {
+			h.Set(p.RequestIDHeader, id.String())
+		}
+	}
+}
+
+// wrapHeaderPolicyWriter returns a writer that applies the policy before the
+// response headers are sent. Like WrapWriter, it returns a variant that
+// supports the same optional interfaces as the common http.ResponseWriter
+// implementations.
+func wrapHeaderPolicyWriter(w http.ResponseWriter, r *http.Request, p *HeaderPolicy) RecordingResponseWriter {
+	_, cn := w.(http.CloseNotifier)
+	_, fl := w.(http.Flusher)
+	_, hj := w.(http.Hijacker)
+	_, rf := w.(io.ReaderFrom)
+
+	pw := headerPolicyWriter{
+		ResponseWriter: w,
+		r:              r,
+		policy:         p,
+	}
+	if cn && fl && hj && rf {
+		return &fancyHeaderPolicyWriter{pw}
+	}
+	if fl {
+		return &flushHeaderPolicyWriter{pw}
+	}
+	return &pw
+}
+
+type headerPolicyWriter struct {
+	http.ResponseWriter
+	r      *http.Request
+	policy *HeaderPolicy
+
+	applied      bool
+	code         int
+	bytesWritten int64
+}
+
+// applyPolicy enforces the policy the first time the headers may be sent.
+func (w *headerPolicyWriter) applyPolicy() {
+	if !w.applied {
+		w.applied = true
+		w.policy.apply(w.ResponseWriter.Header(), w.r)
+	}
+}
+
+func (w *headerPolicyWriter) WriteHeader(code int) {
+	w.applyPolicy()
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *headerPolicyWriter) Write(b []byte) (int, error) {
+	w.applyPolicy()
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+func (w *headerPolicyWriter) Status() int {
+	return w.code
+}
+
+func (w *headerPolicyWriter) BytesWritten() int64 {
+	return w.bytesWritten
+}
+
+// Unwrap returns the underlying writer for use with http.ResponseController.
+func (w *headerPolicyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// fancyHeaderPolicyWriter is a headerPolicyWriter that additionally satisfies
+// http.CloseNotifier, http.Flusher, http.Hijacker, and io.ReaderFrom.
+type fancyHeaderPolicyWriter struct {
+	headerPolicyWriter
+}
+
+func (f *fancyHeaderPolicyWriter) CloseNotify() <-chan bool {
+	cn := f.headerPolicyWriter.ResponseWriter.(http.CloseNotifier)
+	return cn.CloseNotify()
+}
+func (f *fancyHeaderPolicyWriter) Flush() {
+	f.applyPolicy()
+	fl := f.headerPolicyWriter.ResponseWriter.(http.Flusher)
+	fl.Flush()
+}
+func (f *fancyHeaderPolicyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj := f.headerPolicyWriter.ResponseWriter.(http.Hijacker)
+	return hj.Hijack()
+}
+func (f *fancyHeaderPolicyWriter) ReadFrom(r io.Reader) (int64, error) {
+	f.applyPolicy()
+	if f.code == 0 {
+		f.code = http.StatusOK
+	}
+	rf := f.headerPolicyWriter.ResponseWriter.(io.ReaderFrom)
+	n, err := rf.ReadFrom(r)
+	f.bytesWritten += n
+	return n, err
+}
+
+var _ http.CloseNotifier = &fancyHeaderPolicyWriter{}
+var _ http.Flusher = &fancyHeaderPolicyWriter{}
+var _ http.Hijacker = &fancyHeaderPolicyWriter{}
+var _ io.ReaderFrom = &fancyHeaderPolicyWriter{}
+
+type flushHeaderPolicyWriter struct {
+	headerPolicyWriter
+}
+
+func (f *flushHeaderPolicyWriter) Flush() {
+	f.applyPolicy()
+	fl := f.headerPolicyWriter.ResponseWriter.(http.Flusher)
+	fl.Flush()
+}
+
+var _ http.Flusher = &flushHeaderPolicyWriter{}
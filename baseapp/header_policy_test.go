@@ -0,0 +1,74 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestHeaderPolicyHandler(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	mux := goji.NewMux()
+	mux.Use(NewMetricsHandler(registry))
+	mux.Use(hlog.NewHandler(zerolog.Nop()))
+	// Add the request ID to the context without setting the header
+	mux.Use(hlog.RequestIDHandler("rid", ""))
+	mux.Use(NewHeaderPolicyHandler(DefaultHeaderPolicy()))
+	mux.HandleFunc(pat.Get("/"), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Internal-Shard", "7")
+		w.Header().Set("x-internal-host", "db-1")
+		w.Header().Set("X-Powered-By", "go")
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc(pat.Get("/cached"), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("X-Request-ID", "custom")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, "ok", w.Body.String())
+	assert.Empty(t, w.Header().Get("X-Internal-Shard"))
+	assert.Empty(t, w.Header().Get("X-Internal-Host"), "prefixes should not be case-sensitive")
+	assert.Empty(t, w.Header().Get("X-Powered-By"))
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.NotEmpty(t, w.Header().Get("X-Request-ID"), "the request ID should be set from the context")
+
+	c, ok := registry.Get("server.headers.disallowed[header:X-Powered-By]").(metrics.Counter)
+	require.True(t, ok, "disallowed headers should be counted")
+	assert.Equal(t, int64(1), c.Count())
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/cached", nil))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "max-age=60", w.Header().Get("Cache-Control"), "defaults should not replace headers")
+	assert.Equal(t, "custom", w.Header().Get("X-Request-ID"))
+}
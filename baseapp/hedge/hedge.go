@@ -0,0 +1,211 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hedge provides an http.RoundTripper that sends hedged requests to
+// reduce the tail latency of calls to other services.
+//
+// When a request does not complete within a delay, the transport sends a
+// second, identical request. The transport returns the first successful
+// response and cancels the other request. Only idempotent requests are
+// hedged, because the server may receive and process both requests.
+//
+// baseapp does not construct outbound HTTP clients, so use the transport
+// with the clients created by the application:
+//
+//	client := &http.Client{
+//		Transport: hedge.NewTransport(http.DefaultTransport, 50*time.Millisecond),
+//	}
+package hedge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	MetricsKeyRequests = "client.hedge.requests"
+	MetricsKeyHedges   = "client.hedge.hedges"
+	MetricsKeyWins     = "client.hedge.wins"
+)
+
+// Transport is an http.RoundTripper that hedges idempotent requests.
+//
+// For each request that is eligible for hedging, the transport increments
+// the "client.hedge.requests" counter. When it sends a hedged request, it
+// increments the "client.hedge.hedges" counter, and when the hedged request
+// provides the response, it increments the "client.hedge.wins" counter. All
+// counters are tagged with the host of the request, like
+// "client.hedge.hedges[host:api.example.com]", and use the registry from the
+// request context.
+type Transport struct {
+	base  http.RoundTripper
+	delay time.Duration
+}
+
+// NewTransport returns a Transport that sends requests with base and sends a
+// hedged request if a request does not complete within delay. If base is nil,
+// the transport uses http.DefaultTransport. If delay is zero or negative, the
+// transport never hedges requests.
+func NewTransport(base http.RoundTripper, delay time.Duration) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		base:  base,
+		delay: delay,
+	}
+}
+
+type attempt struct {
+	res   *http.Response
+	err   error
+	hedge bool
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.delay <= 0 || !isHedgeable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	registry := baseapp.MetricsCtx(req.Context())
+	host := "host:" + req.URL.Host
+	metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyRequests, host), registry).Inc(1)
+
+	// Each attempt reports to the channel exactly once, so the buffer allows
+	// abandoned attempts to finish without blocking
+	results := make(chan attempt, 2)
+
+	// Cancel each attempt when the function returns unless it wins
+	ctx, cancel := context.WithCancel(req.Context())
+	hedgeCtx, hedgeCancel := context.WithCancel(req.Context())
+	defer func() {
+		if cancel != nil {
+			cancel()
+		}
+		if hedgeCancel != nil {
+			hedgeCancel()
+		}
+	}()
+
+	send := func(r *http.Request, hedge bool) {
+		go func() {
+			res, err := t.base.RoundTrip(r)
+			results <- attempt{res: res, err: err, hedge: hedge}
+		}()
+	}
+
+	send(req.WithContext(ctx), false)
+	pending := 1
+
+	timer := time.NewTimer(t.delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			hedgeReq, err := cloneRequest(req)
+			if err != nil {
+				continue
+			}
+			metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyHedges, host), registry).Inc(1)
+			send(hedgeReq.WithContext(hedgeCtx), true)
+			pending++
+
+		case a := <-results:
+			pending--
+
+			// If another attempt is still running, prefer it to an error
+			if a.err != nil && pending > 0 {
+				continue
+			}
+
+			if pending > 0 {
+				go discard(results, pending)
+			}
+			if a.err != nil {
+				return nil, a.err
+			}
+
+			// Keep the context of the winning attempt until the body is closed
+			var winner context.CancelFunc
+			if a.hedge {
+				metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyWins, host), registry).Inc(1)
+				winner, hedgeCancel = hedgeCancel, nil
+			} else {
+				winner, cancel = cancel, nil
+			}
+			a.res.Body = &cancelBody{ReadCloser: a.res.Body, cancel: winner}
+			return a.res, nil
+		}
+	}
+}
+
+// discard closes the responses of attempts that lost.
+func discard(results <-chan attempt, n int) {
+	for i := 0; i < n; i++ {
+		if a := <-results; a.res != nil {
+			_ = a.res.Body.Close()
+		}
+	}
+}
+
+// isHedgeable returns true if the request is idempotent and can be sent
+// twice. It uses the same rules as the http package uses for retries.
+func isHedgeable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	if _, ok := req.Header["Idempotency-Key"]; ok {
+		return true
+	}
+	if _, ok := req.Header["X-Idempotency-Key"]; ok {
+		return true
+	}
+	return false
+}
+
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	r := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = body
+	}
+	return r, nil
+}
+
+// cancelBody cancels the context of the winning attempt when the response
+// body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
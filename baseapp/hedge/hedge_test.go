@@ -0,0 +1,125 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hedge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransport(t *testing.T) {
+	var calls atomic.Int32
+	var canceled atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			// the first request is slow and should be canceled
+			select {
+			case <-r.Context().Done():
+				canceled.Add(1)
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	host := "host:" + strings.TrimPrefix(srv.URL, "http://")
+	count := func(r metrics.Registry, name string) int64 {
+		if c, ok := r.Get(name + "[" + host + "]").(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	newRequest := func(t *testing.T, method string, body io.Reader) (*http.Request, metrics.Registry) {
+		registry := metrics.NewRegistry()
+		ctx := baseapp.WithMetricsCtx(context.Background(), registry)
+		req, err := http.NewRequestWithContext(ctx, method, srv.URL, body)
+		require.NoError(t, err)
+		return req, registry
+	}
+
+	client := &http.Client{Transport: NewTransport(nil, 10*time.Millisecond)}
+
+	t.Run("hedged", func(t *testing.T) {
+		calls.Store(0)
+		req, registry := newRequest(t, http.MethodGet, nil)
+
+		res, err := client.Do(req)
+		require.NoError(t, err)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, "ok", string(body))
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, int64(1), count(registry, MetricsKeyRequests))
+		assert.Equal(t, int64(1), count(registry, MetricsKeyHedges))
+		assert.Equal(t, int64(1), count(registry, MetricsKeyWins))
+
+		require.Eventually(t, func() bool { return canceled.Load() == 1 }, time.Second, time.Millisecond, "losing request should be canceled")
+	})
+
+	t.Run("notIdempotent", func(t *testing.T) {
+		calls.Store(1)
+		req, registry := newRequest(t, http.MethodPost, strings.NewReader("data"))
+
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Zero(t, count(registry, MetricsKeyRequests))
+	})
+
+	t.Run("fast", func(t *testing.T) {
+		calls.Store(1)
+		req, registry := newRequest(t, http.MethodGet, nil)
+
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, res.Body.Close())
+
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Equal(t, int64(1), count(registry, MetricsKeyRequests))
+		assert.Zero(t, count(registry, MetricsKeyHedges))
+	})
+}
+
+func TestIsHedgeable(t *testing.T) {
+	u, _ := url.Parse("http://example.com")
+
+	assert.True(t, isHedgeable(&http.Request{Method: http.MethodGet, URL: u}))
+	assert.False(t, isHedgeable(&http.Request{Method: http.MethodPost, URL: u}))
+	assert.True(t, isHedgeable(&http.Request{Method: http.MethodPost, URL: u, Header: http.Header{"Idempotency-Key": {"1"}}}))
+
+	body := &http.Request{Method: http.MethodPut, URL: u, Body: io.NopCloser(strings.NewReader("data")), Header: http.Header{"Idempotency-Key": {"1"}}}
+	assert.False(t, isHedgeable(body), "bodies without GetBody cannot be sent twice")
+}
@@ -71,6 +71,24 @@ func IgnoreAll(r *http.Request) {
 	})
 }
 
+// ignoredRuleTag returns a tag that describes the reporting ignored by the
+// request, or an empty string if the request does not ignore reporting.
+func ignoredRuleTag(r *http.Request) string {
+	ctxRule, ok := r.Context().Value(ignoreCtxKey{}).(*IgnoreRule)
+	if !ok {
+		return ""
+	}
+	switch {
+	case ctxRule.Logs && ctxRule.Metrics:
+		return "rule:all"
+	case ctxRule.Logs:
+		return "rule:logs"
+	case ctxRule.Metrics:
+		return "rule:metrics"
+	}
+	return ""
+}
+
 // IsIgnored returns true if the request ignores all of the reporting types set
 // in rule. The request may also ignore other reporting types not set in rule.
 func IsIgnored(r *http.Request, rule IgnoreRule) bool {
@@ -0,0 +1,217 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	// DefaultJournalErrorLength is the default maximum length of errors
+	// recorded in a Journal.
+	DefaultJournalErrorLength = 256
+)
+
+type journalCtxKey struct{}
+type routeErrorCtxKey struct{}
+
+// JournalEntry describes a completed request.
+type JournalEntry struct {
+	Time      time.Time     `json:"time"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	Elapsed   time.Duration `json:"elapsed"`
+	RequestID string        `json:"request_id,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// Journal records the most recent requests handled by a server in a fixed
+// size ring buffer. Use it to find out what a server was doing before it
+// crashed: the journal can be included in the log when the process panics
+// or exits with a fatal error and can be served by an admin endpoint.
+//
+// Requests are recorded by the middleware returned by AccessHandler, which
+// uses the journal from the request context. Add the middleware returned by
+// Handler before the access middleware or use StartJournal. Errors are
+// recorded if they are handled by HandleRouteError.
+type Journal struct {
+	maxErrorLength int
+
+	mu      sync.Mutex
+	entries []JournalEntry
+	next    int
+	full    bool
+}
+
+// NewJournal creates a Journal that keeps the last size requests. Recorded
+// errors are truncated to DefaultJournalErrorLength bytes.
+func NewJournal(size int) *Journal {
+	if size < 1 {
+		size = 1
+	}
+	return &Journal{
+		maxErrorLength: DefaultJournalErrorLength,
+		entries:        make([]JournalEntry, size),
+	}
+}
+
+// StartJournal creates a Journal that keeps the last size requests and adds
+// it to the context of all requests handled by the server. Requests are only
+// recorded if the server's middleware includes AccessHandler, as the default
+// middleware does. Call StartJournal before starting the server.
+func StartJournal(s *Server, size int) *Journal {
+	j := NewJournal(size)
+
+	hs := s.HTTPServer()
+	hs.Handler = j.Handler()(hs.Handler)
+
+	return j
+}
+
+// Handler returns middleware that adds the journal to the request context so
+// that the access middleware records requests.
+func (j *Journal) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), journalCtxKey{}, j)))
+		})
+	}
+}
+
+func journalFromContext(ctx context.Context) *Journal {
+	j, _ := ctx.Value(journalCtxKey{}).(*Journal)
+	return j
+}
+
+// record adds a request to the journal.
+func (j *Journal) record(r *http.Request, status int, elapsed time.Duration) {
+	e := JournalEntry{
+		Time:    time.Now(),
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Status:  status,
+		Elapsed: elapsed,
+	}
+	if rid, ok := hlog.IDFromRequest(r); ok {
+		e.RequestID = rid.String()
+	}
+	if err := routeErrorFromContext(r.Context()); err != nil {
+		e.Error = err.Error()
+		if len(e.Error) > j.maxErrorLength {
+			e.Error = e.Error[:j.maxErrorLength]
+		}
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries[j.next] = e
+	j.next = (j.next + 1) % len(j.entries)
+	if j.next == 0 {
+		j.full = true
+	}
+}
+
+// Entries returns the recorded requests, from oldest to newest.
+func (j *Journal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.full {
+		return append([]JournalEntry(nil), j.entries[:j.next]...)
+	}
+	entries := make([]JournalEntry, 0, len(j.entries))
+	entries = append(entries, j.entries[j.next:]...)
+	return append(entries, j.entries[:j.next]...)
+}
+
+// DumpHandler returns a handler that responds with the recorded requests as
+// a JSON array, from oldest to newest. The handler is intended for admin
+// endpoints and should not be exposed publicly.
+func (j *Journal) DumpHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, http.StatusOK, j.Entries())
+	})
+}
+
+// Log logs the recorded requests as a single entry at the given level.
+func (j *Journal) Log(logger zerolog.Logger, level zerolog.Level) {
+	logger.WithLevel(level).Array("requests", j.array()).Msg("Recent requests")
+}
+
+// LogOnPanic logs the recorded requests at the error level if the calling
+// goroutine is panicking and then continues panicking. It must be called
+// directly with defer, usually at the start of main:
+//
+//	defer journal.LogOnPanic(logger)
+//
+// Panics in request handlers do not crash the server, so they are not logged
+// by this function.
+func (j *Journal) LogOnPanic(logger zerolog.Logger) {
+	if v := recover(); v != nil {
+		j.Log(logger, zerolog.ErrorLevel)
+		panic(v)
+	}
+}
+
+// Hook returns a zerolog hook that adds the recorded requests to entries
+// logged at the fatal and panic levels, which exit the process.
+func (j *Journal) Hook() zerolog.Hook {
+	return zerolog.HookFunc(func(e *zerolog.Event, level zerolog.Level, msg string) {
+		if level == zerolog.FatalLevel || level == zerolog.PanicLevel {
+			e.Array("recent_requests", j.array())
+		}
+	})
+}
+
+func (j *Journal) array() *zerolog.Array {
+	arr := zerolog.Arr()
+	for _, e := range j.Entries() {
+		d := zerolog.Dict().
+			Time("time", e.Time).
+			Str("method", e.Method).
+			Str("path", e.Path).
+			Int("status", e.Status).
+			Dur("elapsed", e.Elapsed)
+		if e.RequestID != "" {
+			d = d.Str("request_id", e.RequestID)
+		}
+		if e.Error != "" {
+			d = d.Str("error", e.Error)
+		}
+		arr = arr.Dict(d)
+	}
+	return arr
+}
+
+func setRouteError(ctx context.Context, err error) {
+	if p, ok := ctx.Value(routeErrorCtxKey{}).(*error); ok {
+		*p = err
+	}
+}
+
+func routeErrorFromContext(ctx context.Context) error {
+	if p, ok := ctx.Value(routeErrorCtxKey{}).(*error); ok {
+		return *p
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bluekeyes/hatpear"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal(t *testing.T) {
+	j := NewJournal(2)
+
+	access := AccessHandler(func(*http.Request, int, int64, time.Duration) {})
+	handler := j.Handler()(access(hatpear.Catch(HandleRouteError)(hatpear.Try(hatpear.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.URL.Path == "/error" {
+			return errors.New(strings.Repeat("x", 2*DefaultJournalErrorLength))
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	})))))
+
+	for _, path := range []string{"/first", "/second", "/error"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	entries := j.Entries()
+	require.Len(t, entries, 2, "journal should only keep the most recent requests")
+	assert.Equal(t, "/second", entries[0].Path)
+	assert.Equal(t, http.StatusNoContent, entries[0].Status)
+	assert.Empty(t, entries[0].Error)
+	assert.Equal(t, "/error", entries[1].Path)
+	assert.Equal(t, http.StatusInternalServerError, entries[1].Status)
+	assert.Len(t, entries[1].Error, DefaultJournalErrorLength)
+
+	t.Run("dump", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		j.DumpHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/journal", nil))
+
+		var dumped []JournalEntry
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &dumped))
+		assert.Equal(t, []string{"/second", "/error"}, []string{dumped[0].Path, dumped[1].Path})
+	})
+
+	t.Run("hook", func(t *testing.T) {
+		var logs bytes.Buffer
+		logger := zerolog.New(&logs).Hook(j.Hook())
+
+		logger.Error().Msg("not fatal")
+		assert.NotContains(t, logs.String(), "recent_requests")
+
+		logs.Reset()
+		logger.WithLevel(zerolog.FatalLevel).Msg("fatal")
+		assert.Contains(t, logs.String(), `"recent_requests":[{`)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		var logs bytes.Buffer
+		logger := zerolog.New(&logs)
+
+		assert.PanicsWithValue(t, "boom", func() {
+			defer j.LogOnPanic(logger)
+			panic("boom")
+		})
+		assert.Contains(t, logs.String(), `"path":"/error"`)
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bluekeyes/hatpear"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	MetricsKeyHandlerLatency = "server.handler.latency"
+	MetricsKeyHandlerErrors  = "server.handler.errors"
+
+	// DefaultJSONMaxBodySize is the default maximum size of request bodies
+	// decoded by JSONHandler.
+	DefaultJSONMaxBodySize = 1 << 20
+)
+
+// Validator is implemented by request types that can check their own values.
+type Validator interface {
+	Validate() error
+}
+
+// JSONHandlerOption configures a handler created by JSONHandler.
+type JSONHandlerOption func(*jsonHandlerConfig)
+
+type jsonHandlerConfig struct {
+	status      int
+	maxBodySize int64
+}
+
+// JSONResponseStatus sets the status of successful responses. The default is
+// 200.
+func JSONResponseStatus(status int) JSONHandlerOption {
+	return func(c *jsonHandlerConfig) {
+		c.status = status
+	}
+}
+
+// JSONMaxBodySize sets the maximum size of request bodies. The default is
+// DefaultJSONMaxBodySize.
+func JSONMaxBodySize(size int64) JSONHandlerOption {
+	return func(c *jsonHandlerConfig) {
+		c.maxBodySize = size
+	}
+}
+
+// JSONHandler returns a handler that decodes the JSON request body into a
+// Req, calls fn, and writes the result as a JSON response with
+// WriteJSONResponse. Requests without a body use the zero value of Req.
+//
+// If the request body is not JSON, the handler responds with status 415. If
+// the body is larger than the maximum size or exceeds the limits set by
+// NewDecompressHandler, the handler responds with status 413. If the body
+// cannot be decoded, or if Req or *Req implements Validator and Validate
+// returns an error, the handler responds with status 400 and a problem that
+// includes the error message. Errors returned by fn are passed to the error
+// handler, like HandleRouteError, and must not be sent to clients without
+// review.
+//
+// The handler records the latency of fn in a timer tagged with the route
+// pattern, like "server.handler.latency[route:/api/users]", and counts
+// failures in the "server.handler.errors" counter, tagged with the route and
+// the kind of failure: "decode", "validate", or "handler". It must be used
+// with the default middleware or other middleware that catches errors with
+// hatpear.
+func JSONHandler[Req, Resp any](fn func(ctx context.Context, req Req) (Resp, error), opts ...JSONHandlerOption) http.Handler {
+	c := jsonHandlerConfig{
+		status:      http.StatusOK,
+		maxBodySize: DefaultJSONMaxBodySize,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return hatpear.Try(hatpear.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		ctx := r.Context()
+		route := "route:" + routeName(r)
+
+		fail := func(status int, kind string, err error) error {
+			CounterFromCtx(ctx, MetricsKeyHandlerErrors, route, "kind:"+kind).Inc(1)
+			hlog.FromRequest(r).Debug().Err(err).Msg("Rejected invalid JSON request")
+			WriteProblem(w, r, Problem{Status: status, Detail: err.Error()})
+			return nil
+		}
+
+		var req Req
+		if err := decodeJSONRequest(w, r, c.maxBodySize, &req); err != nil {
+			return fail(err.status, "decode", err)
+		}
+		if err := validate(&req); err != nil {
+			return fail(http.StatusBadRequest, "validate", err)
+		}
+
+		start := time.Now()
+		resp, err := fn(ctx, req)
+		TimerFromCtx(ctx, MetricsKeyHandlerLatency, route).UpdateSince(start)
+
+		if err != nil {
+			CounterFromCtx(ctx, MetricsKeyHandlerErrors, route, "kind:handler").Inc(1)
+			return err
+		}
+
+		_ = WriteJSONResponse(w, r, c.status, resp)
+		return nil
+	}))
+}
+
+type jsonRequestError struct {
+	status int
+	msg    string
+}
+
+func (e *jsonRequestError) Error() string {
+	return e.msg
+}
+
+// decodeJSONRequest decodes the request body into v, if the request has a
+// body.
+func decodeJSONRequest(w http.ResponseWriter, r *http.Request, maxSize int64, v any) *jsonRequestError {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || !isJSONMediaType(mediaType) {
+			return &jsonRequestError{status: http.StatusUnsupportedMediaType, msg: "request content type must be application/json"}
+		}
+	}
+
+	body := io.Reader(r.Body)
+	if maxSize > 0 {
+		body = http.MaxBytesReader(w, r.Body, maxSize)
+	}
+
+	if err := json.NewDecoder(body).Decode(v); err != nil && err != io.EOF {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) || errors.Is(err, ErrCompressionRatio) {
+			return &jsonRequestError{status: http.StatusRequestEntityTooLarge, msg: "request body is too large"}
+		}
+		return &jsonRequestError{status: http.StatusBadRequest, msg: "invalid JSON request body: " + err.Error()}
+	}
+	return nil
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// validate calls Validate if v or the value it points to implements
+// Validator.
+func validate[T any](v *T) error {
+	if val, ok := any(v).(Validator); ok {
+		return val.Validate()
+	}
+	if val, ok := any(*v).(Validator); ok {
+		return val.Validate()
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bluekeyes/hatpear"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"goji.io"
+	"goji.io/pat"
+)
+
+type greetRequest struct {
+	Name string `json:"name"`
+}
+
+func (r greetRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type greetResponse struct {
+	Greeting string `json:"greeting"`
+}
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string   { return "not found" }
+func (notFoundError) StatusCode() int { return http.StatusNotFound }
+
+func TestJSONHandler(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	mux := goji.NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithMetricsCtx(r.Context(), registry)))
+		})
+	})
+	mux.Use(hatpear.Catch(HandleRouteError))
+	mux.Handle(pat.Post("/greet"), JSONHandler(func(ctx context.Context, req greetRequest) (greetResponse, error) {
+		if req.Name == "nobody" {
+			return greetResponse{}, notFoundError{}
+		}
+		return greetResponse{Greeting: "Hello, " + req.Name}, nil
+	}, JSONResponseStatus(http.StatusCreated)))
+
+	send := func(contentType, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		return w
+	}
+	count := func(name string) int64 {
+		if c, ok := registry.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	w := send("application/json", `{"name": "gopher"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.JSONEq(t, `{"greeting": "Hello, gopher"}`, w.Body.String())
+	assert.Equal(t, int64(1), registry.Get("server.handler.latency[route:/greet]").(metrics.Timer).Count())
+
+	w = send("application/json", `{"name": `)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, int64(1), count("server.handler.errors[kind:decode,route:/greet]"))
+
+	w = send("text/plain", `{"name": "gopher"}`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = send("", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "name is required")
+	assert.Equal(t, int64(1), count("server.handler.errors[kind:validate,route:/greet]"))
+
+	w = send("application/json", `{"name": "nobody"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, int64(1), count("server.handler.errors[kind:handler,route:/greet]"))
+}
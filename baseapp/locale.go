@@ -0,0 +1,271 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultLanguage       = "en"
+	DefaultTimezoneHeader = "X-Timezone"
+)
+
+// Locale is the language and time zone preferred by the client that sent a
+// request.
+type Locale struct {
+	// Language is the language tag to use for responses, like "en" or
+	// "pt-BR".
+	Language string
+
+	// Location is the client's time zone. It is never nil.
+	Location *time.Location
+}
+
+// MessageCatalog provides translated messages. Message returns the
+// translation of the message with the given key in the given language, or
+// false if the catalog has no translation. Keys are usually the message text
+// in the default language.
+type MessageCatalog interface {
+	Message(lang, key string) (string, bool)
+}
+
+// MapCatalog is a MessageCatalog that stores messages in memory. It maps
+// language tags to maps from keys to translations.
+type MapCatalog map[string]map[string]string
+
+func (c MapCatalog) Message(lang, key string) (string, bool) {
+	msg, ok := c[lang][key]
+	return msg, ok
+}
+
+// LocaleOption configures the middleware returned by NewLocaleHandler.
+type LocaleOption func(*localeHandler)
+
+// WithSupportedLanguages sets the languages that the server supports. The
+// handler picks the first language in Accept-Language that matches a
+// supported language, either exactly or by the base language, so "en-GB"
+// matches "en". If no language matches, the handler uses the default
+// language. If this option is not set, the handler uses the client's first
+// preference.
+func WithSupportedLanguages(langs ...string) LocaleOption {
+	return func(h *localeHandler) {
+		h.supported = langs
+	}
+}
+
+// WithDefaultLanguage sets the language used when the client has no
+// preference or when no preference is supported. The default is "en".
+func WithDefaultLanguage(lang string) LocaleOption {
+	return func(h *localeHandler) {
+		h.defaultLanguage = lang
+	}
+}
+
+// WithTimezoneHeader sets the request header that contains the client's time
+// zone as an IANA name, like "America/New_York". The default is "X-Timezone".
+// Invalid time zones are ignored.
+func WithTimezoneHeader(name string) LocaleOption {
+	return func(h *localeHandler) {
+		h.timezoneHeader = name
+	}
+}
+
+// WithDefaultLocation sets the time zone used when the client does not send
+// one. The default is UTC.
+func WithDefaultLocation(loc *time.Location) LocaleOption {
+	return func(h *localeHandler) {
+		h.defaultLocation = loc
+	}
+}
+
+// WithMessageCatalog sets the catalog used by Localize and WriteProblem to
+// translate messages for requests.
+func WithMessageCatalog(c MessageCatalog) LocaleOption {
+	return func(h *localeHandler) {
+		h.catalog = c
+	}
+}
+
+type localeCtxKey struct{}
+type catalogCtxKey struct{}
+
+type localeHandler struct {
+	supported       []string
+	defaultLanguage string
+	timezoneHeader  string
+	defaultLocation *time.Location
+	catalog         MessageCatalog
+}
+
+// NewLocaleHandler returns middleware that adds the client's Locale to the
+// request context. The language comes from the Accept-Language header and the
+// time zone comes from a configurable header. Handlers can get the locale
+// with LocaleFromCtx and translate messages with Localize.
+func NewLocaleHandler(opts ...LocaleOption) func(http.Handler) http.Handler {
+	h := &localeHandler{
+		defaultLanguage: DefaultLanguage,
+		timezoneHeader:  DefaultTimezoneHeader,
+		defaultLocation: time.UTC,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			locale := Locale{
+				Language: h.language(r.Header.Get("Accept-Language")),
+				Location: h.defaultLocation,
+			}
+			if loc, ok := loadLocation(r.Header.Get(h.timezoneHeader)); ok {
+				locale.Location = loc
+			}
+
+			ctx := WithLocale(r.Context(), locale)
+			if h.catalog != nil {
+				ctx = context.WithValue(ctx, catalogCtxKey{}, h.catalog)
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func (h *localeHandler) language(accept string) string {
+	prefs := parseAcceptLanguage(accept)
+	if len(h.supported) == 0 {
+		if len(prefs) > 0 && prefs[0] != "*" {
+			return prefs[0]
+		}
+		return h.defaultLanguage
+	}
+
+	for _, pref := range prefs {
+		for _, lang := range h.supported {
+			if strings.EqualFold(pref, lang) {
+				return lang
+			}
+		}
+		base, _, _ := strings.Cut(pref, "-")
+		for _, lang := range h.supported {
+			if strings.EqualFold(base, lang) {
+				return lang
+			}
+		}
+	}
+	return h.defaultLanguage
+}
+
+// WithLocale stores a locale in a context.
+func WithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeCtxKey{}, locale)
+}
+
+// LocaleFromCtx returns the locale from the context. If the context has no
+// locale, it returns the default language and UTC.
+func LocaleFromCtx(ctx context.Context) Locale {
+	if locale, ok := ctx.Value(localeCtxKey{}).(Locale); ok {
+		return locale
+	}
+	return Locale{Language: DefaultLanguage, Location: time.UTC}
+}
+
+// Localize returns the translation of the message with the given key for the
+// language of the locale in the context. If there are arguments, the
+// translation is used as a format string for fmt.Sprintf. If the context has
+// no catalog or the catalog has no translation, Localize uses key as the
+// message.
+func Localize(ctx context.Context, key string, args ...any) string {
+	msg, _ := localize(ctx, key)
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// localize translates the message and returns true if a translation exists.
+func localize(ctx context.Context, key string) (string, bool) {
+	catalog, ok := ctx.Value(catalogCtxKey{}).(MessageCatalog)
+	if !ok || key == "" {
+		return key, false
+	}
+	if msg, ok := catalog.Message(LocaleFromCtx(ctx).Language, key); ok {
+		return msg, true
+	}
+	return key, false
+}
+
+// parseAcceptLanguage returns the language tags from an Accept-Language
+// header, ordered by preference. Tags with a quality of zero are omitted.
+func parseAcceptLanguage(header string) []string {
+	type pref struct {
+		tag string
+		q   float64
+	}
+
+	var prefs []pref
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag = strings.TrimSpace(tag); tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			prefs = append(prefs, pref{tag: tag, q: q})
+		}
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool {
+		return prefs[i].q > prefs[j].q
+	})
+
+	tags := make([]string, len(prefs))
+	for i, p := range prefs {
+		tags[i] = p.tag
+	}
+	return tags
+}
+
+// locations caches time zones by name. Only valid names are cached, so the
+// size is limited by the time zone database.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, bool) {
+	if name == "" || name == "Local" {
+		return nil, false
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), true
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, false
+	}
+	locations.Store(name, loc)
+	return loc, true
+}
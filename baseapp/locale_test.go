@@ -0,0 +1,104 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocaleHandler(t *testing.T) {
+	catalog := MapCatalog{
+		"fr": {
+			"Not Found":        "Introuvable",
+			"no user named %q": "aucun utilisateur nommé %q",
+			"greeting":         "bonjour",
+		},
+	}
+
+	var locale Locale
+	handler := NewLocaleHandler(
+		WithSupportedLanguages("en", "fr", "pt-BR"),
+		WithMessageCatalog(catalog),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale = LocaleFromCtx(r.Context())
+		WriteProblem(w, r, Problem{
+			Status: http.StatusNotFound,
+			Detail: Localize(r.Context(), "no user named %q", "alice"),
+		})
+	}))
+
+	serve := func(headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("translated", func(t *testing.T) {
+		w := serve(map[string]string{
+			"Accept-Language": "de;q=0.9, fr-CA, en;q=0.5",
+			"X-Timezone":      "Europe/Paris",
+		})
+
+		assert.Equal(t, "fr", locale.Language)
+		assert.Equal(t, "Europe/Paris", locale.Location.String())
+		assert.Equal(t, "fr", w.Header().Get("Content-Language"))
+
+		var p Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		assert.Equal(t, "Introuvable", p.Title)
+		assert.Equal(t, `aucun utilisateur nommé "alice"`, p.Detail)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		w := serve(map[string]string{
+			"Accept-Language": "de, ja;q=0.8",
+			"X-Timezone":      "../etc/passwd",
+		})
+
+		assert.Equal(t, "en", locale.Language)
+		assert.Equal(t, time.UTC, locale.Location)
+		assert.Empty(t, w.Header().Get("Content-Language"))
+
+		var p Problem
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		assert.Equal(t, "Not Found", p.Title)
+		assert.Equal(t, `no user named "alice"`, p.Detail)
+	})
+
+	t.Run("region", func(t *testing.T) {
+		serve(map[string]string{"Accept-Language": "pt-br"})
+		assert.Equal(t, "pt-BR", locale.Language)
+	})
+
+	assert.Equal(t, "greeting", Localize(context.Background(), "greeting"), "contexts without catalogs use the key")
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"fr-CH", "fr", "en", "*"}, parseAcceptLanguage("fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5"))
+	assert.Equal(t, []string{"en"}, parseAcceptLanguage("de;q=0, en"))
+	assert.Empty(t, parseAcceptLanguage(""))
+}
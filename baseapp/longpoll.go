@@ -0,0 +1,105 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+const (
+	MetricsKeyLongPollWait = "server.longpoll.wait"
+
+	// DefaultLongPollMaxWait is the default maximum time that LongPoll waits
+	// for a value.
+	DefaultLongPollMaxWait = 30 * time.Second
+)
+
+// LongPollOption configures LongPoll.
+type LongPollOption func(*longPoll)
+
+// WithLongPollMaxWait sets the maximum time that LongPoll waits for a value.
+// The default is DefaultLongPollMaxWait.
+func WithLongPollMaxWait(d time.Duration) LongPollOption {
+	return func(p *longPoll) {
+		p.maxWait = d
+	}
+}
+
+type longPoll struct {
+	maxWait time.Duration
+}
+
+// LongPoll waits for waitFor to produce a value and writes it as a JSON
+// response with status 200. waitFor must block until a value is available or
+// until its context is done, and return false if there is no value. If no
+// value is available before the maximum wait, LongPoll writes a 204 response
+// so the client can poll again.
+//
+// LongPoll coordinates the maximum wait with the server: if the server has a
+// WriteTimeout, the wait is limited to 90% of the timeout so there is time to
+// write the response before the server closes the connection.
+//
+// If ctx is canceled before a value is available, usually because the client
+// disconnected, LongPoll does not write a response and returns the context
+// error. Otherwise, it returns any error from writing the response.
+//
+// The time spent waiting is recorded in the "server.longpoll.wait" timer,
+// tagged with the result: "ready", "timeout", or "canceled".
+func LongPoll(ctx context.Context, w http.ResponseWriter, r *http.Request, waitFor func(ctx context.Context) (any, bool), opts ...LongPollOption) error {
+	p := &longPoll{
+		maxWait: DefaultLongPollMaxWait,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	maxWait := p.maxWait
+	if srv, ok := r.Context().Value(http.ServerContextKey).(*http.Server); ok && srv.WriteTimeout > 0 {
+		if limit := srv.WriteTimeout * 9 / 10; maxWait <= 0 || maxWait > limit {
+			maxWait = limit
+		}
+	}
+
+	waitCtx := ctx
+	if maxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
+
+	start := time.Now()
+	v, ok := waitFor(waitCtx)
+
+	result := "ready"
+	switch {
+	case ok:
+	case ctx.Err() != nil:
+		result = "canceled"
+	default:
+		result = "timeout"
+	}
+	TimerFromCtx(ctx, MetricsKeyLongPollWait, "result:"+result).UpdateSince(start)
+
+	switch result {
+	case "canceled":
+		return ctx.Err()
+	case "timeout":
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return WriteJSONResponse(w, r, http.StatusOK, v)
+}
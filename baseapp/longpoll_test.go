@@ -0,0 +1,94 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLongPoll(t *testing.T) {
+	registry := metrics.NewRegistry()
+	count := func(result string) int64 {
+		if m, ok := registry.Get(MetricsKeyLongPollWait + "[result:" + result + "]").(metrics.Timer); ok {
+			return m.Count()
+		}
+		return 0
+	}
+
+	poll := func(ctx context.Context, values <-chan string, opts ...LongPollOption) (*httptest.ResponseRecorder, error) {
+		ctx = WithMetricsCtx(ctx, registry)
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+
+		err := LongPoll(ctx, w, r, func(ctx context.Context) (any, bool) {
+			select {
+			case v := <-values:
+				return map[string]string{"event": v}, true
+			case <-ctx.Done():
+				return nil, false
+			}
+		}, opts...)
+		return w, err
+	}
+
+	t.Run("ready", func(t *testing.T) {
+		values := make(chan string, 1)
+		values <- "created"
+
+		w, err := poll(context.Background(), values)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"event": "created"}`, w.Body.String())
+		assert.Equal(t, int64(1), count("ready"))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		start := time.Now()
+		w, err := poll(context.Background(), nil, WithLongPollMaxWait(20*time.Millisecond))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+		assert.Equal(t, int64(1), count("timeout"))
+	})
+
+	t.Run("writeTimeout", func(t *testing.T) {
+		srv := &http.Server{WriteTimeout: 50 * time.Millisecond}
+		ctx := context.WithValue(context.Background(), http.ServerContextKey, srv)
+
+		start := time.Now()
+		w, err := poll(ctx, nil, WithLongPollMaxWait(time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Less(t, time.Since(start), srv.WriteTimeout, "wait should be shorter than the write timeout")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		w, err := poll(ctx, nil)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.False(t, w.Flushed)
+		assert.Empty(t, w.Body.String(), "no response should be written")
+		assert.Equal(t, int64(1), count("canceled"))
+	})
+}
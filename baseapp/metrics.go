@@ -16,11 +16,16 @@ package baseapp
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"os"
 	"runtime"
+	"sync"
 	"time"
 
+	"github.com/palantir/go-baseapp/appmetrics"
 	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
 )
 
 const (
@@ -31,8 +36,26 @@ const (
 	MetricsKeyRequests5xx   = "server.requests.5xx"
 	MetricsKeyLatencySuffix = ".latency"
 
+	MetricsKeyRequestsMiddlewareLatency = "server.requests.middleware.latency"
+	MetricsKeyRequestsHandlerLatency    = "server.requests.handler.latency"
+	MetricsKeyRequestsWriteLatency      = "server.requests.write.latency"
+
+	MetricsKeyRequestsRequestSize  = "server.requests.request.size"
+	MetricsKeyRequestsResponseSize = "server.requests.response.size"
+	MetricsKeyIngressBytes         = "server.ingress.bytes"
+	MetricsKeyEgressBytes          = "server.egress.bytes"
+
+	MetricsKeyRequestsCanceled = "server.requests.canceled"
+
 	MetricsKeyNumGoroutines = "server.goroutines"
 	MetricsKeyMemoryUsed    = "server.mem.used"
+
+	// Metrics about the middleware itself, used to detect misconfiguration
+	MetricsKeyLogWriteErrors  = "baseapp.log.write_errors"
+	MetricsKeyIgnoredRequests = "baseapp.requests.ignored"
+	MetricsKeyPanics          = "baseapp.panics"
+	MetricsKeyRouteErrors     = "baseapp.route_errors"
+	MetricsKeyHijacks         = "baseapp.hijacks"
 )
 
 type metricsCtxKey struct{}
@@ -51,6 +74,45 @@ func WithMetricsCtx(ctx context.Context, registry metrics.Registry) context.Cont
 	return context.WithValue(ctx, metricsCtxKey{}, registry)
 }
 
+// CounterFromCtx returns the counter with the given name and tags from the
+// registry in the context, registering it if it does not exist. Tags use the
+// same format as appmetrics, so the counter is emitted with the tags by the
+// appmetrics emitters. Like metrics.GetOrRegisterCounter, it panics if a
+// metric of a different type is registered with the same name and tags.
+func CounterFromCtx(ctx context.Context, name string, tags ...string) metrics.Counter {
+	return metrics.GetOrRegisterCounter(appmetrics.TaggedName(name, tags...), MetricsCtx(ctx))
+}
+
+// GaugeFromCtx returns the gauge with the given name and tags from the
+// registry in the context, registering it if it does not exist. See
+// CounterFromCtx for details.
+func GaugeFromCtx(ctx context.Context, name string, tags ...string) metrics.Gauge {
+	return metrics.GetOrRegisterGauge(appmetrics.TaggedName(name, tags...), MetricsCtx(ctx))
+}
+
+// MeterFromCtx returns the meter with the given name and tags from the
+// registry in the context, registering it if it does not exist. See
+// CounterFromCtx for details.
+func MeterFromCtx(ctx context.Context, name string, tags ...string) metrics.Meter {
+	return metrics.GetOrRegisterMeter(appmetrics.TaggedName(name, tags...), MetricsCtx(ctx))
+}
+
+// HistogramFromCtx returns the histogram with the given name and tags from
+// the registry in the context, registering it with the default appmetrics
+// sample if it does not exist. See CounterFromCtx for details.
+func HistogramFromCtx(ctx context.Context, name string, tags ...string) metrics.Histogram {
+	return MetricsCtx(ctx).GetOrRegister(appmetrics.TaggedName(name, tags...), func() metrics.Histogram {
+		return metrics.NewHistogram(metrics.NewExpDecaySample(appmetrics.DefaultReservoirSize, appmetrics.DefaultExpDecayAlpha))
+	}).(metrics.Histogram)
+}
+
+// TimerFromCtx returns the timer with the given name and tags from the
+// registry in the context, registering it if it does not exist. See
+// CounterFromCtx for details.
+func TimerFromCtx(ctx context.Context, name string, tags ...string) metrics.Timer {
+	return metrics.GetOrRegisterTimer(appmetrics.TaggedName(name, tags...), MetricsCtx(ctx))
+}
+
 // RegisterDefaultMetrics adds the default metrics provided by this package to
 // the registry. This should be called before any functions emit metrics to
 // ensure that no events are lost.
@@ -66,6 +128,33 @@ func RegisterDefaultMetrics(registry metrics.Registry) {
 		metrics.GetOrRegisterTimer(key+MetricsKeyLatencySuffix, registry)
 	}
 
+	for _, key := range []string{
+		MetricsKeyRequestsMiddlewareLatency,
+		MetricsKeyRequestsHandlerLatency,
+		MetricsKeyRequestsWriteLatency,
+	} {
+		metrics.GetOrRegisterTimer(key, registry)
+	}
+
+	for _, key := range []string{
+		MetricsKeyRequestsRequestSize,
+		MetricsKeyRequestsResponseSize,
+	} {
+		metrics.GetOrRegisterHistogram(key, registry, metrics.NewExpDecaySample(appmetrics.DefaultReservoirSize, appmetrics.DefaultExpDecayAlpha))
+	}
+
+	for _, key := range []string{
+		MetricsKeyIngressBytes,
+		MetricsKeyEgressBytes,
+	} {
+		metrics.GetOrRegisterCounter(key, registry)
+	}
+
+	for _, reason := range []string{CancelReasonClient, CancelReasonTimeout} {
+		metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyRequestsCanceled, "reason:"+reason), registry)
+		metrics.GetOrRegisterTimer(appmetrics.TaggedName(MetricsKeyRequestsCanceled+MetricsKeyLatencySuffix, "reason:"+reason), registry)
+	}
+
 	registry.GetOrRegister(MetricsKeyNumGoroutines, func() metrics.Gauge {
 		return metrics.NewFunctionalGauge(func() int64 {
 			return int64(runtime.NumGoroutine())
@@ -82,7 +171,20 @@ func RegisterDefaultMetrics(registry metrics.Registry) {
 }
 
 // CountRequest is an AccessCallback that records metrics about the request.
-func CountRequest(r *http.Request, status int, _ int64, elapsed time.Duration) {
+// If the request has timing from AccessHandler, CountRequest also records the
+// time spent in middleware, in the handler, and writing the response.
+//
+// CountRequest records the bytes read from the request body and the bytes
+// written in the response body in histograms and adds them to the
+// "server.ingress.bytes" and "server.egress.bytes" counters. Emitters report
+// the change in the counters for each interval, which is the bandwidth used
+// by request and response bodies. Headers and TLS overhead are not included.
+//
+// Requests canceled before completion are also counted in the
+// "server.requests.canceled" counter and timer, tagged with the reason from
+// CancelReason. The timer measures the time spent on requests whose
+// responses were never used.
+func CountRequest(r *http.Request, status int, size int64, elapsed time.Duration) {
 	if IsIgnored(r, IgnoreRule{Metrics: true}) {
 		return
 	}
@@ -96,26 +198,105 @@ func CountRequest(r *http.Request, status int, _ int64, elapsed time.Duration) {
 		t.(metrics.Timer).Update(elapsed)
 	}
 
-	if key := bucketStatus(status); key != "" {
+	requestSize := RequestBytesRead(r.Context())
+	if h := registry.Get(MetricsKeyRequestsRequestSize); h != nil {
+		h.(metrics.Histogram).Update(requestSize)
+	}
+	if h := registry.Get(MetricsKeyRequestsResponseSize); h != nil {
+		h.(metrics.Histogram).Update(size)
+	}
+	if c := registry.Get(MetricsKeyIngressBytes); c != nil {
+		c.(metrics.Counter).Inc(requestSize)
+	}
+	if c := registry.Get(MetricsKeyEgressBytes); c != nil {
+		c.(metrics.Counter).Inc(size)
+	}
+
+	if key, latencyKey := bucketStatus(status); key != "" {
 		if c := registry.Get(key); c != nil {
 			c.(metrics.Counter).Inc(1)
 		}
-		if t := registry.Get(key + MetricsKeyLatencySuffix); t != nil {
+		if t := registry.Get(latencyKey); t != nil {
 			t.(metrics.Timer).Update(elapsed)
 		}
 	}
+
+	if reason := CancelReason(r.Context()); reason != "" {
+		tag := "reason:" + reason
+		if c := registry.Get(appmetrics.TaggedName(MetricsKeyRequestsCanceled, tag)); c != nil {
+			c.(metrics.Counter).Inc(1)
+		}
+		if t := registry.Get(appmetrics.TaggedName(MetricsKeyRequestsCanceled+MetricsKeyLatencySuffix, tag)); t != nil {
+			t.(metrics.Timer).Update(elapsed)
+		}
+	}
+
+	if timing, ok := RequestTimingFromCtx(r.Context()); ok {
+		if t := registry.Get(MetricsKeyRequestsMiddlewareLatency); t != nil && timing.Middleware > 0 {
+			t.(metrics.Timer).Update(timing.Middleware)
+		}
+		if t := registry.Get(MetricsKeyRequestsHandlerLatency); t != nil {
+			t.(metrics.Timer).Update(timing.Handler)
+		}
+		if t := registry.Get(MetricsKeyRequestsWriteLatency); t != nil {
+			t.(metrics.Timer).Update(timing.Write)
+		}
+	}
+}
+
+// logWriteErrors is the state of the zerolog.ErrorHandler set by
+// countLogWriteErrors. The handler is global, so it is only set once and
+// counts failures in the counter of the last registry.
+var logWriteErrors struct {
+	sync.Mutex
+	installed bool
+	counter   metrics.Counter
+}
+
+// countLogWriteErrors counts write failures in the registry. The first call
+// sets zerolog.ErrorHandler to a handler that calls the previous handler;
+// later calls only change the registry of the counter.
+func countLogWriteErrors(registry metrics.Registry) {
+	counter := metrics.GetOrRegisterCounter(MetricsKeyLogWriteErrors, registry)
+
+	logWriteErrors.Lock()
+	defer logWriteErrors.Unlock()
+
+	logWriteErrors.counter = counter
+	if logWriteErrors.installed {
+		return
+	}
+	logWriteErrors.installed = true
+
+	prev := zerolog.ErrorHandler
+	zerolog.ErrorHandler = func(err error) {
+		logWriteErrors.Lock()
+		counter := logWriteErrors.counter
+		logWriteErrors.Unlock()
+
+		counter.Inc(1)
+		if prev != nil {
+			prev(err)
+		} else {
+			// match the default behavior when there is no handler
+			fmt.Fprintf(os.Stderr, "zerolog: could not write event: %v\n", err)
+		}
+	}
 }
 
-func bucketStatus(status int) string {
+// bucketStatus returns the counter and timer keys for the class of status.
+// The keys are constants so that CountRequest does not build them for each
+// request.
+func bucketStatus(status int) (string, string) {
 	switch {
 	case status >= 200 && status < 300:
-		return MetricsKeyRequests2xx
+		return MetricsKeyRequests2xx, MetricsKeyRequests2xx + MetricsKeyLatencySuffix
 	case status >= 300 && status < 400:
-		return MetricsKeyRequests3xx
+		return MetricsKeyRequests3xx, MetricsKeyRequests3xx + MetricsKeyLatencySuffix
 	case status >= 400 && status < 500:
-		return MetricsKeyRequests4xx
+		return MetricsKeyRequests4xx, MetricsKeyRequests4xx + MetricsKeyLatencySuffix
 	case status >= 500 && status < 600:
-		return MetricsKeyRequests5xx
+		return MetricsKeyRequests5xx, MetricsKeyRequests5xx + MetricsKeyLatencySuffix
 	}
-	return ""
+	return "", ""
 }
@@ -0,0 +1,126 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsFromCtx(t *testing.T) {
+	registry := metrics.NewRegistry()
+	ctx := WithMetricsCtx(context.Background(), registry)
+
+	CounterFromCtx(ctx, "middleware.calls", "route:a").Inc(1)
+	CounterFromCtx(ctx, "middleware.calls", "route:a").Inc(1)
+	CounterFromCtx(ctx, "middleware.calls").Inc(1)
+	TimerFromCtx(ctx, "middleware.latency", "route:a").Update(time.Second)
+	HistogramFromCtx(ctx, "middleware.size", "route:a").Update(10)
+	GaugeFromCtx(ctx, "middleware.active", "route:a").Update(3)
+	MeterFromCtx(ctx, "middleware.rate", "route:a").Mark(1)
+
+	assert.Equal(t, int64(2), registry.Get("middleware.calls[route:a]").(metrics.Counter).Count())
+	assert.Equal(t, int64(1), registry.Get("middleware.calls").(metrics.Counter).Count())
+	assert.Equal(t, int64(1), registry.Get("middleware.latency[route:a]").(metrics.Timer).Count())
+	assert.Equal(t, int64(1), registry.Get("middleware.size[route:a]").(metrics.Histogram).Count())
+	assert.Equal(t, int64(3), registry.Get("middleware.active[route:a]").(metrics.Gauge).Value())
+	assert.Equal(t, int64(1), registry.Get("middleware.rate[route:a]").(metrics.Meter).Count())
+}
+
+func TestMiddlewareMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	access := AccessHandler(func(*http.Request, int, int64, time.Duration) {})
+	handler := NewMetricsHandler(registry)(NewIgnoreHandler()(access(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			IgnoreAll(r)
+		case "/quiet":
+			Ignore(r, IgnoreRule{Logs: true})
+		case "/socket":
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if assert.NoError(t, err) {
+				_ = conn.Close()
+			}
+		}
+	}))))
+
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	for _, path := range []string{"/health", "/health", "/quiet", "/"} {
+		res, err := http.Get(srv.URL + path)
+		require.NoError(t, err)
+		_ = res.Body.Close()
+	}
+
+	// use a raw connection because clients retry requests on hijacked
+	// connections that close without a response
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	_, _ = conn.Write([]byte("GET /socket HTTP/1.1\r\nHost: test\r\n\r\n"))
+	_, _ = io.ReadAll(conn)
+	_ = conn.Close()
+
+	count := func(name string) int64 {
+		if c, ok := registry.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+	assert.Equal(t, int64(2), count("baseapp.requests.ignored[rule:all]"))
+	assert.Equal(t, int64(1), count("baseapp.requests.ignored[rule:logs]"))
+	assert.Eventually(t, func() bool { return count("baseapp.hijacks") == 1 }, time.Second, time.Millisecond)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestCountLogWriteErrors(t *testing.T) {
+	prev := zerolog.ErrorHandler
+	defer func() {
+		zerolog.ErrorHandler = prev
+		logWriteErrors.installed = false
+		logWriteErrors.counter = nil
+	}()
+
+	var handled []error
+	zerolog.ErrorHandler = func(err error) { handled = append(handled, err) }
+
+	first := metrics.NewRegistry()
+	countLogWriteErrors(first)
+	second := metrics.NewRegistry()
+	countLogWriteErrors(second)
+
+	logger := zerolog.New(failingWriter{})
+	logger.Info().Msg("lost")
+
+	assert.Equal(t, int64(0), first.Get(MetricsKeyLogWriteErrors).(metrics.Counter).Count())
+	assert.Equal(t, int64(1), second.Get(MetricsKeyLogWriteErrors).(metrics.Counter).Count(), "last registry should count failures")
+	assert.Len(t, handled, 1, "previous handler should be called once")
+}
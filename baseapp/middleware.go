@@ -15,7 +15,11 @@
 package baseapp
 
 import (
+	"context"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/bluekeyes/hatpear"
@@ -32,6 +36,7 @@ import (
 //   - Logs and records metrics for requests, respecting ignore rules
 //   - Handles errors returned by route handlers
 //   - Recovers from panics in route handlers
+//   - Marks the start of route handlers for request timing
 //
 // All components are exported so users can select individual middleware to
 // build their own stack if desired.
@@ -43,7 +48,8 @@ func DefaultMiddleware(logger zerolog.Logger, registry metrics.Registry) []func(
 		NewIgnoreHandler(),
 		AccessHandler(RecordRequest),
 		hatpear.Catch(HandleRouteError),
-		hatpear.Recover(),
+		NewRecoverHandler(),
+		NewTimingHandler(),
 	}
 }
 
@@ -58,21 +64,133 @@ func NewMetricsHandler(registry metrics.Registry) func(http.Handler) http.Handle
 	}
 }
 
-// LogRequest is an AccessCallback that logs request information.
+// accessContext holds the state that AccessHandler tracks for each request:
+// the events added with AddRequestEvent, the request timing, the bytes read
+// from the request body, the cancellation state, and the error handled by
+// HandleRouteError. Storing the state in one context avoids an allocation for
+// each value.
+type accessContext struct {
+	context.Context
+
+	events   requestEvents
+	timing   requestTiming
+	body     countingBody
+	cancel   *cancelState
+	state    cancelState
+	routeErr *error
+	err      error
+}
+
+// newAccessContext returns an accessContext with parent. If parent already
+// records route errors and cancellations, like when AccessHandler is nested,
+// they are recorded in the parent so that all handlers see them.
+func newAccessContext(parent context.Context) *accessContext {
+	c := &accessContext{Context: parent}
+	if p, ok := parent.Value(routeErrorCtxKey{}).(*error); ok {
+		c.routeErr = p
+	} else {
+		c.routeErr = &c.err
+	}
+	if p, ok := parent.Value(cancelCtxKey{}).(*cancelState); ok {
+		c.cancel = p
+	} else {
+		c.state.client = parent
+		c.cancel = &c.state
+	}
+	return c
+}
+
+func (c *accessContext) Value(key any) any {
+	switch key.(type) {
+	case requestEventsCtxKey:
+		return &c.events
+	case requestTimingCtxKey:
+		return &c.timing
+	case requestBodyCtxKey:
+		return &c.body
+	case cancelCtxKey:
+		return c.cancel
+	case routeErrorCtxKey:
+		return c.routeErr
+	}
+	return c.Context.Value(key)
+}
+
+// pathBuffers holds buffers for formatting request paths in LogRequest.
+var pathBuffers = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+// LogRequest is an AccessCallback that logs request information, including
+// any events added with AddRequestEvent. The "size" field is the size of the
+// response and the "request_size" field is the number of bytes read from the
+// request body. If the request was canceled before completion, the
+// "canceled" field contains the reason returned by CancelReason.
+//
+// LogRequest does not allocate for typical requests without events. Fields
+// are only computed if the logger is enabled for the info level.
 func LogRequest(r *http.Request, status int, size int64, elapsed time.Duration) {
 	if IsIgnored(r, IgnoreRule{Logs: true}) {
 		return
 	}
 
-	hlog.FromRequest(r).Info().
-		Str("method", r.Method).
-		Str("path", r.URL.String()).
+	e := hlog.FromRequest(r).Info()
+	if !e.Enabled() {
+		return
+	}
+
+	buf := pathBuffers.Get().(*[]byte)
+	*buf = appendRequestPath((*buf)[:0], r.URL)
+
+	e = e.Str("method", r.Method).
+		Bytes("path", *buf).
 		Str("client_ip", r.RemoteAddr).
 		Int("status", status).
 		Int64("size", size).
+		Int64("request_size", RequestBytesRead(r.Context())).
 		Dur("elapsed", elapsed).
-		Str("user_agent", r.UserAgent()).
-		Msg("http_request")
+		Str("user_agent", r.UserAgent())
+
+	pathBuffers.Put(buf)
+
+	if reason := CancelReason(r.Context()); reason != "" {
+		e = e.Str("canceled", reason)
+	}
+	if events := RequestEvents(r.Context()); len(events) > 0 {
+		e = e.Array("events", requestEventsArray(events, time.Now().Add(-elapsed)))
+	}
+
+	e.Msg("http_request")
+}
+
+// appendRequestPath appends the value of u.String() to dst. URLs of server
+// requests usually only have a path and a query, which are appended without
+// allocating. Other URLs use u.String().
+func appendRequestPath(dst []byte, u *url.URL) []byte {
+	if u.Scheme != "" || u.Opaque != "" || u.User != nil || u.Host != "" || u.OmitHost || u.Fragment != "" || !strings.HasPrefix(u.Path, "/") {
+		return append(dst, u.String()...)
+	}
+
+	dst = append(dst, u.EscapedPath()...)
+	if u.ForceQuery || u.RawQuery != "" {
+		dst = append(dst, '?')
+		dst = append(dst, u.RawQuery...)
+	}
+	return dst
+}
+
+// countMiddlewareEvents records metrics about requests that are useful to
+// detect misconfigured middleware.
+func countMiddlewareEvents(r *http.Request, w RecordingResponseWriter) {
+	if tag := ignoredRuleTag(r); tag != "" {
+		CounterFromCtx(r.Context(), MetricsKeyIgnoredRequests, tag).Inc(1)
+	}
+	if hw, ok := w.(interface{ wasHijacked() bool }); ok && hw.wasHijacked() {
+		CounterFromCtx(r.Context(), MetricsKeyHijacks).Inc(1)
+	}
 }
 
 // RecordRequest is an AccessCallback that logs request information and
@@ -84,14 +202,40 @@ func RecordRequest(r *http.Request, status int, size int64, elapsed time.Duratio
 
 type AccessCallback func(r *http.Request, status int, size int64, duration time.Duration)
 
-// AccessHandler returns a handler that call f after each request.
+// AccessHandler returns a handler that call f after each request. The handler
+// also collects events added with AddRequestEvent so that f can access them
+// with RequestEvents and counts the bytes read from the request body so that
+// f can access them with RequestBytesRead. It also saves the request context
+// so that IsClientGone and CancelReason can tell client disconnects from
+// other cancellations. If the request context contains a Watchdog, the handler
+// also tracks the request with the watchdog, and if it contains a Journal, the
+// handler records the request in the journal. The handler measures the time
+// spent in middleware, in the handler, and writing the response, which f can
+// access with RequestTimingFromCtx.
 func AccessHandler(f AccessCallback) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 			wrapped := WrapWriter(w)
-			next.ServeHTTP(wrapped, r)
-			f(r, wrapped.Status(), wrapped.BytesWritten(), time.Since(start))
+			ac := newAccessContext(r.Context())
+			r = r.WithContext(ac)
+			if r.Body != nil && r.Body != http.NoBody {
+				ac.body.ReadCloser = r.Body
+				r.Body = &ac.body
+			}
+			if wd := watchdogFromContext(r.Context()); wd != nil {
+				wd.serve(wrapped, r, start, next)
+			} else {
+				next.ServeHTTP(wrapped, r)
+			}
+			end := time.Now()
+			elapsed := end.Sub(start)
+			finishRequestTiming(r.Context(), wrapped, start, end)
+			if j := journalFromContext(r.Context()); j != nil {
+				j.record(r, wrapped.Status(), elapsed)
+			}
+			countMiddlewareEvents(r, wrapped)
+			f(r, wrapped.Status(), wrapped.BytesWritten(), elapsed)
 		})
 	}
 }
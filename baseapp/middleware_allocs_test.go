@@ -0,0 +1,36 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The race detector adds allocations, so these tests only run without it.
+
+//go:build !race
+
+package baseapp
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordRequestAllocs(t *testing.T) {
+	r := newBenchmarkRequest()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		RecordRequest(r, http.StatusOK, 12, time.Millisecond)
+	})
+	assert.Zero(t, allocs)
+}
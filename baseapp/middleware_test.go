@@ -0,0 +1,134 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogRequest(t *testing.T) {
+	for _, target := range []string{
+		"/",
+		"/api/v1/items",
+		"/api/v1/items?limit=10&sort=name",
+		"/search?",
+		"/files/a%2Fb",
+		"/caf%C3%A9",
+		"/space here",
+		"http://example.com/proxy?x=1",
+		"*",
+	} {
+		t.Run(target, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			u, err := url.ParseRequestURI(target)
+			if err != nil {
+				u = &url.URL{Path: target}
+			}
+			r.URL = u
+			r = r.WithContext(logger.WithContext(r.Context()))
+
+			LogRequest(r, http.StatusOK, 12, time.Millisecond)
+
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, u.String(), entry["path"])
+			assert.Equal(t, "GET", entry["method"])
+			assert.Equal(t, float64(200), entry["status"])
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		logger := zerolog.New(io.Discard).Level(zerolog.WarnLevel)
+		r := httptest.NewRequest(http.MethodGet, "/items?limit=10", nil)
+		r = r.WithContext(logger.WithContext(r.Context()))
+
+		allocs := testing.AllocsPerRun(100, func() {
+			LogRequest(r, http.StatusOK, 12, time.Millisecond)
+		})
+		assert.Zero(t, allocs)
+	})
+}
+
+func newBenchmarkRequest() *http.Request {
+	registry := metrics.NewRegistry()
+	RegisterDefaultMetrics(registry)
+
+	logger := zerolog.New(io.Discard)
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/items?limit=10&sort=name", nil)
+	r.Header.Set("User-Agent", "benchmark/1.0")
+	ctx := WithMetricsCtx(logger.WithContext(context.Background()), registry)
+	return r.WithContext(ctx)
+}
+
+func BenchmarkLogRequest(b *testing.B) {
+	r := newBenchmarkRequest()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		LogRequest(r, http.StatusOK, 12, time.Millisecond)
+	}
+}
+
+func BenchmarkCountRequest(b *testing.B) {
+	r := newBenchmarkRequest()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CountRequest(r, http.StatusOK, 12, time.Millisecond)
+	}
+}
+
+func BenchmarkRecordRequest(b *testing.B) {
+	r := newBenchmarkRequest()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RecordRequest(r, http.StatusOK, 12, time.Millisecond)
+	}
+}
+
+func BenchmarkAccessHandler(b *testing.B) {
+	r := newBenchmarkRequest()
+	handler := AccessHandler(RecordRequest)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	w := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,631 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi serves an OpenAPI 3 document and validates requests
+// against it.
+//
+// The document is usually embedded in the application binary with go:embed
+// and may be either JSON or YAML. The middleware returned by API.Middleware
+// matches each request to an operation in the document, makes the operation
+// ID available to later handlers, and optionally validates parameters and
+// JSON request bodies, sending a 400 problem response if validation fails.
+//
+// Validation supports the commonly used subset of the schema language: types,
+// "required", "properties", "additionalProperties", "items", "enum", numeric
+// and length bounds, "pattern", "nullable", local "$ref" values, and the
+// "allOf", "anyOf", and "oneOf" combinators. Formats are not validated.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"goji.io/pat"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	MetricsKeyRequests         = "openapi.requests"
+	MetricsKeyValidationErrors = "openapi.validation_errors"
+
+	// DefaultMaxBodySize is the largest request body read for validation.
+	DefaultMaxBodySize = 10 << 20
+
+	// DefaultUIAssetURL is the location of the Swagger UI assets used by
+	// UIHandler if no other location is set.
+	DefaultUIAssetURL = "https://unpkg.com/swagger-ui-dist@5.17.14"
+)
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+type operationCtxKey struct{}
+
+// OperationID returns the ID of the OpenAPI operation matched by the
+// middleware for the request with the given context. It returns the empty
+// string if no operation matched.
+func OperationID(ctx context.Context) string {
+	if op, ok := ctx.Value(operationCtxKey{}).(*operation); ok {
+		return op.id
+	}
+	return ""
+}
+
+// Option configures an API.
+type Option func(*API)
+
+// WithValidation enables or disables request validation. When disabled, the
+// middleware only matches operations. Validation is enabled by default.
+func WithValidation(validate bool) Option {
+	return func(a *API) {
+		a.validate = validate
+	}
+}
+
+// WithBasePath sets a prefix that is removed from request paths before they
+// are matched against the paths in the document. Requests with paths that are
+// not equal to the prefix or inside it, like "/apiv2" for the prefix "/api",
+// do not match any operation.
+func WithBasePath(path string) Option {
+	return func(a *API) {
+		a.basePath = strings.TrimSuffix(path, "/")
+	}
+}
+
+// WithMaxBodySize sets the largest request body read for validation. Larger
+// bodies fail validation.
+func WithMaxBodySize(size int64) Option {
+	return func(a *API) {
+		a.maxBodySize = size
+	}
+}
+
+// WithUIAssetURL sets the location of the Swagger UI assets used by
+// UIHandler. The URL must point to a directory containing the files from the
+// swagger-ui-dist package, like a path served by the application itself. The
+// default is DefaultUIAssetURL.
+func WithUIAssetURL(url string) Option {
+	return func(a *API) {
+		a.uiAssetURL = strings.TrimSuffix(url, "/")
+	}
+}
+
+// API is a parsed OpenAPI document.
+type API struct {
+	raw         []byte
+	contentType string
+	root        map[string]interface{}
+	operations  []*operation
+
+	validate    bool
+	basePath    string
+	maxBodySize int64
+	uiAssetURL  string
+}
+
+// New parses an OpenAPI document in JSON or YAML format.
+func New(doc []byte, opts ...Option) (*API, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(doc, &v); err != nil {
+		return nil, errors.Wrap(err, "openapi: failed to parse document")
+	}
+
+	root, ok := normalize(v).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("openapi: document is not an object")
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, errors.Errorf("openapi: unsupported document version %q", version)
+	}
+
+	a := &API{
+		raw:         doc,
+		contentType: "application/yaml",
+		root:        root,
+		validate:    true,
+		maxBodySize: DefaultMaxBodySize,
+		uiAssetURL:  DefaultUIAssetURL,
+	}
+	if trimmed := bytes.TrimSpace(doc); len(trimmed) > 0 && trimmed[0] == '{' {
+		a.contentType = "application/json"
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if err := a.parseOperations(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Mount registers handlers for the document on the server. The document is
+// served at specPath. If uiPath is not empty, a Swagger UI page for the
+// document is served at that path. See UIHandler for details about where the
+// page loads its assets.
+//
+// Mount does not add the validation middleware; add the result of
+// API.Middleware to the server's middleware stack or to a sub-mux.
+func Mount(s *baseapp.Server, a *API, specPath, uiPath string) {
+	s.Mux().Handle(pat.Get(specPath), a.SpecHandler())
+	if uiPath != "" {
+		s.Mux().Handle(pat.Get(uiPath), a.UIHandler(specPath))
+	}
+}
+
+// SpecHandler returns a handler that serves the original document.
+func (a *API) SpecHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", a.contentType)
+		_, _ = w.Write(a.raw)
+	})
+}
+
+var uiTemplate = template.Must(template.New("ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="{{.AssetURL}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.AssetURL}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = function() {
+      window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`))
+
+// UIHandler returns a handler that serves a Swagger UI page for the document
+// located at specURL.
+//
+// By default, the page loads a pinned version of the Swagger UI assets from a
+// public CDN without subresource integrity checks, so browsers run whatever
+// the CDN serves for that version. Applications that cannot trust the CDN
+// should serve the assets themselves and set their location with
+// WithUIAssetURL.
+func (a *API) UIHandler(specURL string) http.Handler {
+	title := "API Documentation"
+	if info, ok := a.root["info"].(map[string]interface{}); ok {
+		if t, ok := info["title"].(string); ok && t != "" {
+			title = t
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = uiTemplate.Execute(w, map[string]string{
+			"Title":    title,
+			"SpecURL":  specURL,
+			"AssetURL": a.uiAssetURL,
+		})
+	})
+}
+
+// Middleware returns middleware that matches requests to operations in the
+// document and, if enabled, validates them. Requests that do not match any
+// operation are passed to the next handler without validation.
+//
+// The middleware counts requests and validation failures for each operation
+// using tagged metrics in the registry from the request context. The
+// operation tag is the operation ID with characters other than letters,
+// digits, '_', '-', and '.' replaced by underscores. Operations without an ID
+// use a tag derived from the method and path, like "GET_users_id" for "GET
+// /users/{id}".
+func (a *API) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, pathParams := a.match(r)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), operationCtxKey{}, op))
+
+			registry := baseapp.MetricsCtx(r.Context())
+			metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyRequests, op.tag), registry).Inc(1)
+
+			if a.validate {
+				if errs := a.validateRequest(r, op, pathParams); len(errs) > 0 {
+					metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyValidationErrors, op.tag), registry).Inc(1)
+					baseapp.WriteProblem(w, r, baseapp.Problem{
+						Status: http.StatusBadRequest,
+						Detail: "The request does not match the API specification",
+						Extensions: map[string]interface{}{
+							"operation": op.id,
+							"errors":    errs,
+						},
+					})
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type operation struct {
+	id       string
+	tag      string
+	method   string
+	segments []string
+	params   []parameter
+	body     *requestBody
+}
+
+type parameter struct {
+	name     string
+	in       string
+	required bool
+	schema   map[string]interface{}
+}
+
+type requestBody struct {
+	required bool
+	content  map[string]map[string]interface{}
+}
+
+func (a *API) parseOperations() error {
+	paths, _ := a.root["paths"].(map[string]interface{})
+
+	keys := make([]string, 0, len(paths))
+	for k := range paths {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, path := range keys {
+		item, ok := a.resolve(paths[path]).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		shared := a.parseParameters(item["parameters"])
+
+		for _, method := range methods {
+			spec, ok := a.resolve(item[method]).(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			op := &operation{
+				method:   strings.ToUpper(method),
+				segments: splitPath(path),
+			}
+			op.id, _ = spec["operationId"].(string)
+			if op.id == "" {
+				op.id = op.method + " " + path
+			}
+			op.tag = "operation:" + sanitizeTag(op.id)
+
+			// operation parameters override path parameters with the same
+			// name and location
+			params := a.parseParameters(spec["parameters"])
+			for _, p := range shared {
+				if !containsParam(params, p) {
+					params = append(params, p)
+				}
+			}
+			op.params = params
+
+			if body, ok := a.resolve(spec["requestBody"]).(map[string]interface{}); ok {
+				op.body = &requestBody{content: make(map[string]map[string]interface{})}
+				op.body.required, _ = body["required"].(bool)
+				content, _ := body["content"].(map[string]interface{})
+				for mediaType, media := range content {
+					m, _ := media.(map[string]interface{})
+					schema, _ := a.resolve(m["schema"]).(map[string]interface{})
+					op.body.content[strings.ToLower(mediaType)] = schema
+				}
+			}
+
+			a.operations = append(a.operations, op)
+		}
+	}
+
+	// Prefer operations with more literal segments so that "/users/me" is
+	// matched before "/users/{id}"
+	sort.SliceStable(a.operations, func(i, j int) bool {
+		return literalCount(a.operations[i].segments) > literalCount(a.operations[j].segments)
+	})
+	return nil
+}
+
+func (a *API) parseParameters(v interface{}) []parameter {
+	list, _ := v.([]interface{})
+
+	var params []parameter
+	for _, item := range list {
+		p, ok := a.resolve(item).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		param := parameter{}
+		param.name, _ = p["name"].(string)
+		param.in, _ = p["in"].(string)
+		param.required, _ = p["required"].(bool)
+		param.schema, _ = a.resolve(p["schema"]).(map[string]interface{})
+		if param.in == "path" {
+			param.required = true
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+func (a *API) match(r *http.Request) (*operation, map[string]string) {
+	path := r.URL.Path
+	if a.basePath != "" {
+		rest, ok := strings.CutPrefix(path, a.basePath)
+		if !ok || (rest != "" && rest[0] != '/') {
+			return nil, nil
+		}
+		path = rest
+	}
+	segments := splitPath(path)
+
+	for _, op := range a.operations {
+		if op.method != r.Method || len(op.segments) != len(segments) {
+			continue
+		}
+
+		var params map[string]string
+		matched := true
+		for i, s := range op.segments {
+			if name, ok := templateName(s); ok {
+				if params == nil {
+					params = make(map[string]string)
+				}
+				params[name] = segments[i]
+				continue
+			}
+			if s != segments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return op, params
+		}
+	}
+	return nil, nil
+}
+
+func (a *API) validateRequest(r *http.Request, op *operation, pathParams map[string]string) []string {
+	var errs []string
+
+	query := r.URL.Query()
+	for _, p := range op.params {
+		var value string
+		var present bool
+
+		switch p.in {
+		case "path":
+			value, present = pathParams[p.name]
+		case "query":
+			var values []string
+			values, present = query[p.name]
+			if present && len(values) > 0 {
+				value = values[0]
+			}
+		case "header":
+			value = r.Header.Get(p.name)
+			present = value != ""
+		case "cookie":
+			if c, err := r.Cookie(p.name); err == nil {
+				value, present = c.Value, true
+			}
+		default:
+			continue
+		}
+
+		if !present {
+			if p.required {
+				errs = append(errs, fmt.Sprintf("%s parameter %q is required", p.in, p.name))
+			}
+			continue
+		}
+		if p.schema != nil {
+			for _, err := range a.validateValue(coerceParam(value, p.schema), p.schema, p.name) {
+				errs = append(errs, fmt.Sprintf("%s parameter %s", p.in, err))
+			}
+		}
+	}
+
+	if op.body != nil {
+		errs = append(errs, a.validateBody(r, op.body)...)
+	}
+	return errs
+}
+
+func (a *API) validateBody(r *http.Request, body *requestBody) []string {
+	data, err := io.ReadAll(io.LimitReader(r.Body, a.maxBodySize+1))
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(data))
+
+	if err != nil {
+		return []string{"failed to read request body"}
+	}
+	if int64(len(data)) > a.maxBodySize {
+		return []string{"request body is too large to validate"}
+	}
+	if len(data) == 0 {
+		if body.required {
+			return []string{"request body is required"}
+		}
+		return nil
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0]))
+	schema, ok := findMediaType(body.content, mediaType)
+	if !ok {
+		return []string{fmt.Sprintf("content type %q is not supported", mediaType)}
+	}
+	if schema == nil || !isJSON(mediaType) {
+		return nil
+	}
+
+	v, err := decodeJSON(data)
+	if err != nil {
+		return []string{"request body is not valid JSON: " + err.Error()}
+	}
+
+	var errs []string
+	for _, err := range a.validateValue(v, schema, "body") {
+		errs = append(errs, "request "+err)
+	}
+	return errs
+}
+
+func findMediaType(content map[string]map[string]interface{}, mediaType string) (map[string]interface{}, bool) {
+	if schema, ok := content[mediaType]; ok {
+		return schema, true
+	}
+	if major, _, ok := strings.Cut(mediaType, "/"); ok {
+		if schema, ok := content[major+"/*"]; ok {
+			return schema, true
+		}
+	}
+	schema, ok := content["*/*"]
+	return schema, ok
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// resolve follows local references in the document.
+func (a *API) resolve(v interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok {
+			return v
+		}
+		v = a.lookup(ref)
+	}
+	return nil
+}
+
+func (a *API) lookup(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+
+	var curr interface{} = a.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := curr.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		curr = m[part]
+	}
+	return curr
+}
+
+// normalize converts the generic maps produced by the YAML decoder into maps
+// with string keys.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = normalize(val)
+		}
+		return m
+	case []interface{}:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func templateName(segment string) (string, bool) {
+	if len(segment) > 2 && segment[0] == '{' && segment[len(segment)-1] == '}' {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+func literalCount(segments []string) int {
+	n := 0
+	for _, s := range segments {
+		if _, ok := templateName(s); !ok {
+			n++
+		}
+	}
+	return n
+}
+
+func containsParam(params []parameter, p parameter) bool {
+	for _, existing := range params {
+		if existing.name == p.name && existing.in == p.in {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeTag replaces runs of characters that are not safe in metric tags
+// with a single underscore and trims leading and trailing underscores.
+func sanitizeTag(s string) string {
+	var b strings.Builder
+	underscore := false
+	for _, c := range s {
+		if unicode.IsLetter(c) || unicode.IsDigit(c) || c == '-' || c == '.' {
+			b.WriteRune(c)
+			underscore = false
+			continue
+		}
+		if !underscore {
+			b.WriteRune('_')
+			underscore = true
+		}
+	}
+	if tag := strings.Trim(b.String(), "_"); tag != "" {
+		return tag
+	}
+	return "unknown"
+}
@@ -0,0 +1,241 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDocument = `
+openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users/me:
+    get:
+      operationId: getCurrentUser
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        schema:
+          type: integer
+          minimum: 1
+    get:
+      operationId: getUser
+      parameters:
+        - name: fields
+          in: query
+          schema:
+            type: string
+            enum: [name, email]
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+components:
+  schemas:
+    User:
+      type: object
+      additionalProperties: false
+      required: [name]
+      properties:
+        name:
+          type: string
+          minLength: 1
+        age:
+          type: integer
+          minimum: 0
+        tags:
+          type: array
+          items:
+            type: string
+`
+
+func TestMiddleware(t *testing.T) {
+	api, err := New([]byte(testDocument))
+	require.NoError(t, err, "failed to parse document")
+
+	var operationID string
+	h := api.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operationID = OperationID(r.Context())
+	}))
+
+	tests := map[string]struct {
+		Method      string
+		Path        string
+		Body        string
+		Status      int
+		OperationID string
+		Errors      []string
+	}{
+		"literalPath": {
+			Method:      "GET",
+			Path:        "/users/me",
+			Status:      http.StatusOK,
+			OperationID: "getCurrentUser",
+		},
+		"templatePath": {
+			Method:      "GET",
+			Path:        "/users/12?fields=email",
+			Status:      http.StatusOK,
+			OperationID: "getUser",
+		},
+		"unknownPath": {
+			Method: "GET",
+			Path:   "/groups",
+			Status: http.StatusOK,
+		},
+		"invalidParameters": {
+			Method: "GET",
+			Path:   "/users/0?fields=address",
+			Status: http.StatusBadRequest,
+			Errors: []string{
+				`path parameter id: must be at least 1`,
+				`query parameter fields: must be one of [name email]`,
+			},
+		},
+		"validBody": {
+			Method:      "POST",
+			Path:        "/users",
+			Body:        `{"name": "alice", "age": 30, "tags": ["admin"]}`,
+			Status:      http.StatusOK,
+			OperationID: "createUser",
+		},
+		"invalidBody": {
+			Method: "POST",
+			Path:   "/users",
+			Body:   `{"age": 1.5, "tags": [1], "extra": true}`,
+			Status: http.StatusBadRequest,
+			Errors: []string{
+				`request body: property "name" is required`,
+				`request body.age: must be an integer`,
+				`request body: property "extra" is not allowed`,
+				`request body.tags[0]: must be a string`,
+			},
+		},
+		"missingBody": {
+			Method: "POST",
+			Path:   "/users",
+			Status: http.StatusBadRequest,
+			Errors: []string{
+				`request body is required`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			operationID = ""
+
+			r := httptest.NewRequest(test.Method, test.Path, strings.NewReader(test.Body))
+			r.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			require.Equal(t, test.Status, w.Code, "incorrect response status: %s", w.Body.String())
+			assert.Equal(t, test.OperationID, operationID, "incorrect operation ID")
+
+			if len(test.Errors) > 0 {
+				var problem struct {
+					Errors []string `json:"errors"`
+				}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+				assert.ElementsMatch(t, test.Errors, problem.Errors, "incorrect validation errors")
+			}
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	api, err := New([]byte(testDocument), WithBasePath("/api/"))
+	require.NoError(t, err, "failed to parse document")
+
+	var operationID string
+	h := api.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operationID = OperationID(r.Context())
+	}))
+
+	tests := map[string]string{
+		"/api/users/me":   "getCurrentUser",
+		"/apiv2/users/me": "",
+		"/users/me":       "",
+	}
+
+	for path, expected := range tests {
+		operationID = ""
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, expected, operationID, "incorrect operation ID for %s", path)
+	}
+}
+
+func TestOperationMetrics(t *testing.T) {
+	const doc = `
+openapi: 3.0.3
+info:
+  title: Test API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get: {}
+`
+
+	api, err := New([]byte(doc))
+	require.NoError(t, err, "failed to parse document")
+
+	var operationID string
+	h := api.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		operationID = OperationID(r.Context())
+	}))
+
+	registry := metrics.NewRegistry()
+	r := httptest.NewRequest(http.MethodGet, "/users/12", nil)
+	r = r.WithContext(baseapp.WithMetricsCtx(r.Context(), registry))
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "GET /users/{id}", operationID)
+
+	counter, ok := registry.Get("openapi.requests[operation:GET_users_id]").(metrics.Counter)
+	require.True(t, ok, "request counter should use the sanitized operation tag")
+	assert.Equal(t, int64(1), counter.Count())
+}
+
+func TestUIHandler(t *testing.T) {
+	api, err := New([]byte(testDocument), WithUIAssetURL("/static/swagger-ui/"))
+	require.NoError(t, err, "failed to parse document")
+
+	w := httptest.NewRecorder()
+	api.UIHandler("/api/openapi.yaml").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+
+	body := w.Body.String()
+	assert.Contains(t, body, `href="/static/swagger-ui/swagger-ui.css"`)
+	assert.Contains(t, body, `src="/static/swagger-ui/swagger-ui-bundle.js"`)
+	assert.NotContains(t, body, "unpkg.com")
+}
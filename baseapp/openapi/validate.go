@@ -0,0 +1,313 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+var patterns sync.Map
+
+func decodeJSON(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	if d.More() {
+		return nil, fmt.Errorf("unexpected data after top-level value")
+	}
+	return v, nil
+}
+
+// coerceParam converts a parameter string to the type expected by the
+// schema. Values that cannot be converted are returned unchanged so that
+// validation reports the type mismatch.
+func coerceParam(value string, schema map[string]interface{}) interface{} {
+	switch schema["type"] {
+	case "integer", "number":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// validateValue validates v against schema and returns a description of each
+// violation. The path identifies v in the returned messages.
+func (a *API) validateValue(v interface{}, schema map[string]interface{}, path string) []string {
+	schema, _ = a.resolve(schema).(map[string]interface{})
+	if schema == nil {
+		return nil
+	}
+
+	if v == nil {
+		if nullable, _ := schema["nullable"].(bool); nullable || schema["type"] == nil {
+			return nil
+		}
+		return []string{fmt.Sprintf("%s: must not be null", path)}
+	}
+
+	var errs []string
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, s := range all {
+			sub, _ := s.(map[string]interface{})
+			errs = append(errs, a.validateValue(v, sub, path)...)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		if a.countMatches(v, anyOf, path) == 0 {
+			errs = append(errs, fmt.Sprintf("%s: must match at least one schema in anyOf", path))
+		}
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if a.countMatches(v, oneOf, path) != 1 {
+			errs = append(errs, fmt.Sprintf("%s: must match exactly one schema in oneOf", path))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(v, enum) {
+		errs = append(errs, fmt.Sprintf("%s: must be one of %v", path, enum))
+	}
+
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "":
+		// untyped schemas only apply combinators and enums
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: must be an object", path))
+		}
+		errs = append(errs, a.validateObject(obj, schema, path)...)
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: must be an array", path))
+		}
+		errs = append(errs, a.validateArray(arr, schema, path)...)
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: must be a string", path))
+		}
+		errs = append(errs, validateString(s, schema, path)...)
+	case "integer", "number":
+		n, ok := v.(json.Number)
+		if !ok {
+			return append(errs, fmt.Sprintf("%s: must be a %s", path, typ))
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return append(errs, fmt.Sprintf("%s: must be a %s", path, typ))
+		}
+		if typ == "integer" && f != math.Trunc(f) {
+			return append(errs, fmt.Sprintf("%s: must be an integer", path))
+		}
+		errs = append(errs, validateNumber(f, schema, path)...)
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return append(errs, fmt.Sprintf("%s: must be a boolean", path))
+		}
+	}
+
+	return errs
+}
+
+func (a *API) countMatches(v interface{}, schemas []interface{}, path string) int {
+	n := 0
+	for _, s := range schemas {
+		sub, _ := s.(map[string]interface{})
+		if len(a.validateValue(v, sub, path)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (a *API) validateObject(obj map[string]interface{}, schema map[string]interface{}, path string) []string {
+	var errs []string
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: property %q is required", path, name))
+			}
+		}
+	}
+
+	props, _ := schema["properties"].(map[string]interface{})
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if prop, ok := props[k]; ok {
+			sub, _ := prop.(map[string]interface{})
+			errs = append(errs, a.validateValue(obj[k], sub, path+"."+k)...)
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				errs = append(errs, fmt.Sprintf("%s: property %q is not allowed", path, k))
+			}
+		case map[string]interface{}:
+			errs = append(errs, a.validateValue(obj[k], additional, path+"."+k)...)
+		}
+	}
+
+	if n, ok := toFloat(schema["minProperties"]); ok && float64(len(obj)) < n {
+		errs = append(errs, fmt.Sprintf("%s: must have at least %v properties", path, n))
+	}
+	if n, ok := toFloat(schema["maxProperties"]); ok && float64(len(obj)) > n {
+		errs = append(errs, fmt.Sprintf("%s: must have at most %v properties", path, n))
+	}
+	return errs
+}
+
+func (a *API) validateArray(arr []interface{}, schema map[string]interface{}, path string) []string {
+	var errs []string
+
+	if n, ok := toFloat(schema["minItems"]); ok && float64(len(arr)) < n {
+		errs = append(errs, fmt.Sprintf("%s: must have at least %v items", path, n))
+	}
+	if n, ok := toFloat(schema["maxItems"]); ok && float64(len(arr)) > n {
+		errs = append(errs, fmt.Sprintf("%s: must have at most %v items", path, n))
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range arr {
+			errs = append(errs, a.validateValue(item, items, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return errs
+}
+
+func validateString(s string, schema map[string]interface{}, path string) []string {
+	var errs []string
+
+	length := float64(utf8.RuneCountInString(s))
+	if n, ok := toFloat(schema["minLength"]); ok && length < n {
+		errs = append(errs, fmt.Sprintf("%s: must be at least %v characters", path, n))
+	}
+	if n, ok := toFloat(schema["maxLength"]); ok && length > n {
+		errs = append(errs, fmt.Sprintf("%s: must be at most %v characters", path, n))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := compilePattern(pattern)
+		if err == nil && !re.MatchString(s) {
+			errs = append(errs, fmt.Sprintf("%s: must match pattern %q", path, pattern))
+		}
+	}
+	return errs
+}
+
+func validateNumber(f float64, schema map[string]interface{}, path string) []string {
+	var errs []string
+
+	if n, ok := toFloat(schema["minimum"]); ok {
+		if exclusive, _ := schema["exclusiveMinimum"].(bool); exclusive && f <= n {
+			errs = append(errs, fmt.Sprintf("%s: must be greater than %v", path, n))
+		} else if f < n {
+			errs = append(errs, fmt.Sprintf("%s: must be at least %v", path, n))
+		}
+	}
+	if n, ok := toFloat(schema["maximum"]); ok {
+		if exclusive, _ := schema["exclusiveMaximum"].(bool); exclusive && f >= n {
+			errs = append(errs, fmt.Sprintf("%s: must be less than %v", path, n))
+		} else if f > n {
+			errs = append(errs, fmt.Sprintf("%s: must be at most %v", path, n))
+		}
+	}
+	if n, ok := toFloat(schema["multipleOf"]); ok && n > 0 {
+		if q := f / n; q != math.Trunc(q) {
+			errs = append(errs, fmt.Sprintf("%s: must be a multiple of %v", path, n))
+		}
+	}
+	return errs
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+func inEnum(v interface{}, enum []interface{}) bool {
+	for _, e := range enum {
+		if equalValues(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compares a decoded request value to a value from the document,
+// accounting for the different numeric representations.
+func equalValues(v, e interface{}) bool {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		if err != nil {
+			return false
+		}
+		ef, ok := toFloat(e)
+		return ok && f == ef
+	}
+	return reflect.DeepEqual(v, e)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
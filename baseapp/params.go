@@ -15,6 +15,9 @@
 package baseapp
 
 import (
+	"context"
+	"crypto/tls"
+	"net"
 	"net/http"
 	"time"
 
@@ -32,22 +35,12 @@ const (
 // enables logging and configures logging, adds metrics, and adds default
 // middleware. All component parameters are exported and can be selected
 // individually if desired.
+//
+// DefaultParams is equivalent to the parameters of a ServerBuilder with the
+// logger and the metrics prefix. Use a ServerBuilder to change other
+// defaults.
 func DefaultParams(logger zerolog.Logger, metricsPrefix string) []Param {
-	var registry metrics.Registry
-	if metricsPrefix == "" {
-		registry = metrics.NewRegistry()
-	} else {
-		registry = metrics.NewPrefixedRegistry(metricsPrefix)
-	}
-
-	return []Param{
-		WithLogger(logger),
-		WithRegistry(registry),
-		WithMiddleware(DefaultMiddleware(logger, registry)...),
-		WithUTCNanoTime(),
-		WithErrorLogging(RichErrorMarshalFunc),
-		WithMetrics(),
-	}
+	return NewServerBuilder(HTTPConfig{}).Logger(logger).MetricsPrefix(metricsPrefix).Params()
 }
 
 // WithLogger sets a root logger used by the server.
@@ -94,10 +87,44 @@ func WithRegistry(registry metrics.Registry) Param {
 	}
 }
 
+// WithMetricsPrefix adds a prefix to the names of all metrics in the server's
+// registry. After applying all parameters, NewServer replaces the registry
+// with a child registry that adds the prefix, so the order of WithRegistry
+// and WithMetricsPrefix does not matter. The default middleware also uses
+// the child registry, but middleware set with WithMiddleware uses the
+// registry it was created with. The child registry is an
+// appmetrics.TrackedRegistry, so remove its metrics with Server.Registry.
+func WithMetricsPrefix(prefix string) Param {
+	return func(s *Server) error {
+		s.metricsPrefix = prefix
+		return nil
+	}
+}
+
 // WithMetrics enables server and runtime metrics collection.
 func WithMetrics() Param {
 	return func(s *Server) error {
-		s.initFns = append(s.initFns, func(s *Server) { RegisterDefaultMetrics(s.Registry()) })
+		s.initFns = append(s.initFns, func(s *Server) {
+			RegisterDefaultMetrics(s.Registry())
+		})
+		return nil
+	}
+}
+
+// WithLogWriteErrorMetrics counts failures to write log entries in the
+// "baseapp.log.write_errors" counter.
+//
+// Zerolog reports write failures to the global zerolog.ErrorHandler, so the
+// first server to start with this option replaces the handler with one that
+// counts failures and then calls the previous handler, if any. The handler is
+// only replaced once per process; if a process runs multiple servers with
+// this option, failures are counted in the registry of the last server to
+// start.
+func WithLogWriteErrorMetrics() Param {
+	return func(s *Server) error {
+		s.initFns = append(s.initFns, func(s *Server) {
+			countLogWriteErrors(s.Registry())
+		})
 		return nil
 	}
 }
@@ -108,3 +135,92 @@ func WithHTTPServer(server *http.Server) Param {
 		return nil
 	}
 }
+
+// WithTLSCertificate adds a certificate that the server uses to serve TLS
+// connections. Use this with certificates loaded from a secret manager or
+// generated at runtime, like those from SelfSignedCertificate, instead of
+// setting certificate files in the TLSConfig section of the configuration.
+// NewServer returns an error if both are set.
+//
+// The certificate is added to the TLS configuration of the HTTP server, so it
+// also applies to servers set with WithHTTPServer.
+func WithTLSCertificate(cert tls.Certificate) Param {
+	return func(s *Server) error {
+		s.tlsCertificates = append(s.tlsCertificates, cert)
+		return nil
+	}
+}
+
+// WithGetCertificate sets a function that returns the certificate for each
+// TLS connection, like tls.Config.GetCertificate. Use this to rotate
+// certificates without restarting the server. See WithTLSCertificate for
+// details.
+func WithGetCertificate(fn func(*tls.ClientHelloInfo) (*tls.Certificate, error)) Param {
+	return func(s *Server) error {
+		s.getCertificate = fn
+		return nil
+	}
+}
+
+// WithListenFunc sets a function that creates the listeners for the server's
+// addresses instead of net.Listen. Use this to serve on listeners created in
+// other ways, like sockets inherited from a parent process.
+func WithListenFunc(listen func(network, addr string) (net.Listener, error)) Param {
+	return func(s *Server) error {
+		s.listen = listen
+		return nil
+	}
+}
+
+// WithDependencyChecks sets checks that run when the server starts. If any
+// required check fails, Start returns an error without accepting connections.
+// See CheckDependencies for details.
+func WithDependencyChecks(checks ...DependencyCheck) Param {
+	return func(s *Server) error {
+		s.dependencyChecks = append(s.dependencyChecks, checks...)
+		return nil
+	}
+}
+
+// WithAuthorizer sets the Authorizer that checks the permissions of routes
+// registered with Server.Handle.
+func WithAuthorizer(a Authorizer) Param {
+	return func(s *Server) error {
+		s.authorizer = a
+		return nil
+	}
+}
+
+// EmitterFunc publishes the metrics in the server's registry, usually by
+// sending them to a monitoring system on an interval until the context is
+// canceled. It returns an error if it cannot start.
+type EmitterFunc func(ctx context.Context, s *Server) error
+
+// WithUnstartedEmitters adds emitters that start when the server starts,
+// after the server's registry is final. Each emitter runs in a new goroutine
+// and errors are logged with the server's logger. The context of the
+// emitters is canceled when the server begins a graceful shutdown, which
+// requires a ShutdownWaitTime.
+func WithUnstartedEmitters(emitters ...EmitterFunc) Param {
+	return func(s *Server) error {
+		if len(emitters) == 0 {
+			return nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s.OnStart(func(s *Server) {
+			for _, emit := range emitters {
+				go func() {
+					if err := emit(ctx, s); err != nil {
+						s.logger.Error().Err(err).Msg("Failed to start metrics emitter")
+					}
+				}()
+			}
+		})
+		s.OnShutdown(func(context.Context) error {
+			cancel()
+			return nil
+		})
+		return nil
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"goji.io/pat"
+)
+
+// MiddlewarePreset is a named set of server settings, selected with the
+// "preset" key of HTTPConfig or with WithMiddlewarePreset. Presets let local
+// development and production servers differ by one configuration value
+// instead of by code in main.
+type MiddlewarePreset string
+
+const (
+	// MiddlewarePresetProduction uses the default middleware and sets
+	// ReadHeaderTimeout and IdleTimeout on the HTTP server, so that slow or
+	// idle clients cannot hold connections open.
+	MiddlewarePresetProduction MiddlewarePreset = "production"
+
+	// MiddlewarePresetDevelopment logs human-readable lines to stdout, leaves
+	// the HTTP server without timeouts so requests can be paused in a
+	// debugger, and records recent requests in a Journal served as JSON at
+	// PresetDebugRequestsPath.
+	MiddlewarePresetDevelopment MiddlewarePreset = "development"
+)
+
+const (
+	// PresetDebugRequestsPath is the path of the recent requests recorded by
+	// the development preset.
+	PresetDebugRequestsPath = "/debug/requests"
+
+	presetJournalSize       = 100
+	presetReadHeaderTimeout = 10 * time.Second
+	presetIdleTimeout       = 2 * time.Minute
+)
+
+// ConfigSchema implements SchemaProvider.
+func (MiddlewarePreset) ConfigSchema() Schema {
+	return Schema{"enum": []string{"", string(MiddlewarePresetProduction), string(MiddlewarePresetDevelopment)}}
+}
+
+func (p MiddlewarePreset) validate() error {
+	switch p {
+	case "", MiddlewarePresetProduction, MiddlewarePresetDevelopment:
+		return nil
+	}
+	return errors.Errorf("unknown middleware preset %q", p)
+}
+
+// WithMiddlewarePreset sets the preset of the server, replacing the preset
+// from the configuration. See MiddlewarePreset.
+func WithMiddlewarePreset(p MiddlewarePreset) Param {
+	return func(b *Server) error {
+		b.preset = p
+		return nil
+	}
+}
+
+// applyPresetLogger changes the root logger for the preset. It is called
+// before the default middleware is created so that request logs use it.
+func (s *Server) applyPresetLogger() {
+	if s.preset == MiddlewarePresetDevelopment {
+		s.logger = s.logger.Output(zerolog.ConsoleWriter{Out: os.Stdout})
+	}
+}
+
+// applyPresetServer sets the timeouts of an HTTP server created by NewServer.
+// Servers set with WithHTTPServer are not changed.
+func (s *Server) applyPresetServer(hs *http.Server) {
+	if s.preset == MiddlewarePresetProduction {
+		hs.ReadHeaderTimeout = presetReadHeaderTimeout
+		hs.IdleTimeout = presetIdleTimeout
+	}
+}
+
+// applyPresetRoutes adds the handlers and routes of the preset after the
+// server's handler is set.
+func (s *Server) applyPresetRoutes() {
+	if s.preset == MiddlewarePresetDevelopment {
+		j := StartJournal(s, presetJournalSize)
+		s.mux.Handle(pat.Get(PresetDebugRequestsPath), j.DumpHandler())
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goji.io/pat"
+)
+
+func TestMiddlewarePreset(t *testing.T) {
+	s, err := NewServer(HTTPConfig{Preset: MiddlewarePresetProduction}, WithRegistry(metrics.NewRegistry()))
+	require.NoError(t, err)
+	assert.Equal(t, presetReadHeaderTimeout, s.HTTPServer().ReadHeaderTimeout)
+	assert.Equal(t, presetIdleTimeout, s.HTTPServer().IdleTimeout)
+
+	s, err = NewServerBuilder(HTTPConfig{Preset: MiddlewarePresetProduction}).
+		Preset(MiddlewarePresetDevelopment).
+		Build()
+	require.NoError(t, err)
+	assert.Zero(t, s.HTTPServer().ReadHeaderTimeout, "development servers should not have timeouts")
+
+	s.Mux().HandleFunc(pat.Get("/"), func(w http.ResponseWriter, r *http.Request) {})
+	s.HTTPServer().Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	w := httptest.NewRecorder()
+	s.HTTPServer().Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PresetDebugRequestsPath, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var entries []JournalEntry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	require.Len(t, entries, 1, "development servers should record requests")
+	assert.Equal(t, "/", entries[0].Path)
+
+	s, err = NewServer(HTTPConfig{}, WithRegistry(metrics.NewRegistry()))
+	require.NoError(t, err)
+	assert.Zero(t, s.HTTPServer().IdleTimeout, "servers without a preset should not change")
+
+	_, err = NewServer(HTTPConfig{Preset: "staging"})
+	assert.EqualError(t, err, `unknown middleware preset "staging"`)
+}
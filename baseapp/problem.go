@@ -0,0 +1,106 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+)
+
+// Problem is an error response in the "problem details" format defined by RFC
+// 7807. Extensions are serialized as additional top-level members of the
+// document.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	Extensions map[string]interface{} `json:"-"`
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+
+	b, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+
+	// Decode the standard fields and merge in the extensions, with the
+	// standard fields taking precedence
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// WriteProblem writes a problem details response. If the problem does not set
+// a status, it uses 500. If the problem does not set a title, it uses the
+// standard text for the status code. If r is not nil and has a request ID,
+// the ID is included as the "request_id" extension.
+//
+// If r has a message catalog from NewLocaleHandler, WriteProblem translates
+// the title and the detail to the language of the request, using the
+// untranslated text as the message key, and sets the Content-Language header.
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+
+	if r != nil {
+		var title, detail bool
+		p.Title, title = localize(r.Context(), p.Title)
+		p.Detail, detail = localize(r.Context(), p.Detail)
+		if title || detail {
+			w.Header().Set("Content-Language", LocaleFromCtx(r.Context()).Language)
+		}
+
+		if rid, ok := hlog.IDFromRequest(r); ok {
+			ext := make(map[string]interface{}, len(p.Extensions)+1)
+			for k, v := range p.Extensions {
+				ext[k] = v
+			}
+			ext["request_id"] = rid.String()
+			p.Extensions = ext
+		}
+	}
+
+	b, err := json.Marshal(p)
+	if err != nil {
+		// Extensions may contain values that can't be encoded; fall back to
+		// the standard fields in this case
+		p.Extensions = nil
+		b, _ = json.Marshal(p)
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,333 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit limits the request rate of identified clients with token
+// buckets and reports the quota of each client.
+//
+// Each client has a bucket that holds up to Burst tokens and refills at Rate
+// tokens per second. Every request takes a token; requests from clients with
+// an empty bucket receive a 429 response. Clients are identified by a
+// ClientFunc, by default the subject of the baseapp.Identity stored by
+// authentication middleware, so the limiter must run after authentication:
+//
+//	limiter, err := ratelimit.New(server.Registry(), config.RateLimit)
+//	if err != nil {
+//		return err
+//	}
+//	server.Mux().Use(limiter.Handler())
+//	server.Mux().Handle(pat.Get("/api/quota"), limiter.QuotaHandler())
+//
+// To limit the clients of API keys, use the client of the key record:
+//
+//	ratelimit.WithClientFunc(func(r *http.Request) (string, bool) {
+//		record, ok := apikey.FromContext(r.Context())
+//		return record.Client, ok
+//	})
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	MetricsKeyAllowed   = "server.ratelimit.allowed"
+	MetricsKeyRejected  = "server.ratelimit.rejected"
+	MetricsKeyRemaining = "server.ratelimit.remaining"
+
+	// HeaderLimit and HeaderRemaining are the response headers that contain
+	// the burst size of the client and the tokens left after the request.
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+)
+
+// sweepInterval is the minimum time between removals of idle buckets.
+const sweepInterval = time.Minute
+
+// limiterMetrics are the per-client metrics of a Limiter. The tag limits keep
+// the number of series bounded if clients are not a small, known set.
+type limiterMetrics struct {
+	Allowed   appmetrics.Tagged[metrics.Counter]      `metric:"server.ratelimit.allowed" metric-max-tags:"500" metric-tag-ttl:"1h"`
+	Rejected  appmetrics.Tagged[metrics.Counter]      `metric:"server.ratelimit.rejected" metric-max-tags:"500" metric-tag-ttl:"1h"`
+	Remaining appmetrics.Tagged[metrics.GaugeFloat64] `metric:"server.ratelimit.remaining" metric-max-tags:"500,lru" metric-tag-ttl:"1h"`
+}
+
+// Limit is the rate and burst size of a token bucket.
+type Limit struct {
+	// Rate is the number of tokens added to the bucket per second.
+	Rate float64 `yaml:"rate" json:"rate"`
+
+	// Burst is the maximum number of tokens in the bucket, which is the
+	// number of requests a client with a full bucket may send at once.
+	Burst int `yaml:"burst" json:"burst"`
+}
+
+func (l Limit) validate() error {
+	if l.Rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+	if l.Burst < 1 {
+		return errors.New("burst must be at least 1")
+	}
+	return nil
+}
+
+// Config contains the limits of clients. It is usually embedded in a larger
+// configuration struct.
+type Config struct {
+	// Default is the limit of clients that are not in Clients.
+	Default Limit `yaml:"default" json:"default"`
+
+	// Clients are the limits of specific clients.
+	Clients map[string]Limit `yaml:"clients" json:"clients"`
+}
+
+func (c Config) limit(client string) Limit {
+	if l, ok := c.Clients[client]; ok {
+		return l
+	}
+	return c.Default
+}
+
+// ClientFunc returns the client that sent a request. It returns false if the
+// client is not known.
+type ClientFunc func(r *http.Request) (string, bool)
+
+// IdentityClient returns the subject of the baseapp.Identity of the request.
+func IdentityClient(r *http.Request) (string, bool) {
+	id, ok := baseapp.IdentityFromContext(r.Context())
+	return id.Subject, ok && id.Subject != ""
+}
+
+// Option configures a Limiter.
+type Option func(*Limiter)
+
+// WithClientFunc sets the function that identifies the client of requests.
+// The default is IdentityClient.
+func WithClientFunc(fn ClientFunc) Option {
+	return func(l *Limiter) {
+		l.client = fn
+	}
+}
+
+// Quota is the current state of the bucket of a client.
+type Quota struct {
+	Client string  `json:"client"`
+	Rate   float64 `json:"rate"`
+	Burst  int     `json:"burst"`
+
+	// Remaining is the number of whole tokens in the bucket.
+	Remaining int `json:"remaining"`
+
+	// ResetSeconds is the time until the bucket is full.
+	ResetSeconds float64 `json:"resetSeconds"`
+}
+
+// Limiter limits the request rate of clients.
+type Limiter struct {
+	config  Config
+	client  ClientFunc
+	metrics *limiterMetrics
+	now     func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New creates a Limiter with the limits in the configuration and registers
+// its metrics in the registry:
+//
+//   - "server.ratelimit.allowed" and "server.ratelimit.rejected" count the
+//     requests of each client
+//   - "server.ratelimit.remaining" is the number of tokens of each client
+//     after its most recent request
+//
+// All metrics are tagged with the client. To bound the number of series,
+// each metric reports at most 500 clients and stops reporting clients that
+// are idle for an hour; see the "metric-max-tags" tag of appmetrics.Tagged.
+func New(registry metrics.Registry, c Config, opts ...Option) (*Limiter, error) {
+	if err := c.Default.validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid default rate limit")
+	}
+	for client, limit := range c.Clients {
+		if err := limit.validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid rate limit for client %q", client)
+		}
+	}
+
+	m := appmetrics.New[limiterMetrics]()
+	appmetrics.Register(registry, m)
+
+	l := &Limiter{
+		config:  c,
+		client:  IdentityClient,
+		metrics: m,
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.lastSweep = l.now()
+	return l, nil
+}
+
+// Allow takes a token from the bucket of the client and returns the quota
+// after the request. It returns false if the bucket was empty.
+func (l *Limiter) Allow(client string) (Quota, bool) {
+	return l.take(client, 1)
+}
+
+// Quota returns the quota of the client without taking a token.
+func (l *Limiter) Quota(client string) Quota {
+	q, _ := l.take(client, 0)
+	return q
+}
+
+func (l *Limiter) take(client string, n float64) (Quota, bool) {
+	limit := l.config.limit(client)
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[client] = b
+	}
+	b.refill(limit, now)
+
+	allowed := b.tokens >= n
+	if allowed {
+		b.tokens -= n
+	}
+
+	return Quota{
+		Client:       client,
+		Rate:         limit.Rate,
+		Burst:        limit.Burst,
+		Remaining:    int(b.tokens),
+		ResetSeconds: (float64(limit.Burst) - b.tokens) / limit.Rate,
+	}, allowed
+}
+
+// sweep removes the buckets that are full, since a new bucket is the same as
+// a full bucket. This keeps buckets only for recently active clients.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for client, b := range l.buckets {
+		limit := l.config.limit(client)
+		if b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+	}
+	b.last = now
+}
+
+// Handler returns middleware that limits the requests of identified clients.
+// Requests without a client are not limited.
+//
+// Responses have the RateLimit-Limit and RateLimit-Remaining headers. Clients
+// with an empty bucket receive a 429 problem response with a Retry-After
+// header; these requests are logged and added to the request with
+// baseapp.AddRequestEvent as a "rate_limited" event.
+func (l *Limiter) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client, ok := l.client(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			q, allowed := l.Allow(client)
+			l.metrics.Remaining.Tag("client:" + client).Update(float64(q.Remaining))
+
+			w.Header().Set(HeaderLimit, strconv.Itoa(q.Burst))
+			w.Header().Set(HeaderRemaining, strconv.Itoa(q.Remaining))
+
+			if !allowed {
+				l.metrics.Rejected.Tag("client:" + client).Inc(1)
+				l.reject(w, r, q)
+				return
+			}
+
+			l.metrics.Allowed.Tag("client:" + client).Inc(1)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (l *Limiter) reject(w http.ResponseWriter, r *http.Request, q Quota) {
+	// The bucket has less than one token, so it has a token again after at
+	// most 1/Rate seconds
+	retry := max(int(math.Ceil(1/q.Rate)), 1)
+
+	baseapp.AddRequestEvent(r.Context(), "rate_limited", "client", q.Client)
+	hlog.FromRequest(r).Info().
+		Str("client", q.Client).
+		Int("retry_after", retry).
+		Msg("Rate limited request")
+
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	baseapp.WriteProblem(w, r, baseapp.Problem{
+		Status: http.StatusTooManyRequests,
+		Detail: "The client has exceeded its rate limit",
+	})
+}
+
+// QuotaHandler returns a handler that responds with the Quota of the client
+// that sent the request as JSON, so that clients can check their own limits.
+// Requests without a client receive a 401 problem response.
+func (l *Limiter) QuotaHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client, ok := l.client(r)
+		if !ok {
+			baseapp.WriteProblem(w, r, baseapp.Problem{
+				Status: http.StatusUnauthorized,
+				Detail: "The request does not identify a client",
+			})
+			return
+		}
+		baseapp.WriteJSON(w, http.StatusOK, l.Quota(client))
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter(t *testing.T) {
+	registry := metrics.NewRegistry()
+	l, err := New(registry, Config{
+		Default: Limit{Rate: 1, Burst: 2},
+		Clients: map[string]Limit{"batch": {Rate: 10, Burst: 5}},
+	})
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	l.now = func() time.Time { return now }
+	l.lastSweep = now
+
+	handler := l.Handler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(client string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if client != "" {
+			r = r.WithContext(baseapp.WithIdentity(r.Context(), baseapp.Identity{Subject: client}))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	count := func(key, client string) int64 {
+		if c, ok := registry.Get(key + "[client:" + client + "]").(metrics.Counter); ok {
+			return c.Count()
+		}
+		return -1
+	}
+
+	t.Run("burst", func(t *testing.T) {
+		w := serve("alice")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get(HeaderLimit))
+		assert.Equal(t, "1", w.Header().Get(HeaderRemaining))
+
+		assert.Equal(t, http.StatusOK, serve("alice").Code)
+
+		w = serve("alice")
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "1", w.Header().Get("Retry-After"))
+		assert.Equal(t, "0", w.Header().Get(HeaderRemaining))
+
+		assert.Equal(t, int64(2), count(MetricsKeyAllowed, "alice"))
+		assert.Equal(t, int64(1), count(MetricsKeyRejected, "alice"))
+		if g, ok := registry.Get(MetricsKeyRemaining + "[client:alice]").(metrics.GaugeFloat64); assert.True(t, ok) {
+			assert.Equal(t, float64(0), g.Value())
+		}
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		now = now.Add(1500 * time.Millisecond)
+		assert.Equal(t, http.StatusOK, serve("alice").Code)
+		assert.Equal(t, http.StatusTooManyRequests, serve("alice").Code)
+	})
+
+	t.Run("clientLimit", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, serve("batch").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, serve("batch").Code)
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, serve("").Code)
+		}
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		now = now.Add(2 * sweepInterval)
+		l.Quota("carol")
+		assert.Len(t, l.buckets, 1, "full buckets should be removed")
+	})
+}
+
+func TestQuotaHandler(t *testing.T) {
+	l, err := New(metrics.NewRegistry(), Config{Default: Limit{Rate: 2, Burst: 10}})
+	require.NoError(t, err)
+
+	now := time.Unix(1700000000, 0)
+	l.now = func() time.Time { return now }
+	for i := 0; i < 4; i++ {
+		l.Allow("alice")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/quota", nil)
+	r = r.WithContext(baseapp.WithIdentity(r.Context(), baseapp.Identity{Subject: "alice"}))
+	w := httptest.NewRecorder()
+	l.QuotaHandler().ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var q Quota
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &q))
+	assert.Equal(t, Quota{Client: "alice", Rate: 2, Burst: 10, Remaining: 6, ResetSeconds: 2}, q)
+
+	w = httptest.NewRecorder()
+	l.QuotaHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/quota", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New(metrics.NewRegistry(), Config{})
+	assert.EqualError(t, err, "invalid default rate limit: rate must be positive")
+
+	_, err = New(metrics.NewRegistry(), Config{
+		Default: Limit{Rate: 1, Burst: 1},
+		Clients: map[string]Limit{"batch": {Rate: 1}},
+	})
+	assert.EqualError(t, err, `invalid rate limit for client "batch": burst must be at least 1`)
+}
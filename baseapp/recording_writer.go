@@ -43,6 +43,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"time"
 )
 
 // RecordingResponseWriter is a proxy for an http.ResponseWriter that
@@ -78,24 +79,51 @@ type basicRecorder struct {
 	http.ResponseWriter
 	code         int
 	bytesWritten int64
+	hijacked     bool
+
+	// firstByte is when the status was sent and writeTime is the total time
+	// spent in calls that write or flush the response
+	firstByte time.Time
+	writeTime time.Duration
 }
 
 func (b *basicRecorder) WriteHeader(code int) {
 	if b.code == 0 {
 		b.code = code
 	}
+	if b.firstByte.IsZero() {
+		b.firstByte = time.Now()
+	}
 	b.ResponseWriter.WriteHeader(code)
 }
 
 func (b *basicRecorder) Write(buf []byte) (int, error) {
+	start := b.startWrite()
 	if b.code == 0 {
 		b.code = http.StatusOK
 	}
 	n, err := b.ResponseWriter.Write(buf)
 	b.bytesWritten += int64(n)
+	b.writeTime += time.Since(start)
 	return n, err
 }
 
+// startWrite records the first byte time if necessary and returns the start
+// time of a write.
+func (b *basicRecorder) startWrite() time.Time {
+	now := time.Now()
+	if b.firstByte.IsZero() {
+		b.firstByte = now
+	}
+	return now
+}
+
+// writeTiming returns when the status was sent and the total time spent
+// writing the response.
+func (b *basicRecorder) writeTiming() (time.Time, time.Duration) {
+	return b.firstByte, b.writeTime
+}
+
 func (b *basicRecorder) Status() int {
 	return b.code
 }
@@ -104,6 +132,29 @@ func (b *basicRecorder) BytesWritten() int64 {
 	return b.bytesWritten
 }
 
+// wasHijacked returns true if the connection was hijacked through the
+// recorder.
+func (b *basicRecorder) wasHijacked() bool {
+	return b.hijacked
+}
+
+// Unwrap returns the underlying writer for use with http.ResponseController.
+func (b *basicRecorder) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+func (b *basicRecorder) SetReadDeadline(deadline time.Time) error {
+	return http.NewResponseController(b.ResponseWriter).SetReadDeadline(deadline)
+}
+
+func (b *basicRecorder) SetWriteDeadline(deadline time.Time) error {
+	return http.NewResponseController(b.ResponseWriter).SetWriteDeadline(deadline)
+}
+
+func (b *basicRecorder) EnableFullDuplex() error {
+	return http.NewResponseController(b.ResponseWriter).EnableFullDuplex()
+}
+
 // fancyRecorder is a writer that additionally satisfies http.CloseNotifier,
 // http.Flusher, http.Hijacker, and io.ReaderFrom. It exists for the common case
 // of wrapping the http.ResponseWriter that package http gives you, in order to
@@ -117,20 +168,28 @@ func (f *fancyRecorder) CloseNotify() <-chan bool {
 	return cn.CloseNotify()
 }
 func (f *fancyRecorder) Flush() {
+	start := time.Now()
 	fl := f.basicRecorder.ResponseWriter.(http.Flusher)
 	fl.Flush()
+	f.writeTime += time.Since(start)
 }
 func (f *fancyRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	hj := f.basicRecorder.ResponseWriter.(http.Hijacker)
-	return hj.Hijack()
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		f.hijacked = true
+	}
+	return conn, rw, err
 }
 func (f *fancyRecorder) ReadFrom(r io.Reader) (int64, error) {
+	start := f.startWrite()
 	if f.code == 0 {
 		f.code = http.StatusOK
 	}
 	rf := f.basicRecorder.ResponseWriter.(io.ReaderFrom)
 	n, err := rf.ReadFrom(r)
 	f.bytesWritten += n
+	f.writeTime += time.Since(start)
 	return n, err
 }
 
@@ -144,8 +203,10 @@ type flushRecorder struct {
 }
 
 func (f *flushRecorder) Flush() {
+	start := time.Now()
 	fl := f.basicRecorder.ResponseWriter.(http.Flusher)
 	fl.Flush()
+	f.writeTime += time.Since(start)
 }
 
 var _ http.Flusher = &flushRecorder{}
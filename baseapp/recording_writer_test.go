@@ -0,0 +1,59 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorderResponseController(t *testing.T) {
+	t.Run("supported", func(t *testing.T) {
+		var errs []error
+
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := WrapWriter(w)
+			assert.Same(t, w, rw.(interface{ Unwrap() http.ResponseWriter }).Unwrap())
+
+			rc := http.NewResponseController(rw)
+			deadline := time.Now().Add(time.Minute)
+			errs = append(errs,
+				rc.SetReadDeadline(deadline),
+				rc.SetWriteDeadline(deadline),
+				rc.EnableFullDuplex(),
+				rc.Flush(),
+			)
+		}))
+		defer srv.Close()
+
+		res, err := http.Get(srv.URL)
+		if assert.NoError(t, err) {
+			_ = res.Body.Close()
+		}
+		assert.Equal(t, []error{nil, nil, nil, nil}, errs)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		rw := WrapWriter(httptest.NewRecorder())
+
+		err := http.NewResponseController(rw).SetWriteDeadline(time.Now())
+		assert.True(t, errors.Is(err, http.ErrNotSupported), "expected ErrNotSupported, got %v", err)
+	})
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/bluekeyes/hatpear"
+	"github.com/rs/zerolog/hlog"
+)
+
+// maxPanicFrames is the maximum number of frames recorded for a panic.
+const maxPanicFrames = 64
+
+// PanicError is the error stored by the middleware from NewRecoverHandler
+// when a handler panics. It preserves the value passed to panic and the stack
+// of the panic, so that error handlers can inspect them with errors.As:
+//
+//	var perr baseapp.PanicError
+//	if errors.As(err, &perr) {
+//		report(perr.Value, perr.Stack)
+//	}
+//
+// If the value is an error, PanicError wraps it.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack contains the frames of the goroutine that panicked, starting
+	// with the function that called panic.
+	Stack []runtime.Frame
+}
+
+func (e PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func (e PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StackTrace returns the stack of the panic. RichErrorMarshalFunc uses it to
+// log the stack with the error.
+func (e PanicError) StackTrace() []runtime.Frame {
+	return e.Stack
+}
+
+// RecoverOption configures the middleware returned by NewRecoverHandler.
+type RecoverOption func(*recoverHandler)
+
+// WithRepanic re-raises panics after logging them instead of storing them as
+// errors for the error handler. Use it in tests, so that a panic fails the
+// test with the stack of the handler that panicked instead of producing a
+// 500 response.
+func WithRepanic(repanic bool) RecoverOption {
+	return func(h *recoverHandler) {
+		h.repanic = repanic
+	}
+}
+
+type recoverHandler struct {
+	repanic bool
+}
+
+// NewRecoverHandler returns middleware that recovers from panics in later
+// handlers and stores a PanicError with hatpear.Store, so that the error
+// handler installed by hatpear.Catch, usually HandleRouteError, reports it.
+//
+// Panics with http.ErrAbortHandler are not recovered, so that the server can
+// abort the response as intended.
+func NewRecoverHandler(opts ...RecoverOption) func(http.Handler) http.Handler {
+	h := &recoverHandler{}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				err := PanicError{Value: v, Stack: panicStack()}
+				if h.repanic {
+					CounterFromCtx(r.Context(), MetricsKeyPanics).Inc(1)
+					hlog.FromRequest(r).Error().Err(err).
+						Str("method", r.Method).
+						Str("path", r.URL.String()).
+						Msg("Panic while serving route")
+
+					// Deferred functions run on the stack of the panic, so the
+					// new panic includes the frames of the original panic
+					panic(v)
+				}
+				hatpear.Store(r, err)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// panicStack returns the frames of the goroutine that panicked when called
+// from a deferred function, skipping the runtime's panic frames.
+func panicStack() []runtime.Frame {
+	pcs := make([]uintptr, maxPanicFrames)
+	// skip runtime.Callers, panicStack, and the deferred function
+	n := runtime.Callers(3, pcs)
+
+	var stack []runtime.Frame
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if len(stack) > 0 || !strings.HasPrefix(frame.Function, "runtime.") {
+			stack = append(stack, frame)
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bluekeyes/hatpear"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errPanicTest = errors.New("boom")
+
+func panicTestHandler(w http.ResponseWriter, r *http.Request) {
+	panic(errPanicTest)
+}
+
+func TestRecoverHandler(t *testing.T) {
+	var handled error
+	catch := hatpear.Catch(func(w http.ResponseWriter, r *http.Request, err error) {
+		handled = err
+		HandleRouteError(w, r, err)
+	})
+
+	registry := metrics.NewRegistry()
+	handler := NewMetricsHandler(registry)(catch(NewRecoverHandler()(http.HandlerFunc(panicTestHandler))))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, int64(1), registry.Get(MetricsKeyPanics).(metrics.Counter).Count())
+
+	var perr PanicError
+	require.True(t, errors.As(handled, &perr), "handler should receive a PanicError")
+	assert.Equal(t, errPanicTest, perr.Value)
+	assert.ErrorIs(t, handled, errPanicTest, "errors should be wrapped")
+	require.NotEmpty(t, perr.Stack)
+	assert.True(t, strings.HasSuffix(perr.Stack[0].Function, ".panicTestHandler"), "stack should start at the panic: %s", perr.Stack[0].Function)
+	assert.Contains(t, RichErrorMarshalFunc(handled), "panicTestHandler", "logged errors should include the stack")
+}
+
+func TestRecoverHandlerRepanic(t *testing.T) {
+	registry := metrics.NewRegistry()
+	handler := NewMetricsHandler(registry)(NewRecoverHandler(WithRepanic(true))(http.HandlerFunc(panicTestHandler)))
+
+	assert.PanicsWithValue(t, errPanicTest, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+	assert.Equal(t, int64(1), registry.Get(MetricsKeyPanics).(metrics.Counter).Count())
+
+	handler = NewRecoverHandler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}, "aborted handlers should not be recovered")
+}
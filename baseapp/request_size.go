@@ -0,0 +1,46 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+)
+
+type requestBodyCtxKey struct{}
+
+// countingBody is a request body that counts the bytes read from it.
+type countingBody struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n.Add(int64(n))
+	return n, err
+}
+
+// RequestBytesRead returns the number of bytes of the request body read by
+// handlers. AccessHandler counts the bytes, so callbacks can use this to get
+// the size of the request. Bodies that handlers do not read are not counted,
+// and the count is zero if the context is not from AccessHandler.
+func RequestBytesRead(ctx context.Context) int64 {
+	if b, ok := ctx.Value(requestBodyCtxKey{}).(*countingBody); ok {
+		return b.n.Load()
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestSize(t *testing.T) {
+	registry := metrics.NewRegistry()
+	RegisterDefaultMetrics(registry)
+
+	var buf bytes.Buffer
+	handler := NewMetricsHandler(registry)(AccessHandler(RecordRequest)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append(body, body...))
+	})))
+
+	serve := func(body io.Reader) {
+		r := httptest.NewRequest(http.MethodPost, "/upload", body)
+		r = r.WithContext(zerolog.New(&buf).WithContext(r.Context()))
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	serve(strings.NewReader("hello"))
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, float64(5), entry["request_size"])
+	assert.Equal(t, float64(10), entry["size"])
+
+	serve(strings.NewReader("abc"))
+	serve(http.NoBody)
+
+	requestSize := registry.Get(MetricsKeyRequestsRequestSize).(metrics.Histogram)
+	assert.Equal(t, int64(3), requestSize.Count())
+	assert.Equal(t, int64(8), requestSize.Sum())
+	assert.Equal(t, int64(16), registry.Get(MetricsKeyRequestsResponseSize).(metrics.Histogram).Sum())
+	assert.Equal(t, int64(8), registry.Get(MetricsKeyIngressBytes).(metrics.Counter).Count())
+	assert.Equal(t, int64(16), registry.Get(MetricsKeyEgressBytes).(metrics.Counter).Count())
+}
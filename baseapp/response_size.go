@@ -0,0 +1,256 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog/hlog"
+	"goji.io/middleware"
+)
+
+const (
+	MetricsKeyResponseSize         = "server.response.size"
+	MetricsKeyResponseSizeExceeded = "server.response.size.exceeded"
+)
+
+// ErrResponseTooLarge is returned by response writers when a write would
+// exceed the maximum response size set by NewResponseSizeHandler.
+var ErrResponseTooLarge = errors.New("response exceeds maximum size")
+
+// NewResponseSizeHandler returns middleware that records the size of each
+// response in a histogram tagged with the route pattern, like
+// "server.response.size[route:/api/export]". Requests that do not match a
+// route use the "unknown" route.
+//
+// If maxSize is greater than zero, the middleware also limits the size of
+// responses. The first write that exceeds the limit logs an error and
+// increments the "server.response.size.exceeded" counter for the route. If
+// the response status was not sent yet, the middleware sends a 500 problem
+// response instead. Otherwise, the response is truncated. In both cases, the
+// exceeding write and all later writes fail with ErrResponseTooLarge, so
+// handlers that check write errors can stop generating output.
+//
+// This middleware must be used after the middleware that adds a metrics
+// registry and logger to the request context.
+func NewResponseSizeHandler(maxSize int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeName(r)
+			sw := wrapSizeLimitWriter(w, r, route, maxSize)
+			next.ServeHTTP(sw, r)
+
+			if IsIgnored(r, IgnoreRule{Metrics: true}) {
+				return
+			}
+			registry := MetricsCtx(r.Context())
+			metrics.GetOrRegisterHistogram(
+				appmetrics.TaggedName(MetricsKeyResponseSize, "route:"+route),
+				registry,
+				metrics.NewExpDecaySample(appmetrics.DefaultReservoirSize, appmetrics.DefaultExpDecayAlpha),
+			).Update(sw.size())
+		})
+	}
+}
+
+// routeName returns the string form of the goji pattern that matched the
+// request or "unknown" if there is no pattern.
+func routeName(r *http.Request) string {
+	if p, ok := middleware.Pattern(r.Context()).(fmt.Stringer); ok {
+		return p.String()
+	}
+	return "unknown"
+}
+
+// sizeLimitResponseWriter is the common interface of the size limiting
+// writer variants.
+type sizeLimitResponseWriter interface {
+	RecordingResponseWriter
+	size() int64
+}
+
+// wrapSizeLimitWriter returns a writer that records and limits the size of
+// the response. Like WrapWriter, it returns a variant that supports the same
+// optional interfaces as the common http.ResponseWriter implementations.
+func wrapSizeLimitWriter(w http.ResponseWriter, r *http.Request, route string, maxSize int64) sizeLimitResponseWriter {
+	_, cn := w.(http.CloseNotifier)
+	_, fl := w.(http.Flusher)
+	_, hj := w.(http.Hijacker)
+	_, rf := w.(io.ReaderFrom)
+
+	lw := sizeLimitWriter{
+		ResponseWriter: w,
+		r:              r,
+		route:          route,
+		maxSize:        maxSize,
+	}
+	if cn && fl && hj && rf {
+		return &fancySizeLimitWriter{lw}
+	}
+	if fl {
+		return &flushSizeLimitWriter{lw}
+	}
+	return &lw
+}
+
+type sizeLimitWriter struct {
+	http.ResponseWriter
+	r *http.Request
+
+	route   string
+	maxSize int64
+
+	// attempted is the number of bytes the handler tried to write
+	attempted    int64
+	bytesWritten int64
+	code         int
+	exceeded     bool
+}
+
+func (w *sizeLimitWriter) WriteHeader(code int) {
+	if w.exceeded {
+		return
+	}
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *sizeLimitWriter) Write(b []byte) (int, error) {
+	w.attempted += int64(len(b))
+	if w.exceeded {
+		return 0, ErrResponseTooLarge
+	}
+
+	if w.maxSize > 0 && w.attempted > w.maxSize {
+		w.exceed()
+		return 0, ErrResponseTooLarge
+	}
+
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytesWritten += int64(n)
+	return n, err
+}
+
+// exceed records that the response exceeded the limit and sends an error
+// response if possible.
+func (w *sizeLimitWriter) exceed() {
+	w.exceeded = true
+
+	hlog.FromRequest(w.r).Error().
+		Str("route", w.route).
+		Int64("max_size", w.maxSize).
+		Msg("Response exceeded maximum size")
+
+	if !IsIgnored(w.r, IgnoreRule{Metrics: true}) {
+		registry := MetricsCtx(w.r.Context())
+		metrics.GetOrRegisterCounter(
+			appmetrics.TaggedName(MetricsKeyResponseSizeExceeded, "route:"+w.route),
+			registry,
+		).Inc(1)
+	}
+
+	if w.code == 0 {
+		w.code = http.StatusInternalServerError
+		w.ResponseWriter.Header().Del("Content-Length")
+		WriteProblem(w.ResponseWriter, w.r, Problem{
+			Status: http.StatusInternalServerError,
+			Detail: "Response exceeds maximum size",
+		})
+	}
+}
+
+func (w *sizeLimitWriter) Status() int {
+	return w.code
+}
+
+func (w *sizeLimitWriter) BytesWritten() int64 {
+	return w.bytesWritten
+}
+
+func (w *sizeLimitWriter) size() int64 {
+	return w.attempted
+}
+
+// Unwrap returns the underlying writer for use with http.ResponseController.
+func (w *sizeLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// fancySizeLimitWriter is a sizeLimitWriter that additionally satisfies
+// http.CloseNotifier, http.Flusher, http.Hijacker, and io.ReaderFrom.
+type fancySizeLimitWriter struct {
+	sizeLimitWriter
+}
+
+func (f *fancySizeLimitWriter) CloseNotify() <-chan bool {
+	cn := f.sizeLimitWriter.ResponseWriter.(http.CloseNotifier)
+	return cn.CloseNotify()
+}
+func (f *fancySizeLimitWriter) Flush() {
+	fl := f.sizeLimitWriter.ResponseWriter.(http.Flusher)
+	fl.Flush()
+}
+func (f *fancySizeLimitWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj := f.sizeLimitWriter.ResponseWriter.(http.Hijacker)
+	return hj.Hijack()
+}
+func (f *fancySizeLimitWriter) ReadFrom(r io.Reader) (int64, error) {
+	// With a limit, copy through Write so that the limit applies
+	if f.maxSize > 0 {
+		return io.Copy(writerOnly{&f.sizeLimitWriter}, r)
+	}
+	if f.code == 0 {
+		f.code = http.StatusOK
+	}
+	rf := f.sizeLimitWriter.ResponseWriter.(io.ReaderFrom)
+	n, err := rf.ReadFrom(r)
+	f.attempted += n
+	f.bytesWritten += n
+	return n, err
+}
+
+var _ http.CloseNotifier = &fancySizeLimitWriter{}
+var _ http.Flusher = &fancySizeLimitWriter{}
+var _ http.Hijacker = &fancySizeLimitWriter{}
+var _ io.ReaderFrom = &fancySizeLimitWriter{}
+
+type flushSizeLimitWriter struct {
+	sizeLimitWriter
+}
+
+func (f *flushSizeLimitWriter) Flush() {
+	fl := f.sizeLimitWriter.ResponseWriter.(http.Flusher)
+	fl.Flush()
+}
+
+var _ http.Flusher = &flushSizeLimitWriter{}
+
+// writerOnly hides the optional interfaces of a writer so that io.Copy uses
+// its Write method.
+type writerOnly struct {
+	io.Writer
+}
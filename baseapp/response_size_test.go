@@ -0,0 +1,173 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"goji.io"
+	"goji.io/pat"
+)
+
+func TestResponseSizeHandler(t *testing.T) {
+	var writeErr error
+
+	newMux := func(registry metrics.Registry, maxSize int64) *goji.Mux {
+		writeErr = nil
+
+		mux := goji.NewMux()
+		mux.Use(NewMetricsHandler(registry))
+		mux.Use(NewResponseSizeHandler(maxSize))
+		mux.HandleFunc(pat.Get("/small"), func(w http.ResponseWriter, r *http.Request) {
+			_, writeErr = w.Write([]byte("hello"))
+		})
+		mux.HandleFunc(pat.Get("/large"), func(w http.ResponseWriter, r *http.Request) {
+			_, writeErr = w.Write(bytes.Repeat([]byte("a"), 64))
+		})
+		mux.HandleFunc(pat.Get("/stream"), func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			for i := 0; i < 8 && writeErr == nil; i++ {
+				_, writeErr = w.Write(bytes.Repeat([]byte("a"), 8))
+			}
+		})
+		return mux
+	}
+
+	t.Run("recordsSize", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		w := httptest.NewRecorder()
+
+		newMux(registry, 0).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/large", nil))
+
+		assert.NoError(t, writeErr)
+		assert.Equal(t, 64, w.Body.Len())
+
+		h, ok := registry.Get("server.response.size[route:/large]").(metrics.Histogram)
+		if assert.True(t, ok, "histogram was not registered") {
+			assert.Equal(t, int64(64), h.Max())
+		}
+	})
+
+	t.Run("exceededBeforeHeader", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		w := httptest.NewRecorder()
+
+		newMux(registry, 32).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/large", nil))
+
+		assert.ErrorIs(t, writeErr, ErrResponseTooLarge)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+		c, ok := registry.Get("server.response.size.exceeded[route:/large]").(metrics.Counter)
+		if assert.True(t, ok, "counter was not registered") {
+			assert.Equal(t, int64(1), c.Count())
+		}
+	})
+
+	t.Run("exceededAfterHeader", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		w := httptest.NewRecorder()
+
+		newMux(registry, 32).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+		assert.ErrorIs(t, writeErr, ErrResponseTooLarge)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 32, w.Body.Len())
+	})
+
+	t.Run("underLimit", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		w := httptest.NewRecorder()
+
+		newMux(registry, 32).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/small", nil))
+
+		assert.NoError(t, writeErr)
+		assert.Equal(t, "hello", w.Body.String())
+		assert.Nil(t, registry.Get("server.response.size.exceeded[route:/small]"))
+	})
+}
+
+type fancyTestWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w fancyTestWriter) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func (w fancyTestWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func (w fancyTestWriter) ReadFrom(r io.Reader) (int64, error) {
+	return io.Copy(w.ResponseRecorder, r)
+}
+
+func TestSizeLimitWriterInterfaces(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	t.Run("basic", func(t *testing.T) {
+		w := wrapSizeLimitWriter(struct{ http.ResponseWriter }{httptest.NewRecorder()}, r, "/", 0)
+
+		_, fl := w.(http.Flusher)
+		assert.False(t, fl, "writer should not claim to be a flusher")
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		w := wrapSizeLimitWriter(httptest.NewRecorder(), r, "/", 0)
+
+		_, fl := w.(http.Flusher)
+		_, hj := w.(http.Hijacker)
+		assert.True(t, fl, "writer should be a flusher")
+		assert.False(t, hj, "writer should not be a hijacker")
+	})
+
+	t.Run("fancy", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		w := wrapSizeLimitWriter(fancyTestWriter{rec}, r, "/", 8)
+
+		_, cn := w.(http.CloseNotifier)
+		_, fl := w.(http.Flusher)
+		_, hj := w.(http.Hijacker)
+		rf, ok := w.(io.ReaderFrom)
+		assert.True(t, cn && fl && hj && ok, "writer should support all optional interfaces")
+
+		n, err := rf.ReadFrom(strings.NewReader("0123456789"))
+		assert.ErrorIs(t, err, ErrResponseTooLarge, "ReadFrom should enforce the limit")
+		assert.Zero(t, n)
+		assert.Equal(t, http.StatusInternalServerError, w.Status())
+	})
+
+	t.Run("recording", func(t *testing.T) {
+		w := wrapSizeLimitWriter(httptest.NewRecorder(), r, "/", 0)
+
+		var rw http.ResponseWriter = w
+		rec, ok := rw.(RecordingResponseWriter)
+		if assert.True(t, ok, "writer should be a RecordingResponseWriter") {
+			_, _ = rec.Write([]byte("hello"))
+			assert.Equal(t, http.StatusOK, rec.Status())
+			assert.Equal(t, int64(5), rec.BytesWritten())
+		}
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	start := time.Date(2026, time.March, 14, 10, 17, 30, 0, time.UTC)
+
+	tests := map[string]struct {
+		Expr string
+		Next []time.Time
+	}{
+		"everyMinute": {
+			Expr: "* * * * *",
+			Next: []time.Time{
+				time.Date(2026, time.March, 14, 10, 18, 0, 0, time.UTC),
+				time.Date(2026, time.March, 14, 10, 19, 0, 0, time.UTC),
+			},
+		},
+		"steps": {
+			Expr: "*/15 * * * *",
+			Next: []time.Time{
+				time.Date(2026, time.March, 14, 10, 30, 0, 0, time.UTC),
+				time.Date(2026, time.March, 14, 10, 45, 0, 0, time.UTC),
+				time.Date(2026, time.March, 14, 11, 0, 0, 0, time.UTC),
+			},
+		},
+		"listsAndRanges": {
+			Expr: "0 9-10,17 * * *",
+			Next: []time.Time{
+				time.Date(2026, time.March, 14, 17, 0, 0, 0, time.UTC),
+				time.Date(2026, time.March, 15, 9, 0, 0, 0, time.UTC),
+				time.Date(2026, time.March, 15, 10, 0, 0, 0, time.UTC),
+			},
+		},
+		"names": {
+			Expr: "30 2 * jan-feb mon",
+			Next: []time.Time{
+				time.Date(2027, time.January, 4, 2, 30, 0, 0, time.UTC),
+				time.Date(2027, time.January, 11, 2, 30, 0, 0, time.UTC),
+			},
+		},
+		"dayOfMonthOrWeek": {
+			Expr: "0 0 1 * 0",
+			Next: []time.Time{
+				time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, time.March, 22, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, time.March, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"descriptor": {
+			Expr: "@monthly",
+			Next: []time.Time{
+				time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"every": {
+			Expr: "@every 90s",
+			Next: []time.Time{
+				time.Date(2026, time.March, 14, 10, 19, 0, 0, time.UTC),
+				time.Date(2026, time.March, 14, 10, 20, 30, 0, time.UTC),
+			},
+		},
+		"impossible": {
+			Expr: "0 0 30 2 *",
+			Next: []time.Time{{}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s, err := ParseCron(test.Expr)
+			require.NoError(t, err, "failed to parse expression")
+
+			curr := start
+			for i, expected := range test.Next {
+				curr = s.Next(curr)
+				assert.Equal(t, expected, curr, "incorrect activation %d", i)
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, expr := range []string{
+			"* * * *",
+			"60 * * * *",
+			"* 24 * * *",
+			"* * 0 * *",
+			"* * * 13 *",
+			"*/0 * * * *",
+			"5-1 * * * *",
+			"* * * foo *",
+			"@every bar",
+		} {
+			_, err := ParseCron(expr)
+			assert.Error(t, err, "expected error parsing %q", expr)
+		}
+	})
+}
@@ -0,0 +1,480 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduler runs background jobs on cron-style schedules.
+//
+// Jobs run with a context that contains the scheduler's logger and metrics
+// registry, so they can use the same helpers as HTTP handlers. Each job may
+// add random jitter to its activation times, choose what happens when an
+// activation occurs while a previous run is still active, and use a Lease to
+// make sure only one replica of a service runs the job at a time.
+//
+// Use AddRunHook to trace job runs. Runs started from a request with Trigger
+// carry the request context, so tracing hooks can link the job to the
+// request.
+package scheduler
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	MetricsKeyRuns     = "scheduler.runs"
+	MetricsKeyErrors   = "scheduler.errors"
+	MetricsKeySkipped  = "scheduler.skipped"
+	MetricsKeyDuration = "scheduler.duration"
+)
+
+type schedulerMetrics struct {
+	Runs     appmetrics.Tagged[metrics.Counter] `metric:"scheduler.runs"`
+	Errors   appmetrics.Tagged[metrics.Counter] `metric:"scheduler.errors"`
+	Skipped  appmetrics.Tagged[metrics.Counter] `metric:"scheduler.skipped"`
+	Duration appmetrics.Tagged[metrics.Timer]   `metric:"scheduler.duration"`
+}
+
+// OverlapPolicy determines what happens when a job activates while a previous
+// run of the same job is still active.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips activations that occur while the job is running.
+	OverlapSkip OverlapPolicy = iota
+
+	// OverlapQueue runs the job again as soon as the current run finishes.
+	// At most one activation is queued; further activations are skipped.
+	OverlapQueue
+)
+
+// Lease coordinates job execution between replicas. Implementations are
+// usually backed by a shared database or cache.
+type Lease interface {
+	// Acquire attempts to take the lease for the named job for at most ttl.
+	// It returns false if another holder owns the lease.
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+
+	// Release gives up a lease previously returned by Acquire.
+	Release(ctx context.Context, name string) error
+}
+
+// Job is a function that runs on a schedule.
+type Job struct {
+	// Name identifies the job in logs, metrics, and leases. It is required
+	// and must be unique within a scheduler.
+	Name string
+
+	// Schedule determines when the job runs. It is required.
+	Schedule Schedule
+
+	// Run is the function to execute. The context is canceled when the
+	// scheduler stops.
+	Run func(ctx context.Context) error
+
+	// Jitter is the maximum random delay added to each activation.
+	Jitter time.Duration
+
+	// Overlap sets the behavior when the job activates while already running.
+	Overlap OverlapPolicy
+
+	// Lease, if set, must be acquired before each run. Activations where the
+	// lease is held by another replica are skipped.
+	Lease Lease
+
+	// LeaseTTL is the duration requested when acquiring the lease. If zero,
+	// the lease is requested for one minute.
+	LeaseTTL time.Duration
+}
+
+// RunHook is called before each run of a job. It returns the context for the
+// run and a function that is called with the result of the run. Hooks can use
+// this to start and finish tracing spans or to add other values to the job
+// context. The job's Run function receives the context returned by the last
+// hook, so spans started by hooks propagate to the job.
+//
+// For runs started by Trigger, Origin returns the context passed to Trigger.
+// Tracing hooks can use it to link the job span to the span of the request
+// that triggered the job.
+type RunHook func(ctx context.Context, job string) (context.Context, func(err error))
+
+type originCtxKey struct{}
+
+// Origin returns the context passed to Trigger if the run was triggered
+// manually. The returned context is never canceled, but contains the values,
+// like trace information, of the original context.
+func Origin(ctx context.Context) (context.Context, bool) {
+	origin, ok := ctx.Value(originCtxKey{}).(context.Context)
+	return origin, ok
+}
+
+// Scheduler runs jobs on their schedules.
+type Scheduler struct {
+	logger   zerolog.Logger
+	registry metrics.Registry
+	metrics  *schedulerMetrics
+
+	mu     sync.Mutex
+	jobs   map[string]*jobState
+	hooks  []RunHook
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	// runCtx is the context of the current call to Run, or nil if the
+	// scheduler is not running. triggered tracks runs started by Trigger.
+	runCtx    context.Context
+	triggered sync.WaitGroup
+}
+
+type jobState struct {
+	Job
+
+	mu      sync.Mutex
+	running bool
+	queued  bool
+}
+
+// New creates a Scheduler that provides logger and registry to jobs.
+func New(logger zerolog.Logger, registry metrics.Registry) *Scheduler {
+	m := appmetrics.New[schedulerMetrics]()
+	appmetrics.Register(registry, m)
+
+	return &Scheduler{
+		logger:   logger,
+		registry: registry,
+		metrics:  m,
+		jobs:     make(map[string]*jobState),
+	}
+}
+
+// Start creates a Scheduler using the logger and registry of the server and
+// adds the jobs. The jobs start running when the server starts and stop when
+// the server begins a graceful shutdown.
+//
+// Because the server only calls shutdown functions if it has a
+// ShutdownWaitTime, servers without one never stop the scheduler. Their jobs
+// are interrupted when the process exits. Use New and call Run and Stop
+// directly to control the lifetime of the scheduler in other ways.
+func Start(s *baseapp.Server, jobs ...Job) (*Scheduler, error) {
+	sched := New(s.Logger(), s.Registry())
+	for _, j := range jobs {
+		if err := sched.Add(j); err != nil {
+			return nil, err
+		}
+	}
+
+	s.OnStart(func(*baseapp.Server) {
+		go sched.Run(context.Background())
+	})
+	s.OnShutdown(sched.Stop)
+
+	return sched, nil
+}
+
+// AddRunHook adds a hook that is called before each job run. Hooks are called
+// in the order they were added and the functions they return are called in
+// reverse order after the run completes. Hooks must be added before calling
+// Run.
+func (s *Scheduler) AddRunHook(h RunHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, h)
+}
+
+// Add adds a job to the scheduler. Jobs added after calling Run are not
+// scheduled until the next call to Run.
+func (s *Scheduler) Add(j Job) error {
+	if j.Name == "" {
+		return errors.New("scheduler: job name is required")
+	}
+	if j.Schedule == nil {
+		return errors.Errorf("scheduler: job %s: schedule is required", j.Name)
+	}
+	if j.Run == nil {
+		return errors.Errorf("scheduler: job %s: run function is required", j.Name)
+	}
+	if j.LeaseTTL == 0 {
+		j.LeaseTTL = time.Minute
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[j.Name]; exists {
+		return errors.Errorf("scheduler: job %s: already exists", j.Name)
+	}
+	s.jobs[j.Name] = &jobState{Job: j}
+	return nil
+}
+
+// Run runs jobs until the context is canceled or Stop is called. It waits for
+// active jobs to finish before returning.
+func (s *Scheduler) Run(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	s.mu.Lock()
+	s.cancel = cancel
+	s.done = done
+	s.runCtx = ctx
+	jobs := make([]*jobState, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, j := range jobs {
+		wg.Add(1)
+		go func(j *jobState) {
+			defer wg.Done()
+			s.loop(ctx, j, &wg)
+		}(j)
+	}
+	wg.Wait()
+
+	// Prevent new triggered runs before waiting for existing ones
+	s.mu.Lock()
+	s.runCtx = nil
+	s.mu.Unlock()
+	s.triggered.Wait()
+}
+
+// Trigger runs the named job immediately, in addition to its scheduled
+// activations. Like scheduled activations, triggered runs follow the job's
+// overlap policy and lease, so Trigger returns false if the activation was
+// skipped or queued because the job is running. The job runs in the
+// background with the scheduler's context; use Origin in a RunHook to access
+// values from ctx, like the trace of the request that triggered the job.
+//
+// Trigger returns an error if the job does not exist or if the scheduler is
+// not running.
+func (s *Scheduler) Trigger(ctx context.Context, name string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[name]
+	if !ok {
+		return false, errors.Errorf("scheduler: job %s: does not exist", name)
+	}
+	if s.runCtx == nil || s.runCtx.Err() != nil {
+		return false, errors.Errorf("scheduler: job %s: scheduler is not running", name)
+	}
+
+	switch j.tryStart() {
+	case activationSkipped:
+		s.metrics.Skipped.Tag("job:" + j.Name).Inc(1)
+		return false, nil
+	case activationQueued:
+		return false, nil
+	}
+
+	runCtx := context.WithValue(s.runCtx, originCtxKey{}, context.WithoutCancel(ctx))
+	s.triggered.Add(1)
+	go func() {
+		defer s.triggered.Done()
+		for {
+			s.execute(runCtx, j)
+			if !j.finish() {
+				return
+			}
+		}
+	}()
+	return true, nil
+}
+
+// Stop cancels the context of the current call to Run and waits for active
+// jobs to finish. It returns an error if ctx expires before the jobs finish.
+// Stop does nothing if the scheduler is not running.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "scheduler: jobs did not finish before shutdown")
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *jobState, wg *sync.WaitGroup) {
+	for {
+		now := time.Now()
+		next := j.Schedule.Next(now)
+		if next.IsZero() {
+			s.logger.Warn().Str("job", j.Name).Msg("Job schedule has no future activations")
+			return
+		}
+		next = next.Add(jitter(j.Jitter))
+
+		t := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		switch j.tryStart() {
+		case activationSkipped:
+			s.metrics.Skipped.Tag("job:" + j.Name).Inc(1)
+			s.logger.Debug().Str("job", j.Name).Msg("Skipping job activation because the job is still running")
+			continue
+		case activationQueued:
+			s.logger.Debug().Str("job", j.Name).Msg("Queuing job activation because the job is still running")
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				s.execute(ctx, j)
+				if !j.finish() {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// jitter returns a random delay in [0, max).
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+type activation int
+
+const (
+	activationStarted activation = iota
+	activationQueued
+	activationSkipped
+)
+
+// tryStart marks the job as running if it is not already running. Otherwise,
+// it queues or skips the activation depending on the overlap policy.
+func (j *jobState) tryStart() activation {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running {
+		if j.Overlap == OverlapQueue && !j.queued {
+			j.queued = true
+			return activationQueued
+		}
+		return activationSkipped
+	}
+	j.running = true
+	return activationStarted
+}
+
+// finish marks the job as complete. It returns true if another run was queued
+// and the job should run again.
+func (j *jobState) finish() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.queued {
+		j.queued = false
+		return true
+	}
+	j.running = false
+	return false
+}
+
+func (s *Scheduler) execute(ctx context.Context, j *jobState) {
+	logger := s.logger.With().Str("job", j.Name).Logger()
+	tag := "job:" + j.Name
+
+	ctx = logger.WithContext(ctx)
+	ctx = baseapp.WithMetricsCtx(ctx, s.registry)
+
+	if j.Lease != nil {
+		ok, err := j.Lease.Acquire(ctx, j.Name, j.LeaseTTL)
+		if err != nil {
+			s.metrics.Errors.Tag(tag).Inc(1)
+			logger.Error().Err(err).Msg("Failed to acquire job lease")
+			return
+		}
+		if !ok {
+			s.metrics.Skipped.Tag(tag).Inc(1)
+			logger.Debug().Msg("Skipping job activation because the lease is held by another owner")
+			return
+		}
+		defer func() {
+			if err := j.Lease.Release(context.WithoutCancel(ctx), j.Name); err != nil {
+				logger.Warn().Err(err).Msg("Failed to release job lease")
+			}
+		}()
+	}
+
+	s.mu.Lock()
+	hooks := s.hooks
+	s.mu.Unlock()
+
+	var finishers []func(error)
+	for _, h := range hooks {
+		var finish func(error)
+		ctx, finish = h(ctx, j.Name)
+		if finish != nil {
+			finishers = append(finishers, finish)
+		}
+	}
+
+	start := time.Now()
+	err := runJob(ctx, j.Run)
+	elapsed := time.Since(start)
+
+	for i := len(finishers) - 1; i >= 0; i-- {
+		finishers[i](err)
+	}
+
+	s.metrics.Runs.Tag(tag).Inc(1)
+	s.metrics.Duration.Tag(tag).Update(elapsed)
+
+	if err != nil {
+		s.metrics.Errors.Tag(tag).Inc(1)
+		logger.Error().Err(err).Dur("elapsed", elapsed).Msg("Job failed")
+		return
+	}
+	logger.Debug().Dur("elapsed", elapsed).Msg("Job completed")
+}
+
+func runJob(ctx context.Context, run func(context.Context) error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = errors.Errorf("panic: %v", v)
+		}
+	}()
+	return run(ctx)
+}
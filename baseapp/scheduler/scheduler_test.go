@@ -0,0 +1,345 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limitSchedule activates at a fixed interval a limited number of times.
+type limitSchedule struct {
+	interval  time.Duration
+	remaining atomic.Int32
+}
+
+func newLimitSchedule(interval time.Duration, n int32) *limitSchedule {
+	s := &limitSchedule{interval: interval}
+	s.remaining.Store(n)
+	return s
+}
+
+func (s *limitSchedule) Next(t time.Time) time.Time {
+	if s.remaining.Add(-1) < 0 {
+		return time.Time{}
+	}
+	return t.Add(s.interval)
+}
+
+type testLease struct {
+	held bool
+
+	mu       sync.Mutex
+	acquired int
+	released int
+}
+
+func (l *testLease) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held {
+		return false, nil
+	}
+	l.acquired++
+	return true, nil
+}
+
+func (l *testLease) Release(ctx context.Context, name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.released++
+	return nil
+}
+
+func (l *testLease) counts() (int, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.acquired, l.released
+}
+
+func TestScheduler(t *testing.T) {
+	count := func(r metrics.Registry, name string) int64 {
+		if c, ok := r.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	start := func(t *testing.T, jobs ...Job) (*Scheduler, metrics.Registry) {
+		registry := metrics.NewRegistry()
+		sched := New(zerolog.Nop(), registry)
+		for _, j := range jobs {
+			require.NoError(t, sched.Add(j))
+		}
+
+		go sched.Run(context.Background())
+
+		t.Cleanup(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			assert.NoError(t, sched.Stop(ctx))
+		})
+		return sched, registry
+	}
+
+	t.Run("overlapSkip", func(t *testing.T) {
+		var runs atomic.Int32
+		release := make(chan struct{})
+
+		_, registry := start(t, Job{
+			Name:     "skip",
+			Schedule: newLimitSchedule(5*time.Millisecond, 3),
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				<-release
+				return nil
+			},
+		})
+
+		require.Eventually(t, func() bool {
+			return count(registry, "scheduler.skipped[job:skip]") == 2
+		}, 5*time.Second, time.Millisecond)
+		close(release)
+
+		require.Eventually(t, func() bool {
+			return count(registry, "scheduler.runs[job:skip]") == 1
+		}, 5*time.Second, time.Millisecond)
+		assert.Equal(t, int32(1), runs.Load())
+	})
+
+	t.Run("overlapQueue", func(t *testing.T) {
+		var runs atomic.Int32
+		release := make(chan struct{})
+
+		_, registry := start(t, Job{
+			Name:     "queue",
+			Schedule: newLimitSchedule(5*time.Millisecond, 3),
+			Overlap:  OverlapQueue,
+			Run: func(ctx context.Context) error {
+				if runs.Add(1) == 1 {
+					<-release
+				}
+				return nil
+			},
+		})
+
+		require.Eventually(t, func() bool {
+			return count(registry, "scheduler.skipped[job:queue]") == 1
+		}, 5*time.Second, time.Millisecond, "only the activation after the queued one is skipped")
+		close(release)
+
+		require.Eventually(t, func() bool {
+			return count(registry, "scheduler.runs[job:queue]") == 2
+		}, 5*time.Second, time.Millisecond)
+		assert.Equal(t, int32(2), runs.Load())
+	})
+
+	t.Run("leaseAcquired", func(t *testing.T) {
+		lease := &testLease{}
+		var runs atomic.Int32
+
+		_, registry := start(t, Job{
+			Name:     "lease",
+			Schedule: newLimitSchedule(time.Millisecond, 1),
+			Lease:    lease,
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			},
+		})
+
+		require.Eventually(t, func() bool {
+			return count(registry, "scheduler.runs[job:lease]") == 1
+		}, 5*time.Second, time.Millisecond)
+
+		acquired, released := lease.counts()
+		assert.Equal(t, 1, acquired)
+		assert.Equal(t, 1, released)
+		assert.Equal(t, int32(1), runs.Load())
+	})
+
+	t.Run("leaseHeld", func(t *testing.T) {
+		lease := &testLease{held: true}
+		var runs atomic.Int32
+
+		_, registry := start(t, Job{
+			Name:     "held",
+			Schedule: newLimitSchedule(time.Millisecond, 1),
+			Lease:    lease,
+			Run: func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			},
+		})
+
+		require.Eventually(t, func() bool {
+			return count(registry, "scheduler.skipped[job:held]") == 1
+		}, 5*time.Second, time.Millisecond)
+
+		acquired, released := lease.counts()
+		assert.Zero(t, acquired)
+		assert.Zero(t, released, "leases held by others are not released")
+		assert.Zero(t, runs.Load())
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		var runs atomic.Int32
+		var hookErrs []error
+		var hookMu sync.Mutex
+
+		registry := metrics.NewRegistry()
+		sched := New(zerolog.Nop(), registry)
+		sched.AddRunHook(func(ctx context.Context, job string) (context.Context, func(error)) {
+			return ctx, func(err error) {
+				hookMu.Lock()
+				defer hookMu.Unlock()
+				hookErrs = append(hookErrs, err)
+			}
+		})
+		require.NoError(t, sched.Add(Job{
+			Name:     "panic",
+			Schedule: newLimitSchedule(time.Millisecond, 2),
+			Run: func(ctx context.Context) error {
+				if runs.Add(1) == 1 {
+					panic("boom")
+				}
+				return nil
+			},
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go sched.Run(ctx)
+
+		require.Eventually(t, func() bool {
+			return count(registry, "scheduler.runs[job:panic]") == 2
+		}, 5*time.Second, time.Millisecond, "scheduler should keep running after a panic")
+		assert.Equal(t, int64(1), count(registry, "scheduler.errors[job:panic]"))
+
+		hookMu.Lock()
+		defer hookMu.Unlock()
+		require.Len(t, hookErrs, 2)
+		assert.EqualError(t, hookErrs[0], "panic: boom")
+		assert.NoError(t, hookErrs[1])
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		started := make(chan struct{})
+		canceled := make(chan struct{})
+
+		registry := metrics.NewRegistry()
+		sched := New(zerolog.Nop(), registry)
+		require.NoError(t, sched.Add(Job{
+			Name:     "stop",
+			Schedule: newLimitSchedule(time.Millisecond, 1),
+			Run: func(ctx context.Context) error {
+				close(started)
+				<-ctx.Done()
+				close(canceled)
+				return ctx.Err()
+			},
+		}))
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			sched.Run(context.Background())
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		require.NoError(t, sched.Stop(ctx))
+
+		<-canceled
+		<-done
+	})
+
+	t.Run("trigger", func(t *testing.T) {
+		type traceKey struct{}
+		type spanKey struct{}
+
+		registry := metrics.NewRegistry()
+		sched := New(zerolog.Nop(), registry)
+
+		links := make(chan any, 1)
+		sched.AddRunHook(func(ctx context.Context, job string) (context.Context, func(error)) {
+			if origin, ok := Origin(ctx); ok {
+				links <- origin.Value(traceKey{})
+			}
+			return context.WithValue(ctx, spanKey{}, "job-span"), nil
+		})
+
+		spans := make(chan any, 1)
+		require.NoError(t, sched.Add(Job{
+			Name:     "trigger",
+			Schedule: Every(time.Hour),
+			Run: func(ctx context.Context) error {
+				spans <- ctx.Value(spanKey{})
+				return nil
+			},
+		}))
+
+		reqCtx, reqCancel := context.WithCancel(context.WithValue(context.Background(), traceKey{}, "request-trace"))
+		_, err := sched.Trigger(reqCtx, "trigger")
+		assert.Error(t, err, "trigger should fail when the scheduler is not running")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			sched.Run(ctx)
+		}()
+
+		require.Eventually(t, func() bool {
+			started, err := sched.Trigger(reqCtx, "trigger")
+			return err == nil && started
+		}, 5*time.Second, time.Millisecond)
+		reqCancel()
+
+		assert.Equal(t, "request-trace", <-links)
+		assert.Equal(t, "job-span", <-spans)
+		require.Eventually(t, func() bool {
+			return count(registry, "scheduler.runs[job:trigger]") == 1
+		}, 5*time.Second, time.Millisecond)
+
+		_, err = sched.Trigger(context.Background(), "missing")
+		assert.Error(t, err)
+
+		cancel()
+		<-done
+		_, err = sched.Trigger(context.Background(), "trigger")
+		assert.Error(t, err, "trigger should fail after the scheduler stops")
+	})
+}
+
+func TestJitter(t *testing.T) {
+	assert.Zero(t, jitter(0))
+	assert.Zero(t, jitter(-time.Second))
+
+	for i := 0; i < 100; i++ {
+		d := jitter(10 * time.Millisecond)
+		assert.GreaterOrEqual(t, d, time.Duration(0))
+		assert.Less(t, d, 10*time.Millisecond)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package securecookie encrypts and authenticates cookie values.
+//
+// A Codec encrypts values with AES-GCM using the first of its keys and
+// decrypts values encrypted with any of its keys, so keys can be rotated by
+// adding a new key at the front of the list and removing the old key once
+// all cookies encrypted with it have expired. Each encrypted value includes
+// the ID of its key, the name of the cookie, and the time it was created, so
+// values cannot be moved between cookies and are rejected after the maximum
+// age even if the client keeps the cookie.
+package securecookie
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultMaxAge is the maximum age of values if the configuration does
+	// not set one.
+	DefaultMaxAge = 24 * time.Hour
+
+	version = 1
+)
+
+var (
+	ErrInvalidValue = errors.New("securecookie: invalid value")
+	ErrUnknownKey   = errors.New("securecookie: unknown key")
+	ErrExpired      = errors.New("securecookie: expired value")
+)
+
+// Key is an encryption key. The secret must be 16, 24, or 32 bytes, to
+// select AES-128, AES-192, or AES-256. IDs must be unique and at most 255
+// bytes, and should be short because they are included in every cookie.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// GenerateKey creates a random 32-byte key with the given ID.
+func GenerateKey(id string) (Key, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, fmt.Errorf("securecookie: failed to generate key: %w", err)
+	}
+	return Key{ID: id, Secret: secret}, nil
+}
+
+// Config contains options for a Codec. It is usually embedded in a larger
+// configuration struct.
+type Config struct {
+	// Keys are the encryption keys. The first key encrypts new values.
+	Keys []KeyConfig `yaml:"keys" json:"keys"`
+
+	// MaxAge is the maximum age of values. If zero, DefaultMaxAge is used.
+	MaxAge time.Duration `yaml:"max_age" json:"maxAge"`
+}
+
+// KeyConfig is the configuration of a Key. The secret is base64-encoded.
+type KeyConfig struct {
+	ID     string `yaml:"id" json:"id"`
+	Secret string `yaml:"secret" json:"secret"`
+}
+
+// SetValuesFromEnv sets values in the configuration from corresponding
+// environment variables, if they exist. The optional prefix is added to the
+// start of the environment variable names. The COOKIE_KEYS variable contains
+// a comma-separated list of keys in the form "id:secret".
+func (c *Config) SetValuesFromEnv(prefix string) {
+	if v, ok := os.LookupEnv(prefix + "COOKIE_KEYS"); ok {
+		c.Keys = nil
+		for _, k := range strings.Split(v, ",") {
+			id, secret, _ := strings.Cut(strings.TrimSpace(k), ":")
+			if id != "" {
+				c.Keys = append(c.Keys, KeyConfig{ID: id, Secret: secret})
+			}
+		}
+	}
+	if v, ok := os.LookupEnv(prefix + "COOKIE_MAX_AGE"); ok {
+		if d, err := time.ParseDuration(v); err == nil {
+			c.MaxAge = d
+		}
+	}
+}
+
+// Codec encrypts and decrypts cookie values.
+type Codec struct {
+	maxAge  time.Duration
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// New creates a Codec from the configuration.
+func New(c Config) (*Codec, error) {
+	keys := make([]Key, 0, len(c.Keys))
+	for _, kc := range c.Keys {
+		secret, err := base64.StdEncoding.DecodeString(kc.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("securecookie: key %q: invalid base64 secret: %w", kc.ID, err)
+		}
+		keys = append(keys, Key{ID: kc.ID, Secret: secret})
+	}
+
+	maxAge := c.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+	return NewCodec(maxAge, keys...)
+}
+
+// NewCodec creates a Codec that rejects values older than maxAge. It
+// encrypts values with the first key and decrypts values with any key.
+func NewCodec(maxAge time.Duration, keys ...Key) (*Codec, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("securecookie: at least one key is required")
+	}
+	if maxAge <= 0 {
+		return nil, errors.New("securecookie: max age must be positive")
+	}
+
+	c := &Codec{
+		maxAge:  maxAge,
+		primary: keys[0].ID,
+		aeads:   make(map[string]cipher.AEAD, len(keys)),
+	}
+	for _, k := range keys {
+		if k.ID == "" || len(k.ID) > 255 {
+			return nil, fmt.Errorf("securecookie: key ID %q must have between 1 and 255 bytes", k.ID)
+		}
+		if _, ok := c.aeads[k.ID]; ok {
+			return nil, fmt.Errorf("securecookie: duplicate key ID %q", k.ID)
+		}
+		block, err := aes.NewCipher(k.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("securecookie: key %q: %w", k.ID, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("securecookie: key %q: %w", k.ID, err)
+		}
+		c.aeads[k.ID] = aead
+	}
+	return c, nil
+}
+
+// MaxAge returns the maximum age of values.
+func (c *Codec) MaxAge() time.Duration {
+	return c.maxAge
+}
+
+// Encode encrypts a value for the cookie with the given name. The result is
+// safe to use as a cookie value.
+func (c *Codec) Encode(name string, value []byte) (string, error) {
+	return c.encode(name, value, time.Now())
+}
+
+func (c *Codec) encode(name string, value []byte, now time.Time) (string, error) {
+	aead := c.aeads[c.primary]
+
+	// The header is authenticated but not encrypted:
+	// version | len(id) | id | created | nonce
+	header := make([]byte, 0, 2+len(c.primary)+8+aead.NonceSize())
+	header = append(header, version, byte(len(c.primary)))
+	header = append(header, c.primary...)
+	header = binary.BigEndian.AppendUint64(header, uint64(now.Unix()))
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("securecookie: failed to generate nonce: %w", err)
+	}
+	header = append(header, nonce...)
+
+	out := aead.Seal(header, nonce, value, additionalData(name, header))
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Decode decrypts a value created by Encode for the cookie with the given
+// name. It returns ErrExpired if the value is older than the maximum age,
+// ErrUnknownKey if the value was encrypted with a key the codec does not
+// have, and ErrInvalidValue for all other invalid values.
+func (c *Codec) Decode(name, encoded string) ([]byte, error) {
+	return c.decode(name, encoded, time.Now())
+}
+
+func (c *Codec) decode(name, encoded string, now time.Time) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(b) < 2 || b[0] != version {
+		return nil, ErrInvalidValue
+	}
+
+	idLen := int(b[1])
+	if len(b) < 2+idLen+8 {
+		return nil, ErrInvalidValue
+	}
+	aead, ok := c.aeads[string(b[2:2+idLen])]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	headerLen := 2 + idLen + 8 + aead.NonceSize()
+	if len(b) < headerLen+aead.Overhead() {
+		return nil, ErrInvalidValue
+	}
+	header := b[:headerLen]
+	nonce := header[headerLen-aead.NonceSize():]
+
+	value, err := aead.Open(nil, nonce, b[headerLen:], additionalData(name, header))
+	if err != nil {
+		return nil, ErrInvalidValue
+	}
+
+	// Check the age after authenticating so clients cannot learn anything
+	// from forged timestamps
+	created := time.Unix(int64(binary.BigEndian.Uint64(header[2+idLen:])), 0)
+	if now.Sub(created) > c.maxAge {
+		return nil, ErrExpired
+	}
+	return value, nil
+}
+
+// SetCookie encrypts value and sets it as the value of cookie. If the cookie
+// does not set MaxAge or Expires, SetCookie sets MaxAge to the maximum age
+// of the codec.
+func (c *Codec) SetCookie(w http.ResponseWriter, cookie *http.Cookie, value []byte) error {
+	encoded, err := c.Encode(cookie.Name, value)
+	if err != nil {
+		return err
+	}
+
+	cookie.Value = encoded
+	if cookie.MaxAge == 0 && cookie.Expires.IsZero() {
+		cookie.MaxAge = int(c.maxAge.Seconds())
+	}
+	http.SetCookie(w, cookie)
+	return nil
+}
+
+// Cookie returns the decrypted value of the named cookie. It returns
+// http.ErrNoCookie if the request does not have the cookie.
+func (c *Codec) Cookie(r *http.Request, name string) ([]byte, error) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decode(name, cookie.Value)
+}
+
+func additionalData(name string, header []byte) []byte {
+	ad := make([]byte, 0, len(name)+1+len(header))
+	ad = append(ad, name...)
+	ad = append(ad, 0)
+	return append(ad, header...)
+}
@@ -0,0 +1,120 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package securecookie
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodec(t *testing.T) {
+	oldKey, err := GenerateKey("k1")
+	require.NoError(t, err)
+	newKey, err := GenerateKey("k2")
+	require.NoError(t, err)
+
+	oldCodec, err := NewCodec(time.Hour, oldKey)
+	require.NoError(t, err)
+	codec, err := NewCodec(time.Hour, newKey, oldKey)
+	require.NoError(t, err)
+
+	encoded, err := codec.Encode("session", []byte("value"))
+	require.NoError(t, err)
+	value, err := codec.Decode("session", encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "value", string(value))
+
+	_, err = codec.Decode("other", encoded)
+	assert.ErrorIs(t, err, ErrInvalidValue, "values should be bound to the cookie name")
+
+	_, err = oldCodec.Decode("session", encoded)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	rotated, err := oldCodec.Encode("session", []byte("old"))
+	require.NoError(t, err)
+	value, err = codec.Decode("session", rotated)
+	require.NoError(t, err, "values encrypted with old keys should decode")
+	assert.Equal(t, "old", string(value))
+
+	b, _ := base64.RawURLEncoding.DecodeString(encoded)
+	b[len(b)-1] ^= 1
+	_, err = codec.Decode("session", base64.RawURLEncoding.EncodeToString(b))
+	assert.ErrorIs(t, err, ErrInvalidValue)
+
+	for _, s := range []string{"", "!", "AQ", "AQJrMg"} {
+		_, err = codec.Decode("session", s)
+		assert.ErrorIs(t, err, ErrInvalidValue, s)
+	}
+
+	expired, err := codec.encode("session", []byte("value"), time.Now().Add(-2*time.Hour))
+	require.NoError(t, err)
+	_, err = codec.Decode("session", expired)
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestNew(t *testing.T) {
+	secret := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	codec, err := New(Config{Keys: []KeyConfig{{ID: "a", Secret: secret}}})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMaxAge, codec.MaxAge())
+
+	for name, c := range map[string]Config{
+		"noKeys":       {},
+		"badBase64":    {Keys: []KeyConfig{{ID: "a", Secret: "!"}}},
+		"badLength":    {Keys: []KeyConfig{{ID: "a", Secret: "AAAA"}}},
+		"noID":         {Keys: []KeyConfig{{Secret: secret}}},
+		"duplicateIDs": {Keys: []KeyConfig{{ID: "a", Secret: secret}, {ID: "a", Secret: secret}}},
+	} {
+		_, err := New(c)
+		assert.Error(t, err, name)
+	}
+
+	t.Setenv("TEST_COOKIE_KEYS", "b:"+secret+", a:"+secret)
+	t.Setenv("TEST_COOKIE_MAX_AGE", "5m")
+	var c Config
+	c.SetValuesFromEnv("TEST_")
+	assert.Equal(t, []KeyConfig{{ID: "b", Secret: secret}, {ID: "a", Secret: secret}}, c.Keys)
+	assert.Equal(t, 5*time.Minute, c.MaxAge)
+}
+
+func TestCookie(t *testing.T) {
+	key, err := GenerateKey("k")
+	require.NoError(t, err)
+	codec, err := NewCodec(time.Minute, key)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	require.NoError(t, codec.SetCookie(w, &http.Cookie{Name: "state", HttpOnly: true}, []byte("abc")))
+
+	res := w.Result()
+	require.Len(t, res.Cookies(), 1)
+	assert.Equal(t, 60, res.Cookies()[0].MaxAge)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(res.Cookies()[0])
+	value, err := codec.Cookie(r, "state")
+	require.NoError(t, err)
+	assert.Equal(t, "abc", string(value))
+
+	_, err = codec.Cookie(httptest.NewRequest(http.MethodGet, "/", nil), "state")
+	assert.ErrorIs(t, err, http.ErrNoCookie)
+}
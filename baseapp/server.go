@@ -18,7 +18,9 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -26,9 +28,11 @@ import (
 	"sync"
 	"syscall"
 
+	"github.com/palantir/go-baseapp/appmetrics"
 	"github.com/pkg/errors"
 	"github.com/rcrowley/go-metrics"
 	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
 	"goji.io"
 )
 
@@ -43,15 +47,52 @@ type Server struct {
 
 	registry metrics.Registry
 
+	// prefix added to the names of metrics in the registry
+	metricsPrefix string
+
 	// functions that are called once on start
 	initFns []func(*Server)
 	init    sync.Once
+
+	// functions that are called when a graceful shutdown begins
+	shutdownFns []func(context.Context) error
+
+	// checks that must pass before the server starts
+	dependencyChecks []DependencyCheck
+
+	// addresses the server listens on
+	addrs []string
+
+	// function that creates listeners, if not net.Listen
+	listen func(network, addr string) (net.Listener, error)
+
+	// in-memory TLS certificates, used instead of files from the config
+	tlsCertificates []tls.Certificate
+	getCertificate  func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+
+	// authorizer checks the permissions of routes registered with Handle
+	authorizer Authorizer
+
+	// preset selects the logging, timeouts, and debug routes of the server
+	preset MiddlewarePreset
 }
 
 // Param configures a Server instance.
 type Param func(b *Server) error
 
 // NewServer creates a Server instance from configuration and parameters.
+//
+// Unless the parameters set an HTTP server with WithHTTPServer, the server
+// records metrics about TLS handshakes and logs errors reported by the HTTP
+// server, like handshake failures, with the server's logger. Successful
+// handshakes are counted in the "server.tls.handshakes" counter, tagged with
+// the TLS version, and failures in the "server.tls.handshake_errors" counter,
+// tagged with a reason like "protocol_version", "client_certificate", or
+// "sni".
+//
+// The preset from the configuration, or from WithMiddlewarePreset, is applied
+// after the other parameters. NewServer returns an error if the preset is
+// unknown.
 func NewServer(c HTTPConfig, params ...Param) (*Server, error) {
 	logger := zerolog.Nop()
 	base := &Server{
@@ -60,6 +101,7 @@ func NewServer(c HTTPConfig, params ...Param) (*Server, error) {
 		logger:     logger,
 		mux:        goji.NewMux(),
 		registry:   metrics.DefaultRegistry,
+		preset:     c.Preset,
 	}
 
 	for _, p := range params {
@@ -68,6 +110,17 @@ func NewServer(c HTTPConfig, params ...Param) (*Server, error) {
 		}
 	}
 
+	if base.metricsPrefix != "" {
+		// The child registry is only reachable through the server, so track it
+		// to let Tagged metrics skip looking up prefixed names on each call
+		base.registry = appmetrics.NewTrackedRegistry(metrics.NewPrefixedChildRegistry(base.registry, base.metricsPrefix))
+	}
+
+	if err := base.preset.validate(); err != nil {
+		return base, err
+	}
+	base.applyPresetLogger()
+
 	if base.middleware == nil {
 		base.middleware = DefaultMiddleware(base.logger, base.registry)
 	}
@@ -96,16 +149,32 @@ func NewServer(c HTTPConfig, params ...Param) (*Server, error) {
 				},
 			},
 		}
+		base.applyPresetServer(base.server)
+		instrumentTLS(base.server, base.logger, base.registry)
+	}
+
+	if len(base.tlsCertificates) > 0 || base.getCertificate != nil {
+		if c.TLSConfig != nil {
+			return base, errors.New("TLS certificate files cannot be used with in-memory certificates")
+		}
+		if base.server.TLSConfig == nil {
+			base.server.TLSConfig = &tls.Config{}
+		}
+		base.server.TLSConfig.Certificates = append(base.server.TLSConfig.Certificates, base.tlsCertificates...)
+		base.server.TLSConfig.GetCertificate = base.getCertificate
 	}
 
 	if base.server.Addr == "" {
-		addr := c.Address + ":" + strconv.Itoa(c.Port)
-		base.server.Addr = addr
+		base.addrs = c.Addresses()
+		base.server.Addr = base.addrs[0]
+	} else {
+		base.addrs = []string{base.server.Addr}
 	}
 
 	if base.server.Handler == nil {
 		base.server.Handler = base.mux
 	}
+	base.applyPresetRoutes()
 
 	return base, nil
 }
@@ -135,25 +204,130 @@ func (s *Server) Registry() metrics.Registry {
 	return s.registry
 }
 
+// OnStart registers a function that is called once when the server starts,
+// before it accepts connections.
+func (s *Server) OnStart(fn func(*Server)) {
+	s.initFns = append(s.initFns, fn)
+}
+
+// OnShutdown registers a function that is called when the server begins a
+// graceful shutdown, before the HTTP server stops accepting new requests.
+// Functions are called in the reverse of the order they were registered and
+// receive a context that expires at the end of the shutdown wait time.
+//
+// Shutdown functions are only called if the server is configured with a
+// ShutdownWaitTime.
+func (s *Server) OnShutdown(fn func(context.Context) error) {
+	s.shutdownFns = append(s.shutdownFns, fn)
+}
+
 // Start starts the server and blocks.
 func (s *Server) start() error {
+	if len(s.dependencyChecks) > 0 {
+		ctx := s.logger.WithContext(context.Background())
+		if err := CheckDependencies(ctx, s.dependencyChecks...); err != nil {
+			return err
+		}
+	}
+
 	s.init.Do(func() {
 		for _, fn := range s.initFns {
 			fn(s)
 		}
 	})
 
-	addr := s.config.Address + ":" + strconv.Itoa(s.config.Port)
-	s.logger.Info().Msgf("Server listening on %s", addr)
+	if len(s.addrs) > 1 || s.listen != nil {
+		return s.serveAll()
+	}
+
+	s.logger.Info().Msgf("Server listening on %s", s.server.Addr)
 
-	tlsConfig := s.config.TLSConfig
-	if tlsConfig != nil {
-		return s.server.ListenAndServeTLS(tlsConfig.CertFile, tlsConfig.KeyFile)
+	if certFile, keyFile, ok := s.tlsFiles(); ok {
+		return s.server.ListenAndServeTLS(certFile, keyFile)
 	}
 
 	return s.server.ListenAndServe()
 }
 
+// tlsFiles returns the certificate and key files for the server and true if
+// the server uses TLS. The file names are empty if the server uses in-memory
+// certificates.
+func (s *Server) tlsFiles() (string, string, bool) {
+	if c := s.config.TLSConfig; c != nil {
+		return c.CertFile, c.KeyFile, true
+	}
+	return "", "", len(s.tlsCertificates) > 0 || s.getCertificate != nil
+}
+
+// serveAll listens on all of the server's addresses and blocks until the
+// server stops serving on all of them. If listening fails on any address, it
+// closes the other listeners and returns an error that includes all of the
+// failures.
+func (s *Server) serveAll() error {
+	listen := s.listen
+	if listen == nil {
+		listen = net.Listen
+	}
+
+	var listeners []net.Listener
+	var failures []error
+	for _, addr := range s.addrs {
+		l, err := listen("tcp", addr)
+		if err != nil {
+			failures = append(failures, errors.Wrapf(err, "failed to listen on %s", addr))
+			continue
+		}
+		listeners = append(listeners, l)
+	}
+	if len(failures) > 0 {
+		for _, l := range listeners {
+			_ = l.Close()
+		}
+		return stderrors.Join(failures...)
+	}
+
+	for _, l := range listeners {
+		s.logger.Info().Msgf("Server listening on %s", l.Addr())
+	}
+	return s.serveListeners(listeners)
+}
+
+// serveListeners serves requests on all of the listeners and blocks until the
+// server stops serving on all of them. If serving fails on any listener, the
+// server is closed and the returned error includes all failures. Otherwise,
+// it returns http.ErrServerClosed.
+func (s *Server) serveListeners(listeners []net.Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			var err error
+			if certFile, keyFile, ok := s.tlsFiles(); ok {
+				err = s.server.ServeTLS(l, certFile, keyFile)
+			} else {
+				err = s.server.Serve(l)
+			}
+			if err != http.ErrServerClosed {
+				err = errors.Wrapf(err, "failed to serve on %s", l.Addr())
+			}
+			errs <- err
+		}(l)
+	}
+
+	var failures []error
+	for range listeners {
+		if err := <-errs; err != http.ErrServerClosed {
+			if len(failures) == 0 {
+				_ = s.server.Close()
+			}
+			failures = append(failures, err)
+		}
+	}
+	if len(failures) > 0 {
+		return stderrors.Join(failures...)
+	}
+	return http.ErrServerClosed
+}
+
 // Start starts the server and blocks.
 func (s *Server) Start() error {
 	// maintain backwards compatibility
@@ -181,8 +355,26 @@ func (s *Server) Start() error {
 		}
 	}
 
+	return s.shutdown()
+}
+
+// shutdown calls the shutdown functions and gracefully stops the HTTP
+// server, waiting up to the ShutdownWaitTime. If the server does not have a
+// ShutdownWaitTime, it closes the HTTP server immediately.
+func (s *Server) shutdown() error {
+	if s.config.ShutdownWaitTime == nil {
+		return errors.Wrap(s.HTTPServer().Close(), "Failed closing server")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), *s.config.ShutdownWaitTime)
 	defer cancel()
+
+	for i := len(s.shutdownFns) - 1; i >= 0; i-- {
+		if err := s.shutdownFns[i](ctx); err != nil {
+			s.logger.Error().Err(err).Msg("Shutdown function failed")
+		}
+	}
+
 	return errors.Wrap(s.HTTPServer().Shutdown(ctx), "Failed shutting down gracefully")
 }
 
@@ -199,3 +391,37 @@ func WriteJSON(w http.ResponseWriter, status int, obj interface{}) {
 		_, _ = w.Write(b)
 	}
 }
+
+// WriteJSONResponse writes a JSON response like WriteJSON, but reports
+// failures using the request logger. If marshalling obj fails and the
+// response status was not already sent, it writes a 500 problem response
+// using WriteProblem instead of a partial body. Sent statuses are only
+// detected if w is a RecordingResponseWriter, as it is when using the default
+// middleware.
+//
+// WriteJSONResponse returns any marshalling or write error. Because the
+// response is already handled, callers should not pass the error to error
+// handlers that write a new response, like HandleRouteError.
+func WriteJSONResponse(w http.ResponseWriter, r *http.Request, status int, obj interface{}) error {
+	logger := hlog.FromRequest(r)
+
+	b, err := json.Marshal(obj)
+	if err != nil {
+		err = errors.Wrap(err, "failed to marshal JSON response")
+		logger.Error().Err(err).Msg("Failed to write JSON response")
+
+		if rw, ok := w.(RecordingResponseWriter); !ok || rw.Status() == 0 {
+			WriteProblem(w, r, Problem{Status: http.StatusInternalServerError})
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(b); err != nil {
+		err = errors.Wrap(err, "failed to write JSON response")
+		logger.Debug().Err(err).Msg("Failed to write JSON response")
+		return err
+	}
+	return nil
+}
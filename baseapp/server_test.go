@@ -0,0 +1,168 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goji.io/pat"
+)
+
+func TestWriteJSONResponse(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	t.Run("success", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		err := WriteJSONResponse(w, r, http.StatusCreated, map[string]string{"id": "1"})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"id": "1"}`, w.Body.String())
+	})
+
+	t.Run("marshalError", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		err := WriteJSONResponse(w, r, http.StatusOK, map[string]any{"fn": func() {}})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"title": "Internal Server Error", "status": 500}`, w.Body.String())
+	})
+
+	t.Run("marshalErrorAfterWrite", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		rw := WrapWriter(w)
+		rw.WriteHeader(http.StatusAccepted)
+
+		err := WriteJSONResponse(rw, r, http.StatusOK, map[string]any{"fn": func() {}})
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Empty(t, w.Body.String())
+	})
+}
+
+func TestServeMultipleAddresses(t *testing.T) {
+	newServer := func(t *testing.T, address string) *Server {
+		s, err := NewServer(HTTPConfig{Address: address})
+		require.NoError(t, err)
+		s.Mux().HandleFunc(pat.New("/*"), func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		})
+		return s
+	}
+
+	t.Run("serve", func(t *testing.T) {
+		s := newServer(t, "127.0.0.1")
+
+		var listeners []net.Listener
+		for i := 0; i < 2; i++ {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			listeners = append(listeners, l)
+		}
+
+		done := make(chan error)
+		go func() { done <- s.serveListeners(listeners) }()
+
+		for _, l := range listeners {
+			res, err := http.Get("http://" + l.Addr().String())
+			require.NoError(t, err)
+			body, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			assert.Equal(t, "ok", string(body))
+		}
+
+		require.NoError(t, s.HTTPServer().Close())
+		assert.Equal(t, http.ErrServerClosed, <-done)
+	})
+
+	t.Run("serveFailure", func(t *testing.T) {
+		s := newServer(t, "127.0.0.1")
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		acceptErr := errors.New("accept failed")
+		err = s.serveListeners([]net.Listener{l, failingListener{l.Addr(), acceptErr}})
+		assert.ErrorIs(t, err, acceptErr)
+		assert.NotErrorIs(t, err, http.ErrServerClosed)
+	})
+
+	t.Run("listenFailure", func(t *testing.T) {
+		used, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer func() { _ = used.Close() }()
+
+		s := newServer(t, "127.0.0.1:0,"+used.Addr().String()+","+used.Addr().String())
+
+		err = s.serveAll()
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to listen on "+used.Addr().String())
+		assert.Len(t, err.(interface{ Unwrap() []error }).Unwrap(), 2, "error should include all failures")
+	})
+}
+
+type failingListener struct {
+	addr net.Addr
+	err  error
+}
+
+func (l failingListener) Accept() (net.Conn, error) { return nil, l.err }
+func (l failingListener) Close() error              { return nil }
+func (l failingListener) Addr() net.Addr            { return l.addr }
+
+func TestServeTLSCertificate(t *testing.T) {
+	cert, err := SelfSignedCertificate("127.0.0.1")
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s, err := NewServer(HTTPConfig{Address: "127.0.0.1"}, WithTLSCertificate(cert))
+	require.NoError(t, err)
+	s.Mux().HandleFunc(pat.New("/*"), func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+
+	done := make(chan error)
+	go func() { done <- s.serveListeners([]net.Listener{l}) }()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert.Leaf)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	res, err := client.Get("https://" + l.Addr().String())
+	require.NoError(t, err)
+	body, _ := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	assert.Equal(t, "ok", string(body))
+
+	require.NoError(t, s.HTTPServer().Close())
+	assert.Equal(t, http.ErrServerClosed, <-done)
+
+	_, err = NewServer(HTTPConfig{TLSConfig: &TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}}, WithTLSCertificate(cert))
+	assert.Error(t, err, "files and in-memory certificates should conflict")
+}
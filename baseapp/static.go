@@ -0,0 +1,150 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// precompressedEncodings are the content encodings of precompressed files, in
+// order of preference, with the extensions of their files.
+var precompressedEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{encoding: "br", ext: ".br"},
+	{encoding: "gzip", ext: ".gz"},
+}
+
+// NewStaticHandler returns a handler that serves the files in fsys, like
+// http.FileServerFS, and serves precompressed variants of files to clients
+// that accept them. Mount it with http.StripPrefix to serve files under a
+// path:
+//
+//	mux.Handle(pat.Get("/static/*"), http.StripPrefix("/static", baseapp.NewStaticHandler(assets)))
+//
+// If fsys contains "app.js.br" or "app.js.gz" next to "app.js", a request for
+// "/app.js" receives the Brotli or gzip variant with the matching
+// Content-Encoding header, so large files are compressed once at build time
+// instead of on each request. Brotli is preferred when the Accept-Encoding
+// header allows both. Responses for files with variants always include
+// "Vary: Accept-Encoding", so caches store each encoding separately.
+//
+// Variants are only used for files with a content type known from their
+// extension; other files are always served uncompressed.
+func NewStaticHandler(fsys fs.FS) http.Handler {
+	files := http.FileServerFS(fsys)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := staticFileName(r.URL.Path)
+		if !ok || !servePrecompressed(w, r, fsys, name) {
+			files.ServeHTTP(w, r)
+		}
+	})
+}
+
+// staticFileName returns the name in the file system of the file served for
+// the path. It returns false for paths that http.FileServerFS redirects.
+func staticFileName(upath string) (string, bool) {
+	if strings.HasSuffix(upath, "/index.html") {
+		return "", false
+	}
+	name := strings.TrimPrefix(path.Clean("/"+upath), "/")
+	if strings.HasSuffix(upath, "/") {
+		name = path.Join(name, "index.html")
+	}
+	return name, true
+}
+
+// servePrecompressed serves the preferred variant of the file accepted by the
+// client. It returns false if it did not write a response, after setting the
+// Vary header if the file has variants.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	ctype := mime.TypeByExtension(path.Ext(name))
+	if ctype == "" {
+		return false
+	}
+
+	accept := r.Header.Get("Accept-Encoding")
+	hasVariants := false
+	for _, p := range precompressedEncodings {
+		variant, err := fs.Stat(fsys, name+p.ext)
+		if err != nil || variant.IsDir() {
+			continue
+		}
+		hasVariants = true
+		if !acceptsEncoding(accept, p.encoding) {
+			continue
+		}
+
+		f, err := fsys.Open(name + p.ext)
+		if err != nil {
+			continue
+		}
+		content, ok := f.(io.ReadSeeker)
+		if !ok {
+			_ = f.Close()
+			continue
+		}
+
+		// Set the content type of the uncompressed file, so that ServeContent
+		// does not detect it from the compressed bytes
+		h := w.Header()
+		h.Add("Vary", "Accept-Encoding")
+		h.Set("Content-Encoding", p.encoding)
+		h.Set("Content-Type", ctype)
+		http.ServeContent(w, r, name, variant.ModTime(), content)
+		_ = f.Close()
+		return true
+	}
+
+	if hasVariants {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	return false
+}
+
+// acceptsEncoding returns true if the Accept-Encoding header allows the
+// encoding, either by name or with "*", with a non-zero quality.
+func acceptsEncoding(header, encoding string) bool {
+	wildcard := false
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		switch name = strings.TrimSpace(name); {
+		case strings.EqualFold(name, encoding):
+			return q > 0
+		case name == "*":
+			wildcard = q > 0
+		}
+	}
+	return wildcard
+}
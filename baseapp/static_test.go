@@ -0,0 +1,136 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js":             {Data: []byte("console.log('app')")},
+		"app.js.br":          {Data: []byte("brotli")},
+		"app.js.gz":          {Data: []byte("gzip")},
+		"style.css":          {Data: []byte("body {}")},
+		"style.css.gz":       {Data: []byte("gzip css")},
+		"plain.txt":          {Data: []byte("plain")},
+		"data.unknownext":    {Data: []byte("data")},
+		"data.unknownext.gz": {Data: []byte("gzip data")},
+		"docs/index.html":    {Data: []byte("<html></html>")},
+		"docs/index.html.gz": {Data: []byte("gzip html")},
+	}
+	handler := NewStaticHandler(fsys)
+
+	tests := map[string]struct {
+		Path           string
+		AcceptEncoding string
+		Body           string
+		Encoding       string
+		Vary           bool
+	}{
+		"brotliPreferred": {
+			Path:           "/app.js",
+			AcceptEncoding: "gzip, deflate, br",
+			Body:           "brotli",
+			Encoding:       "br",
+			Vary:           true,
+		},
+		"gzipOnly": {
+			Path:           "/app.js",
+			AcceptEncoding: "gzip",
+			Body:           "gzip",
+			Encoding:       "gzip",
+			Vary:           true,
+		},
+		"brotliRefused": {
+			Path:           "/app.js",
+			AcceptEncoding: "br;q=0, *",
+			Body:           "gzip",
+			Encoding:       "gzip",
+			Vary:           true,
+		},
+		"noEncoding": {
+			Path: "/app.js",
+			Body: "console.log('app')",
+			Vary: true,
+		},
+		"missingVariant": {
+			Path:           "/style.css",
+			AcceptEncoding: "br",
+			Body:           "body {}",
+			Vary:           true,
+		},
+		"noVariants": {
+			Path:           "/plain.txt",
+			AcceptEncoding: "gzip, br",
+			Body:           "plain",
+		},
+		"unknownType": {
+			Path:           "/data.unknownext",
+			AcceptEncoding: "gzip",
+			Body:           "data",
+		},
+		"directoryIndex": {
+			Path:           "/docs/",
+			AcceptEncoding: "gzip",
+			Body:           "gzip html",
+			Encoding:       "gzip",
+			Vary:           true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.Path, nil)
+			if test.AcceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", test.AcceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, test.Body, w.Body.String())
+			assert.Equal(t, test.Encoding, w.Header().Get("Content-Encoding"))
+			if test.Vary {
+				assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+			} else {
+				assert.Empty(t, w.Header().Get("Vary"))
+			}
+			if test.Encoding != "" {
+				assert.NotContains(t, w.Header().Get("Content-Type"), "gzip")
+			}
+		})
+	}
+}
+
+func TestStaticHandlerRedirect(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/index.html":    {Data: []byte("<html></html>")},
+		"docs/index.html.gz": {Data: []byte("gzip html")},
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/docs/index.html", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	NewStaticHandler(fsys).ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "./", w.Header().Get("Location"))
+}
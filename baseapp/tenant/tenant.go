@@ -0,0 +1,321 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tenant identifies the tenant of requests in multi-tenant services.
+//
+// The middleware returned by NewHandler uses a Resolver to find the tenant
+// for each request, stores it in the request context, and adds it to the
+// request logger and, with WithTraceHook, to traces. A Tagger converts
+// tenants to metric tags while limiting the number of distinct values.
+package tenant
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/palantir/go-baseapp/baseapp"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	// DefaultOverflowTag is the tag value used by a Tagger for tenants that
+	// exceed the limit.
+	DefaultOverflowTag = "other"
+)
+
+type tenantCtxKey struct{}
+
+// FromContext returns the tenant stored in the context.
+func FromContext(ctx context.Context) (string, bool) {
+	t, ok := ctx.Value(tenantCtxKey{}).(string)
+	return t, ok
+}
+
+// WithTenant stores a tenant in the context.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+// Resolver finds the tenant for a request. It returns the empty string if the
+// request has no tenant.
+type Resolver interface {
+	ResolveTenant(r *http.Request) (string, error)
+}
+
+// ResolverFunc is a function that implements Resolver.
+type ResolverFunc func(r *http.Request) (string, error)
+
+func (fn ResolverFunc) ResolveTenant(r *http.Request) (string, error) {
+	return fn(r)
+}
+
+// FromHeader returns a Resolver that reads the tenant from a request header.
+func FromHeader(name string) Resolver {
+	return ResolverFunc(func(r *http.Request) (string, error) {
+		return strings.TrimSpace(r.Header.Get(name)), nil
+	})
+}
+
+// FromSubdomain returns a Resolver that reads the tenant from the label of the
+// request host immediately before domain, if the host is a subdomain of
+// domain. For example, with the domain "example.com", the tenant for both
+// "acme.example.com" and "api.acme.example.com" is "acme".
+func FromSubdomain(domain string) Resolver {
+	suffix := "." + strings.Trim(strings.ToLower(domain), ".")
+	return ResolverFunc(func(r *http.Request) (string, error) {
+		host := strings.ToLower(r.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return "", nil
+		}
+		sub := strings.TrimSuffix(host, suffix)
+		if i := strings.LastIndexByte(sub, '.'); i >= 0 {
+			sub = sub[i+1:]
+		}
+		return sub, nil
+	})
+}
+
+// FromPathPrefix returns a Resolver that reads the tenant from the path
+// segment following prefix. For example, with the prefix "/tenants/", the
+// tenant for "/tenants/acme/reports" is "acme".
+func FromPathPrefix(prefix string) Resolver {
+	return ResolverFunc(func(r *http.Request) (string, error) {
+		rest, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			return "", nil
+		}
+		tenant, _, _ := strings.Cut(rest, "/")
+		return tenant, nil
+	})
+}
+
+// First returns a Resolver that returns the first non-empty tenant found by
+// the resolvers, in order.
+func First(resolvers ...Resolver) Resolver {
+	return ResolverFunc(func(r *http.Request) (string, error) {
+		for _, res := range resolvers {
+			t, err := res.ResolveTenant(r)
+			if err != nil || t != "" {
+				return t, err
+			}
+		}
+		return "", nil
+	})
+}
+
+// Option configures the middleware returned by NewHandler.
+type Option func(*handler)
+
+// Required rejects requests without a tenant with a 400 response. By
+// default, requests without a tenant are passed to the next handler.
+func Required(required bool) Option {
+	return func(h *handler) {
+		h.required = required
+	}
+}
+
+// WithLogField sets the name of the field that contains the tenant in request
+// logs. If the name is empty, the tenant is not logged. By default, the field
+// is named "tenant".
+func WithLogField(name string) Option {
+	return func(h *handler) {
+		h.logField = name
+	}
+}
+
+// WithTraceHook sets a function that is called with the request context and
+// the tenant for each request that has a tenant. Use it to add the tenant to
+// the active span of the application's tracing library.
+func WithTraceHook(fn func(ctx context.Context, tenant string)) Option {
+	return func(h *handler) {
+		h.traceHook = fn
+	}
+}
+
+type handler struct {
+	resolver  Resolver
+	required  bool
+	logField  string
+	traceHook func(ctx context.Context, tenant string)
+}
+
+// NewHandler returns middleware that resolves the tenant for each request and
+// stores it in the request context. Requests where the resolver returns an
+// error receive a 400 response.
+//
+// The middleware must appear after the logging middleware in the stack for
+// the tenant to appear in request logs.
+func NewHandler(resolver Resolver, opts ...Option) func(http.Handler) http.Handler {
+	h := &handler{
+		resolver: resolver,
+		logField: "tenant",
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t, err := h.resolver.ResolveTenant(r)
+			if err != nil {
+				hlog.FromRequest(r).Debug().Err(err).Msg("Failed to resolve tenant")
+				baseapp.WriteProblem(w, r, baseapp.Problem{
+					Status: http.StatusBadRequest,
+					Detail: "The request tenant is invalid",
+				})
+				return
+			}
+			if t == "" {
+				if h.required {
+					baseapp.WriteProblem(w, r, baseapp.Problem{
+						Status: http.StatusBadRequest,
+						Detail: "The request does not specify a tenant",
+					})
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if h.logField != "" {
+				hlog.FromRequest(r).UpdateContext(func(c zerolog.Context) zerolog.Context {
+					return c.Str(h.logField, t)
+				})
+			}
+			if h.traceHook != nil {
+				h.traceHook(r.Context(), t)
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), t)))
+		})
+	}
+}
+
+// Tagger converts tenants to metric tags. To protect against unbounded
+// cardinality, a Tagger tracks the tenants it has seen and uses an overflow
+// value for all tenants after the first max distinct tenants.
+//
+// Because tenants usually come from client-controlled request data, the first
+// tenants seen after startup are not necessarily the important ones. Use
+// WithAllowed to list tenants that always receive their own tag.
+//
+// Tenants that are not valid tag values, because they contain whitespace,
+// control characters, commas, colons, or square brackets, use the overflow
+// value and do not count against the limit.
+type Tagger struct {
+	key      string
+	max      int
+	overflow string
+
+	mu      sync.RWMutex
+	allowed map[string]struct{}
+	seen    map[string]struct{}
+}
+
+// NewTagger creates a Tagger that allows at most max distinct tenant tags in
+// addition to any allowed tenants. Use a max of zero to only tag allowed
+// tenants.
+func NewTagger(max int) *Tagger {
+	return &Tagger{
+		key:      "tenant",
+		max:      max,
+		overflow: DefaultOverflowTag,
+		allowed:  make(map[string]struct{}),
+		seen:     make(map[string]struct{}),
+	}
+}
+
+// WithAllowed adds tenants that always receive their own tag. Allowed tenants
+// do not count against the limit. Invalid tenants are ignored.
+func (t *Tagger) WithAllowed(tenants ...string) *Tagger {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, tenant := range tenants {
+		if isValidTenantTag(tenant) {
+			t.allowed[tenant] = struct{}{}
+		}
+	}
+	return t
+}
+
+// WithKey sets the tag key. The default key is "tenant".
+func (t *Tagger) WithKey(key string) *Tagger {
+	t.key = key
+	return t
+}
+
+// WithOverflow sets the tag value used for tenants that exceed the limit.
+func (t *Tagger) WithOverflow(value string) *Tagger {
+	t.overflow = value
+	return t
+}
+
+// Tag returns a metric tag for the tenant in the form "key:value".
+func (t *Tagger) Tag(tenant string) string {
+	return t.key + ":" + t.value(tenant)
+}
+
+// TagContext returns a metric tag for the tenant in the context. It uses the
+// overflow value if the context has no tenant.
+func (t *Tagger) TagContext(ctx context.Context) string {
+	tenant, _ := FromContext(ctx)
+	return t.Tag(tenant)
+}
+
+func (t *Tagger) value(tenant string) string {
+	if !isValidTenantTag(tenant) {
+		return t.overflow
+	}
+
+	t.mu.RLock()
+	_, allowed := t.allowed[tenant]
+	_, seen := t.seen[tenant]
+	t.mu.RUnlock()
+	if allowed || seen {
+		return tenant
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.seen[tenant]; ok {
+		return tenant
+	}
+	if len(t.seen) >= t.max {
+		return t.overflow
+	}
+	t.seen[tenant] = struct{}{}
+	return tenant
+}
+
+func isValidTenantTag(tenant string) bool {
+	if tenant == "" {
+		return false
+	}
+	return strings.IndexFunc(tenant, func(c rune) bool {
+		switch c {
+		case ',', ':', '[', ']':
+			return true
+		}
+		return unicode.IsSpace(c) || unicode.IsControl(c)
+	}) < 0
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenant
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvers(t *testing.T) {
+	tests := map[string]struct {
+		Resolver Resolver
+		Setup    func(r *http.Request)
+		Tenant   string
+	}{
+		"header": {
+			Resolver: FromHeader("X-Tenant"),
+			Setup:    func(r *http.Request) { r.Header.Set("X-Tenant", "acme") },
+			Tenant:   "acme",
+		},
+		"subdomain": {
+			Resolver: FromSubdomain("example.com"),
+			Setup:    func(r *http.Request) { r.Host = "api.acme.example.com:8080" },
+			Tenant:   "acme",
+		},
+		"subdomainMismatch": {
+			Resolver: FromSubdomain("example.com"),
+			Setup:    func(r *http.Request) { r.Host = "acme.example.org" },
+		},
+		"pathPrefix": {
+			Resolver: FromPathPrefix("/tenants/"),
+			Setup:    func(r *http.Request) { r.URL.Path = "/tenants/acme/reports" },
+			Tenant:   "acme",
+		},
+		"first": {
+			Resolver: First(FromHeader("X-Tenant"), FromPathPrefix("/tenants/")),
+			Setup:    func(r *http.Request) { r.URL.Path = "/tenants/acme" },
+			Tenant:   "acme",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			test.Setup(r)
+
+			tenant, err := test.Resolver.ResolveTenant(r)
+			assert.NoError(t, err)
+			assert.Equal(t, test.Tenant, tenant)
+		})
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	var tenant, traced string
+	trace := WithTraceHook(func(ctx context.Context, tenant string) {
+		traced = tenant
+	})
+
+	h := NewHandler(FromHeader("X-Tenant"), Required(true), trace)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, _ = FromContext(r.Context())
+	}))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Tenant", "acme")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "acme", tenant)
+	assert.Equal(t, "acme", traced)
+
+	r = httptest.NewRequest("GET", "/", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestTagger(t *testing.T) {
+	tagger := NewTagger(2)
+
+	assert.Equal(t, "tenant:a", tagger.Tag("a"))
+	assert.Equal(t, "tenant:b", tagger.Tag("b"))
+	assert.Equal(t, "tenant:other", tagger.Tag("c"))
+	assert.Equal(t, "tenant:a", tagger.Tag("a"))
+	assert.Equal(t, "tenant:other", tagger.Tag(""))
+
+	t.Run("allowed", func(t *testing.T) {
+		tagger := NewTagger(1).WithAllowed("important")
+
+		assert.Equal(t, "tenant:a", tagger.Tag("a"))
+		assert.Equal(t, "tenant:other", tagger.Tag("b"))
+		assert.Equal(t, "tenant:important", tagger.Tag("important"))
+
+		tagger = NewTagger(0).WithAllowed("important")
+		assert.Equal(t, "tenant:other", tagger.Tag("a"))
+		assert.Equal(t, "tenant:important", tagger.Tag("important"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		tagger := NewTagger(1)
+
+		for _, tenant := range []string{"a,b", "a]", "[a", "a:b", "a b"} {
+			assert.Equal(t, "tenant:other", tagger.Tag(tenant), "invalid tenant %q", tenant)
+		}
+		assert.Equal(t, "tenant:a", tagger.Tag("a"), "invalid tenants should not use slots")
+	})
+}
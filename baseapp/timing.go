@@ -0,0 +1,107 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestTiming splits the time spent serving a request into phases. The
+// phases are measured by AccessHandler and together add up to the elapsed
+// time passed to the access callback.
+type RequestTiming struct {
+	// Middleware is the time between AccessHandler and the handler marked by
+	// NewTimingHandler. It is zero if the request did not reach a timing
+	// handler.
+	Middleware time.Duration
+
+	// Handler is the time spent in the handler, excluding time spent writing
+	// the response. If the request did not reach a timing handler, Handler
+	// includes all middleware after AccessHandler.
+	Handler time.Duration
+
+	// Write is the time spent writing and flushing the response to the
+	// client. Slow clients increase the write time but not the handler time.
+	Write time.Duration
+
+	// FirstByte is the time between the start of the request and when the
+	// response status was sent. It is zero if no response was sent.
+	FirstByte time.Duration
+}
+
+type requestTimingCtxKey struct{}
+
+type requestTiming struct {
+	handlerStart time.Time
+	timing       RequestTiming
+	done         bool
+}
+
+// NewTimingHandler returns middleware that marks the start of the handler for
+// the timing recorded by AccessHandler. Add it after all other middleware, so
+// that the time spent in the middleware stack is reported separately from the
+// handler time. DefaultMiddleware includes this handler.
+func NewTimingHandler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if t, ok := r.Context().Value(requestTimingCtxKey{}).(*requestTiming); ok && t.handlerStart.IsZero() {
+				t.handlerStart = time.Now()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestTimingFromCtx returns the timing of the request with the given
+// context. It returns false if the request was not handled by AccessHandler or
+// if the request is still in progress. Access callbacks can use this to
+// report the phases of each request.
+func RequestTimingFromCtx(ctx context.Context) (RequestTiming, bool) {
+	t, ok := ctx.Value(requestTimingCtxKey{}).(*requestTiming)
+	if !ok || !t.done {
+		return RequestTiming{}, false
+	}
+	return t.timing, true
+}
+
+// finishRequestTiming computes the timing for a request that started at start
+// and finished at end, using the write times tracked by the writer.
+func finishRequestTiming(ctx context.Context, w RecordingResponseWriter, start, end time.Time) {
+	t, ok := ctx.Value(requestTimingCtxKey{}).(*requestTiming)
+	if !ok {
+		return
+	}
+
+	handlerStart := start
+	if !t.handlerStart.IsZero() {
+		handlerStart = t.handlerStart
+		t.timing.Middleware = handlerStart.Sub(start)
+	}
+
+	if tw, ok := w.(interface {
+		writeTiming() (time.Time, time.Duration)
+	}); ok {
+		firstByte, write := tw.writeTiming()
+		if !firstByte.IsZero() {
+			t.timing.FirstByte = firstByte.Sub(start)
+		}
+		t.timing.Write = write
+	}
+
+	t.timing.Handler = max(end.Sub(handlerStart)-t.timing.Write, 0)
+	t.done = true
+}
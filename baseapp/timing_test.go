@@ -0,0 +1,81 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowWriter is a response writer that takes time to write, like a writer
+// connected to a slow client.
+type slowWriter struct {
+	*httptest.ResponseRecorder
+	delay time.Duration
+}
+
+func (w *slowWriter) Write(b []byte) (int, error) {
+	time.Sleep(w.delay)
+	return w.ResponseRecorder.Write(b)
+}
+
+func TestRequestTiming(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	registry := metrics.NewRegistry()
+	RegisterDefaultMetrics(registry)
+
+	var timing RequestTiming
+	var ok bool
+	callback := func(r *http.Request, status int, size int64, elapsed time.Duration) {
+		CountRequest(r, status, size, elapsed)
+		timing, ok = RequestTimingFromCtx(r.Context())
+		assert.Equal(t, elapsed, timing.Middleware+timing.Handler+timing.Write)
+	}
+
+	slowMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(delay)
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	handler := NewMetricsHandler(registry)(AccessHandler(callback)(slowMiddleware(NewTimingHandler()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, inProgress := RequestTimingFromCtx(r.Context())
+		assert.False(t, inProgress, "timing should not be available until the request finishes")
+
+		time.Sleep(delay)
+		_, _ = w.Write([]byte("ok"))
+	})))))
+
+	w := &slowWriter{ResponseRecorder: httptest.NewRecorder(), delay: delay}
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.True(t, ok, "timing should be available in the access callback")
+	assert.GreaterOrEqual(t, timing.Middleware, delay)
+	assert.GreaterOrEqual(t, timing.Handler, delay)
+	assert.GreaterOrEqual(t, timing.Write, delay)
+	assert.GreaterOrEqual(t, timing.FirstByte, timing.Middleware+delay)
+
+	for _, key := range []string{MetricsKeyRequestsMiddlewareLatency, MetricsKeyRequestsHandlerLatency, MetricsKeyRequestsWriteLatency} {
+		assert.Equal(t, int64(1), registry.Get(key).(metrics.Timer).Count(), key)
+	}
+}
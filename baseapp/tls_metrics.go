@@ -0,0 +1,130 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/palantir/go-baseapp/appmetrics"
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+)
+
+const (
+	MetricsKeyTLSHandshakes      = "server.tls.handshakes"
+	MetricsKeyTLSResumed         = "server.tls.resumed"
+	MetricsKeyTLSHandshakeErrors = "server.tls.handshake_errors"
+)
+
+// instrumentTLS configures the server to record metrics about TLS handshakes
+// and to send errors reported by the HTTP server to the logger. See NewServer
+// for the metrics.
+func instrumentTLS(server *http.Server, logger zerolog.Logger, registry metrics.Registry) {
+	if cfg := server.TLSConfig; cfg != nil {
+		verify := cfg.VerifyConnection
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if verify != nil {
+				if err := verify(cs); err != nil {
+					return err
+				}
+			}
+			metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyTLSHandshakes, "version:"+tlsVersionName(cs.Version)), registry).Inc(1)
+			if cs.DidResume {
+				metrics.GetOrRegisterCounter(MetricsKeyTLSResumed, registry).Inc(1)
+			}
+			return nil
+		}
+	}
+
+	if server.ErrorLog == nil {
+		server.ErrorLog = log.New(&serverErrorWriter{logger: logger, registry: registry}, "", 0)
+	}
+}
+
+// serverErrorWriter receives errors logged by an http.Server and writes them
+// to a zerolog logger. It records TLS handshake failures as metrics.
+type serverErrorWriter struct {
+	logger   zerolog.Logger
+	registry metrics.Registry
+}
+
+func (w *serverErrorWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimSpace(p))
+
+	// The http package reports handshake failures with messages like
+	// "http: TLS handshake error from 192.0.2.1:54321: <error>"
+	const prefix = "http: TLS handshake error from "
+	if rest, ok := strings.CutPrefix(msg, prefix); ok {
+		addr, cause, _ := strings.Cut(rest, ": ")
+		reason := tlsErrorReason(cause)
+
+		metrics.GetOrRegisterCounter(appmetrics.TaggedName(MetricsKeyTLSHandshakeErrors, "reason:"+reason), w.registry).Inc(1)
+		w.logger.Warn().
+			Str("client_ip", addr).
+			Str("reason", reason).
+			Str("error", cause).
+			Msg("TLS handshake failed")
+		return len(p), nil
+	}
+
+	w.logger.Error().Str("error", msg).Msg("HTTP server error")
+	return len(p), nil
+}
+
+// tlsErrorReason classifies a TLS handshake error.
+func tlsErrorReason(err string) string {
+	contains := func(substrs ...string) bool {
+		for _, s := range substrs {
+			if strings.Contains(err, s) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case contains("unsupported versions", "protocol version"):
+		return "protocol_version"
+	case contains("no cipher suite", "no mutually supported"):
+		return "cipher_suite"
+	case contains("didn't provide a certificate", "failed to verify certificate", "bad certificate", "certificate required", "client certificate"):
+		return "client_certificate"
+	case contains("unrecognized name", "no certificate available", "server name"):
+		return "sni"
+	case contains("EOF", "connection reset", "broken pipe", "timeout", "i/o timeout"):
+		return "connection"
+	case contains("first record does not look like a TLS handshake"):
+		return "not_tls"
+	}
+	return "other"
+}
+
+func tlsVersionName(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "tls1.0"
+	case tls.VersionTLS11:
+		return "tls1.1"
+	case tls.VersionTLS12:
+		return "tls1.2"
+	case tls.VersionTLS13:
+		return "tls1.3"
+	}
+	return "unknown"
+}
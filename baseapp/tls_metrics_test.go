@@ -0,0 +1,88 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+func TestInstrumentTLS(t *testing.T) {
+	var logs syncBuffer
+	registry := metrics.NewRegistry()
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = &tls.Config{MinVersion: tls.VersionTLS13}
+	ts.Config.TLSConfig = ts.TLS
+	instrumentTLS(ts.Config, zerolog.New(&logs), registry)
+	ts.StartTLS()
+	defer ts.Close()
+
+	count := func(name string) int64 {
+		if c, ok := registry.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	res, err := ts.Client().Get(ts.URL)
+	require.NoError(t, err)
+	_ = res.Body.Close()
+	assert.Equal(t, int64(1), count("server.tls.handshakes[version:tls1.3]"))
+
+	transport := ts.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.MaxVersion = tls.VersionTLS12
+	_, err = (&http.Client{Transport: transport}).Get(ts.URL)
+	require.Error(t, err)
+
+	assert.Eventually(t, func() bool {
+		return count("server.tls.handshake_errors[reason:protocol_version]") == 1
+	}, time.Second, time.Millisecond)
+	assert.Contains(t, logs.String(), `"message":"TLS handshake failed"`)
+}
+
+func TestTLSErrorReason(t *testing.T) {
+	assert.Equal(t, "client_certificate", tlsErrorReason("tls: client didn't provide a certificate"))
+	assert.Equal(t, "protocol_version", tlsErrorReason("tls: client offered only unsupported versions: [303]"))
+	assert.Equal(t, "connection", tlsErrorReason("EOF"))
+	assert.Equal(t, "other", tlsErrorReason("something else"))
+}
@@ -0,0 +1,357 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	MetricsKeyUploadBytes  = "server.uploads.bytes"
+	MetricsKeyUploadFiles  = "server.uploads.files"
+	MetricsKeyUploadErrors = "server.uploads.errors"
+
+	// DefaultMaxUploadPartSize is the default maximum size of each file in a
+	// multipart upload.
+	DefaultMaxUploadPartSize = 1 << 30
+
+	// DefaultMaxUploadParts is the default maximum number of parts in a
+	// multipart upload.
+	DefaultMaxUploadParts = 100
+
+	// DefaultMaxUploadValueSize is the default maximum total size of the
+	// non-file values in a multipart upload.
+	DefaultMaxUploadValueSize = 1 << 20
+)
+
+var (
+	// ErrNotMultipart is returned by ReadUpload when the request is not a
+	// multipart/form-data request.
+	ErrNotMultipart = errors.New("request content type must be multipart/form-data")
+
+	// ErrTooManyParts is returned by ReadUpload when the request contains
+	// more than the maximum number of parts.
+	ErrTooManyParts = errors.New("request contains too many parts")
+)
+
+// BlobSink stores uploaded files. WriteBlob reads the content from r until
+// EOF and stores it with the given key. Implementations must stop and return
+// an error if reading from r fails or if ctx is canceled. If WriteBlob
+// returns an error, it should not leave a partial blob behind.
+//
+// The interface matches the streaming upload functions of most object
+// storage clients, so an S3-compatible store can implement it by passing r
+// as the body of a PutObject request.
+type BlobSink interface {
+	WriteBlob(ctx context.Context, key string, r io.Reader) error
+}
+
+// BlobSinkFunc is a function that implements BlobSink.
+type BlobSinkFunc func(ctx context.Context, key string, r io.Reader) error
+
+func (f BlobSinkFunc) WriteBlob(ctx context.Context, key string, r io.Reader) error {
+	return f(ctx, key, r)
+}
+
+// NewFileSink returns a BlobSink that stores blobs as files in a directory,
+// using the key as the file name. Files are written to a temporary file and
+// renamed after the content is complete. Keys that are not local file names,
+// like "../file", are rejected.
+func NewFileSink(dir string) BlobSink {
+	return BlobSinkFunc(func(ctx context.Context, key string, r io.Reader) error {
+		if !filepath.IsLocal(key) {
+			return errors.Errorf("invalid blob key %q", key)
+		}
+		path := filepath.Join(dir, key)
+
+		f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+		if err != nil {
+			return errors.Wrap(err, "failed to create blob file")
+		}
+		defer func() {
+			_ = f.Close()
+			_ = os.Remove(f.Name())
+		}()
+
+		if _, err := io.Copy(f, r); err != nil {
+			return errors.Wrap(err, "failed to write blob file")
+		}
+		if err := f.Close(); err != nil {
+			return errors.Wrap(err, "failed to write blob file")
+		}
+		return errors.Wrap(os.Rename(f.Name(), path), "failed to rename blob file")
+	})
+}
+
+// UploadedFile describes a file part of a multipart upload that was stored
+// in a BlobSink.
+type UploadedFile struct {
+	// FormName is the name of the form field that contained the file.
+	FormName string
+
+	// FileName is the file name sent by the client. Do not use it as a path
+	// without sanitizing it.
+	FileName string
+
+	// ContentType is the content type of the part sent by the client.
+	ContentType string
+
+	// Key is the key of the blob in the sink.
+	Key string
+
+	// Size is the size of the file in bytes.
+	Size int64
+
+	// SHA256 is the hex-encoded SHA-256 checksum of the file.
+	SHA256 string
+}
+
+// Upload is the result of reading a multipart upload.
+type Upload struct {
+	// Files are the file parts stored in the sink, in request order.
+	Files []UploadedFile
+
+	// Values are the non-file parts of the request.
+	Values url.Values
+}
+
+// UploadOption configures ReadUpload.
+type UploadOption func(*uploadConfig)
+
+// WithMaxUploadPartSize sets the maximum size of each file in an upload. The
+// default is DefaultMaxUploadPartSize. If size is zero or negative, the size
+// is not limited.
+func WithMaxUploadPartSize(size int64) UploadOption {
+	return func(c *uploadConfig) {
+		c.maxPartSize = size
+	}
+}
+
+// WithMaxUploadParts sets the maximum number of parts, including non-file
+// parts, in an upload. The default is DefaultMaxUploadParts. If n is zero or
+// negative, the number of parts is not limited.
+func WithMaxUploadParts(n int) UploadOption {
+	return func(c *uploadConfig) {
+		c.maxParts = n
+	}
+}
+
+// WithMaxUploadValueSize sets the maximum total size of the non-file values in
+// an upload. The default is DefaultMaxUploadValueSize.
+func WithMaxUploadValueSize(size int64) UploadOption {
+	return func(c *uploadConfig) {
+		c.maxValueSize = size
+	}
+}
+
+// WithBlobKey sets the function that picks the sink key for each file part.
+// By default, each file uses a random hex key.
+func WithBlobKey(fn func(r *http.Request, p *multipart.Part) string) UploadOption {
+	return func(c *uploadConfig) {
+		c.key = fn
+	}
+}
+
+// WithUploadProgress sets a function that is called after each read of a file
+// part with the number of bytes of the part read so far. The function is
+// called from the goroutine that reads the upload.
+func WithUploadProgress(fn func(p *multipart.Part, n int64)) UploadOption {
+	return func(c *uploadConfig) {
+		c.progress = fn
+	}
+}
+
+type uploadConfig struct {
+	maxPartSize  int64
+	maxParts     int
+	maxValueSize int64
+	key          func(*http.Request, *multipart.Part) string
+	progress     func(*multipart.Part, int64)
+}
+
+// ReadUpload reads a multipart/form-data request and streams each file part
+// to the sink as it is read, so uploads are never buffered in memory. Parts
+// without a file name are read into the Values of the returned Upload.
+//
+// Reading stops with an error if a file is larger than the maximum part size,
+// if the request has too many parts, or if the request context is canceled.
+// A file that exceeds the maximum size fails with an *http.MaxBytesError,
+// which JSONHandler and most callers should report with status 413. When
+// ReadUpload returns an error, the returned Upload contains the files that
+// were stored before the error, so the caller can delete them.
+//
+// Uploaded bytes are counted in the "server.uploads.bytes" counter as they are
+// read and stored files in the "server.uploads.files" counter. Failures are
+// counted in the "server.uploads.errors" counter, tagged with a reason like
+// "invalid", "parts", "size", "canceled", or "sink".
+func ReadUpload(r *http.Request, sink BlobSink, opts ...UploadOption) (*Upload, error) {
+	c := &uploadConfig{
+		maxPartSize:  DefaultMaxUploadPartSize,
+		maxParts:     DefaultMaxUploadParts,
+		maxValueSize: DefaultMaxUploadValueSize,
+		key:          randomBlobKey,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	ctx := r.Context()
+	upload := &Upload{Values: make(url.Values)}
+
+	fail := func(reason string, err error) (*Upload, error) {
+		CounterFromCtx(ctx, MetricsKeyUploadErrors, "reason:"+reason).Inc(1)
+		return upload, err
+	}
+
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return fail("invalid", ErrNotMultipart)
+	}
+
+	mr := multipart.NewReader(r.Body, params["boundary"])
+	valueSize := int64(0)
+
+	for n := 0; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return fail("canceled", err)
+		}
+
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return upload, nil
+		}
+		if err != nil {
+			return fail("invalid", errors.Wrap(err, "failed to read multipart request"))
+		}
+		if c.maxParts > 0 && n >= c.maxParts {
+			_ = p.Close()
+			return fail("parts", ErrTooManyParts)
+		}
+
+		if p.FileName() == "" {
+			b, err := io.ReadAll(io.LimitReader(p, c.maxValueSize-valueSize+1))
+			_ = p.Close()
+			if err != nil {
+				return fail("invalid", errors.Wrap(err, "failed to read multipart request"))
+			}
+			if valueSize += int64(len(b)); valueSize > c.maxValueSize {
+				return fail("size", &http.MaxBytesError{Limit: c.maxValueSize})
+			}
+			upload.Values.Add(p.FormName(), string(b))
+			continue
+		}
+
+		pr := &uploadPartReader{
+			ctx:      ctx,
+			part:     p,
+			hash:     sha256.New(),
+			maxSize:  c.maxPartSize,
+			progress: c.progress,
+		}
+		file := UploadedFile{
+			FormName:    p.FormName(),
+			FileName:    p.FileName(),
+			ContentType: p.Header.Get("Content-Type"),
+			Key:         c.key(r, p),
+		}
+
+		err = sink.WriteBlob(ctx, file.Key, pr)
+		_ = p.Close()
+
+		// Prefer errors from reading the part, which sinks may wrap
+		if pr.err != nil {
+			return fail(pr.reason, pr.err)
+		}
+		if err != nil {
+			return fail("sink", errors.Wrapf(err, "failed to store file %q", file.FileName))
+		}
+
+		file.Size = pr.n
+		file.SHA256 = hex.EncodeToString(pr.hash.Sum(nil))
+		upload.Files = append(upload.Files, file)
+		CounterFromCtx(ctx, MetricsKeyUploadFiles).Inc(1)
+	}
+}
+
+// uploadPartReader reads a file part, computing its checksum and enforcing
+// the size limit and context cancellation.
+type uploadPartReader struct {
+	ctx      context.Context
+	part     *multipart.Part
+	hash     hash.Hash
+	maxSize  int64
+	progress func(*multipart.Part, int64)
+
+	n      int64
+	err    error
+	reason string
+}
+
+func (u *uploadPartReader) Read(p []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	if err := u.ctx.Err(); err != nil {
+		return 0, u.fail("canceled", err)
+	}
+
+	// Read one byte past the limit to detect parts that are too large
+	if u.maxSize > 0 && int64(len(p)) > u.maxSize-u.n+1 {
+		p = p[:u.maxSize-u.n+1]
+	}
+
+	n, err := u.part.Read(p)
+	if u.maxSize > 0 && u.n+int64(n) > u.maxSize {
+		n = int(u.maxSize - u.n)
+		err = u.fail("size", &http.MaxBytesError{Limit: u.maxSize})
+	}
+
+	u.n += int64(n)
+	u.hash.Write(p[:n])
+	CounterFromCtx(u.ctx, MetricsKeyUploadBytes).Inc(int64(n))
+	if u.progress != nil && n > 0 {
+		u.progress(u.part, u.n)
+	}
+
+	if err != nil && err != io.EOF && u.err == nil {
+		err = u.fail("invalid", errors.Wrap(err, "failed to read multipart request"))
+	}
+	return n, err
+}
+
+func (u *uploadPartReader) fail(reason string, err error) error {
+	u.reason = reason
+	u.err = err
+	return err
+}
+
+func randomBlobKey(*http.Request, *multipart.Part) string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rcrowley/go-metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadUpload(t *testing.T) {
+	registry := metrics.NewRegistry()
+	count := func(name string) int64 {
+		if c, ok := registry.Get(name).(metrics.Counter); ok {
+			return c.Count()
+		}
+		return 0
+	}
+
+	type part struct {
+		name, file, content string
+	}
+	newRequest := func(ctx context.Context, parts ...part) *http.Request {
+		var b bytes.Buffer
+		mw := multipart.NewWriter(&b)
+		for _, p := range parts {
+			var w io.Writer
+			if p.file != "" {
+				w, _ = mw.CreateFormFile(p.name, p.file)
+			} else {
+				w, _ = mw.CreateFormField(p.name)
+			}
+			_, _ = io.WriteString(w, p.content)
+		}
+		_ = mw.Close()
+
+		r := httptest.NewRequest(http.MethodPost, "/upload", &b)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		return r.WithContext(WithMetricsCtx(ctx, registry))
+	}
+
+	checksum := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+
+	t.Run("fileSink", func(t *testing.T) {
+		dir := t.TempDir()
+		keys := 0
+		key := func(*http.Request, *multipart.Part) string {
+			keys++
+			return "blob" + string(rune('0'+keys))
+		}
+
+		var progress []int64
+		r := newRequest(context.Background(),
+			part{name: "title", content: "report"},
+			part{name: "data", file: "a.txt", content: "hello"},
+			part{name: "data", file: "b.txt", content: strings.Repeat("b", 10000)},
+		)
+		upload, err := ReadUpload(r, NewFileSink(dir), WithBlobKey(key), WithUploadProgress(func(_ *multipart.Part, n int64) {
+			progress = append(progress, n)
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, "report", upload.Values.Get("title"))
+		require.Len(t, upload.Files, 2)
+		assert.Equal(t, UploadedFile{
+			FormName:    "data",
+			FileName:    "a.txt",
+			ContentType: "application/octet-stream",
+			Key:         "blob1",
+			Size:        5,
+			SHA256:      checksum("hello"),
+		}, upload.Files[0])
+		assert.Equal(t, int64(10000), upload.Files[1].Size)
+		assert.Equal(t, checksum(strings.Repeat("b", 10000)), upload.Files[1].SHA256)
+
+		data, err := os.ReadFile(filepath.Join(dir, "blob1"))
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 2, "temporary files were not removed")
+
+		assert.Equal(t, int64(10000), progress[len(progress)-1])
+		assert.Equal(t, int64(10005), count(MetricsKeyUploadBytes))
+		assert.Equal(t, int64(2), count(MetricsKeyUploadFiles))
+	})
+
+	t.Run("partTooLarge", func(t *testing.T) {
+		dir := t.TempDir()
+		r := newRequest(context.Background(),
+			part{name: "a", file: "a.txt", content: "small"},
+			part{name: "b", file: "b.txt", content: strings.Repeat("b", 1000)},
+		)
+		upload, err := ReadUpload(r, NewFileSink(dir), WithMaxUploadPartSize(100))
+
+		var maxBytesErr *http.MaxBytesError
+		require.True(t, errors.As(err, &maxBytesErr), "unexpected error: %v", err)
+		assert.Equal(t, int64(100), maxBytesErr.Limit)
+		assert.Len(t, upload.Files, 1, "stored files are returned with the error")
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "partial file was not removed")
+		assert.Equal(t, int64(1), count(MetricsKeyUploadErrors+"[reason:size]"))
+	})
+
+	t.Run("tooManyParts", func(t *testing.T) {
+		r := newRequest(context.Background(),
+			part{name: "a", content: "1"},
+			part{name: "b", content: "2"},
+			part{name: "c", content: "3"},
+		)
+		_, err := ReadUpload(r, NewFileSink(t.TempDir()), WithMaxUploadParts(2))
+		assert.ErrorIs(t, err, ErrTooManyParts)
+		assert.Equal(t, int64(1), count(MetricsKeyUploadErrors+"[reason:parts]"))
+	})
+
+	t.Run("valueTooLarge", func(t *testing.T) {
+		r := newRequest(context.Background(), part{name: "a", content: strings.Repeat("a", 100)})
+		_, err := ReadUpload(r, NewFileSink(t.TempDir()), WithMaxUploadValueSize(10))
+
+		var maxBytesErr *http.MaxBytesError
+		assert.True(t, errors.As(err, &maxBytesErr), "unexpected error: %v", err)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		r := newRequest(ctx, part{name: "a", file: "a.txt", content: strings.Repeat("a", 100)})
+
+		sink := BlobSinkFunc(func(ctx context.Context, key string, r io.Reader) error {
+			cancel()
+			_, err := io.Copy(io.Discard, r)
+			return err
+		})
+		upload, err := ReadUpload(r, sink)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, upload.Files)
+		assert.Equal(t, int64(1), count(MetricsKeyUploadErrors+"[reason:canceled]"))
+	})
+
+	t.Run("sinkError", func(t *testing.T) {
+		r := newRequest(context.Background(), part{name: "a", file: "a.txt", content: "a"})
+
+		sink := BlobSinkFunc(func(ctx context.Context, key string, r io.Reader) error {
+			return errors.New("storage unavailable")
+		})
+		_, err := ReadUpload(r, sink)
+		assert.ErrorContains(t, err, "storage unavailable")
+		assert.Equal(t, int64(1), count(MetricsKeyUploadErrors+"[reason:sink]"))
+	})
+
+	t.Run("notMultipart", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}"))
+		r.Header.Set("Content-Type", "application/json")
+		_, err := ReadUpload(r, NewFileSink(t.TempDir()))
+		assert.ErrorIs(t, err, ErrNotMultipart)
+	})
+
+	t.Run("invalidKey", func(t *testing.T) {
+		r := newRequest(context.Background(), part{name: "a", file: "a.txt", content: "a"})
+		key := func(*http.Request, *multipart.Part) string { return "../escape" }
+		_, err := ReadUpload(r, NewFileSink(t.TempDir()), WithBlobKey(key))
+		assert.ErrorContains(t, err, "invalid blob key")
+	})
+}
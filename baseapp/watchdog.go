@@ -0,0 +1,235 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog/hlog"
+)
+
+const (
+	MetricsKeyLongRunningRequests = "server.requests.long_running"
+)
+
+// watchdogLabel is the profiler label that identifies the goroutines serving
+// a tracked request.
+const watchdogLabel = "watchdog_request"
+
+type watchdogCtxKey struct{}
+
+// Watchdog tracks in-flight requests and reports requests that run for longer
+// than a threshold. Each long-running request is logged once with the stack
+// traces of the goroutines serving it. The watchdog also maintains a gauge
+// with the current number of long-running requests.
+//
+// Requests are tracked by the middleware returned by AccessHandler, which
+// uses the watchdog from the request context. Add the middleware returned by
+// Handler before the access middleware, or use StartWatchdog, and then call
+// Run or Check periodically.
+//
+// To find stack traces, tracked requests run with a profiler label. Stacks
+// are only collected when a request first exceeds the threshold.
+type Watchdog struct {
+	threshold   time.Duration
+	longRunning metrics.Gauge
+	nextID      atomic.Uint64
+
+	mu       sync.Mutex
+	requests map[*inflightRequest]struct{}
+	cancel   context.CancelFunc
+}
+
+type inflightRequest struct {
+	r        *http.Request
+	start    time.Time
+	id       string
+	reported bool
+}
+
+// NewWatchdog creates a Watchdog that reports requests that run for longer
+// than threshold. It registers the long-running request gauge in registry.
+func NewWatchdog(registry metrics.Registry, threshold time.Duration) *Watchdog {
+	return &Watchdog{
+		threshold:   threshold,
+		longRunning: metrics.GetOrRegisterGauge(MetricsKeyLongRunningRequests, registry),
+		requests:    make(map[*inflightRequest]struct{}),
+	}
+}
+
+// Handler returns middleware that adds the watchdog to the request context so
+// that the access middleware tracks requests.
+func (wd *Watchdog) Handler() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), watchdogCtxKey{}, wd)))
+		})
+	}
+}
+
+func watchdogFromContext(ctx context.Context) *Watchdog {
+	wd, _ := ctx.Value(watchdogCtxKey{}).(*Watchdog)
+	return wd
+}
+
+// serve calls next while tracking the request. The request is considered to
+// start at the given time.
+func (wd *Watchdog) serve(w http.ResponseWriter, r *http.Request, start time.Time, next http.Handler) {
+	req := &inflightRequest{
+		r:     r,
+		start: start,
+		id:    strconv.FormatUint(wd.nextID.Add(1), 10),
+	}
+
+	wd.mu.Lock()
+	wd.requests[req] = struct{}{}
+	wd.mu.Unlock()
+
+	defer func() {
+		wd.mu.Lock()
+		delete(wd.requests, req)
+		wd.mu.Unlock()
+	}()
+
+	pprof.Do(r.Context(), pprof.Labels(watchdogLabel, req.id), func(ctx context.Context) {
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Run calls Check at the given interval until the context is canceled or Stop
+// is called.
+func (wd *Watchdog) Run(ctx context.Context, interval time.Duration) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wd.mu.Lock()
+	wd.cancel = cancel
+	wd.mu.Unlock()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			wd.Check()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stop stops the current call to Run.
+func (wd *Watchdog) Stop() {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	if wd.cancel != nil {
+		wd.cancel()
+	}
+}
+
+// Check updates the long-running request gauge and logs any requests that
+// exceeded the threshold since the last check. It returns the number of
+// long-running requests.
+func (wd *Watchdog) Check() int {
+	now := time.Now()
+
+	var count int
+	var report []*inflightRequest
+
+	wd.mu.Lock()
+	for req := range wd.requests {
+		if now.Sub(req.start) < wd.threshold {
+			continue
+		}
+		count++
+		if !req.reported {
+			req.reported = true
+			report = append(report, req)
+		}
+	}
+	wd.mu.Unlock()
+
+	wd.longRunning.Update(int64(count))
+
+	if len(report) > 0 {
+		profile := goroutineProfile()
+		for _, req := range report {
+			hlog.FromRequest(req.r).Warn().
+				Str("method", req.r.Method).
+				Str("path", req.r.URL.String()).
+				Dur("elapsed", now.Sub(req.start)).
+				Str("stack", string(labeledStacks(profile, req.id))).
+				Msg("Request exceeded watchdog threshold")
+		}
+	}
+
+	return count
+}
+
+// StartWatchdog creates a Watchdog using the server's registry and adds it to
+// the context of all requests handled by the server. The watchdog checks
+// requests at the given interval while the server is running and stops when
+// the server begins a graceful shutdown or when Stop is called. Requests are
+// only tracked if the server's middleware includes AccessHandler, as the
+// default middleware does. Call StartWatchdog before starting the server.
+func StartWatchdog(s *Server, threshold, interval time.Duration) *Watchdog {
+	wd := NewWatchdog(s.Registry(), threshold)
+
+	hs := s.HTTPServer()
+	hs.Handler = wd.Handler()(hs.Handler)
+
+	s.OnStart(func(*Server) {
+		go wd.Run(context.Background(), interval)
+	})
+	s.OnShutdown(func(context.Context) error {
+		wd.Stop()
+		return nil
+	})
+
+	return wd
+}
+
+// goroutineProfile returns the stack traces of all goroutines, grouped by
+// stack and profiler labels.
+func goroutineProfile() []byte {
+	var b bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&b, 1); err != nil {
+		return nil
+	}
+	return b.Bytes()
+}
+
+// labeledStacks extracts the stack traces of the goroutines with the
+// watchdog label id from the output of goroutineProfile.
+func labeledStacks(profile []byte, id string) []byte {
+	label := []byte(strconv.Quote(watchdogLabel) + ":" + strconv.Quote(id))
+
+	var stacks [][]byte
+	for _, stack := range bytes.Split(profile, []byte("\n\n")) {
+		if bytes.Contains(stack, label) {
+			stacks = append(stacks, stack)
+		}
+	}
+	return bytes.Join(stacks, []byte("\n\n"))
+}
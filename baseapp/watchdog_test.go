@@ -0,0 +1,122 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+	"time"
+
+	"github.com/rcrowley/go-metrics"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/hlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goji.io/pat"
+)
+
+func TestWatchdog(t *testing.T) {
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+
+	registry := metrics.NewRegistry()
+	wd := NewWatchdog(registry, 10*time.Millisecond)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	access := AccessHandler(func(*http.Request, int, int64, time.Duration) {})
+	handler := hlog.NewHandler(logger)(wd.Handler()(access(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+
+	<-started
+	assert.Equal(t, 0, wd.Check(), "new request should not be long-running")
+
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 1, wd.Check())
+	assert.Equal(t, 1, wd.Check(), "request should still be long-running")
+	assert.Equal(t, int64(1), registry.Get(MetricsKeyLongRunningRequests).(metrics.Gauge).Value())
+
+	close(release)
+	<-done
+
+	assert.Equal(t, 0, wd.Check())
+	assert.Equal(t, int64(0), registry.Get(MetricsKeyLongRunningRequests).(metrics.Gauge).Value())
+
+	lines := bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n"))
+	require.Len(t, lines, 1, "request should only be logged once")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(lines[0], &entry))
+	assert.Equal(t, "/slow", entry["path"])
+	assert.Contains(t, entry["stack"], "TestWatchdog", "stack should include the handler goroutine")
+}
+
+func TestLabeledStacks(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+
+	started := make(chan struct{})
+	go pprof.Do(context.Background(), pprof.Labels(watchdogLabel, "1"), func(context.Context) {
+		close(started)
+		<-done
+	})
+	<-started
+
+	profile := goroutineProfile()
+	assert.Contains(t, string(labeledStacks(profile, "1")), "TestLabeledStacks")
+	assert.Empty(t, labeledStacks(profile, "10"), "labels should match exactly")
+}
+
+func TestStartWatchdog(t *testing.T) {
+	s, err := NewServer(HTTPConfig{}, WithRegistry(metrics.NewRegistry()))
+	require.NoError(t, err)
+
+	wd := StartWatchdog(s, time.Second, time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		wd.Run(context.Background(), time.Millisecond)
+	}()
+
+	require.Eventually(t, func() bool {
+		wd.mu.Lock()
+		defer wd.mu.Unlock()
+		return wd.cancel != nil
+	}, time.Second, time.Millisecond)
+
+	wd.Stop()
+	<-done
+
+	var tracked *Watchdog
+	s.Mux().HandleFunc(pat.Get("/"), func(w http.ResponseWriter, r *http.Request) {
+		tracked = watchdogFromContext(r.Context())
+	})
+	s.HTTPServer().Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Same(t, wd, tracked, "requests should have the watchdog in their context")
+}
@@ -0,0 +1,89 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// RequestMatcher reports whether a request matches a condition.
+type RequestMatcher func(r *http.Request) bool
+
+// When returns middleware that applies mw only to requests that match. Other
+// requests skip mw and are passed directly to the next handler. Use When to
+// avoid running expensive middleware, like authentication or body capture, on
+// routes that do not need it while keeping a single middleware stack:
+//
+//	baseapp.When(baseapp.Not(baseapp.MatchPathPrefix("/health")), auth)
+//
+// The middleware returned by mw is created once, when the stack is built, and
+// not for each request.
+func When(match RequestMatcher, mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if match(r) {
+				wrapped.ServeHTTP(w, r)
+			} else {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// MatchPathPrefix returns a RequestMatcher that matches requests with a path
+// that starts with any of the prefixes. The prefixes are compared to the
+// decoded path without considering path segments, so "/api" matches both
+// "/api/users" and "/apis".
+func MatchPathPrefix(prefixes ...string) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchMethod returns a RequestMatcher that matches requests with any of the
+// methods. Methods are case-sensitive.
+func MatchMethod(methods ...string) RequestMatcher {
+	return func(r *http.Request) bool {
+		return slices.Contains(methods, r.Method)
+	}
+}
+
+// MatchHeader returns a RequestMatcher that matches requests with a header
+// with the given name and value. If value is empty, it matches requests that
+// have the header with any value.
+func MatchHeader(name, value string) RequestMatcher {
+	return func(r *http.Request) bool {
+		values := r.Header.Values(name)
+		if value == "" {
+			return len(values) > 0
+		}
+		return slices.Contains(values, value)
+	}
+}
+
+// Not returns a RequestMatcher that matches requests that do not match m.
+func Not(m RequestMatcher) RequestMatcher {
+	return func(r *http.Request) bool {
+		return !m(r)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Palantir Technologies, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baseapp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhen(t *testing.T) {
+	var calls int
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			next.ServeHTTP(w, r)
+		})
+	}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := map[string]struct {
+		Matcher RequestMatcher
+		Method  string
+		Path    string
+		Header  http.Header
+		Match   bool
+	}{
+		"pathPrefix": {
+			Matcher: MatchPathPrefix("/health", "/api/"),
+			Path:    "/api/users",
+			Match:   true,
+		},
+		"pathPrefixMismatch": {
+			Matcher: MatchPathPrefix("/health", "/api/"),
+			Path:    "/static/app.js",
+		},
+		"method": {
+			Matcher: MatchMethod(http.MethodPost, http.MethodPut),
+			Method:  http.MethodPut,
+			Path:    "/",
+			Match:   true,
+		},
+		"methodMismatch": {
+			Matcher: MatchMethod(http.MethodPost, http.MethodPut),
+			Path:    "/",
+		},
+		"headerPresent": {
+			Matcher: MatchHeader("Authorization", ""),
+			Path:    "/",
+			Header:  http.Header{"Authorization": {"Bearer token"}},
+			Match:   true,
+		},
+		"headerValue": {
+			Matcher: MatchHeader("X-Debug", "true"),
+			Path:    "/",
+			Header:  http.Header{"X-Debug": {"false", "true"}},
+			Match:   true,
+		},
+		"headerMissing": {
+			Matcher: MatchHeader("X-Debug", ""),
+			Path:    "/",
+		},
+		"not": {
+			Matcher: Not(MatchPathPrefix("/health")),
+			Path:    "/health",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			calls = 0
+
+			method := test.Method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, test.Path, nil)
+			for k, v := range test.Header {
+				r.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+
+			When(test.Matcher, mw)(ok).ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusOK, w.Code, "next handler was not called")
+			if test.Match {
+				assert.Equal(t, 1, calls, "middleware was not called")
+			} else {
+				assert.Equal(t, 0, calls, "middleware was called")
+			}
+		})
+	}
+}
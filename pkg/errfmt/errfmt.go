@@ -62,6 +62,67 @@ func Print(err error) string {
 	return err.Error() + fmtStack(deepestStack)
 }
 
+// Cause describes one error in the chain of causes of an error.
+type Cause struct {
+	// Message is the message of the error, including the messages of its
+	// causes.
+	Message string
+
+	// Type is the name of the error's type, like "*errors.errorString".
+	Type string
+
+	// HasStack is true if the error includes a stack trace.
+	HasStack bool
+}
+
+// Causes returns the chain of causes of err, starting with err itself and
+// ending with the root cause. It follows both Cause and Unwrap methods. For
+// errors that wrap multiple errors, the causes of each wrapped error are
+// included in order. It returns nil if err is nil.
+func Causes(err error) []Cause {
+	var causes []Cause
+	var visit func(error)
+	visit = func(err error) {
+		for err != nil {
+			c := Cause{
+				Message: err.Error(),
+				Type:    fmt.Sprintf("%T", err),
+			}
+			switch err.(type) {
+			case pkgErrorsStackTracer, runtimeStackTracer:
+				c.HasStack = true
+			}
+			causes = append(causes, c)
+
+			switch e := err.(type) {
+			case causer:
+				err = e.Cause()
+			case interface{ Unwrap() error }:
+				err = e.Unwrap()
+			case interface{ Unwrap() []error }:
+				for _, inner := range e.Unwrap() {
+					visit(inner)
+				}
+				return
+			default:
+				return
+			}
+		}
+	}
+	visit(err)
+	return causes
+}
+
+// RootCause returns the last error in the chain of causes of err. It returns
+// false if err is nil.
+func RootCause(err error) (Cause, bool) {
+	causes := Causes(err)
+	if len(causes) == 0 {
+		return Cause{}, false
+	}
+	return causes[len(causes)-1], true
+}
+
 func fmtStack(tracer interface{}) string {
 	switch t := tracer.(type) {
 	case pkgErrorsStackTracer:
@@ -16,6 +16,7 @@ package errfmt
 
 import (
 	"errors"
+	"fmt"
 	"runtime"
 	"strings"
 	"testing"
@@ -120,3 +121,41 @@ func newStackTraceError(msg string) error {
 
 func (ste stackTraceError) Error() string               { return ste.msg }
 func (ste stackTraceError) StackTrace() []runtime.Frame { return ste.st }
+
+func TestCauses(t *testing.T) {
+	t.Run("nilError", func(t *testing.T) {
+		assert.Nil(t, Causes(nil))
+	})
+
+	t.Run("chain", func(t *testing.T) {
+		root := errors.New("root")
+		err := fmt.Errorf("outer: %w", pkgerrors.WithMessage(root, "middle"))
+
+		causes := Causes(err)
+		require.Len(t, causes, 3)
+
+		assert.Equal(t, Cause{Message: "outer: middle: root", Type: "*fmt.wrapError"}, causes[0])
+		assert.Equal(t, "*errors.withMessage", causes[1].Type)
+		assert.Equal(t, Cause{Message: "root", Type: "*errors.errorString"}, causes[2])
+	})
+
+	t.Run("stack", func(t *testing.T) {
+		causes := Causes(pkgerrors.New("root"))
+		require.Len(t, causes, 1)
+		assert.True(t, causes[0].HasStack)
+	})
+
+	t.Run("joined", func(t *testing.T) {
+		err := errors.Join(errors.New("a"), newStackTraceError("b"))
+
+		causes := Causes(err)
+		require.Len(t, causes, 3)
+		assert.Equal(t, "a", causes[1].Message)
+		assert.Equal(t, "b", causes[2].Message)
+		assert.True(t, causes[2].HasStack)
+
+		root, ok := RootCause(err)
+		assert.True(t, ok)
+		assert.Equal(t, "b", root.Message)
+	})
+}